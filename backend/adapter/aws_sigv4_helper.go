@@ -0,0 +1,173 @@
+package adapter
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsSigV4Algorithm  = "AWS4-HMAC-SHA256"
+	awsSigV4TimeFormat = "20060102T150405Z"
+	awsSigV4DateFormat = "20060102"
+)
+
+type AWSSigV4Param struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // 临时凭证，可选
+	Region          string
+	Service         string
+}
+
+// awsURIEncode 按 SigV4 规则编码，只保留 A-Z a-z 0-9 - _ . ~
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// awsCanonicalURI S3 使用原始路径且只编码一次，其他服务先规范化路径再对已编码的路径再编码一次
+func awsCanonicalURI(u *url.URL, service string) string {
+	if service == "s3" {
+		p := u.Path
+		if p == "" {
+			return "/"
+		}
+		return awsURIEncode(p, false)
+	}
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return awsURIEncode(cleaned, false)
+}
+
+// awsCanonicalQuery 参数按编码后的名称和值排序
+func awsCanonicalQuery(u *url.URL) string {
+	values, _ := url.ParseQuery(u.RawQuery)
+	pairs := make([]string, 0, len(values))
+	for k, vs := range values {
+		for _, v := range vs {
+			pairs = append(pairs, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsSignedHeaderExcluded 不参与签名的头，可能在转发过程中被改写
+var awsSignedHeaderExcluded = map[string]bool{
+	"authorization": true,
+	"user-agent":    true,
+}
+
+// awsCanonicalHeaders 返回规范化的头部字符串和 SignedHeaders
+func awsCanonicalHeaders(request *http.Request) (string, string) {
+	headers := make(map[string][]string)
+	for k, vs := range request.Header {
+		name := strings.ToLower(k)
+		if awsSignedHeaderExcluded[name] {
+			continue
+		}
+		for _, v := range vs {
+			headers[name] = append(headers[name], strings.Join(strings.Fields(v), " "))
+		}
+	}
+	host := request.Host
+	if host == "" {
+		host = request.URL.Host
+	}
+	headers["host"] = []string{host}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.Join(headers[name], ",") + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+func awsCanonicalRequest(request *http.Request, service, hashedPayload string) (string, string) {
+	canonicalHeaders, signedHeaders := awsCanonicalHeaders(request)
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s",
+		request.Method,
+		awsCanonicalURI(request.URL, service),
+		awsCanonicalQuery(request.URL),
+		canonicalHeaders,
+		signedHeaders,
+		hashedPayload), signedHeaders
+}
+
+func awsSigningKey(secretAccessKey, date, region, service string) string {
+	kDate := hmacSha256(date, "AWS4"+secretAccessKey)
+	kRegion := hmacSha256(region, kDate)
+	kService := hmacSha256(service, kRegion)
+	return hmacSha256("aws4_request", kService)
+}
+
+// signAWSSigV4 按给定时间签名，设置 X-Amz-Date、X-Amz-Security-Token 和 Authorization
+func signAWSSigV4(request *http.Request, payload []byte, param AWSSigV4Param, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(awsSigV4TimeFormat)
+	date := now.Format(awsSigV4DateFormat)
+	hashedPayload := sha256hex(string(payload))
+
+	request.Header.Del("Authorization")
+	request.Header.Set("X-Amz-Date", amzDate)
+	if param.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", param.SessionToken)
+	}
+	if param.Service == "s3" {
+		request.Header.Set("X-Amz-Content-Sha256", hashedPayload)
+	}
+
+	// step 1: build canonical request string
+	canonicalRequest, signedHeaders := awsCanonicalRequest(request, param.Service, hashedPayload)
+
+	// step 2: build string to sign
+	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", date, param.Region, param.Service)
+	string2sign := fmt.Sprintf("%s\n%s\n%s\n%s",
+		awsSigV4Algorithm,
+		amzDate,
+		credentialScope,
+		sha256hex(canonicalRequest))
+
+	// step 3: sign string
+	signingKey := awsSigningKey(param.SecretAccessKey, date, param.Region, param.Service)
+	signature := hex.EncodeToString([]byte(hmacSha256(string2sign, signingKey)))
+
+	// step 4: build authorization
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigV4Algorithm,
+		param.AccessKeyID,
+		credentialScope,
+		signedHeaders,
+		signature))
+}
+
+// SignAWSSigV4 使用当前时间对请求进行 AWS Signature V4 签名，payload 必须与请求体一致
+func SignAWSSigV4(request *http.Request, payload []byte, param AWSSigV4Param) {
+	signAWSSigV4(request, payload, param, time.Now())
+}
@@ -0,0 +1,225 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 接口请求体编码，空字符串等同于 json
+const (
+	BodyEncodingJSON      = "json"
+	BodyEncodingForm      = "form"
+	BodyEncodingMultipart = "multipart"
+	BodyEncodingRaw       = "raw"
+)
+
+// formField 展开后的表单字段，保持输出顺序稳定
+type formField struct {
+	key   string
+	value string
+}
+
+// formatFormScalar 数字不使用科学计数法，null 作为空字符串
+func formatFormScalar(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func isFormScalar(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return true
+}
+
+// flattenFormValue 展开嵌套结构：对象使用 a[b]，标量数组重复键名 a=1&a=2，包含对象或数组的数组使用 a[0][b]
+func flattenFormValue(key string, v any, fields *[]formField) {
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			flattenFormValue(key+"["+k+"]", val[k], fields)
+		}
+	case []any:
+		scalar := true
+		for _, item := range val {
+			if !isFormScalar(item) {
+				scalar = false
+				break
+			}
+		}
+		for i, item := range val {
+			if scalar {
+				flattenFormValue(key, item, fields)
+			} else {
+				flattenFormValue(key+"["+strconv.Itoa(i)+"]", item, fields)
+			}
+		}
+	default:
+		*fields = append(*fields, formField{key: key, value: formatFormScalar(val)})
+	}
+}
+
+func flattenFormBody(body map[string]any) []formField {
+	keys := make([]string, 0, len(body))
+	for k := range body {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]formField, 0, len(body))
+	for _, k := range keys {
+		flattenFormValue(k, body[k], &fields)
+	}
+	return fields
+}
+
+// parseDataURL 解析 data:<mime>;base64,<data> 格式的文件内容
+func parseDataURL(value string) (string, []byte, bool) {
+	if !strings.HasPrefix(value, "data:") {
+		return "", nil, false
+	}
+	header, data, ok := strings.Cut(value[len("data:"):], ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return "", nil, false
+	}
+	content, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", nil, false
+	}
+	mediaType := strings.TrimSuffix(header, ";base64")
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	return mediaType, content, true
+}
+
+// commonFileExtensions 常见类型使用固定扩展名，mime.ExtensionsByType 的结果依赖系统配置
+var commonFileExtensions = map[string]string{
+	"application/json": ".json",
+	"application/pdf":  ".pdf",
+	"application/xml":  ".xml",
+	"application/zip":  ".zip",
+	"image/gif":        ".gif",
+	"image/jpeg":       ".jpg",
+	"image/png":        ".png",
+	"text/csv":         ".csv",
+	"text/plain":       ".txt",
+}
+
+// fileNameFor 根据字段名和 MIME 类型生成上传文件名
+func fileNameFor(key, mediaType string) string {
+	name := strings.NewReplacer("[", "_", "]", "").Replace(key)
+	if base, _, err := mime.ParseMediaType(mediaType); err == nil {
+		if ext, ok := commonFileExtensions[base]; ok {
+			return name + ext
+		}
+		if exts, _ := mime.ExtensionsByType(base); len(exts) > 0 {
+			return name + exts[0]
+		}
+	}
+	return name
+}
+
+func encodeMultipartBody(body map[string]any) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, f := range flattenFormBody(body) {
+		// data URL 形式的字符串作为文件上传
+		if mediaType, content, ok := parseDataURL(f.value); ok {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+				"name":     f.key,
+				"filename": fileNameFor(f.key, mediaType),
+			}))
+			h.Set("Content-Type", mediaType)
+			part, err := writer.CreatePart(h)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err := part.Write(content); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		if err := writer.WriteField(f.key, f.value); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// encodeRawBody 唯一的 body 参数原样发送，XML 内容默认使用 application/xml
+func encodeRawBody(body map[string]any) ([]byte, string, error) {
+	if len(body) != 1 {
+		return nil, "", errors.New("raw body encoding requires exactly one body parameter")
+	}
+	var content string
+	for _, v := range body {
+		switch val := v.(type) {
+		case string:
+			content = val
+		default:
+			data, err := json.Marshal(val)
+			if err != nil {
+				return nil, "", fmt.Errorf("marshal body failed: %w", err)
+			}
+			content = string(data)
+		}
+	}
+	contentType := "text/plain; charset=utf-8"
+	if strings.HasPrefix(strings.TrimSpace(content), "<") {
+		contentType = "application/xml; charset=utf-8"
+	}
+	return []byte(content), contentType, nil
+}
+
+// encodeRequestBody 按接口配置的编码生成请求体，返回请求体和默认的 Content-Type
+func encodeRequestBody(encoding string, body map[string]any) ([]byte, string, error) {
+	switch encoding {
+	case "", BodyEncodingJSON:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, "", fmt.Errorf("marshal body failed: %w", err)
+		}
+		return data, "", nil
+	case BodyEncodingForm:
+		values := url.Values{}
+		for _, f := range flattenFormBody(body) {
+			values.Add(f.key, f.value)
+		}
+		return []byte(values.Encode()), "application/x-www-form-urlencoded", nil
+	case BodyEncodingMultipart:
+		return encodeMultipartBody(body)
+	case BodyEncodingRaw:
+		return encodeRawBody(body)
+	}
+	return nil, "", fmt.Errorf("unsupported body encoding: %s", encoding)
+}
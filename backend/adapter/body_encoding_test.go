@@ -0,0 +1,131 @@
+package adapter
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFlattenFormBody(t *testing.T) {
+	body := map[string]any{
+		"name":  "alice",
+		"age":   float64(30),
+		"big":   float64(1000000),
+		"tags":  []any{"a", "b"},
+		"user":  map[string]any{"city": "SZ", "zip": nil},
+		"items": []any{map[string]any{"id": float64(1)}, map[string]any{"id": float64(2)}},
+	}
+	var got []string
+	for _, f := range flattenFormBody(body) {
+		got = append(got, f.key+"="+f.value)
+	}
+	expected := "age=30,big=1000000,items[0][id]=1,items[1][id]=2,name=alice,tags=a,tags=b,user[city]=SZ,user[zip]="
+	if strings.Join(got, ",") != expected {
+		t.Errorf("unexpected fields\n got: %s\nwant: %s", strings.Join(got, ","), expected)
+	}
+}
+
+func TestEncodeRequestBody(t *testing.T) {
+	data, contentType, err := encodeRequestBody("", map[string]any{"a": "1"})
+	if err != nil || string(data) != `{"a":"1"}` || contentType != "" {
+		t.Errorf("json: unexpected %s %q %v", data, contentType, err)
+	}
+
+	data, contentType, err = encodeRequestBody(BodyEncodingForm, map[string]any{"q": "a b", "user": map[string]any{"name": "x&y"}})
+	if err != nil || contentType != "application/x-www-form-urlencoded" {
+		t.Fatalf("form: unexpected %q %v", contentType, err)
+	}
+	values, _ := url.ParseQuery(string(data))
+	if values.Get("q") != "a b" || values.Get("user[name]") != "x&y" {
+		t.Errorf("form: unexpected body %s", data)
+	}
+
+	data, contentType, err = encodeRequestBody(BodyEncodingRaw, map[string]any{"body": "  <note>hi</note>"})
+	if err != nil || string(data) != "  <note>hi</note>" || contentType != "application/xml; charset=utf-8" {
+		t.Errorf("raw xml: unexpected %s %q %v", data, contentType, err)
+	}
+	data, contentType, err = encodeRequestBody(BodyEncodingRaw, map[string]any{"body": "plain text"})
+	if err != nil || string(data) != "plain text" || contentType != "text/plain; charset=utf-8" {
+		t.Errorf("raw text: unexpected %s %q %v", data, contentType, err)
+	}
+	if _, _, err := encodeRequestBody(BodyEncodingRaw, map[string]any{"a": "1", "b": "2"}); err == nil {
+		t.Error("raw with two body parameters should fail")
+	}
+	if _, _, err := encodeRequestBody("xml", map[string]any{"a": "1"}); err == nil {
+		t.Error("unsupported encoding should fail")
+	}
+}
+
+func TestEncodeMultipartBody(t *testing.T) {
+	data, contentType, err := encodeRequestBody(BodyEncodingMultipart, map[string]any{
+		"title": "report",
+		"meta":  map[string]any{"owner": "bob"},
+		"file":  "data:text/plain;base64,aGVsbG8=",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	form, err := multipart.NewReader(strings.NewReader(string(data)), params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Value["title"][0] != "report" || form.Value["meta[owner]"][0] != "bob" {
+		t.Errorf("unexpected fields %v", form.Value)
+	}
+	files := form.File["file"]
+	if len(files) != 1 {
+		t.Fatalf("expected one file part, got %v", form.File)
+	}
+	if files[0].Filename != "file.txt" || files[0].Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("unexpected file part %s %v", files[0].Filename, files[0].Header)
+	}
+	f, _ := files[0].Open()
+	content, _ := io.ReadAll(f)
+	if string(content) != "hello" {
+		t.Errorf("unexpected file content %q", content)
+	}
+}
+
+func TestBuildCommonHttpRequest_BodyEncoding(t *testing.T) {
+	params := Parameters{
+		BodyParams:   map[string]any{"name": "alice"},
+		HeaderParams: map[string]any{},
+	}
+	meta := RequestMeta{URL: "https://api.example.com/users", Method: http.MethodPost, BodyEncoding: BodyEncodingForm}
+	request, payload, err := BuildCommonHttpRequest(context.Background(), params, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != "name=alice" || request.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected form request %s %s", payload, request.Header.Get("Content-Type"))
+	}
+
+	// 参数中声明的 Content-Type 优先，multipart 必须使用生成的 boundary
+	params.HeaderParams["Content-Type"] = "application/x-www-form-urlencoded; charset=gbk"
+	request, _, _ = BuildCommonHttpRequest(context.Background(), params, meta)
+	if got := request.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded; charset=gbk" {
+		t.Errorf("declared Content-Type should be kept, got %s", got)
+	}
+	params.HeaderParams["Content-Type"] = "multipart/form-data"
+	meta.BodyEncoding = BodyEncodingMultipart
+	request, _, _ = BuildCommonHttpRequest(context.Background(), params, meta)
+	if got := request.Header.Get("Content-Type"); !strings.Contains(got, "boundary=") {
+		t.Errorf("multipart Content-Type should carry boundary, got %s", got)
+	}
+
+	// GET 请求的 body 参数仍然放到查询参数中
+	meta.Method = http.MethodGet
+	request, payload, _ = BuildCommonHttpRequest(context.Background(), params, meta)
+	if len(payload) != 0 || request.URL.Query().Get("name") != "alice" {
+		t.Errorf("GET should move body to query, got %s %s", payload, request.URL.RawQuery)
+	}
+}
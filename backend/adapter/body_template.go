@@ -0,0 +1,142 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// bodyTemplateFuncs 模板中可用的函数，json 把参数值输出为 JSON 字面量 (字符串带引号，对象和数组原样嵌入)
+var bodyTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"default": func(def, v any) any {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	bodyTemplateEscaper: escapeJSONString,
+}
+
+// bodyTemplateEscaper 解析 JSON 模板时追加到每个输出动作末尾的转义函数
+const bodyTemplateEscaper = "_body_template_escape"
+
+// escapeJSONString 按 JSON 字符串内容转义输出值，参数值中的引号和反斜杠不能闭合模板中的字符串或插入字段
+func escapeJSONString(v any) string {
+	if v == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(fmt.Sprint(v))
+	quoted := bytes.TrimSpace(buf.Bytes())
+	return string(quoted[1 : len(quoted)-1])
+}
+
+// ParseBodyTemplate 解析请求体模板 (Go text/template)，空字符串表示不使用模板。
+// 除 raw 编码外，{{.x}} 的输出按 JSON 字符串内容转义，{{json .x}} 原样嵌入 JSON 字面量
+func ParseBodyTemplate(raw, encoding string) (*template.Template, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("body").Funcs(bodyTemplateFuncs).Option("missingkey=zero").Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body_template: %v", err)
+	}
+	if encoding != BodyEncodingRaw {
+		for _, t := range tmpl.Templates() {
+			if t.Tree != nil {
+				escapeBodyTemplateActions(t.Tree.Root)
+			}
+		}
+	}
+	return tmpl, nil
+}
+
+// escapeBodyTemplateActions 给输出动作追加转义函数，最后一个命令是 json 的动作和变量声明不处理
+func escapeBodyTemplateActions(list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.ActionNode:
+			if len(n.Pipe.Decl) > 0 {
+				continue
+			}
+			last := n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+			if ident, ok := last.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "json" {
+				continue
+			}
+			escaper := parse.NewIdentifier(bodyTemplateEscaper).SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{escaper}})
+		case *parse.IfNode:
+			escapeBodyTemplateActions(n.List)
+			escapeBodyTemplateActions(n.ElseList)
+		case *parse.RangeNode:
+			escapeBodyTemplateActions(n.List)
+			escapeBodyTemplateActions(n.ElseList)
+		case *parse.WithNode:
+			escapeBodyTemplateActions(n.List)
+			escapeBodyTemplateActions(n.ElseList)
+		}
+	}
+}
+
+// renderBodyTemplate 以 body 参数为数据渲染模板，除 raw 编码外结果必须是 JSON 对象
+func renderBodyTemplate(tmpl *template.Template, encoding string, body map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, body); err != nil {
+		return nil, fmt.Errorf("render body_template failed: %v", err)
+	}
+	if encoding == BodyEncodingRaw {
+		return buf.Bytes(), nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &obj); err != nil || obj == nil {
+		return nil, errors.New("body_template must render a JSON object, use {{json .param}} to insert values")
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return compacted.Bytes(), nil
+}
+
+// encodeTemplateBody 渲染模板并按接口编码生成请求体
+func encodeTemplateBody(tmpl *template.Template, encoding string, body map[string]any) ([]byte, string, error) {
+	rendered, err := renderBodyTemplate(tmpl, encoding, body)
+	if err != nil {
+		return nil, "", err
+	}
+	switch encoding {
+	case "", BodyEncodingJSON:
+		// 保留模板中的字段顺序
+		return rendered, "", nil
+	case BodyEncodingRaw:
+		return encodeRawBody(map[string]any{"body": string(rendered)})
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(rendered, &obj); err != nil {
+		return nil, "", err
+	}
+	return encodeRequestBody(encoding, obj)
+}
+
+// ValidateBodyTemplate 保存接口前用示例参数渲染一次，确保模板可以注册为工具
+func ValidateBodyTemplate(raw, encoding string, sample map[string]any) error {
+	tmpl, err := ParseBodyTemplate(raw, encoding)
+	if err != nil || tmpl == nil {
+		return err
+	}
+	_, err = renderBodyTemplate(tmpl, encoding, sample)
+	return err
+}
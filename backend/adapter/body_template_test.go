@@ -0,0 +1,145 @@
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseBodyTemplate(t *testing.T) {
+	tmpl, err := ParseBodyTemplate("  ", "")
+	if err != nil || tmpl != nil {
+		t.Errorf("empty template should be ignored, got %v %v", tmpl, err)
+	}
+	if _, err := ParseBodyTemplate(`{"data": {{json .name}`, ""); err == nil || !strings.Contains(err.Error(), "invalid body_template") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestRenderBodyTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		encoding string
+		body     map[string]any
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "json api envelope",
+			template: `{"data": {"type": "users", "attributes": {"name": {{json .name}}, "tags": {{json .tags}}}}}`,
+			body:     map[string]any{"name": `a "quoted" name`, "tags": []any{"x", "y"}},
+			expected: `{"data":{"type":"users","attributes":{"name":"a \"quoted\" name","tags":["x","y"]}}}`,
+		},
+		{
+			name:     "missing parameter and default",
+			template: `{"page": {{json (default 1 .page)}}, "q": {{json .q}}}`,
+			body:     map[string]any{},
+			expected: `{"page":1,"q":null}`,
+		},
+		{
+			name:     "range",
+			template: `{"ids": [{{range $i, $id := .ids}}{{if $i}},{{end}}{"id": {{json $id}}}{{end}}]}`,
+			body:     map[string]any{"ids": []any{float64(1), float64(2)}},
+			expected: `{"ids":[{"id":1},{"id":2}]}`,
+		},
+		{
+			name:     "string values are escaped",
+			template: `{"q": "{{.q}}", "role": "user"{{with .note}}, "note": "{{.}}"{{end}}}`,
+			body:     map[string]any{"q": `x", "role": "admin`, "note": "a\\b\n"},
+			expected: `{"q":"x\", \"role\": \"admin","role":"user","note":"a\\b\n"}`,
+		},
+		{
+			name:     "missing string value",
+			template: `{"q": "{{.q}}", "n": {{.n}}}`,
+			body:     map[string]any{"n": float64(3)},
+			expected: `{"q":"","n":3}`,
+		},
+		{
+			name:     "injection outside string",
+			template: `{"n": {{.n}}}`,
+			body:     map[string]any{"n": `1, "role": "admin"`},
+			wantErr:  "body_template must render a JSON object",
+		},
+		{
+			name:     "raw xml",
+			template: `<user><name>{{.name}}</name></user>`,
+			encoding: BodyEncodingRaw,
+			body:     map[string]any{"name": "bob"},
+			expected: `<user><name>bob</name></user>`,
+		},
+		{
+			name:     "not a json object",
+			template: `{"name": {{.name}}}`,
+			body:     map[string]any{"name": "bob"},
+			wantErr:  "body_template must render a JSON object",
+		},
+		{
+			name:     "execution error",
+			template: `{{index .name 5}}`,
+			body:     map[string]any{"name": float64(1)},
+			wantErr:  "render body_template failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseBodyTemplate(tt.template, tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := renderBodyTemplate(tmpl, tt.encoding, tt.body)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("unexpected body\n got: %s\nwant: %s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildCommonHttpRequest_BodyTemplate(t *testing.T) {
+	tmpl, _ := ParseBodyTemplate(`{"envelope": {"version": 2, "payload": {"user": {{json .user}}}}}`, "")
+	meta := RequestMeta{URL: "https://api.example.com/users", Method: http.MethodPost, BodyTemplate: tmpl}
+	params := Parameters{BodyParams: map[string]any{"user": map[string]any{"name": "alice"}}}
+	_, payload, err := BuildCommonHttpRequest(context.Background(), params, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != `{"envelope":{"version":2,"payload":{"user":{"name":"alice"}}}}` {
+		t.Errorf("unexpected payload %s", payload)
+	}
+
+	// 只有固定字段的模板在没有 body 参数时也会渲染
+	fixed, _ := ParseBodyTemplate(`{"action": "ping"}`, "")
+	meta.BodyTemplate = fixed
+	_, payload, _ = BuildCommonHttpRequest(context.Background(), Parameters{}, meta)
+	if string(payload) != `{"action":"ping"}` {
+		t.Errorf("unexpected payload %s", payload)
+	}
+
+	// 模板渲染结果按表单编码发送
+	meta.BodyTemplate = tmpl
+	meta.BodyEncoding = BodyEncodingForm
+	request, payload, _ := BuildCommonHttpRequest(context.Background(), params, meta)
+	if string(payload) != "envelope%5Bpayload%5D%5Buser%5D%5Bname%5D=alice&envelope%5Bversion%5D=2" {
+		t.Errorf("unexpected form payload %s", payload)
+	}
+	if request.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected Content-Type %s", request.Header.Get("Content-Type"))
+	}
+
+	// GET 请求不使用模板
+	meta.Method = http.MethodGet
+	request, payload, _ = BuildCommonHttpRequest(context.Background(), Parameters{BodyParams: map[string]any{"q": "x"}}, meta)
+	if len(payload) != 0 || request.URL.Query().Get("q") != "x" {
+		t.Errorf("GET should ignore body_template, got %s %s", payload, request.URL.RawQuery)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -29,26 +30,95 @@ type TencentCloudAPIParam struct {
 	Action    string
 	Region    string
 	Payload   string
+
+	Method      string            // 请求方法，默认 POST
+	Path        string            // 已编码的请求路径，默认 /
+	Query       string            // 已编码的查询字符串，与实际发送的保持一致
+	ContentType string            // 默认 application/json; charset=utf-8
+	Headers     map[string]string // 需要一起签名的其他请求头
+	Token       string            // 临时凭证的 Token，可选
+	Language    string            // 返回信息的语言，可选
+	Timestamp   int64             // 签名时间，默认当前时间
+}
+
+// tc3CanonicalHeaders 头部名称和值统一小写并去掉首尾空格，按名称排序
+func tc3CanonicalHeaders(headers map[string]string) (string, string) {
+	lowered := make(map[string]string, len(headers))
+	names := make([]string, 0, len(headers))
+	for k, v := range headers {
+		name := strings.ToLower(strings.TrimSpace(k))
+		if _, ok := lowered[name]; !ok {
+			names = append(names, name)
+		}
+		lowered[name] = strings.ToLower(strings.TrimSpace(v))
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + lowered[name] + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
 }
 
 func SignatureTCHeader(tcp TencentCloudAPIParam) (map[string]string, error) {
 
 	algorithm := "TC3-HMAC-SHA256"
 
-	timestamp := time.Now().Unix()
+	timestamp := tcp.Timestamp
+	if timestamp == 0 {
+		timestamp = time.Now().Unix()
+	}
+	httpRequestMethod := strings.ToUpper(tcp.Method)
+	if httpRequestMethod == "" {
+		httpRequestMethod = "POST"
+	}
+	canonicalURI := tcp.Path
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	contentType := tcp.ContentType
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+
+	headers := map[string]string{
+		"Authorization":  "",
+		"Content-Type":   contentType,
+		"Host":           tcp.Host,
+		"X-TC-Action":    tcp.Action,
+		"X-TC-Timestamp": fmt.Sprintf("%d", timestamp),
+		"X-TC-Version":   tcp.Version,
+		"X-TC-Region":    tcp.Region,
+	}
+	if tcp.Token != "" {
+		headers["X-TC-Token"] = tcp.Token
+	}
+	if tcp.Language != "" {
+		headers["X-TC-Language"] = tcp.Language
+	}
 
 	// step 1: build canonical request string
-	httpRequestMethod := "POST"
-	canonicalURI := "/"
-	canonicalQueryString := ""
-	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\nx-tc-action:%s\n",
-		"application/json; charset=utf-8", tcp.Host, strings.ToLower(tcp.Action))
-	signedHeaders := "content-type;host;x-tc-action"
+	signed := map[string]string{
+		"content-type": contentType,
+		"host":         tcp.Host,
+		"x-tc-action":  tcp.Action,
+	}
+	// 与签名生成的头同名的请求头会被覆盖，不参与签名
+	generated := make(map[string]bool, len(headers))
+	for k := range headers {
+		generated[strings.ToLower(k)] = true
+	}
+	for k, v := range tcp.Headers {
+		if name := strings.ToLower(k); !generated[name] {
+			signed[name] = v
+		}
+	}
+	canonicalHeaders, signedHeaders := tc3CanonicalHeaders(signed)
 	hashedRequestPayload := sha256hex(tcp.Payload)
 	canonicalRequest := fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s",
 		httpRequestMethod,
 		canonicalURI,
-		canonicalQueryString,
+		tcp.Query,
 		canonicalHeaders,
 		signedHeaders,
 		hashedRequestPayload)
@@ -70,22 +140,12 @@ func SignatureTCHeader(tcp TencentCloudAPIParam) (map[string]string, error) {
 	signature := hex.EncodeToString([]byte(hmacSha256(string2sign, secretSigning)))
 
 	// step 4: build authorization
-	authorization := fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+	headers["Authorization"] = fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
 		algorithm,
 		tcp.SecretId,
 		credentialScope,
 		signedHeaders,
 		signature)
 
-	headers := map[string]string{
-		"Authorization":  authorization,
-		"Content-Type":   "application/json; charset=utf-8",
-		"Host":           tcp.Host,
-		"X-TC-Action":    tcp.Action,
-		"X-TC-Timestamp": fmt.Sprintf("%d", timestamp),
-		"X-TC-Version":   tcp.Version,
-		"X-TC-Region":    tcp.Region,
-	}
-
 	return headers, nil
 }
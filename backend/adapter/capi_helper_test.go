@@ -393,6 +393,56 @@ func TestTencentCloudAPIParam_AllFields(t *testing.T) {
 	}
 }
 
+func TestSignatureTCHeader_RequestDetails(t *testing.T) {
+	base := TencentCloudAPIParam{
+		SecretId:  "test-id",
+		SecretKey: "test-key",
+		Host:      "cvm.tencentcloudapi.com",
+		Service:   "cvm",
+		Version:   "2017-03-12",
+		Action:    "DescribeInstances",
+		Region:    "ap-guangzhou",
+		Timestamp: 1551113065,
+	}
+
+	post, _ := SignatureTCHeader(base)
+	if post["X-TC-Timestamp"] != "1551113065" {
+		t.Errorf("expected fixed timestamp, got %s", post["X-TC-Timestamp"])
+	}
+	if !strings.Contains(post["Authorization"], "Credential=test-id/2019-02-25/cvm/tc3_request") {
+		t.Errorf("unexpected credential scope: %s", post["Authorization"])
+	}
+	again, _ := SignatureTCHeader(base)
+	if post["Authorization"] != again["Authorization"] {
+		t.Error("signature should be deterministic for a fixed timestamp")
+	}
+
+	get := base
+	get.Method = "GET"
+	get.Path = "/v2/instances"
+	get.Query = "Limit=10&Offset=0"
+	get.ContentType = "application/x-www-form-urlencoded"
+	get.Headers = map[string]string{"X-Custom": "Value", "X-TC-Region": "overridden", "Authorization": "stale"}
+	get.Token = "sts-token"
+	get.Language = "en-US"
+	headers, _ := SignatureTCHeader(get)
+	if headers["Authorization"] == post["Authorization"] {
+		t.Error("method, path and query should be part of the signature")
+	}
+	if !strings.Contains(headers["Authorization"], "SignedHeaders=content-type;host;x-custom;x-tc-action,") {
+		t.Errorf("unexpected signed headers: %s", headers["Authorization"])
+	}
+	if headers["Content-Type"] != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected Content-Type: %s", headers["Content-Type"])
+	}
+	if headers["X-TC-Token"] != "sts-token" || headers["X-TC-Language"] != "en-US" {
+		t.Errorf("expected X-TC-Token and X-TC-Language, got %v", headers)
+	}
+	if _, ok := post["X-TC-Token"]; ok {
+		t.Error("X-TC-Token should only be set for temporary credentials")
+	}
+}
+
 func BenchmarkSignatureTCHeader(b *testing.B) {
 	tcp := TencentCloudAPIParam{
 		SecretId:  "AKIDz8krbsJ5yKBZQpn74WFkmLPx3*******",
@@ -0,0 +1,286 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"mcp-adapter/backend/models"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 熔断器作用范围
+const (
+	CircuitBreakerScopeHost      = "host"      // 同一上游主机的所有工具共享状态 (默认)
+	CircuitBreakerScopeInterface = "interface" // 每个工具单独计算
+)
+
+// 熔断器状态
+const (
+	CircuitClosed   = "closed"    // 正常放行，统计连续失败次数
+	CircuitOpen     = "open"      // 直接拒绝，冷却时间结束后进入半开
+	CircuitHalfOpen = "half_open" // 放行一个探测请求，成功后关闭，失败后重新打开
+)
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitCooldown         = 30 * time.Second
+)
+
+// CircuitBreakerConfig 熔断配置，作为 http_config 的 circuit_breaker 字段，接口配置整体覆盖应用配置。
+// 连接错误、超时和 5xx 响应计为失败，其他状态码说明上游可用，计为成功
+type CircuitBreakerConfig struct {
+	Scope            string `json:"scope,omitempty"`             // host (默认) 或 interface
+	FailureThreshold int    `json:"failure_threshold,omitempty"` // 连续失败多少次后打开，默认 5
+	Cooldown         string `json:"cooldown,omitempty"`          // 打开后多久进入半开状态，默认 30s
+}
+
+func (c *CircuitBreakerConfig) validate() error {
+	switch c.Scope {
+	case "", CircuitBreakerScopeHost, CircuitBreakerScopeInterface:
+	default:
+		return fmt.Errorf("http_config circuit_breaker.scope must be %s or %s", CircuitBreakerScopeHost, CircuitBreakerScopeInterface)
+	}
+	if c.FailureThreshold < 0 {
+		return errors.New("http_config circuit_breaker.failure_threshold must not be negative")
+	}
+	if _, err := parseDurationField("circuit_breaker.cooldown", c.Cooldown); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *CircuitBreakerConfig) threshold() int {
+	if c.FailureThreshold == 0 {
+		return defaultCircuitFailureThreshold
+	}
+	return c.FailureThreshold
+}
+
+func (c *CircuitBreakerConfig) cooldown() time.Duration {
+	d, _ := parseDurationField("circuit_breaker.cooldown", c.Cooldown)
+	if d == 0 {
+		return defaultCircuitCooldown
+	}
+	return d
+}
+
+// CircuitOpenError 熔断器打开时拒绝调用，ToolResultText 返回给 LLM 的错误包含重试等待时间
+type CircuitOpenError struct {
+	Scope      string        // host 或 interface
+	Name       string        // 主机名或工具名
+	Failures   int           // 打开前的连续失败次数
+	RetryAfter time.Duration // 距离下一次探测的时间
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("service unavailable: circuit breaker for %s %s is open after %d consecutive failures, retry after %s",
+		e.Scope, e.Name, e.Failures, e.RetryAfter)
+}
+
+// ToolResultText 以 JSON 返回错误，retry_after_seconds 向上取整
+func (e *CircuitOpenError) ToolResultText() string {
+	data, _ := json.Marshal(map[string]any{
+		"error":               e.Error(),
+		"scope":               e.Scope,
+		"retry_after_seconds": int(math.Ceil(e.RetryAfter.Seconds())),
+	})
+	return string(data)
+}
+
+// circuitBreaker 一个主机或工具的熔断状态，阈值和冷却时间由每次调用的配置决定，
+// 同一主机下配置不同的工具共享失败计数
+type circuitBreaker struct {
+	mu        sync.Mutex
+	key       string
+	scope     string
+	name      string
+	appID     int64 // interface 范围的熔断器所属的应用，host 范围为 0
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool // 半开状态下是否已经有探测请求在进行
+	lastError string
+	now       func() time.Time
+}
+
+// circuitBreakers 熔断器，"host:<host>" 或 "interface:<id>" -> *circuitBreaker
+var circuitBreakers sync.Map
+
+// circuitBreakerFor 返回工具使用的熔断器，未配置时返回 nil。
+// host 范围按展开环境变量后的 URL 主机共享，无法解析主机时退回 interface 范围
+func circuitBreakerFor(cfg *CircuitBreakerConfig, iface *models.Interface, env map[string]string) *circuitBreaker {
+	interfaceKey := fmt.Sprintf("%s:%d", CircuitBreakerScopeInterface, iface.ID)
+	if cfg == nil {
+		circuitBreakers.Delete(interfaceKey)
+		return nil
+	}
+	b := &circuitBreaker{key: interfaceKey, scope: CircuitBreakerScopeInterface, name: iface.Name, appID: iface.AppID, state: CircuitClosed, now: time.Now}
+	if cfg.Scope != CircuitBreakerScopeInterface {
+		if host := upstreamHost(iface.URL, env); host != "" {
+			circuitBreakers.Delete(interfaceKey)
+			b = &circuitBreaker{key: CircuitBreakerScopeHost + ":" + host, scope: CircuitBreakerScopeHost, name: host, state: CircuitClosed, now: time.Now}
+		}
+	}
+	v, _ := circuitBreakers.LoadOrStore(b.key, b)
+	return v.(*circuitBreaker)
+}
+
+// upstreamHost 展开环境变量后解析 URL 中的主机和端口
+func upstreamHost(rawURL string, env map[string]string) string {
+	expanded, err := ExpandEnvironment(rawURL, env)
+	if err != nil {
+		return ""
+	}
+	u, err := url.Parse(expanded)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// dropCircuitBreakers 移除工具的熔断器，interfaceID 为 0 时忽略
+func dropCircuitBreakers(interfaceID int64) {
+	if interfaceID == 0 {
+		return
+	}
+	circuitBreakers.Delete(fmt.Sprintf("%s:%d", CircuitBreakerScopeInterface, interfaceID))
+}
+
+// dropApplicationCircuitBreakers 移除应用下所有工具的熔断器，appID 为 0 时忽略；
+// host 范围的熔断器可能被其他应用共享，不会移除
+func dropApplicationCircuitBreakers(appID int64) {
+	if appID == 0 {
+		return
+	}
+	circuitBreakers.Range(func(k, v any) bool {
+		if b := v.(*circuitBreaker); b.scope == CircuitBreakerScopeInterface && b.appID == appID {
+			circuitBreakers.Delete(k)
+		}
+		return true
+	})
+}
+
+// transition 切换状态并记录日志，调用方需要持有锁
+func (b *circuitBreaker) transition(state, reason string) {
+	if b.state == state {
+		return
+	}
+	log.Printf("Circuit breaker %s: %s -> %s (%s)", b.key, b.state, state, reason)
+	b.state = state
+}
+
+// allow 检查是否放行调用，放行时返回记录调用结果的函数，必须调用且只调用一次
+func (b *circuitBreaker) allow(cfg *CircuitBreakerConfig) (func(ctx context.Context, err error), error) {
+	if b == nil {
+		return func(context.Context, error) {}, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if b.state == CircuitOpen {
+		if wait := b.openedAt.Add(cfg.cooldown()).Sub(now); wait > 0 {
+			return nil, &CircuitOpenError{Scope: b.scope, Name: b.name, Failures: b.failures, RetryAfter: wait}
+		}
+		b.transition(CircuitHalfOpen, "cooldown elapsed")
+	}
+	probe := false
+	if b.state == CircuitHalfOpen {
+		if b.probing {
+			// 探测请求的结果未知，给出 1 秒的重试提示
+			return nil, &CircuitOpenError{Scope: b.scope, Name: b.name, Failures: b.failures, RetryAfter: time.Second}
+		}
+		b.probing, probe = true, true
+	}
+	return func(ctx context.Context, err error) { b.record(cfg, probe, ctx, err) }, nil
+}
+
+// record 记录调用结果，调用方取消的调用和发送请求前的错误不影响熔断状态
+func (b *circuitBreaker) record(cfg *CircuitBreakerConfig, probe bool, ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	failed, counted := isUpstreamFailure(ctx, err)
+	if !counted {
+		return
+	}
+	if !failed {
+		b.failures = 0
+		b.transition(CircuitClosed, "call succeeded")
+		return
+	}
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == CircuitHalfOpen || b.failures >= cfg.threshold() {
+		b.openedAt = b.now()
+		b.transition(CircuitOpen, fmt.Sprintf("%d consecutive failures, last error: %s", b.failures, b.lastError))
+	}
+}
+
+// isUpstreamFailure 判断调用结果是否说明上游不可用：连接错误、超时和 5xx 响应计为失败，
+// 其他状态码计为成功；gRPC 状态按对应的 HTTP 状态码判断；调用方取消和构造请求时的错误不计入
+func isUpstreamFailure(ctx context.Context, err error) (failed, counted bool) {
+	if err == nil {
+		return false, true
+	}
+	if ctx.Err() != nil {
+		return false, false
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500, true
+	}
+	var grpcErr *GRPCStatusError
+	if errors.As(err, &grpcErr) {
+		return grpcErr.HTTPStatus() >= 500, true
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true, true
+	}
+	// 数据源等非 HTTP 上游的连接失败和超时
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true, true
+	}
+	return false, false
+}
+
+// CircuitBreakerStatus 熔断器当前状态
+type CircuitBreakerStatus struct {
+	Key       string     `json:"key"`
+	Scope     string     `json:"scope"`
+	Name      string     `json:"name"`
+	AppID     int64      `json:"app_id,omitempty"`
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"` // 连续失败次数
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// CircuitBreakerStatuses 返回当前实例中所有熔断器的状态，按 key 排序
+func CircuitBreakerStatuses() []CircuitBreakerStatus {
+	statuses := make([]CircuitBreakerStatus, 0)
+	circuitBreakers.Range(func(_, v any) bool {
+		b := v.(*circuitBreaker)
+		b.mu.Lock()
+		status := CircuitBreakerStatus{Key: b.key, Scope: b.scope, Name: b.name, AppID: b.appID, State: b.state, Failures: b.failures, LastError: b.lastError}
+		if b.state != CircuitClosed {
+			openedAt := b.openedAt
+			status.OpenedAt = &openedAt
+		}
+		b.mu.Unlock()
+		statuses = append(statuses, status)
+		return true
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Key < statuses[j].Key })
+	return statuses
+}
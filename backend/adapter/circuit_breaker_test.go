@@ -0,0 +1,183 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestIsUpstreamFailure(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	connErr := &url.Error{Op: "Get", URL: "http://api", Err: errors.New("connection refused")}
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		err     error
+		failed  bool
+		counted bool
+	}{
+		{name: "success", ctx: context.Background(), err: nil, failed: false, counted: true},
+		{name: "connection error", ctx: context.Background(), err: connErr, failed: true, counted: true},
+		{name: "server error", ctx: context.Background(), err: &HTTPStatusError{StatusCode: 503}, failed: true, counted: true},
+		{name: "client error", ctx: context.Background(), err: &HTTPStatusError{StatusCode: 404}, failed: false, counted: true},
+		{name: "caller canceled", ctx: canceled, err: connErr, failed: false, counted: false},
+		{name: "request not sent", ctx: context.Background(), err: errors.New("invalid url"), failed: false, counted: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failed, counted := isUpstreamFailure(tt.ctx, tt.err)
+			if failed != tt.failed || counted != tt.counted {
+				t.Errorf("expected failed=%v counted=%v, got %v %v", tt.failed, tt.counted, failed, counted)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	now := time.Now()
+	cfg := &CircuitBreakerConfig{FailureThreshold: 2, Cooldown: "10s"}
+	b := &circuitBreaker{key: "host:api", scope: CircuitBreakerScopeHost, name: "api", state: CircuitClosed, now: func() time.Time { return now }}
+	ctx := context.Background()
+	failure := &HTTPStatusError{StatusCode: http.StatusBadGateway}
+
+	call := func(err error) error {
+		record, allowErr := b.allow(cfg)
+		if allowErr != nil {
+			return allowErr
+		}
+		record(ctx, err)
+		return nil
+	}
+
+	// 成功的调用重置连续失败次数
+	call(failure)
+	call(nil)
+	call(failure)
+	if b.state != CircuitClosed || b.failures != 1 {
+		t.Fatalf("expected closed with 1 failure, got %s %d", b.state, b.failures)
+	}
+	call(failure)
+	if b.state != CircuitOpen {
+		t.Fatalf("expected open after threshold, got %s", b.state)
+	}
+
+	now = now.Add(4 * time.Second)
+	err := call(nil)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || openErr.RetryAfter != 6*time.Second || openErr.Failures != 2 {
+		t.Fatalf("expected open error with 6s retry, got %v", err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(openErr.ToolResultText()), &payload); err != nil || payload["retry_after_seconds"] != float64(6) ||
+		!strings.Contains(payload["error"].(string), "service unavailable") {
+		t.Errorf("unexpected tool result %s", openErr.ToolResultText())
+	}
+
+	// 冷却结束后只放行一个探测请求，探测失败重新打开
+	now = now.Add(6 * time.Second)
+	record, err := b.allow(cfg)
+	if err != nil || b.state != CircuitHalfOpen {
+		t.Fatalf("expected half-open probe, got %s %v", b.state, err)
+	}
+	if _, err := b.allow(cfg); err == nil {
+		t.Fatal("expected concurrent call during probe to be rejected")
+	}
+	record(ctx, failure)
+	if b.state != CircuitOpen || !b.openedAt.Equal(now) {
+		t.Fatalf("expected failed probe to reopen, got %s", b.state)
+	}
+
+	// 探测请求没有发出时保持半开，成功后关闭
+	now = now.Add(10 * time.Second)
+	record, _ = b.allow(cfg)
+	record(ctx, errors.New("rate limited"))
+	if b.state != CircuitHalfOpen || b.probing {
+		t.Fatalf("expected half-open without probe, got %s %v", b.state, b.probing)
+	}
+	if err := call(nil); err != nil || b.state != CircuitClosed || b.failures != 0 {
+		t.Fatalf("expected successful probe to close, got %s %d %v", b.state, b.failures, err)
+	}
+}
+
+func TestAddToolCircuitBreaker(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+
+	var calls int32
+	var healthy atomic.Bool
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstream.Close()
+
+	app := models.Application{Name: "Breaker", Path: "breaker-app", Protocol: "sse", HTTPConfig: `{"circuit_breaker": {"failure_threshold": 2, "cooldown": "1h"}}`}
+	createWithEnabled(t, &app, true)
+	for _, name := range []string{"first", "second"} {
+		iface := models.Interface{AppID: app.ID, Name: name, Protocol: "http", URL: upstream.URL + "/" + name, Method: "GET", AuthType: "none"}
+		createWithEnabled(t, &iface, true)
+	}
+	isolated := models.Interface{AppID: app.ID, Name: "isolated", Protocol: "http", URL: upstream.URL + "/isolated", Method: "GET", AuthType: "none",
+		HTTPConfig: `{"circuit_breaker": {"scope": "interface", "failure_threshold": 1}}`}
+	createWithEnabled(t, &isolated, true)
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := sm.sseServers.Load("breaker-app")
+	call := func(tool string) *mcp.CallToolResult {
+		result, err := s.(*Server).server.GetTool(tool).Handler(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	text := func(r *mcp.CallToolResult) string { return r.Content[0].(mcp.TextContent).Text }
+
+	call("first")
+	call("second")
+	// 同一主机的工具共享熔断器，打开后不再请求上游
+	result := call("first")
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	if !result.IsError || !strings.Contains(text(result), "circuit breaker for host "+host+" is open") {
+		t.Fatalf("expected open circuit error, got %s", text(result))
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("expected open circuit not to reach upstream, got %d calls", n)
+	}
+
+	// interface 范围的熔断器单独计算
+	healthy.Store(true)
+	if r := call("isolated"); r.IsError {
+		t.Errorf("expected isolated tool to pass, got %s", text(r))
+	}
+	found := map[string]string{}
+	for _, status := range CircuitBreakerStatuses() {
+		found[status.Key] = status.State
+	}
+	if found["host:"+host] != CircuitOpen || found["interface:"+strconv.FormatInt(isolated.ID, 10)] != CircuitClosed {
+		t.Errorf("unexpected breaker states %v", found)
+	}
+
+	sm.removeApplication(&app)
+	for _, status := range CircuitBreakerStatuses() {
+		if status.Key == "interface:"+strconv.FormatInt(isolated.ID, 10) {
+			t.Error("expected interface breaker to be dropped with the application")
+		}
+	}
+	circuitBreakers.Delete("host:" + host)
+}
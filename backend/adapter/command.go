@@ -0,0 +1,334 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// CommandAllowlistEnv 允许执行的本地命令，逗号分隔，每项为 name=/path/to/bin 或 name (从 PATH 查找)
+// 未设置时 command 和 mcp 接口都不可用，命令只能由部署方配置，不能通过接口管理
+const CommandAllowlistEnv = "MCP_ADAPTER_COMMANDS"
+
+const (
+	maxCommandOutput = 1 << 20 // 标准输出上限
+	maxCommandStderr = 4096    // 错误信息中保留的标准错误输出
+)
+
+// commandPassthroughEnv 从网关进程传递给子进程的环境变量，其余只使用应用环境变量，避免泄露主密钥等配置
+var commandPassthroughEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TZ", "TMPDIR"}
+
+var commandNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// parseCommandAllowlist 解析允许执行的命令，名称 -> 可执行文件
+func parseCommandAllowlist() (map[string]string, error) {
+	raw := strings.TrimSpace(os.Getenv(CommandAllowlistEnv))
+	if raw == "" {
+		return nil, fmt.Errorf("local commands are disabled: %s is not set", CommandAllowlistEnv)
+	}
+	commands := make(map[string]string)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, path, ok := strings.Cut(item, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !ok {
+			path = name
+		}
+		if !commandNamePattern.MatchString(name) || path == "" {
+			return nil, fmt.Errorf("invalid %s entry %q", CommandAllowlistEnv, item)
+		}
+		commands[name] = path
+	}
+	return commands, nil
+}
+
+// LookupCommand 返回允许执行的命令的可执行文件路径
+func LookupCommand(name string) (string, error) {
+	commands, err := parseCommandAllowlist()
+	if err != nil {
+		return "", err
+	}
+	path, ok := commands[name]
+	if !ok {
+		return "", fmt.Errorf("command %s is not allowed, add it to %s", name, CommandAllowlistEnv)
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return "", fmt.Errorf("command %s: %v", name, err)
+	}
+	return resolved, nil
+}
+
+// commandEnv 子进程的环境变量：网关的基础变量加上应用环境变量，值可以是 secret://name 引用
+func commandEnv(ctx context.Context, env map[string]string) ([]string, error) {
+	result := make([]string, 0, len(commandPassthroughEnv)+len(env))
+	for _, name := range commandPassthroughEnv {
+		if _, override := env[name]; override {
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			result = append(result, name+"="+value)
+		}
+	}
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := resolveCallSecret(ctx, env[name])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, name+"="+value)
+	}
+	return result, nil
+}
+
+// commandTimeout 命令和 stdio MCP 调用的超时时间，使用 http_config 中的 timeout
+func commandTimeout(cfg HTTPClientConfig) time.Duration {
+	timeout, _ := parseDurationField("timeout", cfg.Timeout)
+	if timeout == 0 {
+		timeout = defaultHTTPTimeout
+	}
+	return timeout
+}
+
+// tailBuffer 只保留最后 limit 字节的输出
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.limit:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(bytes.ToValidUTF8(b.buf, nil)))
+}
+
+// limitedBuffer 超过上限时停止写入并取消命令
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+	cancel   context.CancelFunc
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.exceeded {
+		return len(p), nil
+	}
+	if b.buf.Len()+len(p) > b.limit {
+		b.exceeded = true
+		b.cancel()
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// parseCommandURL 解析 command://name 格式的地址
+func parseCommandURL(raw string) (string, error) {
+	name, ok := strings.CutPrefix(raw, "command://")
+	if !ok || !commandNamePattern.MatchString(name) {
+		return "", errors.New("command url must be command://name")
+	}
+	return name, nil
+}
+
+// commandArgument 一行参数模板
+type commandArgument struct {
+	line int
+	tmpl *template.Template
+	flag bool // 模板本身以 - 开头，允许渲染结果是选项
+}
+
+// parseCommandArgs 每行一个参数，使用 Go text/template 语法，空行忽略
+func parseCommandArgs(raw string) ([]commandArgument, error) {
+	args := make([]commandArgument, 0)
+	for i, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		tmpl, err := template.New("arg").Funcs(bodyTemplateFuncs).Option("missingkey=zero").Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command argument on line %d: %v", i+1, err)
+		}
+		args = append(args, commandArgument{line: i + 1, tmpl: tmpl, flag: strings.HasPrefix(line, "-")})
+	}
+	return args, nil
+}
+
+// renderCommandArgs 以 body 参数渲染每行参数，每行对应一个独立的参数，不经过 shell；
+// 渲染结果为空的行被省略，用于可选参数，例如 {{if .verbose}}-v{{end}}
+func renderCommandArgs(args []commandArgument, body map[string]any) ([]string, error) {
+	if body == nil {
+		body = map[string]any{}
+	}
+	result := make([]string, 0, len(args))
+	for _, arg := range args {
+		var buf strings.Builder
+		if err := arg.tmpl.Execute(&buf, body); err != nil {
+			return nil, fmt.Errorf("render command argument on line %d failed: %v", arg.line, err)
+		}
+		value := buf.String()
+		if value == "" {
+			continue
+		}
+		// 参数值不能变成选项，需要时在模板中写出选项名，例如 --ref={{.ref}}
+		if !arg.flag && strings.HasPrefix(value, "-") {
+			return nil, fmt.Errorf("command argument on line %d must not start with -", arg.line)
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// ValidateCommandArgs 保存接口前用示例参数渲染一次参数模板
+func ValidateCommandArgs(raw string, sample map[string]any) error {
+	args, err := parseCommandArgs(raw)
+	if err != nil {
+		return err
+	}
+	_, err = renderCommandArgs(args, sample)
+	return err
+}
+
+// checkLocalProcessParams 本地进程只接收 body 参数
+func checkLocalProcessParams(protocol string, params []models.InterfaceParameter) error {
+	for _, p := range params {
+		if p.Group != "output" && p.Location != "body" {
+			return fmt.Errorf("%s interfaces do not support %s parameter %s", protocol, p.Location, p.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateCommandInterface 校验 command 接口的地址、参数模板和参数位置，
+// 地址不使用环境变量时检查命令在允许列表中
+func ValidateCommandInterface(rawURL, args string, params []models.InterfaceParameter) error {
+	if !strings.Contains(rawURL, "${") {
+		name, err := parseCommandURL(rawURL)
+		if err != nil {
+			return err
+		}
+		if _, err := LookupCommand(name); err != nil {
+			return err
+		}
+	}
+	if err := checkLocalProcessParams("command", params); err != nil {
+		return err
+	}
+	_, err := parseCommandArgs(args)
+	return err
+}
+
+// CommandAdapter 处理 command 协议的接口：执行允许列表中的本地命令，
+// 参数由 body 参数渲染，返回标准输出；退出码非 0 时返回标准错误输出
+type CommandAdapter struct {
+}
+
+func (h CommandAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
+	rawURL, err := expandCallEnvironment(ctx, meta.URL, meta.Env)
+	if err != nil {
+		return nil, err
+	}
+	name, err := parseCommandURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	path, err := LookupCommand(name)
+	if err != nil {
+		return nil, err
+	}
+	tmpls, err := parseCommandArgs(meta.Query)
+	if err != nil {
+		return nil, err
+	}
+	parameters, err = ResolveSecrets(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+	args, err := renderCommandArgs(tmpls, parameters.BodyParams)
+	if err != nil {
+		return nil, err
+	}
+	env, err := commandEnv(ctx, meta.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	// request 只用于记录调用日志
+	request, err := http.NewRequestWithContext(ctx, meta.Method, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	recordUpstreamRequest(request, 0)
+	timeout := commandTimeout(meta.HTTPConfig)
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stdout := &limitedBuffer{limit: maxCommandOutput, cancel: cancel}
+	stderr := &tailBuffer{limit: maxCommandStderr}
+	cmd := exec.CommandContext(runCtx, path, args...)
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// 子进程派生的后台进程可能一直持有输出管道
+	cmd.WaitDelay = time.Second
+	err = cmd.Run()
+	switch {
+	case stdout.exceeded:
+		err = fmt.Errorf("command output exceeds %d bytes", maxCommandOutput)
+	case err == nil:
+	case ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("command timed out after %s: %w", timeout, context.DeadlineExceeded)
+	default:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			err = fmt.Errorf("run command %s failed: %w", name, err)
+		} else if msg := stderr.String(); msg != "" {
+			err = fmt.Errorf("command exited with code %d: %s", exitErr.ExitCode(), msg)
+		} else {
+			err = fmt.Errorf("command exited with code %d", exitErr.ExitCode())
+		}
+	}
+	if err != nil {
+		recordUpstreamResponse(ctx, 0, []byte(err.Error()))
+		return nil, err
+	}
+	data := stdout.buf.Bytes()
+	recordUpstreamResponse(ctx, http.StatusOK, data)
+	return data, nil
+}
+
+func (h CommandAdapter) Compatible(meta RequestMeta) bool {
+	// 凭证通过应用环境变量传给命令
+	return meta.Protocol == "command" && meta.AuthType == "none"
+}
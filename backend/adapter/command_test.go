@@ -0,0 +1,116 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestLookupCommand(t *testing.T) {
+	t.Setenv(CommandAllowlistEnv, "")
+	if _, err := LookupCommand("sh"); err == nil || !strings.Contains(err.Error(), "local commands are disabled") {
+		t.Errorf("expected disabled error, got %v", err)
+	}
+
+	t.Setenv(CommandAllowlistEnv, " shell=/bin/sh, sh ,missing=/no/such/binary")
+	if path, err := LookupCommand("shell"); err != nil || path != "/bin/sh" {
+		t.Errorf("shell = %q, %v", path, err)
+	}
+	if path, err := LookupCommand("sh"); err != nil || !strings.HasSuffix(path, "/sh") {
+		t.Errorf("sh = %q, %v", path, err)
+	}
+	if _, err := LookupCommand("rm"); err == nil || err.Error() != "command rm is not allowed, add it to MCP_ADAPTER_COMMANDS" {
+		t.Errorf("expected not allowed error, got %v", err)
+	}
+	if _, err := LookupCommand("missing"); err == nil {
+		t.Error("expected error for missing binary")
+	}
+
+	t.Setenv(CommandAllowlistEnv, "bad name=/bin/sh")
+	if _, err := LookupCommand("sh"); err == nil || !strings.Contains(err.Error(), "invalid MCP_ADAPTER_COMMANDS entry") {
+		t.Errorf("expected invalid entry error, got %v", err)
+	}
+}
+
+func TestRenderCommandArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		body    map[string]any
+		want    []string
+		wantErr string
+	}{
+		{name: "one argument per line", args: "log\n\n  --max-count={{.count}}  \n--\n{{.path}}", body: map[string]any{"count": float64(5), "path": "a b.txt"}, want: []string{"log", "--max-count=5", "--", "a b.txt"}},
+		{name: "optional flag", args: "{{if .verbose}}-v{{end}}\nstatus", body: map[string]any{"verbose": false}, want: []string{"status"}},
+		{name: "json and default", args: "{{json .filter}}\n{{default \"main\" .ref}}", body: map[string]any{"filter": map[string]any{"a": 1}}, want: []string{`{"a":1}`, "main"}},
+		{name: "no shell expansion", args: "{{.name}}", body: map[string]any{"name": "$(rm -rf /); `id`"}, want: []string{"$(rm -rf /); `id`"}},
+		{name: "option injection", args: "show\n{{.ref}}", body: map[string]any{"ref": "--output=/etc/passwd"}, wantErr: "command argument on line 2 must not start with -"},
+		{name: "invalid template", args: "{{.ref", wantErr: "invalid command argument on line 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpls, err := parseCommandArgs(tt.args)
+			var got []string
+			if err == nil {
+				got, err = renderCommandArgs(tmpls, tt.body)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, "\x00") != strings.Join(tt.want, "\x00") {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandAdapterDoRequest(t *testing.T) {
+	t.Setenv(CommandAllowlistEnv, "sh=/bin/sh")
+	t.Setenv(SecretKeyEnv, "gateway-master-key")
+	h := CommandAdapter{}
+	meta := RequestMeta{URL: "command://sh", Method: "POST", AuthType: "none", Protocol: "command", Env: map[string]string{"GREETING": "hello"}}
+	if !h.Compatible(meta) || (HTTPSimpleAdapter{}).Compatible(meta) {
+		t.Fatal("expected only the command handle to accept command interfaces")
+	}
+	run := func(script string, body map[string]any, cfg HTTPClientConfig) (string, error) {
+		m := meta
+		m.Query = "-c\n" + script + "\nsh\n{{.name}}"
+		m.HTTPConfig = cfg
+		data, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{BodyParams: body}, m)
+		return string(data), err
+	}
+
+	// 参数作为位置参数传入，不会被 shell 解释；子进程只能看到应用环境变量
+	out, err := run(`echo "$GREETING, $1 ${MCP_ADAPTER_SECRET_KEY:-unset}"; echo ignored >&2`, map[string]any{"name": "$(id)"}, HTTPClientConfig{})
+	if err != nil || out != "hello, $(id) unset\n" {
+		t.Errorf("unexpected output %q, %v", out, err)
+	}
+
+	if _, err := run("echo oops >&2; exit 3", nil, HTTPClientConfig{}); err == nil || err.Error() != "command exited with code 3: oops" {
+		t.Errorf("expected exit error, got %v", err)
+	}
+	if _, err := run("exec sleep 5", nil, HTTPClientConfig{Timeout: "100ms"}); err == nil || !strings.Contains(err.Error(), "command timed out after 100ms") || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	if _, err := run("head -c 2000000 /dev/zero", nil, HTTPClientConfig{}); err == nil || err.Error() != "command output exceeds 1048576 bytes" {
+		t.Errorf("expected output limit error, got %v", err)
+	}
+	if _, err := run("echo $1", map[string]any{"name": "-e"}, HTTPClientConfig{}); err == nil || !strings.Contains(err.Error(), "must not start with -") {
+		t.Errorf("expected option injection error, got %v", err)
+	}
+
+	denied := meta
+	denied.URL = "command://rm"
+	if _, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{}, denied); err == nil || !strings.Contains(err.Error(), "command rm is not allowed") {
+		t.Errorf("expected not allowed error, got %v", err)
+	}
+}
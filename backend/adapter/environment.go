@@ -0,0 +1,139 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mcp-adapter/backend/models"
+	"regexp"
+	"sort"
+)
+
+// envPlaceholder 匹配 ${VAR} 形式的环境变量占位符
+var envPlaceholder = regexp.MustCompile(`\$\{([^}]*)}`)
+
+// envName 环境变量名称规则
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseEnvironment 解析应用环境变量，必须是 JSON 对象，值只能是字符串、数字或布尔值
+func ParseEnvironment(raw string) (map[string]string, error) {
+	env := make(map[string]string)
+	if len(bytes.TrimSpace([]byte(raw))) == 0 {
+		return env, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.UseNumber()
+	var values map[string]any
+	if err := decoder.Decode(&values); err != nil || values == nil {
+		return nil, fmt.Errorf("environment must be a JSON object")
+	}
+	for name, value := range values {
+		if !envName.MatchString(name) {
+			return nil, fmt.Errorf("invalid environment variable name: %q", name)
+		}
+		switch v := value.(type) {
+		case string:
+			env[name] = v
+		case json.Number:
+			env[name] = v.String()
+		case bool:
+			env[name] = fmt.Sprintf("%t", v)
+		default:
+			return nil, fmt.Errorf("environment variable %s must be a string, number or boolean", name)
+		}
+	}
+	return env, nil
+}
+
+// EnvironmentSecretRefs 返回环境变量值中引用的密钥名称，按变量名排序
+func EnvironmentSecretRefs(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var names []string
+	for _, key := range keys {
+		if name, ok := ParseSecretRef(env[key]); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ExpandEnvironment 替换字符串中的 ${VAR} 占位符，变量未定义时返回错误
+func ExpandEnvironment(s string, env map[string]string) (string, error) {
+	var missing []string
+	result := envPlaceholder.ReplaceAllStringFunc(s, func(match string) string {
+		name := match[2 : len(match)-1]
+		if value, ok := env[name]; ok {
+			return value
+		}
+		missing = append(missing, name)
+		return match
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unknown environment variable: %s", missing[0])
+	}
+	return result, nil
+}
+
+// expandCallEnvironment 在 DoRequest 中展开 ${VAR} 占位符，值为 secret:// 引用的变量先解析为明文，
+// 解析出的明文记录到本次调用，写入调用日志前替换
+func expandCallEnvironment(ctx context.Context, s string, env map[string]string) (string, error) {
+	var resolved map[string]string
+	for _, match := range envPlaceholder.FindAllStringSubmatch(s, -1) {
+		name := match[1]
+		if _, ok := ParseSecretRef(env[name]); !ok {
+			continue
+		}
+		plain, err := resolveCallSecret(ctx, env[name])
+		if err != nil {
+			return "", fmt.Errorf("environment variable %s: %w", name, err)
+		}
+		if resolved == nil {
+			resolved = maps.Clone(env)
+		}
+		resolved[name] = plain
+	}
+	if resolved == nil {
+		return ExpandEnvironment(s, env)
+	}
+	return ExpandEnvironment(s, resolved)
+}
+
+// checkEnvironmentReferences 注册工具前检查 URL 和 fixed 参数默认值引用的变量都已定义；
+// 值为密钥引用的变量在 fixed 参数中只能单独作为整个默认值，调用时作为密钥解析
+func checkEnvironmentReferences(iface *models.Interface, params []models.InterfaceParameter, env map[string]string) error {
+	values := []string{iface.URL}
+	for _, p := range params {
+		if p.Group != "fixed" || p.DefaultValue == nil {
+			continue
+		}
+		values = append(values, *p.DefaultValue)
+		for _, match := range envPlaceholder.FindAllStringSubmatch(*p.DefaultValue, -1) {
+			if _, ok := ParseSecretRef(env[match[1]]); ok && match[0] != *p.DefaultValue {
+				return fmt.Errorf("fixed parameter %s: secret environment variable %s must be the whole value", p.Name, match[1])
+			}
+		}
+	}
+	missing := make(map[string]bool)
+	for _, value := range values {
+		for _, match := range envPlaceholder.FindAllStringSubmatch(value, -1) {
+			if _, ok := env[match[1]]; !ok {
+				missing[match[1]] = true
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown environment variables: %v", names)
+}
@@ -0,0 +1,124 @@
+package adapter
+
+import (
+	"mcp-adapter/backend/models"
+	"testing"
+)
+
+func TestParseEnvironment(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		expected    map[string]string
+		expectError bool
+	}{
+		{name: "empty string", raw: "", expected: map[string]string{}},
+		{name: "empty object", raw: "{}", expected: map[string]string{}},
+		{
+			name:     "scalar values",
+			raw:      `{"BASE_URL":"https://api.example.com","PORT":8080,"RATIO":0.5,"DEBUG":true}`,
+			expected: map[string]string{"BASE_URL": "https://api.example.com", "PORT": "8080", "RATIO": "0.5", "DEBUG": "true"},
+		},
+		{name: "not an object", raw: `["a"]`, expectError: true},
+		{name: "null", raw: `null`, expectError: true},
+		{name: "invalid json", raw: `{"a":`, expectError: true},
+		{name: "nested value", raw: `{"A":{"B":"c"}}`, expectError: true},
+		{name: "null value", raw: `{"A":null}`, expectError: true},
+		{name: "invalid name", raw: `{"BASE-URL":"x"}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := ParseEnvironment(tt.raw)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %v", env)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(env) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, env)
+			}
+			for k, v := range tt.expected {
+				if env[k] != v {
+					t.Errorf("expected %s=%s, got %s", k, v, env[k])
+				}
+			}
+		})
+	}
+}
+
+func TestExpandEnvironment(t *testing.T) {
+	env := map[string]string{"HOST": "api.example.com", "VERSION": "v2", "EMPTY": ""}
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{name: "no placeholder", input: "https://example.com/a", expected: "https://example.com/a"},
+		{name: "multiple placeholders", input: "https://${HOST}/${VERSION}/users", expected: "https://api.example.com/v2/users"},
+		{name: "empty value", input: "a${EMPTY}b", expected: "ab"},
+		{name: "path parameter untouched", input: "${HOST}/users/{id}", expected: "api.example.com/users/{id}"},
+		{name: "unknown variable", input: "${MISSING}/users", expectError: true},
+		{name: "nil environment", input: "${HOST}", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := env
+			if tt.name == "nil environment" {
+				e = nil
+			}
+			result, err := ExpandEnvironment(tt.input, e)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %s", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCheckEnvironmentReferences(t *testing.T) {
+	iface := &models.Interface{URL: "${BASE_URL}/users"}
+	params := []models.InterfaceParameter{
+		{Name: "token", Group: "fixed", DefaultValue: stringPtr("Bearer ${TOKEN}")},
+		{Name: "q", Group: "input", DefaultValue: stringPtr("${IGNORED}")},
+	}
+
+	if err := checkEnvironmentReferences(iface, params, map[string]string{"BASE_URL": "x", "TOKEN": "y"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := checkEnvironmentReferences(iface, params, map[string]string{})
+	if err == nil || err.Error() != "unknown environment variables: [BASE_URL TOKEN]" {
+		t.Errorf("expected missing variables error, got %v", err)
+	}
+	// 值为密钥引用的变量不能拼接在 fixed 参数中
+	err = checkEnvironmentReferences(iface, params, map[string]string{"BASE_URL": "x", "TOKEN": "secret://token"})
+	if err == nil || err.Error() != "fixed parameter token: secret environment variable TOKEN must be the whole value" {
+		t.Errorf("expected embedded secret error, got %v", err)
+	}
+	params[0].DefaultValue = stringPtr("${TOKEN}")
+	if err := checkEnvironmentReferences(iface, params, map[string]string{"BASE_URL": "x", "TOKEN": "secret://token"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEnvironmentSecretRefs(t *testing.T) {
+	env := map[string]string{"TOKEN": "secret://gh-token", "BASE_URL": "https://api.example.com", "DB": "secret://db-dsn", "NOTE": "Bearer secret://x"}
+	refs := EnvironmentSecretRefs(env)
+	if len(refs) != 2 || refs[0] != "db-dsn" || refs[1] != "gh-token" {
+		t.Errorf("unexpected refs: %v", refs)
+	}
+}
@@ -0,0 +1,88 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// headerName HTTP 头名称规则 (RFC 7230 token)
+var headerName = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
+// forbiddenForwardHeaders 由 HTTP 客户端或连接本身管理的头，不能透传
+var forbiddenForwardHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Te":                true,
+	"Trailer":           true,
+	"Upgrade":           true,
+	"Proxy-Connection":  true,
+}
+
+// ParseForwardHeaders 解析应用的透传头配置
+// 配置是 JSON 对象，键为 MCP 客户端请求中的头，值为转发到上游时使用的头名称，为空表示不改名
+// 返回的映射中键和值都已规范化 (http.CanonicalHeaderKey)
+func ParseForwardHeaders(raw string) (map[string]string, error) {
+	headers := make(map[string]string)
+	if len(bytes.TrimSpace([]byte(raw))) == 0 {
+		return headers, nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(raw), &values); err != nil || values == nil {
+		return nil, fmt.Errorf("forward_headers must be a JSON object of header names")
+	}
+	targets := make(map[string]string)
+	for from, to := range values {
+		if to == "" {
+			to = from
+		}
+		for _, name := range []string{from, to} {
+			if !headerName.MatchString(name) {
+				return nil, fmt.Errorf("invalid header name: %q", name)
+			}
+			if forbiddenForwardHeaders[http.CanonicalHeaderKey(name)] {
+				return nil, fmt.Errorf("header %s cannot be forwarded", http.CanonicalHeaderKey(name))
+			}
+		}
+		from, to = http.CanonicalHeaderKey(from), http.CanonicalHeaderKey(to)
+		if _, ok := headers[from]; ok {
+			return nil, fmt.Errorf("duplicate forwarded header: %s", from)
+		}
+		if other, ok := targets[to]; ok {
+			names := []string{other, from}
+			sort.Strings(names)
+			return nil, fmt.Errorf("headers %s are forwarded to the same header %s", strings.Join(names, ", "), to)
+		}
+		headers[from] = to
+		targets[to] = from
+	}
+	return headers, nil
+}
+
+// applyForwardHeaders 把 MCP 客户端请求中的头按配置转发到上游请求
+// 在鉴权之后调用，调用方自己的凭证优先于接口配置的共享凭证；客户端没有发送的头不会覆盖已有值
+func applyForwardHeaders(request *http.Request, req mcp.CallToolRequest, meta RequestMeta) {
+	if req.Header == nil {
+		return
+	}
+	for from, to := range meta.ForwardHeaders {
+		values := req.Header.Values(from)
+		if len(values) == 0 {
+			continue
+		}
+		request.Header.Del(to)
+		for _, v := range values {
+			request.Header.Add(to, v)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package adapter
+
+import (
+	"context"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseForwardHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"same name", `{"authorization": ""}`, map[string]string{"Authorization": "Authorization"}, false},
+		{"rename", `{"X-User-Token": "x-backend-token"}`, map[string]string{"X-User-Token": "X-Backend-Token"}, false},
+		{"not an object", `["Authorization"]`, nil, true},
+		{"non string value", `{"Authorization": true}`, nil, true},
+		{"invalid name", `{"X User": ""}`, nil, true},
+		{"invalid target", `{"X-User": "a:b"}`, nil, true},
+		{"forbidden header", `{"Host": ""}`, nil, true},
+		{"forbidden target", `{"X-Len": "content-length"}`, nil, true},
+		{"duplicate source", `{"authorization": "", "Authorization": "X-Auth"}`, nil, true},
+		{"duplicate target", `{"X-A": "Authorization", "X-B": "Authorization"}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseForwardHeaders(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseForwardHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("expected %s -> %s, got %s", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyForwardHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	req := mcp.CallToolRequest{Header: http.Header{}}
+	req.Header.Set("Authorization", "Bearer user-token")
+	req.Header.Set("X-User-Token", "u-1")
+	req.Header.Set("X-Not-Forwarded", "secret")
+	meta := RequestMeta{
+		URL:      server.URL,
+		Method:   http.MethodGet,
+		Protocol: "http",
+		AuthType: "none",
+		ForwardHeaders: map[string]string{
+			"Authorization": "Authorization",
+			"X-User-Token":  "X-Backend-User",
+			"X-Tenant":      "X-Tenant",
+		},
+	}
+
+	_, err := HTTPSimpleAdapter{}.DoRequest(context.Background(), req,
+		Parameters{HeaderParams: map[string]any{"X-Tenant": "fixed-tenant"}}, meta)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := received.Get("Authorization"); got != "Bearer user-token" {
+		t.Errorf("expected forwarded Authorization, got %q", got)
+	}
+	if got := received.Get("X-Backend-User"); got != "u-1" {
+		t.Errorf("expected renamed header, got %q", got)
+	}
+	if received.Get("X-User-Token") != "" || received.Get("X-Not-Forwarded") != "" {
+		t.Error("headers not listed in the config should not be forwarded")
+	}
+	// 客户端没有发送的头不会覆盖 fixed 参数
+	if got := received.Get("X-Tenant"); got != "fixed-tenant" {
+		t.Errorf("expected fixed header to be kept, got %q", got)
+	}
+
+	// 调用方的凭证优先于接口配置的共享凭证
+	meta.AuthType = "bearer"
+	meta.Ext = map[string]string{ExtAuthConfig: `{"token":"shared-token"}`}
+	if _, err := (HTTPAuthAdapter{}).DoRequest(context.Background(), req, Parameters{}, meta); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := received.Get("Authorization"); got != "Bearer user-token" {
+		t.Errorf("expected caller credential to win, got %q", got)
+	}
+	if _, err := (HTTPAuthAdapter{}).DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{}, meta); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := received.Get("Authorization"); got != "Bearer shared-token" {
+		t.Errorf("expected shared credential when caller sends none, got %q", got)
+	}
+}
+
+func TestToolForwardsApplicationHeaders(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	app := models.Application{Name: "Forward", Path: "forward-app", Protocol: "sse", ForwardHeaders: `{"X-User-Token":"Authorization"}`}
+	createWithEnabled(t, &app, true)
+	iface := models.Interface{AppID: app.ID, Name: "whoami", Protocol: "http", URL: server.URL, Method: "GET", AuthType: "none"}
+	createWithEnabled(t, &iface, true)
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatalf("add application failed: %v", err)
+	}
+
+	s, _ := sm.sseServers.Load("forward-app")
+	tool := s.(*Server).server.GetTool("whoami")
+	if tool == nil {
+		t.Fatal("tool not registered")
+	}
+	req := mcp.CallToolRequest{Header: http.Header{"X-User-Token": []string{"Bearer u-42"}}}
+	req.Params.Name = "whoami"
+	req.Params.Arguments = map[string]any{}
+	result, err := tool.Handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("tool call failed: %v %+v", err, result)
+	}
+	if got := received.Get("Authorization"); got != "Bearer u-42" {
+		t.Errorf("expected forwarded header, got %q", got)
+	}
+
+	invalid := app
+	invalid.ForwardHeaders = `{"Host":""}`
+	other := models.Interface{AppID: app.ID, Name: "other", Protocol: "http", URL: server.URL, Method: "GET", AuthType: "none", Enabled: true}
+	if err := sm.addTool(&other, &invalid); err == nil {
+		t.Error("expected invalid forward headers error")
+	}
+}
@@ -0,0 +1,258 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// graphQLAcceptHeader GraphQL over HTTP 推荐的响应类型，兼容只返回 application/json 的服务
+const graphQLAcceptHeader = "application/graphql-response+json, application/json"
+
+// IntrospectionQuery 标准内省查询，导入时用于获取 schema
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    types {
+      kind
+      name
+      description
+      fields {
+        name
+        description
+        args { ...InputValue }
+        type { ...TypeRef }
+      }
+      inputFields { ...InputValue }
+      enumValues { name }
+    }
+  }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } }
+}`
+
+// ValidateGraphQLQuery 校验 GraphQL 查询文档：只能包含一个 query 或 mutation 操作，
+// body 参数都必须声明为变量，没有默认值的非空变量必须有对应的 body 参数
+func ValidateGraphQLQuery(query string, params []models.InterfaceParameter) error {
+	if strings.TrimSpace(query) == "" {
+		return errors.New("graphql interfaces require a query")
+	}
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return fmt.Errorf("invalid graphql query: %v", err)
+	}
+	if len(doc.Operations) != 1 {
+		return errors.New("graphql query must contain exactly one operation")
+	}
+	op := doc.Operations[0]
+	if op.Operation == ast.Subscription {
+		return errors.New("graphql subscriptions are not supported")
+	}
+	body := make(map[string]bool)
+	for _, p := range params {
+		if p.Location == "body" && p.Group != "output" {
+			body[p.Name] = true
+		}
+	}
+	declared := make(map[string]bool)
+	for _, v := range op.VariableDefinitions {
+		declared[v.Variable] = true
+		if v.Type.NonNull && v.DefaultValue == nil && !body[v.Variable] {
+			return fmt.Errorf("graphql variable $%s is required but has no body parameter", v.Variable)
+		}
+	}
+	for _, p := range params {
+		if body[p.Name] && !declared[p.Name] {
+			return fmt.Errorf("parameter %s is not declared as a variable in the graphql query", p.Name)
+		}
+	}
+	return nil
+}
+
+// GraphQLError 上游返回了 GraphQL errors，保留错误列表和部分结果
+type GraphQLError struct {
+	Errors []json.RawMessage `json:"errors"`
+	Data   json.RawMessage   `json:"data,omitempty"`
+	status *HTTPStatusError
+}
+
+func (e *GraphQLError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, raw := range e.Errors {
+		var item struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(raw, &item) == nil && item.Message != "" {
+			messages = append(messages, item.Message)
+		}
+	}
+	if len(messages) == 0 {
+		return "graphql request failed"
+	}
+	return "graphql request failed: " + strings.Join(messages, "; ")
+}
+
+// Unwrap 非 2xx 响应中的 errors 保留原始状态码，熔断仍按状态码统计失败
+func (e *GraphQLError) Unwrap() error {
+	if e.status == nil {
+		return nil
+	}
+	return e.status
+}
+
+// ToolResultText 以 JSON 形式返回 errors 和部分结果，便于模型根据 path 判断哪个字段出错
+func (e *GraphQLError) ToolResultText() string {
+	result := map[string]any{"errors": e.Errors}
+	if len(e.Data) > 0 && !bytes.Equal(bytes.TrimSpace(e.Data), []byte("null")) {
+		result["data"] = e.Data
+	}
+	if e.status != nil {
+		result["status_code"] = e.status.StatusCode
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return e.Error()
+	}
+	return string(data)
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage   `json:"data"`
+	Errors []json.RawMessage `json:"errors"`
+}
+
+// unwrapGraphQLResponse 返回响应中的 data，errors 非空时转换为 GraphQLError
+func unwrapGraphQLResponse(data []byte) ([]byte, error) {
+	var resp graphQLResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("invalid graphql response: %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, &GraphQLError{Errors: resp.Errors, Data: resp.Data}
+	}
+	if len(resp.Data) == 0 {
+		return nil, errors.New("invalid graphql response: missing data")
+	}
+	return resp.Data, nil
+}
+
+// buildGraphQLRequest 构建 POST 请求，请求体为 {"query": 文档, "variables": body 参数}，
+// URL、query、header、path 参数的处理与 HTTP 接口一致
+func buildGraphQLRequest(ctx context.Context, parameters Parameters, meta RequestMeta) (*http.Request, []byte, error) {
+	variables := parameters.BodyParams
+	if variables == nil {
+		variables = map[string]any{}
+	}
+	parameters.BodyParams = map[string]any{"query": meta.Query, "variables": variables}
+	meta.Method = http.MethodPost
+	meta.BodyEncoding = BodyEncodingJSON
+	meta.BodyTemplate = nil
+	request, payload, err := BuildCommonHttpRequest(ctx, parameters, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request.Header.Get("Content-Type") == "" {
+		request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	if request.Header.Get("Accept") == "" {
+		request.Header.Set("Accept", graphQLAcceptHeader)
+	}
+	return request, payload, nil
+}
+
+// GraphQLAdapter 处理 graphql 协议的接口：发送保存的查询文档，返回 data，errors 转换为工具错误
+type GraphQLAdapter struct {
+}
+
+func (h GraphQLAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
+	cfg, err := ParseAuthConfig(meta.AuthType, meta.Ext[ExtAuthConfig])
+	if err != nil {
+		return nil, err
+	}
+	parameters, err = ResolveSecrets(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+	request, payload, err := buildGraphQLRequest(ctx, parameters, meta)
+	if err != nil {
+		return nil, err
+	}
+	switch c := cfg.(type) {
+	case nil:
+		applyForwardHeaders(request, req, meta)
+	case AWSSigV4AuthConfig:
+		param, err := awsSigV4Credentials(ctx, req, c)
+		if err != nil {
+			return nil, err
+		}
+		// 透传的头也需要参与签名
+		applyForwardHeaders(request, req, meta)
+		SignAWSSigV4(request, payload, param)
+	default:
+		if err := applyAuth(ctx, request, cfg, meta.HTTPConfig); err != nil {
+			return nil, err
+		}
+		applyForwardHeaders(request, req, meta)
+	}
+	data, status, err := executeHTTPRequest(request, meta.HTTPConfig)
+	invalidateOAuth2TokenOnUnauthorized(cfg, status)
+	if err != nil {
+		// 很多服务在 4xx/5xx 响应中同样返回 errors，优先使用其中的错误信息
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) && !statusErr.Truncated {
+			var resp graphQLResponse
+			if json.Unmarshal([]byte(statusErr.Body), &resp) == nil && len(resp.Errors) > 0 {
+				return nil, &GraphQLError{Errors: resp.Errors, Data: resp.Data, status: statusErr}
+			}
+		}
+		return nil, err
+	}
+	return unwrapGraphQLResponse(data)
+}
+
+func (h GraphQLAdapter) Compatible(meta RequestMeta) bool {
+	if meta.Protocol != "graphql" {
+		return false
+	}
+	switch meta.AuthType {
+	case "none", "bearer", "basic", "apikey", "oauth2", "aws_sigv4":
+		return true
+	}
+	return false
+}
+
+// IntrospectGraphQL 向 GraphQL 端点发送内省查询，返回 data 部分
+func IntrospectGraphQL(ctx context.Context, endpoint, authType, authConfig string, env map[string]string, httpConfig HTTPClientConfig) ([]byte, error) {
+	meta := RequestMeta{
+		URL:        endpoint,
+		Method:     http.MethodPost,
+		AuthType:   authType,
+		Protocol:   "graphql",
+		Env:        env,
+		Ext:        map[string]string{ExtAuthConfig: authConfig},
+		HTTPConfig: httpConfig,
+		Query:      IntrospectionQuery,
+	}
+	return GraphQLAdapter{}.DoRequest(ctx, mcp.CallToolRequest{}, Parameters{}, meta)
+}
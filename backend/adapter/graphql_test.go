@@ -0,0 +1,204 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestValidateGraphQLQuery(t *testing.T) {
+	body := func(name string) models.InterfaceParameter {
+		return models.InterfaceParameter{Name: name, Location: "body", Group: "input"}
+	}
+	tests := []struct {
+		name    string
+		query   string
+		params  []models.InterfaceParameter
+		wantErr string
+	}{
+		{name: "query with variables", query: `query user($id: ID!, $limit: Int) { user(id: $id) { posts(limit: $limit) { id } } }`, params: []models.InterfaceParameter{body("id"), body("limit")}},
+		{name: "mutation", query: `mutation { reset }`},
+		{name: "non-null variable with default", query: `query list($first: Int! = 10) { items(first: $first) { id } }`},
+		{name: "output and header parameters ignored", query: `{ me { id } }`, params: []models.InterfaceParameter{
+			{Name: "me", Location: "body", Group: "output"},
+			{Name: "X-Tenant", Location: "header", Group: "input"},
+		}},
+		{name: "empty", query: "  ", wantErr: "graphql interfaces require a query"},
+		{name: "syntax error", query: `query { user(id: ) }`, wantErr: "invalid graphql query"},
+		{name: "multiple operations", query: `query a { a } query b { b }`, wantErr: "exactly one operation"},
+		{name: "subscription", query: `subscription { events { id } }`, wantErr: "subscriptions are not supported"},
+		{name: "required variable without parameter", query: `query user($id: ID!) { user(id: $id) { id } }`, wantErr: "graphql variable $id is required"},
+		{name: "undeclared parameter", query: `{ me { id } }`, params: []models.InterfaceParameter{body("id")}, wantErr: "parameter id is not declared"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGraphQLQuery(tt.query, tt.params)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGraphQLAdapterDoRequest(t *testing.T) {
+	var received struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	var header http.Header
+	var rawQuery string
+	status, response := http.StatusOK, ""
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		header, rawQuery = r.Header.Clone(), r.URL.RawQuery
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &received); err != nil {
+			t.Errorf("invalid request body %s: %v", data, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	defer upstream.Close()
+
+	query := `query user($id: ID!) { user(id: $id) { name } }`
+	meta := RequestMeta{
+		URL:      upstream.URL + "/graphql",
+		Method:   http.MethodPost,
+		AuthType: "bearer",
+		Protocol: "graphql",
+		Ext:      map[string]string{ExtAuthConfig: `{"token": "t0ken"}`},
+		Query:    query,
+	}
+	params := Parameters{
+		BodyParams:  map[string]any{"id": "42"},
+		QueryParams: map[string]any{"tenant": "acme"},
+	}
+	h := GraphQLAdapter{}
+	if !h.Compatible(meta) || (HTTPAuthAdapter{}).Compatible(meta) {
+		t.Fatal("expected only the graphql handle to accept graphql interfaces")
+	}
+
+	response = `{"data": {"user": {"name": "Ada"}}}`
+	data, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"user": {"name": "Ada"}}` {
+		t.Errorf("expected data to be unwrapped, got %s", data)
+	}
+	if received.Query != query || received.Variables["id"] != "42" {
+		t.Errorf("unexpected request body %+v", received)
+	}
+	if header.Get("Authorization") != "Bearer t0ken" || !strings.HasPrefix(header.Get("Content-Type"), "application/json") {
+		t.Errorf("unexpected request headers %v", header)
+	}
+	if rawQuery != "tenant=acme" {
+		t.Errorf("expected query parameters in the URL, got %q", rawQuery)
+	}
+
+	// 200 响应中的 errors 转换为工具错误，保留部分结果
+	response = `{"data": {"user": null}, "errors": [{"message": "not found", "path": ["user"]}]}`
+	_, err = h.DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta)
+	var gqlErr *GraphQLError
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("expected GraphQLError, got %v", err)
+	}
+	if err.Error() != "graphql request failed: not found" {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		t.Error("expected 200 response not to carry a status error")
+	}
+	var text map[string]any
+	if err := json.Unmarshal([]byte(gqlErr.ToolResultText()), &text); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := text["errors"]; !ok {
+		t.Errorf("expected errors in tool result, got %v", text)
+	}
+
+	// 非 2xx 响应中的 errors 同样转换，并保留状态码
+	status, response = http.StatusBadRequest, `{"errors": [{"message": "Variable \"$id\" got invalid value"}]}`
+	_, err = h.DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta)
+	if !errors.As(err, &gqlErr) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected GraphQLError wrapping the 400 status, got %v", err)
+	}
+	if !strings.Contains(gqlErr.ToolResultText(), `"status_code":400`) {
+		t.Errorf("expected status code in tool result, got %s", gqlErr.ToolResultText())
+	}
+
+	// 不是 GraphQL 格式的错误响应保持 HTTPStatusError
+	status, response = http.StatusBadGateway, `upstream down`
+	_, err = h.DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta)
+	if errors.As(err, &gqlErr) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected plain HTTPStatusError, got %v", err)
+	}
+}
+
+func TestAddToolGraphQL(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+	sm.handles = append(sm.handles, GraphQLAdapter{})
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]any `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Variables["id"] == "missing" {
+			w.Write([]byte(`{"data": null, "errors": [{"message": "user missing not found"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"user": {"id": "` + body.Variables["id"].(string) + `", "name": "Ada"}}}`))
+	}))
+	defer upstream.Close()
+
+	app := models.Application{Name: "GraphQL", Path: "graphql-app", Protocol: "sse"}
+	createWithEnabled(t, &app, true)
+	iface := models.Interface{AppID: app.ID, Name: "user", Protocol: "graphql", URL: upstream.URL, Method: "POST", AuthType: "none",
+		Query: `query user($id: ID!) { user(id: $id) { id name } }`}
+	createWithEnabled(t, &iface, true)
+	database.GetDB().Create(&models.InterfaceParameter{AppID: app.ID, InterfaceID: iface.ID, Name: "id", Type: "string", Location: "body", Required: true, Group: "input"})
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatal(err)
+	}
+	defer sm.removeApplication(&app)
+
+	s, _ := sm.sseServers.Load("graphql-app")
+	call := func(id string) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]any{"id": id}
+		result, err := s.(*Server).server.GetTool("user").Handler(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	text := func(r *mcp.CallToolResult) string { return r.Content[0].(mcp.TextContent).Text }
+
+	if result := call("1"); result.IsError || text(result) != `{"user": {"id": "1", "name": "Ada"}}` {
+		t.Errorf("unexpected result %s", text(result))
+	}
+	result := call("missing")
+	if !result.IsError || !strings.Contains(text(result), "user missing not found") {
+		t.Errorf("expected graphql errors as tool error, got %s", text(result))
+	}
+}
@@ -0,0 +1,382 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// parseGRPCURL 解析 grpc://host:port/package.Service/Method 格式的地址，grpcs 使用 TLS，
+// 导入时的地址可以不带方法
+func parseGRPCURL(raw string) (target string, secure bool, method string, err error) {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	switch {
+	case ok && scheme == "grpc":
+	case ok && scheme == "grpcs":
+		secure = true
+	default:
+		return "", false, "", errors.New("grpc url must start with grpc:// or grpcs://")
+	}
+	target, method, _ = strings.Cut(rest, "/")
+	if target == "" {
+		return "", false, "", errors.New("grpc url has no host")
+	}
+	return target, secure, method, nil
+}
+
+// checkGRPCMethodName 方法名格式为 package.Service/Method
+func checkGRPCMethodName(method string) error {
+	service, name, ok := strings.Cut(method, "/")
+	if !ok || service == "" || name == "" || strings.Contains(name, "/") {
+		return errors.New("grpc url must end with /package.Service/Method")
+	}
+	return nil
+}
+
+// ValidateGRPCEndpoint 校验导入时使用的服务地址，地址中不能包含方法
+func ValidateGRPCEndpoint(rawURL string) error {
+	_, _, method, err := parseGRPCURL(rawURL)
+	if err != nil {
+		return err
+	}
+	if method != "" {
+		return errors.New("grpc url must not contain a method, use grpc://host:port")
+	}
+	return nil
+}
+
+// ValidateGRPCInterface 校验 grpc 接口的地址和参数：只支持 body 和 header 参数；
+// 配置了描述集时检查方法存在、是一元调用，并且 body 参数都是请求消息的字段
+func ValidateGRPCInterface(rawURL, descriptorSet string, params []models.InterfaceParameter) error {
+	_, _, method, err := parseGRPCURL(rawURL)
+	if err != nil {
+		return err
+	}
+	if err := checkGRPCMethodName(method); err != nil {
+		return err
+	}
+	for _, p := range params {
+		if p.Group != "output" && (p.Location == "query" || p.Location == "path") {
+			return fmt.Errorf("grpc interfaces do not support %s parameter %s", p.Location, p.Name)
+		}
+	}
+	if strings.TrimSpace(descriptorSet) == "" {
+		return nil
+	}
+	files, err := ParseDescriptorSet(descriptorSet)
+	if err != nil {
+		return err
+	}
+	md, err := findGRPCMethod(files, method)
+	if err != nil {
+		return err
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return fmt.Errorf("grpc method %s is streaming, only unary methods are supported", method)
+	}
+	fields := md.Input().Fields()
+	for _, p := range params {
+		if p.Group == "output" || p.Location != "body" {
+			continue
+		}
+		if fields.ByName(protoreflect.Name(p.Name)) == nil && fields.ByJSONName(p.Name) == nil {
+			return fmt.Errorf("parameter %s is not a field of message %s", p.Name, md.Input().FullName())
+		}
+	}
+	return nil
+}
+
+// GRPCStatusError 上游返回了非 OK 的 gRPC 状态，保留状态码、消息和错误详情
+type GRPCStatusError struct {
+	Code    codes.Code
+	Message string
+	Details []json.RawMessage // google.rpc.Status.details，无法解析的类型只保留 @type
+}
+
+func newGRPCStatusError(s *status.Status) *GRPCStatusError {
+	e := &GRPCStatusError{Code: s.Code(), Message: s.Message()}
+	for _, detail := range s.Proto().GetDetails() {
+		data, err := protojson.Marshal(detail)
+		if err != nil {
+			data, _ = json.Marshal(map[string]string{"@type": detail.GetTypeUrl()})
+		}
+		var buf bytes.Buffer
+		if json.Compact(&buf, data) == nil {
+			data = buf.Bytes()
+		}
+		e.Details = append(e.Details, data)
+	}
+	return e
+}
+
+func (e *GRPCStatusError) Error() string {
+	return fmt.Sprintf("grpc request failed: %s: %s", e.Code, e.Message)
+}
+
+// HTTPStatus 按 gRPC 和 HTTP 状态码的通用对应关系转换，熔断和调用日志按该状态码处理
+func (e *GRPCStatusError) HTTPStatus() int {
+	switch e.Code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	// Unknown, Internal, DataLoss
+	return http.StatusInternalServerError
+}
+
+// ToolResultText 以 JSON 返回状态码名称、消息和错误详情
+func (e *GRPCStatusError) ToolResultText() string {
+	result := map[string]any{"code": e.Code.String(), "message": e.Message}
+	if len(e.Details) > 0 {
+		result["details"] = e.Details
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return e.Error()
+	}
+	return string(data)
+}
+
+var (
+	grpcConns   = make(map[string]*grpc.ClientConn)
+	grpcConnsMu sync.Mutex
+)
+
+// grpcConnFor 按地址和 TLS 配置复用连接，返回的 key 用于缓存反射结果
+func grpcConnFor(target string, secure bool, cfg HTTPClientConfig) (*grpc.ClientConn, string, error) {
+	tlsConfig := HTTPClientConfig{ConnectTimeout: cfg.ConnectTimeout}
+	if secure {
+		tlsConfig.CACert, tlsConfig.ClientCert, tlsConfig.ClientKey = cfg.CACert, cfg.ClientCert, cfg.ClientKey
+		tlsConfig.InsecureSkipVerify = cfg.InsecureSkipVerify
+		if err := resolveAuthSecrets(context.Background(), &tlsConfig.CACert, &tlsConfig.ClientCert, &tlsConfig.ClientKey); err != nil {
+			return nil, "", err
+		}
+	}
+	data, _ := json.Marshal(struct {
+		Target string
+		Secure bool
+		Config HTTPClientConfig
+	}{target, secure, tlsConfig})
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	grpcConnsMu.Lock()
+	defer grpcConnsMu.Unlock()
+	if conn, ok := grpcConns[key]; ok {
+		return conn, key, nil
+	}
+	creds := insecure.NewCredentials()
+	if secure {
+		c, err := tlsConfigFor(tlsConfig)
+		if err != nil {
+			return nil, "", err
+		}
+		creds = credentials.NewTLS(c)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if connectTimeout, _ := parseDurationField("connect_timeout", tlsConfig.ConnectTimeout); connectTimeout > 0 {
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.DefaultConfig, MinConnectTimeout: connectTimeout}))
+	}
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid grpc target %s: %w", target, err)
+	}
+	grpcConns[key] = conn
+	return conn, key, nil
+}
+
+// grpcCall 一次 gRPC 调用的连接和带 metadata 的上下文。鉴权和透传的头按 HTTP 请求的方式处理后作为
+// metadata 发送，request 只用于生成 metadata 和记录调用日志
+type grpcCall struct {
+	conn    *grpc.ClientConn
+	connKey string
+	method  string
+	request *http.Request
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func newGRPCCall(ctx context.Context, req mcp.CallToolRequest, headers map[string]any, meta RequestMeta, cfg any) (*grpcCall, error) {
+	rawURL, err := expandCallEnvironment(ctx, meta.URL, meta.Env)
+	if err != nil {
+		return nil, err
+	}
+	target, secure, method, err := parseGRPCURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	conn, connKey, err := grpcConnFor(target, secure, meta.HTTPConfig)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	for name, val := range headers {
+		request.Header.Set(name, fmt.Sprintf("%v", val))
+	}
+	if apiKey, ok := cfg.(APIKeyAuthConfig); ok && apiKey.In == "query" {
+		return nil, errors.New("apikey auth in query is not supported for grpc interfaces")
+	}
+	if cfg != nil {
+		if err := applyAuth(ctx, request, cfg, meta.HTTPConfig); err != nil {
+			return nil, err
+		}
+	}
+	applyForwardHeaders(request, req, meta)
+	md := metadata.MD{}
+	for name, values := range request.Header {
+		md.Append(strings.ToLower(name), values...)
+	}
+	timeout, _ := parseDurationField("timeout", meta.HTTPConfig.Timeout)
+	if timeout == 0 {
+		timeout = defaultHTTPTimeout
+	}
+	callCtx, cancel := context.WithTimeout(metadata.NewOutgoingContext(ctx, md), timeout)
+	return &grpcCall{conn: conn, connKey: connKey, method: method, request: request, ctx: callCtx, cancel: cancel}, nil
+}
+
+// GRPCAdapter 处理 grpc 协议的接口：body 参数转换为请求消息调用一元方法，响应消息转换为 JSON。
+// 方法描述来自接口保存的描述集，没有描述集时通过服务端反射获取
+type GRPCAdapter struct {
+}
+
+func (h GRPCAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
+	cfg, err := ParseAuthConfig(meta.AuthType, meta.Ext[ExtAuthConfig])
+	if err != nil {
+		return nil, err
+	}
+	parameters, err = ResolveSecrets(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+	call, err := newGRPCCall(ctx, req, parameters.HeaderParams, meta, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer call.cancel()
+	if err := checkGRPCMethodName(call.method); err != nil {
+		return nil, err
+	}
+
+	var md protoreflect.MethodDescriptor
+	var files *protoregistry.Files
+	if strings.TrimSpace(meta.DescriptorSet) != "" {
+		if files, err = ParseDescriptorSet(meta.DescriptorSet); err == nil {
+			md, err = findGRPCMethod(files, call.method)
+		}
+	} else {
+		md, files, err = reflectGRPCMethod(call.ctx, call.conn, call.connKey, call.method)
+	}
+	if err != nil {
+		// 反射时连接失败同样说明上游不可用
+		if s, ok := status.FromError(err); ok {
+			return nil, newGRPCStatusError(s)
+		}
+		return nil, err
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("grpc method %s is streaming, only unary methods are supported", call.method)
+	}
+	types := dynamicpb.NewTypes(files)
+
+	body := parameters.BodyParams
+	if body == nil {
+		body = map[string]any{}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	input := dynamicpb.NewMessage(md.Input())
+	if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(data, input); err != nil {
+		return nil, fmt.Errorf("invalid grpc request: %v", err)
+	}
+
+	recordUpstreamRequest(call.request, 0)
+	output := dynamicpb.NewMessage(md.Output())
+	if err := call.conn.Invoke(call.ctx, "/"+call.method, input, output); err != nil {
+		statusErr := newGRPCStatusError(status.Convert(err))
+		recordUpstreamResponse(ctx, statusErr.HTTPStatus(), []byte(statusErr.ToolResultText()))
+		invalidateOAuth2TokenOnUnauthorized(cfg, statusErr.HTTPStatus())
+		return nil, statusErr
+	}
+	// 未设置的字段输出零值，和出参定义保持一致
+	data, err = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true, Resolver: types}.Marshal(output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grpc response: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, err
+	}
+	recordUpstreamResponse(ctx, http.StatusOK, buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+func (h GRPCAdapter) Compatible(meta RequestMeta) bool {
+	if meta.Protocol != "grpc" {
+		return false
+	}
+	switch meta.AuthType {
+	case "none", "bearer", "basic", "apikey", "oauth2":
+		return true
+	}
+	return false
+}
+
+// ReflectGRPCServices 通过服务端反射获取服务描述，services 为空时获取所有服务，导入时使用
+func ReflectGRPCServices(ctx context.Context, endpoint string, services []string, authType, authConfig string, env map[string]string, httpConfig HTTPClientConfig) (*protoregistry.Files, error) {
+	cfg, err := ParseAuthConfig(authType, authConfig)
+	if err != nil {
+		return nil, err
+	}
+	meta := RequestMeta{URL: endpoint, AuthType: authType, Protocol: "grpc", Env: env, HTTPConfig: httpConfig}
+	call, err := newGRPCCall(ctx, mcp.CallToolRequest{}, nil, meta, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer call.cancel()
+	return reflectFiles(call.ctx, call.conn, services)
+}
@@ -0,0 +1,333 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// 描述集和反射结果中缺少的标准类型使用内置定义
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// grpcReflectionTTL 通过服务端反射解析的方法描述的缓存时间，过期后重新查询以感知服务升级
+const grpcReflectionTTL = 5 * time.Minute
+
+// reflectionServicePrefix 反射服务自身不作为可导入的服务
+const reflectionServicePrefix = "grpc.reflection."
+
+// descriptorSets 已解析的描述集，描述集内容的摘要 -> *protoregistry.Files
+var descriptorSets sync.Map
+
+// ParseDescriptorSet 解析 base64 编码的 FileDescriptorSet (protoc --include_imports --descriptor_set_out 的输出)，
+// 描述集中缺少的 google/protobuf 标准类型使用内置定义
+func ParseDescriptorSet(encoded string) (*protoregistry.Files, error) {
+	encoded = strings.TrimSpace(encoded)
+	sum := sha256.Sum256([]byte(encoded))
+	key := hex.EncodeToString(sum[:])
+	if files, ok := descriptorSets.Load(key); ok {
+		return files.(*protoregistry.Files), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor_set: not base64: %v", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor_set: %v", err)
+	}
+	if len(set.File) == 0 {
+		return nil, errors.New("invalid descriptor_set: no files")
+	}
+	files, err := buildFiles(set.File)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor_set: %w", err)
+	}
+	descriptorSets.Store(key, files)
+	return files, nil
+}
+
+// buildFiles 按依赖顺序注册文件描述
+func buildFiles(protos []*descriptorpb.FileDescriptorProto) (*protoregistry.Files, error) {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(protos))
+	for _, fd := range protos {
+		byName[fd.GetName()] = fd
+	}
+	files := new(protoregistry.Files)
+	visiting := make(map[string]bool)
+	var register func(name string) error
+	register = func(name string) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		fd, ok := byName[name]
+		if !ok {
+			global, err := protoregistry.GlobalFiles.FindFileByPath(name)
+			if err != nil {
+				return fmt.Errorf("missing dependency %s", name)
+			}
+			return files.RegisterFile(global)
+		}
+		if visiting[name] {
+			return fmt.Errorf("import cycle at %s", name)
+		}
+		visiting[name] = true
+		for _, dep := range fd.GetDependency() {
+			if err := register(dep); err != nil {
+				return err
+			}
+		}
+		file, err := protodesc.NewFile(fd, files)
+		if err != nil {
+			return err
+		}
+		return files.RegisterFile(file)
+	}
+	for _, fd := range protos {
+		if err := register(fd.GetName()); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// EncodeDescriptorSet 将文件及其依赖编码为 base64 的 FileDescriptorSet，导入时每个接口只保存自己用到的文件
+func EncodeDescriptorSet(file protoreflect.FileDescriptor) (string, error) {
+	var set descriptorpb.FileDescriptorSet
+	seen := make(map[string]bool)
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	add(file)
+	data, err := proto.Marshal(&set)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// findGRPCMethod 在文件描述中查找 pkg.Service/Method 对应的方法
+func findGRPCMethod(files *protoregistry.Files, method string) (protoreflect.MethodDescriptor, error) {
+	serviceName, methodName, _ := strings.Cut(method, "/")
+	d, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("grpc service %s not found", serviceName)
+	}
+	service, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a grpc service", serviceName)
+	}
+	md := service.Methods().ByName(protoreflect.Name(methodName))
+	if md == nil {
+		return nil, fmt.Errorf("grpc method %s not found in service %s", methodName, serviceName)
+	}
+	return md, nil
+}
+
+// GRPCServices 返回文件描述中的所有服务，反射服务除外
+func GRPCServices(files *protoregistry.Files) []protoreflect.ServiceDescriptor {
+	services := make([]protoreflect.ServiceDescriptor, 0)
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			sd := fd.Services().Get(i)
+			if !strings.HasPrefix(string(sd.FullName()), reflectionServicePrefix) {
+				services = append(services, sd)
+			}
+		}
+		return true
+	})
+	return services
+}
+
+// reflectionClient 一个反射流上的查询，收到的文件描述在流内累积
+type reflectionClient struct {
+	stream reflectionpb.ServerReflection_ServerReflectionInfoClient
+	protos map[string]*descriptorpb.FileDescriptorProto
+	order  []*descriptorpb.FileDescriptorProto
+}
+
+func newReflectionClient(ctx context.Context, conn *grpc.ClientConn) (*reflectionClient, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &reflectionClient{stream: stream, protos: make(map[string]*descriptorpb.FileDescriptorProto)}, nil
+}
+
+func (c *reflectionClient) close() {
+	_ = c.stream.CloseSend()
+	// 读完剩余的消息，让服务端结束流
+	for {
+		if _, err := c.stream.Recv(); err != nil {
+			return
+		}
+	}
+}
+
+func (c *reflectionClient) send(req *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+	if err := c.stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.stream.Recv()
+	if err == io.EOF {
+		return nil, errors.New("grpc reflection stream closed by server")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("grpc reflection error: %s", e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// listServices 返回服务端公开的服务名，反射服务除外
+func (c *reflectionClient) listServices() ([]string, error) {
+	resp, err := c.send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		if !strings.HasPrefix(s.GetName(), reflectionServicePrefix) {
+			names = append(names, s.GetName())
+		}
+	}
+	return names, nil
+}
+
+func (c *reflectionClient) addFiles(resp *reflectionpb.ServerReflectionResponse) error {
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		var fd descriptorpb.FileDescriptorProto
+		if err := proto.Unmarshal(raw, &fd); err != nil {
+			return fmt.Errorf("invalid file descriptor from grpc reflection: %v", err)
+		}
+		if c.protos[fd.GetName()] == nil {
+			c.protos[fd.GetName()] = &fd
+			c.order = append(c.order, &fd)
+		}
+	}
+	return nil
+}
+
+// fetchSymbol 获取定义符号的文件，服务端没有一并返回的依赖逐个按文件名查询
+func (c *reflectionClient) fetchSymbol(symbol string) error {
+	resp, err := c.send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.addFiles(resp); err != nil {
+		return err
+	}
+	for i := 0; i < len(c.order); i++ {
+		for _, dep := range c.order[i].GetDependency() {
+			if c.protos[dep] != nil {
+				continue
+			}
+			if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				continue
+			}
+			resp, err := c.send(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			})
+			if err != nil {
+				return err
+			}
+			if err := c.addFiles(resp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *reflectionClient) files() (*protoregistry.Files, error) {
+	files, err := buildFiles(c.order)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors from grpc reflection: %w", err)
+	}
+	return files, nil
+}
+
+// reflectFiles 通过服务端反射获取服务的文件描述，services 为空时获取所有服务
+func reflectFiles(ctx context.Context, conn *grpc.ClientConn, services []string) (*protoregistry.Files, error) {
+	client, err := newReflectionClient(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer client.close()
+	if len(services) == 0 {
+		if services, err = client.listServices(); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range services {
+		if err := client.fetchSymbol(name); err != nil {
+			return nil, fmt.Errorf("grpc service %s: %w", name, err)
+		}
+	}
+	return client.files()
+}
+
+type reflectedMethod struct {
+	method  protoreflect.MethodDescriptor
+	types   *protoregistry.Files
+	expires time.Time
+}
+
+// reflectedMethods 通过反射解析的方法，连接 key + 方法名 -> reflectedMethod
+var reflectedMethods sync.Map
+
+// reflectGRPCMethod 通过服务端反射解析方法描述，结果缓存 grpcReflectionTTL
+func reflectGRPCMethod(ctx context.Context, conn *grpc.ClientConn, connKey, method string) (protoreflect.MethodDescriptor, *protoregistry.Files, error) {
+	key := connKey + "|" + method
+	if v, ok := reflectedMethods.Load(key); ok {
+		if cached := v.(reflectedMethod); time.Now().Before(cached.expires) {
+			return cached.method, cached.types, nil
+		}
+	}
+	serviceName, _, _ := strings.Cut(method, "/")
+	files, err := reflectFiles(ctx, conn, []string{serviceName})
+	if err != nil {
+		return nil, nil, fmt.Errorf("grpc reflection failed: %w", err)
+	}
+	md, err := findGRPCMethod(files, method)
+	if err != nil {
+		return nil, nil, err
+	}
+	reflectedMethods.Store(key, reflectedMethod{method: md, types: files, expires: time.Now().Add(grpcReflectionTTL)})
+	return md, files, nil
+}
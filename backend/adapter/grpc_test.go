@@ -0,0 +1,314 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testUserProto demo/v1/user.proto 的文件描述:
+//
+//	service UserService { rpc GetUser(GetUserRequest) returns (User); rpc WatchUsers(GetUserRequest) returns (stream User); }
+const testUserProto = `
+name: "demo/v1/user.proto"
+package: "demo.v1"
+dependency: "google/protobuf/timestamp.proto"
+syntax: "proto3"
+message_type {
+  name: "GetUserRequest"
+  field { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "id" }
+  field { name: "with_tags" number: 2 label: LABEL_OPTIONAL type: TYPE_BOOL json_name: "withTags" }
+}
+message_type {
+  name: "User"
+  field { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "id" }
+  field { name: "display_name" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "displayName" }
+  field { name: "role" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".demo.v1.Role" json_name: "role" }
+  field { name: "tags" number: 4 label: LABEL_REPEATED type: TYPE_STRING json_name: "tags" }
+  field { name: "created_at" number: 5 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" json_name: "createdAt" }
+}
+enum_type {
+  name: "Role"
+  value { name: "ROLE_UNSPECIFIED" number: 0 }
+  value { name: "ROLE_ADMIN" number: 1 }
+}
+service {
+  name: "UserService"
+  method { name: "GetUser" input_type: ".demo.v1.GetUserRequest" output_type: ".demo.v1.User" }
+  method { name: "WatchUsers" input_type: ".demo.v1.GetUserRequest" output_type: ".demo.v1.User" server_streaming: true }
+}`
+
+func testUserFiles(t *testing.T) *protoregistry.Files {
+	var fd descriptorpb.FileDescriptorProto
+	if err := prototext.Unmarshal([]byte(testUserProto), &fd); err != nil {
+		t.Fatal(err)
+	}
+	files, err := buildFiles([]*descriptorpb.FileDescriptorProto{&fd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func testUserDescriptorSet(t *testing.T, files *protoregistry.Files) string {
+	fd, err := files.FindFileByPath("demo/v1/user.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := EncodeDescriptorSet(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+// startTestUserServer 启动进程内的 UserService，注册 v1 反射服务，返回地址和收到的 metadata
+func startTestUserServer(t *testing.T, files *protoregistry.Files) (string, *metadata.MD) {
+	d, err := files.FindDescriptorByName("demo.v1.UserService")
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := d.(protoreflect.ServiceDescriptor).Methods().ByName("GetUser")
+	var received metadata.MD
+	s := grpc.NewServer()
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "demo.v1.UserService",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "GetUser",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				received, _ = metadata.FromIncomingContext(ctx)
+				in := dynamicpb.NewMessage(method.Input())
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				id := in.Get(method.Input().Fields().ByName("id")).Int()
+				if id == 404 {
+					st, _ := status.New(codes.NotFound, "user 404 not found").WithDetails(wrapperspb.String("check the id"))
+					return nil, st.Err()
+				}
+				out := dynamicpb.NewMessage(method.Output())
+				fields := method.Output().Fields()
+				out.Set(fields.ByName("id"), protoreflect.ValueOfInt64(id))
+				out.Set(fields.ByName("display_name"), protoreflect.ValueOfString("Ada"))
+				out.Set(fields.ByName("role"), protoreflect.ValueOfEnum(1))
+				if in.Get(method.Input().Fields().ByName("with_tags")).Bool() {
+					tags := out.Mutable(fields.ByName("tags")).List()
+					tags.Append(protoreflect.ValueOfString("admin"))
+				}
+				return out, nil
+			},
+		}},
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "WatchUsers",
+			ServerStreams: true,
+			Handler:       func(any, grpc.ServerStream) error { return nil },
+		}},
+	}, struct{}{})
+	reflectionpb.RegisterServerReflectionServer(s, reflection.NewServerV1(reflection.ServerOptions{Services: s, DescriptorResolver: files}))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String(), &received
+}
+
+func TestValidateGRPCInterface(t *testing.T) {
+	files := testUserFiles(t)
+	set := testUserDescriptorSet(t, files)
+	body := func(name string) models.InterfaceParameter {
+		return models.InterfaceParameter{Name: name, Location: "body", Group: "input"}
+	}
+	tests := []struct {
+		name    string
+		url     string
+		set     string
+		params  []models.InterfaceParameter
+		wantErr string
+	}{
+		{name: "reflection", url: "grpc://${USER_HOST}/demo.v1.UserService/GetUser", params: []models.InterfaceParameter{body("anything")}},
+		{name: "descriptor set", url: "grpcs://users:443/demo.v1.UserService/GetUser", set: set, params: []models.InterfaceParameter{
+			body("id"), body("withTags"),
+			{Name: "x-tenant", Location: "header", Group: "input"},
+			{Name: "display_name", Location: "body", Group: "output"},
+		}},
+		{name: "http url", url: "http://users/demo.v1.UserService/GetUser", wantErr: "must start with grpc:// or grpcs://"},
+		{name: "no host", url: "grpc:///demo.v1.UserService/GetUser", wantErr: "has no host"},
+		{name: "no method", url: "grpc://users:50051", wantErr: "must end with /package.Service/Method"},
+		{name: "query parameter", url: "grpc://users/demo.v1.UserService/GetUser", params: []models.InterfaceParameter{{Name: "id", Location: "query", Group: "input"}}, wantErr: "do not support query parameter id"},
+		{name: "unknown service", url: "grpc://users/demo.v1.OrderService/GetUser", set: set, wantErr: "grpc service demo.v1.OrderService not found"},
+		{name: "unknown method", url: "grpc://users/demo.v1.UserService/DeleteUser", set: set, wantErr: "grpc method DeleteUser not found"},
+		{name: "streaming", url: "grpc://users/demo.v1.UserService/WatchUsers", set: set, wantErr: "only unary methods are supported"},
+		{name: "unknown field", url: "grpc://users/demo.v1.UserService/GetUser", set: set, params: []models.InterfaceParameter{body("name")}, wantErr: "parameter name is not a field of message demo.v1.GetUserRequest"},
+		{name: "invalid descriptor set", url: "grpc://users/demo.v1.UserService/GetUser", set: "not base64!", wantErr: "invalid descriptor_set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGRPCInterface(tt.url, tt.set, tt.params)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGRPCAdapterDoRequest(t *testing.T) {
+	files := testUserFiles(t)
+	addr, received := startTestUserServer(t, files)
+	meta := RequestMeta{
+		URL:      "grpc://" + addr + "/demo.v1.UserService/GetUser",
+		Method:   http.MethodPost,
+		AuthType: "bearer",
+		Protocol: "grpc",
+		Ext:      map[string]string{ExtAuthConfig: `{"token": "t0ken"}`},
+	}
+	params := Parameters{
+		BodyParams:   map[string]any{"id": float64(42), "with_tags": true},
+		HeaderParams: map[string]any{"X-Tenant": "acme"},
+	}
+	h := GRPCAdapter{}
+	if !h.Compatible(meta) || (HTTPAuthAdapter{}).Compatible(meta) {
+		t.Fatal("expected only the grpc handle to accept grpc interfaces")
+	}
+
+	want := map[string]any{"id": "42", "display_name": "Ada", "role": "ROLE_ADMIN", "tags": []any{"admin"}, "created_at": nil}
+	check := func(name string, meta RequestMeta) {
+		data, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got map[string]any
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: invalid response %s: %v", name, data, err)
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s: expected %s, got %s", name, wantJSON, data)
+		}
+		if received.Get("authorization")[0] != "Bearer t0ken" || received.Get("x-tenant")[0] != "acme" {
+			t.Errorf("%s: unexpected metadata %v", name, *received)
+		}
+	}
+	// 服务端反射
+	check("reflection", meta)
+	// 接口保存的描述集
+	withSet := meta
+	withSet.DescriptorSet = testUserDescriptorSet(t, files)
+	check("descriptor set", withSet)
+
+	// 非 OK 状态转换为 GRPCStatusError，保留错误详情
+	params.BodyParams = map[string]any{"id": "404"}
+	_, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta)
+	var grpcErr *GRPCStatusError
+	if !errors.As(err, &grpcErr) || grpcErr.Code != codes.NotFound || grpcErr.HTTPStatus() != http.StatusNotFound {
+		t.Fatalf("expected NotFound status error, got %v", err)
+	}
+	if err.Error() != "grpc request failed: NotFound: user 404 not found" {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+	if text := grpcErr.ToolResultText(); !strings.Contains(text, `"code":"NotFound"`) || !strings.Contains(text, `"value":"check the id"`) {
+		t.Errorf("unexpected tool result %s", text)
+	}
+	if failed, counted := isUpstreamFailure(context.Background(), err); failed || !counted {
+		t.Error("expected NotFound not to count as upstream failure")
+	}
+
+	// 请求消息中没有的字段
+	params.BodyParams = map[string]any{"name": "Ada"}
+	if _, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta); err == nil || !strings.Contains(err.Error(), "invalid grpc request") {
+		t.Errorf("expected invalid request error, got %v", err)
+	}
+
+	// 流式方法
+	streaming := withSet
+	streaming.URL = "grpc://" + addr + "/demo.v1.UserService/WatchUsers"
+	if _, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, params, streaming); err == nil || !strings.Contains(err.Error(), "only unary methods") {
+		t.Errorf("expected streaming error, got %v", err)
+	}
+
+	// 连接失败计为上游故障
+	lis, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := lis.Addr().String()
+	lis.Close()
+	for _, m := range []RequestMeta{withSet, meta} {
+		m.URL = "grpc://" + closed + "/demo.v1.UserService/GetUser"
+		_, err = h.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{}, m)
+		if !errors.As(err, &grpcErr) || grpcErr.Code != codes.Unavailable {
+			t.Errorf("expected Unavailable, got %v", err)
+		}
+		if failed, counted := isUpstreamFailure(context.Background(), err); !failed || !counted {
+			t.Error("expected Unavailable to count as upstream failure")
+		}
+	}
+}
+
+func TestAddToolGRPC(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+	sm.handles = append(sm.handles, GRPCAdapter{})
+	files := testUserFiles(t)
+	addr, _ := startTestUserServer(t, files)
+
+	app := models.Application{Name: "GRPC", Path: "grpc-app", Protocol: "sse", Environment: `{"USER_HOST": "` + addr + `"}`}
+	createWithEnabled(t, &app, true)
+	iface := models.Interface{AppID: app.ID, Name: "get_user", Protocol: "grpc", URL: "grpc://${USER_HOST}/demo.v1.UserService/GetUser", Method: "POST", AuthType: "none",
+		DescriptorSet: testUserDescriptorSet(t, files)}
+	createWithEnabled(t, &iface, true)
+	database.GetDB().Create(&models.InterfaceParameter{AppID: app.ID, InterfaceID: iface.ID, Name: "id", Type: "string", Location: "body", Required: true, Group: "input"})
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatal(err)
+	}
+	defer sm.removeApplication(&app)
+
+	s, _ := sm.sseServers.Load("grpc-app")
+	call := func(id string) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]any{"id": id}
+		tool := s.(*Server).server.GetTool("get_user")
+		if tool == nil {
+			t.Fatal("tool get_user not registered")
+		}
+		result, err := tool.Handler(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	text := func(r *mcp.CallToolResult) string { return r.Content[0].(mcp.TextContent).Text }
+
+	if result := call("7"); result.IsError || !strings.Contains(text(result), `"id":"7"`) {
+		t.Errorf("unexpected result %s", text(result))
+	}
+	result := call("404")
+	if !result.IsError || !strings.Contains(text(result), `"code":"NotFound"`) {
+		t.Errorf("expected grpc status as tool error, got %s", text(result))
+	}
+}
@@ -0,0 +1,430 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ExtAuthConfig RequestMeta.Ext 中保存接口鉴权配置 (JSON String) 的键
+const ExtAuthConfig = "auth_config"
+
+// BearerAuthConfig 静态 Bearer Token
+type BearerAuthConfig struct {
+	Token string `json:"token"`
+}
+
+// BasicAuthConfig HTTP Basic 鉴权
+type BasicAuthConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// APIKeyAuthConfig API Key 放在 header 或 query 中
+type APIKeyAuthConfig struct {
+	Name  string `json:"name"`
+	In    string `json:"in"` // header, query
+	Value string `json:"value"`
+}
+
+// OAuth2AuthConfig OAuth2 client credentials 模式
+type OAuth2AuthConfig struct {
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes,omitempty"`
+	Audience     string   `json:"audience,omitempty"`
+	AuthStyle    string   `json:"auth_style,omitempty"` // header (默认): 使用 Basic 头传递客户端凭证, body: 放在表单中
+}
+
+// AWSSigV4AuthConfig AWS Signature V4 签名，access key 为空时从 MCP 客户端请求头中读取
+type AWSSigV4AuthConfig struct {
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty"`
+	Region          string `json:"region"`
+	Service         string `json:"service"`
+}
+
+// ParseAuthConfig 按鉴权类型解析并校验配置，none 和 capi 不需要配置
+// 配置中的字符串值可以使用 secret://name 引用密钥，调用时才会解析
+func ParseAuthConfig(authType, raw string) (any, error) {
+	raw = strings.TrimSpace(raw)
+	decode := func(target any) error {
+		if raw == "" {
+			return fmt.Errorf("auth_config is required for auth type %s", authType)
+		}
+		decoder := json.NewDecoder(strings.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(target); err != nil {
+			return fmt.Errorf("invalid auth_config for auth type %s: %v", authType, err)
+		}
+		return nil
+	}
+	switch authType {
+	case "none", "capi":
+		if raw != "" {
+			return nil, fmt.Errorf("auth_config is not supported for auth type %s", authType)
+		}
+		return nil, nil
+	case "bearer":
+		var cfg BearerAuthConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Token == "" {
+			return nil, errors.New("bearer auth requires token")
+		}
+		return cfg, nil
+	case "basic":
+		var cfg BasicAuthConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Username == "" {
+			return nil, errors.New("basic auth requires username")
+		}
+		return cfg, nil
+	case "apikey":
+		var cfg APIKeyAuthConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Name == "" || cfg.Value == "" {
+			return nil, errors.New("apikey auth requires name and value")
+		}
+		if cfg.In != "header" && cfg.In != "query" {
+			return nil, errors.New("apikey auth 'in' must be header or query")
+		}
+		return cfg, nil
+	case "oauth2":
+		var cfg OAuth2AuthConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		if cfg.TokenURL == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, errors.New("oauth2 auth requires token_url, client_id and client_secret")
+		}
+		if u, err := url.Parse(cfg.TokenURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errors.New("oauth2 token_url must be an absolute URL")
+		}
+		if cfg.AuthStyle != "" && cfg.AuthStyle != "header" && cfg.AuthStyle != "body" {
+			return nil, errors.New("oauth2 auth_style must be header or body")
+		}
+		return cfg, nil
+	case "aws_sigv4":
+		var cfg AWSSigV4AuthConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Region == "" || cfg.Service == "" {
+			return nil, errors.New("aws_sigv4 auth requires region and service")
+		}
+		if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+			return nil, errors.New("aws_sigv4 auth requires both access_key_id and secret_access_key, or neither to use client headers")
+		}
+		if cfg.SessionToken != "" && cfg.AccessKeyID == "" {
+			return nil, errors.New("aws_sigv4 session_token requires access_key_id and secret_access_key")
+		}
+		return cfg, nil
+	default:
+		return nil, fmt.Errorf("unsupported auth type: %s", authType)
+	}
+}
+
+// AuthConfigSecretRefs 返回配置中引用的密钥名称，用于保存时校验
+func AuthConfigSecretRefs(cfg any) []string {
+	var values []string
+	switch c := cfg.(type) {
+	case BearerAuthConfig:
+		values = []string{c.Token}
+	case BasicAuthConfig:
+		values = []string{c.Username, c.Password}
+	case APIKeyAuthConfig:
+		values = []string{c.Value}
+	case OAuth2AuthConfig:
+		values = []string{c.ClientID, c.ClientSecret}
+	case AWSSigV4AuthConfig:
+		values = []string{c.AccessKeyID, c.SecretAccessKey, c.SessionToken}
+	}
+	names := make([]string, 0)
+	for _, v := range values {
+		if name, ok := ParseSecretRef(v); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// AuthConfigPlaintextCredential 返回第一个没有使用 secret:// 引用的凭据字段名，
+// 凭据只能保存为密钥引用，避免明文出现在接口详情和导出文件中
+func AuthConfigPlaintextCredential(cfg any) (string, bool) {
+	var fields [][2]string
+	switch c := cfg.(type) {
+	case BearerAuthConfig:
+		fields = [][2]string{{"token", c.Token}}
+	case BasicAuthConfig:
+		fields = [][2]string{{"password", c.Password}}
+	case APIKeyAuthConfig:
+		fields = [][2]string{{"value", c.Value}}
+	case OAuth2AuthConfig:
+		fields = [][2]string{{"client_secret", c.ClientSecret}}
+	case AWSSigV4AuthConfig:
+		fields = [][2]string{{"secret_access_key", c.SecretAccessKey}, {"session_token", c.SessionToken}}
+	}
+	for _, f := range fields {
+		if _, ok := ParseSecretRef(f[1]); f[1] != "" && !ok {
+			return f[0], true
+		}
+	}
+	return "", false
+}
+
+// oauth2Token 缓存的 access token
+type oauth2Token struct {
+	accessToken string
+	tokenType   string
+	expiry      time.Time
+}
+
+// oauth2TokenRefreshSkew token 在过期前这段时间内就会重新获取
+const oauth2TokenRefreshSkew = 60 * time.Second
+
+// oauth2DefaultTTL token 端点没有返回 expires_in 时的缓存时间
+const oauth2DefaultTTL = 5 * time.Minute
+
+var (
+	oauth2Tokens   = make(map[string]oauth2Token)
+	oauth2Fetches  = make(map[string]*sync.Mutex) // 每个配置一把锁，同一配置同时只有一个请求访问 token 端点
+	oauth2TokensMu sync.Mutex                     // 只保护上面两个 map，不在请求 token 端点期间持有
+)
+
+func oauth2CacheKey(cfg OAuth2AuthConfig) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		cfg.TokenURL, cfg.ClientID, cfg.ClientSecret, strings.Join(cfg.Scopes, " "), cfg.Audience, cfg.AuthStyle,
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedOAuth2Token 返回缓存中未临近过期的 token
+func cachedOAuth2Token(key string) (oauth2Token, bool) {
+	oauth2TokensMu.Lock()
+	defer oauth2TokensMu.Unlock()
+	token, ok := oauth2Tokens[key]
+	return token, ok && time.Now().Add(oauth2TokenRefreshSkew).Before(token.expiry)
+}
+
+// fetchOAuth2Token 获取 token，命中缓存且未临近过期时直接返回，token 端点与接口使用相同的 HTTP 客户端配置
+// 同一配置的并发调用只有一个请求 token 端点，其余等待后使用它获取的 token；不同配置互不阻塞
+func fetchOAuth2Token(ctx context.Context, cfg OAuth2AuthConfig, httpConfig HTTPClientConfig) (oauth2Token, error) {
+	key := oauth2CacheKey(cfg)
+	if token, ok := cachedOAuth2Token(key); ok {
+		return token, nil
+	}
+
+	oauth2TokensMu.Lock()
+	fetch, ok := oauth2Fetches[key]
+	if !ok {
+		fetch = &sync.Mutex{}
+		oauth2Fetches[key] = fetch
+	}
+	oauth2TokensMu.Unlock()
+
+	fetch.Lock()
+	defer fetch.Unlock()
+	// 等待期间其他调用可能已经获取了新的 token
+	if token, ok := cachedOAuth2Token(key); ok {
+		return token, nil
+	}
+	token, err := requestOAuth2Token(ctx, cfg, httpConfig)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	oauth2TokensMu.Lock()
+	oauth2Tokens[key] = token
+	oauth2TokensMu.Unlock()
+	return token, nil
+}
+
+// requestOAuth2Token 使用 client_credentials 模式向 token 端点请求新的 token
+func requestOAuth2Token(ctx context.Context, cfg OAuth2AuthConfig, httpConfig HTTPClientConfig) (oauth2Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	if cfg.Audience != "" {
+		form.Set("audience", cfg.Audience)
+	}
+	if cfg.AuthStyle == "body" {
+		form.Set("client_id", cfg.ClientID)
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("create token request failed: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if cfg.AuthStyle != "body" {
+		request.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+	client, err := httpClientFor(httpConfig)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("oauth2 token request failed: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}(resp.Body)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return oauth2Token{}, fmt.Errorf("oauth2 token request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil || body.AccessToken == "" {
+		return oauth2Token{}, errors.New("oauth2 token response does not contain access_token")
+	}
+	ttl := oauth2DefaultTTL
+	if body.ExpiresIn > 0 {
+		ttl = time.Duration(body.ExpiresIn) * time.Second
+	}
+	return oauth2Token{accessToken: body.AccessToken, tokenType: body.TokenType, expiry: time.Now().Add(ttl)}, nil
+}
+
+// invalidateOAuth2Token 上游返回 401 时丢弃缓存，下次调用重新获取
+func invalidateOAuth2Token(cfg OAuth2AuthConfig) {
+	oauth2TokensMu.Lock()
+	defer oauth2TokensMu.Unlock()
+	delete(oauth2Tokens, oauth2CacheKey(cfg))
+}
+
+// resolveAuthSecrets 解析配置中的 secret:// 引用，ctx 属于某次工具调用时明文会记录到调用日志的脱敏列表
+func resolveAuthSecrets(ctx context.Context, values ...*string) error {
+	for _, v := range values {
+		resolved, err := resolveCallSecret(ctx, *v)
+		if err != nil {
+			return err
+		}
+		*v = resolved
+	}
+	return nil
+}
+
+// applyAuth 按配置给请求添加鉴权信息
+func applyAuth(ctx context.Context, request *http.Request, cfg any, httpConfig HTTPClientConfig) error {
+	switch c := cfg.(type) {
+	case BearerAuthConfig:
+		if err := resolveAuthSecrets(ctx, &c.Token); err != nil {
+			return err
+		}
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	case BasicAuthConfig:
+		if err := resolveAuthSecrets(ctx, &c.Username, &c.Password); err != nil {
+			return err
+		}
+		request.SetBasicAuth(c.Username, c.Password)
+	case APIKeyAuthConfig:
+		if err := resolveAuthSecrets(ctx, &c.Value); err != nil {
+			return err
+		}
+		if c.In == "query" {
+			query := request.URL.Query()
+			query.Set(c.Name, c.Value)
+			request.URL.RawQuery = query.Encode()
+		} else {
+			request.Header.Set(c.Name, c.Value)
+		}
+	case OAuth2AuthConfig:
+		if err := resolveAuthSecrets(ctx, &c.ClientID, &c.ClientSecret); err != nil {
+			return err
+		}
+		token, err := fetchOAuth2Token(ctx, c, httpConfig)
+		if err != nil {
+			return err
+		}
+		tokenType := token.tokenType
+		if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+			tokenType = "Bearer"
+		}
+		request.Header.Set("Authorization", tokenType+" "+token.accessToken)
+	default:
+		return fmt.Errorf("unsupported auth config: %T", cfg)
+	}
+	return nil
+}
+
+// HTTPAuthAdapter 处理 bearer, basic, apikey, oauth2 鉴权的 HTTP 接口
+type HTTPAuthAdapter struct {
+}
+
+func (h HTTPAuthAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
+	cfg, err := ParseAuthConfig(meta.AuthType, meta.Ext[ExtAuthConfig])
+	if err != nil {
+		return nil, err
+	}
+	parameters, err = ResolveSecrets(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+	request, _, err := BuildCommonHttpRequest(ctx, parameters, meta)
+	if err != nil {
+		return nil, err
+	}
+	if request.Header.Get("Content-Type") == "" {
+		request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	if err := applyAuth(ctx, request, cfg, meta.HTTPConfig); err != nil {
+		return nil, err
+	}
+	applyForwardHeaders(request, req, meta)
+	data, status, err := executeHTTPRequest(request, meta.HTTPConfig)
+	invalidateOAuth2TokenOnUnauthorized(cfg, status)
+	return data, err
+}
+
+// invalidateOAuth2TokenOnUnauthorized 上游返回 401 时丢弃缓存的 token，下次调用重新获取
+func invalidateOAuth2TokenOnUnauthorized(cfg any, status int) {
+	if status != http.StatusUnauthorized {
+		return
+	}
+	if oauth2Cfg, ok := cfg.(OAuth2AuthConfig); ok {
+		_ = resolveAuthSecrets(context.Background(), &oauth2Cfg.ClientID, &oauth2Cfg.ClientSecret)
+		invalidateOAuth2Token(oauth2Cfg)
+	}
+}
+
+func (h HTTPAuthAdapter) Compatible(meta RequestMeta) bool {
+	if meta.Protocol != "http" {
+		return false
+	}
+	switch meta.AuthType {
+	case "bearer", "basic", "apikey", "oauth2":
+		return true
+	}
+	return false
+}
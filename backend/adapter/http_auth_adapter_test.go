@@ -0,0 +1,399 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"mcp-adapter/backend/database"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseAuthConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		authType string
+		raw      string
+		wantErr  bool
+	}{
+		{"none without config", "none", "", false},
+		{"none with config", "none", `{"token":"x"}`, true},
+		{"capi without config", "capi", "  ", false},
+		{"bearer", "bearer", `{"token":"abc"}`, false},
+		{"bearer missing token", "bearer", `{}`, true},
+		{"bearer missing config", "bearer", "", true},
+		{"bearer unknown field", "bearer", `{"token":"abc","extra":1}`, true},
+		{"basic", "basic", `{"username":"u","password":"p"}`, false},
+		{"basic missing username", "basic", `{"password":"p"}`, true},
+		{"apikey header", "apikey", `{"name":"X-Api-Key","in":"header","value":"k"}`, false},
+		{"apikey query", "apikey", `{"name":"key","in":"query","value":"k"}`, false},
+		{"apikey invalid in", "apikey", `{"name":"key","in":"cookie","value":"k"}`, true},
+		{"apikey missing value", "apikey", `{"name":"key","in":"query"}`, true},
+		{"oauth2", "oauth2", `{"token_url":"https://auth.example.com/token","client_id":"id","client_secret":"s"}`, false},
+		{"oauth2 relative token url", "oauth2", `{"token_url":"/token","client_id":"id","client_secret":"s"}`, true},
+		{"oauth2 missing secret", "oauth2", `{"token_url":"https://auth.example.com/token","client_id":"id"}`, true},
+		{"oauth2 invalid auth style", "oauth2", `{"token_url":"https://auth.example.com/token","client_id":"id","client_secret":"s","auth_style":"query"}`, true},
+		{"aws_sigv4", "aws_sigv4", `{"region":"us-east-1","service":"s3","access_key_id":"AKID","secret_access_key":"secret://aws"}`, false},
+		{"aws_sigv4 client headers", "aws_sigv4", `{"region":"us-east-1","service":"execute-api"}`, false},
+		{"aws_sigv4 missing region", "aws_sigv4", `{"service":"s3"}`, true},
+		{"aws_sigv4 partial keys", "aws_sigv4", `{"region":"us-east-1","service":"s3","access_key_id":"AKID"}`, true},
+		{"aws_sigv4 token without keys", "aws_sigv4", `{"region":"us-east-1","service":"s3","session_token":"t"}`, true},
+		{"invalid json", "bearer", `{token}`, true},
+		{"unknown auth type", "digest", `{}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAuthConfig(tt.authType, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAuthConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPAuthAdapter_Compatible(t *testing.T) {
+	adapter := HTTPAuthAdapter{}
+	for _, authType := range []string{"bearer", "basic", "apikey", "oauth2"} {
+		if !adapter.Compatible(RequestMeta{Protocol: "http", AuthType: authType}) {
+			t.Errorf("expected %s to be compatible", authType)
+		}
+	}
+	for _, authType := range []string{"none", "capi"} {
+		if adapter.Compatible(RequestMeta{Protocol: "http", AuthType: authType}) {
+			t.Errorf("expected %s to be incompatible", authType)
+		}
+	}
+	if adapter.Compatible(RequestMeta{Protocol: "grpc", AuthType: "bearer"}) {
+		t.Error("expected non-http protocol to be incompatible")
+	}
+}
+
+func TestHTTPAuthAdapter_DoRequest(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		authType string
+		config   string
+		check    func(t *testing.T, r *http.Request)
+	}{
+		{
+			name:     "bearer",
+			authType: "bearer",
+			config:   `{"token":"abc"}`,
+			check: func(t *testing.T, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "Bearer abc" {
+					t.Errorf("expected bearer token, got %q", got)
+				}
+			},
+		},
+		{
+			name:     "basic",
+			authType: "basic",
+			config:   `{"username":"user","password":"pass"}`,
+			check: func(t *testing.T, r *http.Request) {
+				username, password, ok := r.BasicAuth()
+				if !ok || username != "user" || password != "pass" {
+					t.Errorf("expected basic auth user/pass, got %q/%q", username, password)
+				}
+			},
+		},
+		{
+			name:     "apikey header",
+			authType: "apikey",
+			config:   `{"name":"X-Api-Key","in":"header","value":"k1"}`,
+			check: func(t *testing.T, r *http.Request) {
+				if got := r.Header.Get("X-Api-Key"); got != "k1" {
+					t.Errorf("expected api key header, got %q", got)
+				}
+			},
+		},
+		{
+			name:     "apikey query",
+			authType: "apikey",
+			config:   `{"name":"key","in":"query","value":"k2"}`,
+			check: func(t *testing.T, r *http.Request) {
+				if got := r.URL.Query().Get("key"); got != "k2" {
+					t.Errorf("expected api key query, got %q", got)
+				}
+				if got := r.URL.Query().Get("city"); got != "beijing" {
+					t.Errorf("existing query params should be kept, got %q", got)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			_, err := HTTPAuthAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{},
+				Parameters{QueryParams: map[string]any{"city": "beijing"}},
+				RequestMeta{URL: server.URL, Method: http.MethodGet, Protocol: "http", AuthType: tt.authType,
+					Ext: map[string]string{ExtAuthConfig: tt.config}})
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if received == nil {
+				t.Fatal("upstream did not receive the request")
+			}
+			tt.check(t, received)
+		})
+	}
+}
+
+// newOAuth2TestServers 启动 token 端点和受保护的上游接口，上游只接受最近一次签发的 token
+func newOAuth2TestServers(t *testing.T, expiresIn int) (tokenServer, apiServer *httptest.Server, tokenCalls *int32) {
+	tokenCalls = new(int32)
+	var current atomic.Value
+	current.Store("")
+	tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		if clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(tokenCalls, 1)
+		token := fmt.Sprintf("token-%d", n)
+		current.Store(token)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":%q,"token_type":"bearer","expires_in":%d}`, token, expiresIn)
+	}))
+	apiServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+current.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(func() {
+		tokenServer.Close()
+		apiServer.Close()
+	})
+	return tokenServer, apiServer, tokenCalls
+}
+
+func callOAuth2(t *testing.T, apiURL, config string) ([]byte, error) {
+	t.Helper()
+	return HTTPAuthAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{},
+		RequestMeta{URL: apiURL, Method: http.MethodGet, Protocol: "http", AuthType: "oauth2",
+			Ext: map[string]string{ExtAuthConfig: config}})
+}
+
+func TestHTTPAuthAdapter_OAuth2CachesToken(t *testing.T) {
+	tokenServer, apiServer, tokenCalls := newOAuth2TestServers(t, 3600)
+	config := fmt.Sprintf(`{"token_url":%q,"client_id":"client","client_secret":"secret"}`, tokenServer.URL)
+
+	for i := 0; i < 3; i++ {
+		if _, err := callOAuth2(t, apiServer.URL, config); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	if got := atomic.LoadInt32(tokenCalls); got != 1 {
+		t.Errorf("expected token endpoint to be called once, got %d", got)
+	}
+
+	// client_secret 放在表单中时使用独立的缓存
+	bodyConfig := fmt.Sprintf(`{"token_url":%q,"client_id":"client","client_secret":"secret","auth_style":"body"}`, tokenServer.URL)
+	if _, err := callOAuth2(t, apiServer.URL, bodyConfig); err != nil {
+		t.Fatalf("body auth style call failed: %v", err)
+	}
+	if got := atomic.LoadInt32(tokenCalls); got != 2 {
+		t.Errorf("expected a new token for a different config, got %d calls", got)
+	}
+}
+
+func TestHTTPAuthAdapter_OAuth2RefreshesNearExpiry(t *testing.T) {
+	// expires_in 小于刷新提前量，每次调用都会重新获取
+	tokenServer, apiServer, tokenCalls := newOAuth2TestServers(t, 30)
+	config := fmt.Sprintf(`{"token_url":%q,"client_id":"client","client_secret":"secret","scopes":["read"]}`, tokenServer.URL)
+
+	for i := 0; i < 2; i++ {
+		if _, err := callOAuth2(t, apiServer.URL, config); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	if got := atomic.LoadInt32(tokenCalls); got != 2 {
+		t.Errorf("expected token to be refreshed before expiry, got %d calls", got)
+	}
+}
+
+func TestHTTPAuthAdapter_OAuth2InvalidatesOn401(t *testing.T) {
+	tokenServer, apiServer, tokenCalls := newOAuth2TestServers(t, 3600)
+	config := fmt.Sprintf(`{"token_url":%q,"client_id":"client","client_secret":"secret","audience":"api"}`, tokenServer.URL)
+
+	if _, err := callOAuth2(t, apiServer.URL, config); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	// 模拟 token 在服务端被吊销，缓存中的 token 不再有效
+	cfg, _ := ParseAuthConfig("oauth2", config)
+	oauth2TokensMu.Lock()
+	key := oauth2CacheKey(cfg.(OAuth2AuthConfig))
+	token := oauth2Tokens[key]
+	token.accessToken = "revoked"
+	oauth2Tokens[key] = token
+	oauth2TokensMu.Unlock()
+
+	if _, err := callOAuth2(t, apiServer.URL, config); err == nil {
+		t.Fatal("expected 401 with revoked token")
+	}
+	if _, err := callOAuth2(t, apiServer.URL, config); err != nil {
+		t.Fatalf("expected new token after 401, got %v", err)
+	}
+	if got := atomic.LoadInt32(tokenCalls); got != 2 {
+		t.Errorf("expected token to be fetched again after 401, got %d calls", got)
+	}
+}
+
+func TestHTTPAuthAdapter_OAuth2TokenError(t *testing.T) {
+	tokenServer, apiServer, _ := newOAuth2TestServers(t, 3600)
+	config := fmt.Sprintf(`{"token_url":%q,"client_id":"client","client_secret":"wrong"}`, tokenServer.URL)
+	if _, err := callOAuth2(t, apiServer.URL, config); err == nil {
+		t.Error("expected error when token endpoint rejects credentials")
+	}
+}
+
+func TestHTTPAuthAdapter_ResolvesSecrets(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "test-master-key")
+	database.InitDatabase(":memory:")
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM secrets") })
+	createTestSecret(t, "bearer-token", "from-store")
+	createTestSecret(t, "oauth-secret", "secret")
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	_, err := HTTPAuthAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{},
+		RequestMeta{URL: server.URL, Method: http.MethodGet, Protocol: "http", AuthType: "bearer",
+			Ext: map[string]string{ExtAuthConfig: `{"token":"secret://bearer-token"}`}})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if received != "Bearer from-store" {
+		t.Errorf("expected resolved bearer token, got %q", received)
+	}
+
+	tokenServer, apiServer, _ := newOAuth2TestServers(t, 3600)
+	config := fmt.Sprintf(`{"token_url":%q,"client_id":"client","client_secret":"secret://oauth-secret"}`, tokenServer.URL)
+	if _, err := callOAuth2(t, apiServer.URL, config); err != nil {
+		t.Fatalf("oauth2 with secret ref failed: %v", err)
+	}
+
+	_, err = HTTPAuthAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{},
+		RequestMeta{URL: server.URL, Method: http.MethodGet, Protocol: "http", AuthType: "bearer",
+			Ext: map[string]string{ExtAuthConfig: `{"token":"secret://missing"}`}})
+	if err == nil {
+		t.Error("expected error for missing secret")
+	}
+}
+
+func TestAuthConfigSecretRefs(t *testing.T) {
+	cfg, err := ParseAuthConfig("basic", `{"username":"secret://user","password":"secret://pass"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := AuthConfigSecretRefs(cfg)
+	if len(refs) != 2 || refs[0] != "user" || refs[1] != "pass" {
+		t.Errorf("unexpected refs: %v", refs)
+	}
+	cfg, _ = ParseAuthConfig("bearer", `{"token":"plain"}`)
+	if refs := AuthConfigSecretRefs(cfg); len(refs) != 0 {
+		t.Errorf("expected no refs, got %v", refs)
+	}
+}
+
+func TestOAuth2TokenExpiry(t *testing.T) {
+	tokenServer, _, _ := newOAuth2TestServers(t, 0)
+	cfg := OAuth2AuthConfig{TokenURL: tokenServer.URL, ClientID: "client", ClientSecret: "secret", Scopes: []string{"default-ttl"}}
+	token, err := fetchOAuth2Token(context.Background(), cfg, HTTPClientConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining := time.Until(token.expiry); remaining < oauth2DefaultTTL-time.Minute || remaining > oauth2DefaultTTL {
+		t.Errorf("expected default ttl when expires_in is missing, got %v", remaining)
+	}
+}
+
+func TestOAuth2TokenConcurrentFetch(t *testing.T) {
+	tokenServer, _, tokenCalls := newOAuth2TestServers(t, 3600)
+	cached := OAuth2AuthConfig{TokenURL: tokenServer.URL, ClientID: "client", ClientSecret: "secret", Scopes: []string{"cached"}}
+	if _, err := fetchOAuth2Token(context.Background(), cached, HTTPClientConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 慢速 token 端点，请求到达后等待放行
+	var slowCalls int32
+	arrived, release := make(chan struct{}, 1), make(chan struct{})
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&slowCalls, 1)
+		arrived <- struct{}{}
+		<-release
+		_, _ = w.Write([]byte(`{"access_token":"slow","expires_in":3600}`))
+	}))
+	defer slowServer.Close()
+	slow := OAuth2AuthConfig{TokenURL: slowServer.URL, ClientID: "client", ClientSecret: "secret"}
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 5)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := fetchOAuth2Token(context.Background(), slow, HTTPClientConfig{})
+			if err != nil {
+				t.Error(err)
+			}
+			tokens[i] = token.accessToken
+		}(i)
+	}
+	<-arrived
+
+	// 其他配置的缓存读取不等待慢速请求
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := fetchOAuth2Token(context.Background(), cached, HTTPClientConfig{}); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("cached token lookup blocked by another token request")
+	}
+
+	close(release)
+	wg.Wait()
+	if got := atomic.LoadInt32(&slowCalls); got != 1 {
+		t.Errorf("expected concurrent fetches to share one token request, got %d", got)
+	}
+	for _, token := range tokens {
+		if token != "slow" {
+			t.Errorf("unexpected tokens %v", tokens)
+			break
+		}
+	}
+	if got := atomic.LoadInt32(tokenCalls); got != 1 {
+		t.Errorf("expected cached token to be reused, got %d calls", got)
+	}
+}
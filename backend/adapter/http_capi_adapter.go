@@ -4,10 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
-	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -15,13 +12,35 @@ import (
 type HTTPCAPIAdapter struct {
 }
 
+// MCP 客户端通过这些请求头传入 capi 凭证，优先于 header 参数
+const (
+	capiSecretIdHeader  = "TC-API-SecretId"
+	capiSecretKeyHeader = "TC-API-SecretKey"
+	capiTokenHeader     = "TC-API-Token"
+)
+
+// capiConfigParams 作为 capi 签名配置的 header 参数，不会作为请求头发送
+var capiConfigParams = []string{"Host", "Service", "Version", "Action", "Region", "SecretId", "SecretKey", "Token", "Language"}
+
 func (h HTTPCAPIAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
 
+	parameters, err := ResolveSecrets(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
 	if err := checkCommonParam([]string{"Host", "Service", "Version", "Action", "Region"}, parameters.HeaderParams); err != nil {
 		return nil, err
 	}
-	secretId := req.Header.Get("TC-API-SecretId")
-	secretKey := req.Header.Get("TC-API-SecretKey")
+	secretId := req.Header.Get(capiSecretIdHeader)
+	secretKey := req.Header.Get(capiSecretKeyHeader)
+	token := req.Header.Get(capiTokenHeader)
+	if secretId == "" || secretKey == "" {
+		// 客户端没有提供完整凭证时，使用 header 参数中的凭证，临时 Token 也随之使用参数中的值
+		token = ""
+		if v, ok := parameters.HeaderParams["Token"]; ok {
+			token = fmt.Sprintf("%v", v)
+		}
+	}
 	if _, ok := parameters.HeaderParams["SecretId"]; ok && secretId == "" {
 		secretId = fmt.Sprintf("%v", parameters.HeaderParams["SecretId"])
 	}
@@ -35,40 +54,56 @@ func (h HTTPCAPIAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest,
 	if err != nil {
 		return nil, err
 	}
-	tcp := TencentCloudAPIParam{
-		SecretId:  secretId,
-		SecretKey: secretKey,
-		Host:      fmt.Sprintf("%v", parameters.HeaderParams["Host"]),
-		Service:   fmt.Sprintf("%v", parameters.HeaderParams["Service"]),
-		Version:   fmt.Sprintf("%v", parameters.HeaderParams["Version"]),
-		Action:    fmt.Sprintf("%v", parameters.HeaderParams["Action"]),
-		Region:    fmt.Sprintf("%v", parameters.HeaderParams["Region"]),
-		Payload:   string(payload),
-	}
-	tcHeaders, err := SignatureTCHeader(tcp)
-	request.Header = http.Header{}
-	for k, v := range tcHeaders {
-		request.Header.Set(k, v)
+	for _, name := range capiConfigParams {
+		request.Header.Del(name)
 	}
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(request)
-	if err != nil {
-		return nil, err
+	// 透传的头和用户声明的头一起参与签名
+	applyForwardHeaders(request, req, meta)
+	contentType := request.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+		if request.Method == http.MethodGet {
+			contentType = "application/x-www-form-urlencoded"
+		}
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			log.Printf("Error closing response body: %v", err)
+	userHeaders := make(map[string]string, len(request.Header))
+	for k := range request.Header {
+		if k != "Content-Type" {
+			userHeaders[k] = request.Header.Get(k)
 		}
-	}(resp.Body)
-	data, err := io.ReadAll(resp.Body)
+	}
+	language := ""
+	if v, ok := parameters.HeaderParams["Language"]; ok {
+		language = fmt.Sprintf("%v", v)
+	}
+	tcp := TencentCloudAPIParam{
+		SecretId:    secretId,
+		SecretKey:   secretKey,
+		Host:        fmt.Sprintf("%v", parameters.HeaderParams["Host"]),
+		Service:     fmt.Sprintf("%v", parameters.HeaderParams["Service"]),
+		Version:     fmt.Sprintf("%v", parameters.HeaderParams["Version"]),
+		Action:      fmt.Sprintf("%v", parameters.HeaderParams["Action"]),
+		Region:      fmt.Sprintf("%v", parameters.HeaderParams["Region"]),
+		Payload:     string(payload),
+		Method:      request.Method,
+		Path:        request.URL.EscapedPath(),
+		Query:       request.URL.RawQuery,
+		ContentType: contentType,
+		Headers:     userHeaders,
+		Token:       token,
+		Language:    language,
+	}
+	tcHeaders, err := SignatureTCHeader(tcp)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http request failed: %s", string(data))
+	for k, v := range tcHeaders {
+		request.Header.Set(k, v)
 	}
-	return data, nil
+	// Go 发送请求时使用 request.Host 而不是 Host 头
+	request.Host = tcp.Host
+	data, _, err := executeHTTPRequest(request, meta.HTTPConfig)
+	return data, err
 }
 
 func checkCommonParam(names []string, params map[string]any) error {
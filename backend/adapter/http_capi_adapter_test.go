@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"log"
@@ -498,3 +499,156 @@ func BenchmarkHTTPCAPIAdapter_DoRequest(b *testing.B) {
 		_, _ = adapter.DoRequest(context.Background(), req, parameters, meta)
 	}
 }
+
+// verifyTC3 按 TC3-HMAC-SHA256 规则用服务端收到的请求重新计算签名
+func verifyTC3(t *testing.T, r *http.Request, body []byte, secretKey string) {
+	t.Helper()
+	auth := r.Header.Get("Authorization")
+	var credential, signedHeaders, signature string
+	for _, part := range strings.Split(strings.TrimPrefix(auth, "TC3-HMAC-SHA256 "), ", ") {
+		switch {
+		case strings.HasPrefix(part, "Credential="):
+			credential = strings.TrimPrefix(part, "Credential=")
+		case strings.HasPrefix(part, "SignedHeaders="):
+			signedHeaders = strings.TrimPrefix(part, "SignedHeaders=")
+		case strings.HasPrefix(part, "Signature="):
+			signature = strings.TrimPrefix(part, "Signature=")
+		}
+	}
+	scope := strings.SplitN(credential, "/", 2)
+	if len(scope) != 2 {
+		t.Fatalf("invalid credential in Authorization: %s", auth)
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.ToLower(strings.TrimSpace(value)) + "\n")
+	}
+	path := r.URL.EscapedPath()
+	canonicalRequest := strings.Join([]string{
+		r.Method, path, r.URL.RawQuery, canonicalHeaders.String(), signedHeaders, sha256hex(string(body)),
+	}, "\n")
+	scopeParts := strings.Split(scope[1], "/")
+	date, service := scopeParts[0], scopeParts[1]
+	string2sign := strings.Join([]string{
+		"TC3-HMAC-SHA256", r.Header.Get("X-TC-Timestamp"), scope[1], sha256hex(canonicalRequest),
+	}, "\n")
+	key := hmacSha256("tc3_request", hmacSha256(service, hmacSha256(date, "TC3"+secretKey)))
+	if expected := hex.EncodeToString([]byte(hmacSha256(string2sign, key))); expected != signature {
+		t.Errorf("signature mismatch for canonical request:\n%s", canonicalRequest)
+	}
+}
+
+func TestHTTPCAPIAdapter_SignsActualRequest(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"Response":{}}`))
+	}))
+	defer server.Close()
+
+	common := map[string]any{
+		"Host":      "cos.tencentcloudapi.com",
+		"Service":   "cos",
+		"Version":   "2023-01-01",
+		"Action":    "ListObjects",
+		"Region":    "ap-guangzhou",
+		"SecretId":  "param-id",
+		"SecretKey": "param-key",
+	}
+	withHeaders := func(extra map[string]any) map[string]any {
+		headers := make(map[string]any)
+		for k, v := range common {
+			headers[k] = v
+		}
+		for k, v := range extra {
+			headers[k] = v
+		}
+		return headers
+	}
+
+	t.Run("GET with query and path", func(t *testing.T) {
+		_, err := HTTPCAPIAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{
+			HeaderParams: withHeaders(map[string]any{"X-Custom-Trace": "Trace-1"}),
+			PathParams:   map[string]any{"bucket": "my-bucket"},
+			QueryParams:  map[string]any{"prefix": "a b", "limit": 10},
+			BodyParams:   map[string]any{"marker": "m1"},
+		}, RequestMeta{URL: server.URL + "/buckets/{bucket}/objects", Method: http.MethodGet, Protocol: "http", AuthType: "capi"})
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if received.Method != http.MethodGet || received.URL.Path != "/buckets/my-bucket/objects" {
+			t.Errorf("unexpected request %s %s", received.Method, received.URL.Path)
+		}
+		if received.URL.Query().Get("marker") != "m1" || received.URL.Query().Get("prefix") != "a b" {
+			t.Errorf("expected body and query params in query string, got %s", received.URL.RawQuery)
+		}
+		if got := received.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+			t.Errorf("unexpected Content-Type for GET: %s", got)
+		}
+		if received.Host != "cos.tencentcloudapi.com" {
+			t.Errorf("expected Host cos.tencentcloudapi.com, got %s", received.Host)
+		}
+		// 签名配置参数不会作为请求头发送，用户声明的头会保留并参与签名
+		for _, name := range []string{"Service", "Action", "SecretId", "SecretKey"} {
+			if received.Header.Get(name) != "" {
+				t.Errorf("config param %s should not be sent as a header", name)
+			}
+		}
+		if received.Header.Get("X-Custom-Trace") != "Trace-1" {
+			t.Error("user-declared header should be kept")
+		}
+		if !strings.Contains(received.Header.Get("Authorization"), "SignedHeaders=content-type;host;x-custom-trace;x-tc-action,") {
+			t.Errorf("user header should be signed: %s", received.Header.Get("Authorization"))
+		}
+		if len(body) != 0 {
+			t.Errorf("GET request should not have a body, got %s", body)
+		}
+		verifyTC3(t, received, body, "param-key")
+	})
+
+	t.Run("POST with token and language", func(t *testing.T) {
+		_, err := HTTPCAPIAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{
+			HeaderParams: withHeaders(map[string]any{"Token": "sts-token", "Language": "en-US"}),
+			BodyParams:   map[string]any{"Limit": 1},
+		}, RequestMeta{URL: server.URL + "/", Method: http.MethodPost, Protocol: "http", AuthType: "capi"})
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if received.Header.Get("X-TC-Token") != "sts-token" || received.Header.Get("X-TC-Language") != "en-US" {
+			t.Errorf("expected X-TC-Token and X-TC-Language, got %v", received.Header)
+		}
+		if received.Header.Get("Token") != "" || received.Header.Get("Language") != "" {
+			t.Error("Token and Language params should not be sent as headers")
+		}
+		if got := received.Header.Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("unexpected Content-Type for POST: %s", got)
+		}
+		verifyTC3(t, received, body, "param-key")
+	})
+
+	t.Run("client credentials with token", func(t *testing.T) {
+		req := mcp.CallToolRequest{Header: http.Header{}}
+		req.Header.Set("TC-API-SecretId", "client-id")
+		req.Header.Set("TC-API-SecretKey", "client-key")
+		req.Header.Set("TC-API-Token", "client-token")
+		_, err := HTTPCAPIAdapter{}.DoRequest(context.Background(), req, Parameters{
+			HeaderParams: withHeaders(map[string]any{"Token": "param-token"}),
+		}, RequestMeta{URL: server.URL, Method: http.MethodPost, Protocol: "http", AuthType: "capi"})
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if !strings.Contains(received.Header.Get("Authorization"), "Credential=client-id/") {
+			t.Errorf("expected client credential, got %s", received.Header.Get("Authorization"))
+		}
+		if received.Header.Get("X-TC-Token") != "client-token" {
+			t.Errorf("expected client token, got %q", received.Header.Get("X-TC-Token"))
+		}
+		verifyTC3(t, received, body, "client-key")
+	})
+}
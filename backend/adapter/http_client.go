@@ -0,0 +1,436 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHTTPTimeout 未配置 timeout 时单次请求的总超时时间
+const defaultHTTPTimeout = 15 * time.Second
+
+const (
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultRetryMaxBackoff = 5 * time.Second
+	maxHTTPRetries         = 10
+)
+
+// defaultRetryStatusCodes 未配置 status_codes 时触发重试的状态码
+var defaultRetryStatusCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// HTTPRetryConfig 重试配置，只对幂等方法 (GET, HEAD, OPTIONS, PUT, DELETE) 生效
+type HTTPRetryConfig struct {
+	MaxRetries  int    `json:"max_retries"`            // 最大重试次数
+	Backoff     string `json:"backoff,omitempty"`      // 首次重试前的等待时间，之后指数增长，默认 200ms
+	MaxBackoff  string `json:"max_backoff,omitempty"`  // 等待时间上限，默认 5s
+	StatusCodes []int  `json:"status_codes,omitempty"` // 触发重试的状态码，默认 502, 503, 504；连接错误总会重试
+}
+
+// HTTPClientConfig 调用上游接口的 HTTP 客户端配置，应用级配置作为默认值，接口级配置按字段覆盖
+// 时间使用 Go duration 格式 (如 500ms, 10s)，证书使用 PEM 文本，可以通过 secret://name 引用密钥
+type HTTPClientConfig struct {
+	ConnectTimeout     string                `json:"connect_timeout,omitempty"` // 建立连接超时
+	Timeout            string                `json:"timeout,omitempty"`         // 单次请求总超时，默认 15s
+	Retry              *HTTPRetryConfig      `json:"retry,omitempty"`
+	CACert             string                `json:"ca_cert,omitempty"`     // 自定义 CA 证书
+	ClientCert         string                `json:"client_cert,omitempty"` // mTLS 客户端证书
+	ClientKey          string                `json:"client_key,omitempty"`  // mTLS 客户端私钥
+	InsecureSkipVerify *bool                 `json:"insecure_skip_verify,omitempty"`
+	Proxy              string                `json:"proxy,omitempty"`           // http, https 或 socks5 代理地址，默认读取环境变量
+	SuccessStatus      []string              `json:"success_status,omitempty"`  // 视为成功的状态码或状态码段 (如 200, 2xx, 404)，默认 2xx
+	CircuitBreaker     *CircuitBreakerConfig `json:"circuit_breaker,omitempty"` // 上游连续失败后暂停调用，默认不启用
+
+	clientKey string // 复用 HTTP 客户端的键，注册工具和远程 MCP 服务器时设置，为空时不复用
+}
+
+// ParseHTTPConfig 解析并校验 HTTP 客户端配置，空字符串表示使用默认配置
+func ParseHTTPConfig(raw string) (HTTPClientConfig, error) {
+	var cfg HTTPClientConfig
+	if strings.TrimSpace(raw) == "" {
+		return cfg, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("invalid http_config: %v", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func parseDurationField(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("http_config %s must be a positive duration such as 500ms or 10s", name)
+	}
+	return d, nil
+}
+
+func (c HTTPClientConfig) validate() error {
+	for name, value := range map[string]string{"connect_timeout": c.ConnectTimeout, "timeout": c.Timeout} {
+		if _, err := parseDurationField(name, value); err != nil {
+			return err
+		}
+	}
+	if c.Retry != nil {
+		if c.Retry.MaxRetries < 0 || c.Retry.MaxRetries > maxHTTPRetries {
+			return fmt.Errorf("http_config retry.max_retries must be between 0 and %d", maxHTTPRetries)
+		}
+		if _, err := parseDurationField("retry.backoff", c.Retry.Backoff); err != nil {
+			return err
+		}
+		if _, err := parseDurationField("retry.max_backoff", c.Retry.MaxBackoff); err != nil {
+			return err
+		}
+		for _, code := range c.Retry.StatusCodes {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("http_config retry.status_codes contains invalid status code %d", code)
+			}
+		}
+	}
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return errors.New("http_config proxy must be an http, https or socks5 URL")
+		}
+	}
+	for _, s := range c.SuccessStatus {
+		if !validSuccessStatus(s) {
+			return fmt.Errorf("http_config success_status contains invalid status %q", s)
+		}
+	}
+	if c.CircuitBreaker != nil {
+		if err := c.CircuitBreaker.validate(); err != nil {
+			return err
+		}
+	}
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return errors.New("http_config client_cert and client_key must be set together")
+	}
+	// 引用密钥的证书在调用时才能解析，这里只校验明文证书
+	if c.CACert != "" && !isSecretRef(c.CACert) {
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(c.CACert)) {
+			return errors.New("http_config ca_cert does not contain a valid PEM certificate")
+		}
+	}
+	if c.ClientCert != "" && !isSecretRef(c.ClientCert) && !isSecretRef(c.ClientKey) {
+		if _, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey)); err != nil {
+			return fmt.Errorf("http_config client certificate is invalid: %v", err)
+		}
+	}
+	return nil
+}
+
+func isSecretRef(value string) bool {
+	_, ok := ParseSecretRef(value)
+	return ok
+}
+
+// HTTPConfigSecretRefs 返回配置中引用的密钥名称
+func HTTPConfigSecretRefs(cfg HTTPClientConfig) []string {
+	names := make([]string, 0)
+	for _, v := range []string{cfg.CACert, cfg.ClientCert, cfg.ClientKey} {
+		if name, ok := ParseSecretRef(v); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// MergeHTTPConfig 接口配置中设置了的字段覆盖应用配置
+func MergeHTTPConfig(app, iface HTTPClientConfig) HTTPClientConfig {
+	merged := app
+	if iface.ConnectTimeout != "" {
+		merged.ConnectTimeout = iface.ConnectTimeout
+	}
+	if iface.Timeout != "" {
+		merged.Timeout = iface.Timeout
+	}
+	if iface.Retry != nil {
+		merged.Retry = iface.Retry
+	}
+	if iface.CACert != "" {
+		merged.CACert = iface.CACert
+	}
+	if iface.ClientCert != "" {
+		merged.ClientCert, merged.ClientKey = iface.ClientCert, iface.ClientKey
+	}
+	if iface.InsecureSkipVerify != nil {
+		merged.InsecureSkipVerify = iface.InsecureSkipVerify
+	}
+	if iface.Proxy != "" {
+		merged.Proxy = iface.Proxy
+	}
+	if iface.SuccessStatus != nil {
+		merged.SuccessStatus = iface.SuccessStatus
+	}
+	if iface.CircuitBreaker != nil {
+		merged.CircuitBreaker = iface.CircuitBreaker
+	}
+	return merged
+}
+
+// httpClientEntry 池中的客户端，sum 是创建时配置 (包括解析后的证书) 的摘要
+type httpClientEntry struct {
+	client *http.Client
+	sum    string
+}
+
+var (
+	httpClients   = make(map[string]*httpClientEntry)
+	httpClientsMu sync.Mutex
+)
+
+// interfaceHTTPClientKey 工具使用的 HTTP 客户端键
+func interfaceHTTPClientKey(appID, interfaceID int64) string {
+	return fmt.Sprintf("app:%d:interface:%d", appID, interfaceID)
+}
+
+// upstreamHTTPClientKey 远程 MCP 服务器使用的 HTTP 客户端键
+func upstreamHTTPClientKey(appID int64) string {
+	return fmt.Sprintf("app:%d:upstream", appID)
+}
+
+// httpClientFor 按工具或远程 MCP 服务器复用 HTTP 客户端，证书中的密钥引用在这里解析；
+// 配置或密钥变化时重建客户端并关闭旧客户端的空闲连接，没有 clientKey 的一次性请求 (如导入时的内省) 不复用
+func httpClientFor(cfg HTTPClientConfig) (*http.Client, error) {
+	if err := resolveAuthSecrets(context.Background(), &cfg.CACert, &cfg.ClientCert, &cfg.ClientKey); err != nil {
+		return nil, err
+	}
+	if cfg.clientKey == "" {
+		return newHTTPClient(cfg)
+	}
+	cfg.Retry, cfg.SuccessStatus, cfg.CircuitBreaker = nil, nil, nil
+	data, _ := json.Marshal(cfg)
+	digest := sha256.Sum256(data)
+	sum := hex.EncodeToString(digest[:])
+
+	httpClientsMu.Lock()
+	defer httpClientsMu.Unlock()
+	if entry, ok := httpClients[cfg.clientKey]; ok {
+		if entry.sum == sum {
+			return entry.client, nil
+		}
+		// 进行中的请求不受影响，连接用完后关闭
+		entry.client.CloseIdleConnections()
+		delete(httpClients, cfg.clientKey)
+	}
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	httpClients[cfg.clientKey] = &httpClientEntry{client: client, sum: sum}
+	return client, nil
+}
+
+// dropInterfaceHTTPClient 移除工具的 HTTP 客户端并关闭空闲连接，interfaceID 为 0 时忽略
+func dropInterfaceHTTPClient(appID, interfaceID int64) {
+	if interfaceID == 0 {
+		return
+	}
+	key := interfaceHTTPClientKey(appID, interfaceID)
+	httpClientsMu.Lock()
+	defer httpClientsMu.Unlock()
+	if entry, ok := httpClients[key]; ok {
+		entry.client.CloseIdleConnections()
+		delete(httpClients, key)
+	}
+}
+
+// dropApplicationHTTPClients 移除应用下所有工具和远程 MCP 服务器的 HTTP 客户端，appID 为 0 时忽略
+func dropApplicationHTTPClients(appID int64) {
+	if appID == 0 {
+		return
+	}
+	prefix := fmt.Sprintf("app:%d:", appID)
+	httpClientsMu.Lock()
+	defer httpClientsMu.Unlock()
+	for key, entry := range httpClients {
+		if strings.HasPrefix(key, prefix) {
+			entry.client.CloseIdleConnections()
+			delete(httpClients, key)
+		}
+	}
+}
+
+func newHTTPClient(cfg HTTPClientConfig) (*http.Client, error) {
+	timeout, _ := parseDurationField("timeout", cfg.Timeout)
+	if timeout == 0 {
+		timeout = defaultHTTPTimeout
+	}
+	connectTimeout, _ := parseDurationField("connect_timeout", cfg.ConnectTimeout)
+	if connectTimeout == 0 {
+		connectTimeout = 30 * time.Second
+	}
+
+	tlsConfig, err := tlsConfigFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// tlsConfigFor 按配置生成 TLS 配置，调用方需要先解析证书中的密钥引用
+func tlsConfigFor(cfg HTTPClientConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.InsecureSkipVerify != nil && *cfg.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if cfg.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(cfg.CACert)) {
+			return nil, errors.New("http_config ca_cert does not contain a valid PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(cfg.ClientCert), []byte(cfg.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("http_config client certificate is invalid: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// isIdempotent 只有幂等方法才会自动重试
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryBackoff 第 attempt 次重试前的等待时间
+func retryBackoff(retry *HTTPRetryConfig, attempt int) time.Duration {
+	backoff, _ := parseDurationField("retry.backoff", retry.Backoff)
+	if backoff == 0 {
+		backoff = defaultRetryBackoff
+	}
+	maxBackoff, _ := parseDurationField("retry.max_backoff", retry.MaxBackoff)
+	if maxBackoff == 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+func shouldRetryStatus(retry *HTTPRetryConfig, status int) bool {
+	codes := retry.StatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryStatusCodes
+	}
+	for _, code := range codes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// executeHTTPRequest 发送请求并读取响应，未命中 success_status 时返回 *HTTPStatusError，同时返回状态码供调用方判断
+// 幂等请求在连接错误或命中配置的状态码时按指数退避重试
+func executeHTTPRequest(request *http.Request, cfg HTTPClientConfig) ([]byte, int, error) {
+	client, err := httpClientFor(cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+	retries := 0
+	if cfg.Retry != nil && isIdempotent(request.Method) {
+		retries = cfg.Retry.MaxRetries
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-request.Context().Done():
+				return nil, 0, request.Context().Err()
+			case <-time.After(retryBackoff(cfg.Retry, attempt)):
+			}
+			if request.GetBody != nil {
+				body, err := request.GetBody()
+				if err != nil {
+					return nil, 0, err
+				}
+				request.Body = body
+			}
+		}
+		recordUpstreamRequest(request, attempt)
+		data, status, err := doHTTPRequest(client, request, cfg.SuccessStatus)
+		if attempt < retries && (status == 0 && request.Context().Err() == nil || shouldRetryStatus(cfg.Retry, status)) {
+			log.Printf("Retrying %s %s after attempt %d: status %d, error %v", request.Method, request.URL.Redacted(), attempt+1, status, err)
+			continue
+		}
+		return data, status, err
+	}
+}
+
+func doHTTPRequest(client *http.Client, request *http.Request, successStatus []string) ([]byte, int, error) {
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}(resp.Body)
+	data, err := io.ReadAll(resp.Body)
+	recordUpstreamResponse(request.Context(), resp.StatusCode, data)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if !isSuccessStatus(successStatus, resp.StatusCode) {
+		return nil, resp.StatusCode, newHTTPStatusError(resp, data)
+	}
+	return data, resp.StatusCode, nil
+}
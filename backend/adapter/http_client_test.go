@@ -0,0 +1,293 @@
+package adapter
+
+import (
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-adapter/backend/database"
+)
+
+func TestParseHTTPConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "empty", raw: ""},
+		{name: "full", raw: `{"connect_timeout":"2s","timeout":"10s","retry":{"max_retries":3,"backoff":"100ms","max_backoff":"1s","status_codes":[429,503]},"insecure_skip_verify":false,"proxy":"socks5://127.0.0.1:1080"}`},
+		{name: "secret refs", raw: `{"ca_cert":"secret://ca","client_cert":"secret://cert","client_key":"secret://key"}`},
+		{name: "not json", raw: `timeout=1s`, wantErr: "invalid http_config"},
+		{name: "unknown field", raw: `{"timeouts":"1s"}`, wantErr: "invalid http_config"},
+		{name: "bad duration", raw: `{"timeout":"10"}`, wantErr: "timeout must be a positive duration"},
+		{name: "negative duration", raw: `{"connect_timeout":"-1s"}`, wantErr: "connect_timeout must be a positive duration"},
+		{name: "too many retries", raw: `{"retry":{"max_retries":11}}`, wantErr: "max_retries must be between 0 and 10"},
+		{name: "bad status code", raw: `{"retry":{"max_retries":1,"status_codes":[600]}}`, wantErr: "invalid status code 600"},
+		{name: "bad proxy scheme", raw: `{"proxy":"ftp://proxy:21"}`, wantErr: "proxy must be an http, https or socks5 URL"},
+		{name: "cert without key", raw: `{"client_cert":"secret://cert"}`, wantErr: "must be set together"},
+		{name: "bad ca cert", raw: `{"ca_cert":"not a certificate"}`, wantErr: "ca_cert does not contain a valid PEM certificate"},
+		{name: "circuit breaker", raw: `{"circuit_breaker":{"scope":"interface","failure_threshold":3,"cooldown":"10s"}}`},
+		{name: "bad circuit breaker scope", raw: `{"circuit_breaker":{"scope":"app"}}`, wantErr: "circuit_breaker.scope must be host or interface"},
+		{name: "bad circuit breaker cooldown", raw: `{"circuit_breaker":{"cooldown":"0s"}}`, wantErr: "circuit_breaker.cooldown must be a positive duration"},
+		{name: "negative failure threshold", raw: `{"circuit_breaker":{"failure_threshold":-1}}`, wantErr: "failure_threshold must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseHTTPConfig(tt.raw)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHTTPConfigSecretRefs(t *testing.T) {
+	cfg, err := ParseHTTPConfig(`{"ca_cert":"secret://ca","client_cert":"secret://cert","client_key":"secret://key"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := HTTPConfigSecretRefs(cfg)
+	if strings.Join(refs, ",") != "ca,cert,key" {
+		t.Errorf("unexpected refs: %v", refs)
+	}
+}
+
+func TestMergeHTTPConfig(t *testing.T) {
+	skip := true
+	app := HTTPClientConfig{
+		Timeout:    "30s",
+		Retry:      &HTTPRetryConfig{MaxRetries: 2},
+		Proxy:      "http://proxy:8080",
+		ClientCert: "secret://app-cert",
+		ClientKey:  "secret://app-key",
+	}
+	iface := HTTPClientConfig{
+		Timeout:            "5s",
+		InsecureSkipVerify: &skip,
+		ClientCert:         "secret://iface-cert",
+		ClientKey:          "secret://iface-key",
+	}
+	merged := MergeHTTPConfig(app, iface)
+	if merged.Timeout != "5s" {
+		t.Errorf("interface timeout should override, got %s", merged.Timeout)
+	}
+	if merged.Retry == nil || merged.Retry.MaxRetries != 2 {
+		t.Errorf("application retry should be kept, got %+v", merged.Retry)
+	}
+	if merged.Proxy != "http://proxy:8080" {
+		t.Errorf("application proxy should be kept, got %s", merged.Proxy)
+	}
+	if merged.InsecureSkipVerify == nil || !*merged.InsecureSkipVerify {
+		t.Error("interface insecure_skip_verify should override")
+	}
+	if merged.ClientCert != "secret://iface-cert" || merged.ClientKey != "secret://iface-key" {
+		t.Errorf("client cert and key should be overridden together, got %s %s", merged.ClientCert, merged.ClientKey)
+	}
+}
+
+func TestHTTPClientFor_Pooled(t *testing.T) {
+	key := interfaceHTTPClientKey(1, 1)
+	t.Cleanup(func() { dropApplicationHTTPClients(1) })
+	a, err := httpClientFor(HTTPClientConfig{Timeout: "7s", Retry: &HTTPRetryConfig{MaxRetries: 1}, clientKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := httpClientFor(HTTPClientConfig{Timeout: "7s", Retry: &HTTPRetryConfig{MaxRetries: 3}, clientKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Error("configs differing only in retry should share a client")
+	}
+	other, err := httpClientFor(HTTPClientConfig{Timeout: "7s", clientKey: interfaceHTTPClientKey(1, 12)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == other {
+		t.Error("different interfaces should use different clients")
+	}
+	if unkeyed, _ := httpClientFor(HTTPClientConfig{Timeout: "7s"}); unkeyed == a {
+		t.Error("requests without a client key should not use pooled clients")
+	}
+
+	// 配置变化时替换客户端并关闭旧客户端的空闲连接
+	closed := make(chan struct{}, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	server.Start()
+	defer server.Close()
+	resp, err := a.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	c, err := httpClientFor(HTTPClientConfig{Timeout: "8s", clientKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == c {
+		t.Error("a changed config should replace the client")
+	}
+	if c.Timeout != 8*time.Second {
+		t.Errorf("expected timeout 8s, got %v", c.Timeout)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("idle connections of the replaced client should be closed")
+	}
+
+	dropInterfaceHTTPClient(1, 1)
+	httpClientsMu.Lock()
+	_, remaining := httpClients[key]
+	_, kept := httpClients[interfaceHTTPClientKey(1, 12)]
+	httpClientsMu.Unlock()
+	if remaining || !kept {
+		t.Error("dropping interface 1 should keep the client of interface 12")
+	}
+}
+
+func TestExecuteHTTPRequest_RetryIdempotent(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(append([]byte("ok:"), body...))
+	}))
+	defer server.Close()
+	cfg := HTTPClientConfig{Retry: &HTTPRetryConfig{MaxRetries: 3, Backoff: "1ms"}}
+
+	request, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	data, status, err := executeHTTPRequest(request, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != http.StatusOK || string(data) != "ok:payload" {
+		t.Errorf("unexpected response %d %s", status, data)
+	}
+	if n := atomic.LoadInt32(&count); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestExecuteHTTPRequest_NoRetry(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// POST 不是幂等方法
+	request, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("{}"))
+	_, status, err := executeHTTPRequest(request, HTTPClientConfig{Retry: &HTTPRetryConfig{MaxRetries: 3, Backoff: "1ms"}})
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 error, got %d %v", status, err)
+	}
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("POST should not be retried, got %d attempts", n)
+	}
+
+	// 未命中状态码
+	atomic.StoreInt32(&count, 0)
+	request, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	_, _, err = executeHTTPRequest(request, HTTPClientConfig{Retry: &HTTPRetryConfig{MaxRetries: 3, Backoff: "1ms", StatusCodes: []int{429}}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("503 is not in status_codes, got %d attempts", n)
+	}
+}
+
+func TestExecuteHTTPRequest_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, _, err := executeHTTPRequest(request, HTTPClientConfig{Timeout: "50ms"})
+	if err == nil || !strings.Contains(err.Error(), "Client.Timeout") {
+		t.Fatalf("expected client timeout, got %v", err)
+	}
+}
+
+func TestExecuteHTTPRequest_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	defer server.Close()
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, _, err := executeHTTPRequest(request, HTTPClientConfig{}); err == nil {
+		t.Fatal("self-signed certificate should be rejected by default")
+	}
+
+	cfg, err := ParseHTTPConfig(`{"ca_cert":` + strconv.Quote(caPEM) + `}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	data, _, err := executeHTTPRequest(request, cfg)
+	if err != nil || string(data) != "secure" {
+		t.Fatalf("custom CA should be trusted, got %s %v", data, err)
+	}
+
+	// CA 证书也可以引用密钥
+	t.Setenv(SecretKeyEnv, "test-master-key")
+	database.InitDatabase(":memory:")
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM secrets") })
+	createTestSecret(t, "test-ca", caPEM)
+	request, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, _, err := executeHTTPRequest(request, HTTPClientConfig{CACert: "secret://test-ca"}); err != nil {
+		t.Fatalf("CA from secret should be trusted, got %v", err)
+	}
+
+	skip := true
+	request, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, _, err := executeHTTPRequest(request, HTTPClientConfig{InsecureSkipVerify: &skip}); err != nil {
+		t.Fatalf("insecure_skip_verify should skip verification, got %v", err)
+	}
+}
+
+func TestExecuteHTTPRequest_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, "http://upstream.invalid/path?a=1", nil)
+	data, _, err := executeHTTPRequest(request, HTTPClientConfig{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "via proxy" || proxied != "http://upstream.invalid/path?a=1" {
+		t.Errorf("request should go through proxy, got %s %s", data, proxied)
+	}
+}
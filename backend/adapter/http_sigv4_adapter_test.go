@@ -0,0 +1,91 @@
+package adapter
+
+import (
+	"context"
+	"io"
+	"mcp-adapter/backend/database"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestHTTPSigV4Adapter_Compatible(t *testing.T) {
+	adapter := HTTPSigV4Adapter{}
+	if !adapter.Compatible(RequestMeta{Protocol: "http", AuthType: "aws_sigv4"}) {
+		t.Error("expected aws_sigv4 to be compatible")
+	}
+	for _, authType := range []string{"none", "capi", "bearer"} {
+		if adapter.Compatible(RequestMeta{Protocol: "http", AuthType: authType}) {
+			t.Errorf("expected %s to be incompatible", authType)
+		}
+	}
+}
+
+func TestHTTPSigV4Adapter_DoRequest(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "test-master-key")
+	database.InitDatabase(":memory:")
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM secrets") })
+	createTestSecret(t, "aws-secret", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	meta := RequestMeta{
+		URL:            server.URL + "/bucket/{key}",
+		Method:         http.MethodPut,
+		Protocol:       "http",
+		AuthType:       "aws_sigv4",
+		Ext:            map[string]string{ExtAuthConfig: `{"region":"ap-east-1","service":"s3","access_key_id":"AKIDCONFIG","secret_access_key":"secret://aws-secret"}`},
+		ForwardHeaders: map[string]string{"X-Trace-Id": "X-Trace-Id"},
+	}
+	req := mcp.CallToolRequest{Header: http.Header{"X-Trace-Id": []string{"trace-1"}}}
+	params := Parameters{PathParams: map[string]any{"key": "a.txt"}, BodyParams: map[string]any{"content": "hello"}}
+	if _, err := (HTTPSigV4Adapter{}).DoRequest(context.Background(), req, params, meta); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	auth := received.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDCONFIG/") || !strings.Contains(auth, "/ap-east-1/s3/aws4_request") {
+		t.Errorf("unexpected Authorization: %s", auth)
+	}
+	if !strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-trace-id,") {
+		t.Errorf("expected forwarded header to be signed, got %s", auth)
+	}
+	if got := received.Header.Get("X-Amz-Content-Sha256"); got != sha256hex(string(body)) {
+		t.Errorf("payload hash %s does not match body %q", got, body)
+	}
+
+	// 客户端请求头中的凭证优先于配置
+	req.Header.Set("AWS-Access-Key-Id", "AKIDCLIENT")
+	req.Header.Set("AWS-Secret-Access-Key", "client-secret")
+	req.Header.Set("AWS-Session-Token", "client-token")
+	if _, err := (HTTPSigV4Adapter{}).DoRequest(context.Background(), req, params, meta); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if auth := received.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDCLIENT/") {
+		t.Errorf("expected client credential, got %s", auth)
+	}
+	if got := received.Header.Get("X-Amz-Security-Token"); got != "client-token" {
+		t.Errorf("expected client session token, got %q", got)
+	}
+
+	// 配置中没有凭证且客户端也没有提供
+	meta.Ext = map[string]string{ExtAuthConfig: `{"region":"us-east-1","service":"execute-api"}`}
+	_, err := HTTPSigV4Adapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta)
+	if err == nil || !strings.Contains(err.Error(), "missing access key") {
+		t.Errorf("expected missing access key error, got %v", err)
+	}
+
+	meta.Ext = map[string]string{ExtAuthConfig: `{"region":"us-east-1","service":"s3","access_key_id":"AKID","secret_access_key":"secret://missing"}`}
+	if _, err := (HTTPSigV4Adapter{}).DoRequest(context.Background(), mcp.CallToolRequest{}, params, meta); err == nil {
+		t.Error("expected error for missing secret")
+	}
+}
@@ -3,14 +3,11 @@ package adapter
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -18,34 +15,22 @@ import (
 type HTTPSimpleAdapter struct {
 }
 
-func (h HTTPSimpleAdapter) DoRequest(ctx context.Context, _ mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
+func (h HTTPSimpleAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
 
-	request, _, err := BuildCommonHttpRequest(ctx, parameters, meta)
+	parameters, err := ResolveSecrets(ctx, parameters)
 	if err != nil {
 		return nil, err
 	}
-	if request.Header.Get("Content-Type") == "" {
-		request.Header.Set("Content-Type", "application/json; charset=utf-8")
-	}
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			log.Printf("Error closing response body: %v", err)
-		}
-	}(resp.Body)
-	data, err := io.ReadAll(resp.Body)
+	request, _, err := BuildCommonHttpRequest(ctx, parameters, meta)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http request failed: %s", string(data))
+	if request.Header.Get("Content-Type") == "" {
+		request.Header.Set("Content-Type", "application/json; charset=utf-8")
 	}
-	return data, nil
+	applyForwardHeaders(request, req, meta)
+	data, _, err := executeHTTPRequest(request, meta.HTTPConfig)
+	return data, err
 }
 
 func BuildCommonHttpRequest(ctx context.Context, parameters Parameters, meta RequestMeta) (*http.Request, []byte, error) {
@@ -76,8 +61,11 @@ func BuildCommonHttpRequest(ctx context.Context, parameters Parameters, meta Req
 		headers.Set(name, fmt.Sprintf("%v", val))
 	}
 
-	// Step 4: 解析Path参数
-	finalURL := meta.URL
+	// Step 4: 展开环境变量并解析Path参数
+	finalURL, err := expandCallEnvironment(ctx, meta.URL, meta.Env)
+	if err != nil {
+		return nil, nil, err
+	}
 	for name, value := range parameters.PathParams {
 		// 支持 {name} 和 :name 两种格式
 		finalURL = strings.ReplaceAll(finalURL, "{"+name+"}", fmt.Sprintf("%v", value))
@@ -109,12 +97,21 @@ func BuildCommonHttpRequest(ctx context.Context, parameters Parameters, meta Req
 	u.RawQuery = queryVals.Encode()
 	payload := make([]byte, 0)
 	var body io.Reader
-	if len(bodyMap) > 0 {
-		b, err := json.Marshal(bodyMap)
+	contentType := ""
+	if meta.BodyTemplate != nil && meta.Method != http.MethodGet && meta.Method != http.MethodHead {
+		// 模板中可能只有固定的信封字段，即使没有 body 参数也需要渲染
+		b, ct, err := encodeTemplateBody(meta.BodyTemplate, meta.BodyEncoding, bodyMap)
 		if err != nil {
-			return nil, nil, fmt.Errorf("marshal body failed: %w", err)
+			return nil, nil, err
 		}
-		payload = b
+		payload, contentType = b, ct
+		body = bytes.NewBuffer(b)
+	} else if len(bodyMap) > 0 {
+		b, ct, err := encodeRequestBody(meta.BodyEncoding, bodyMap)
+		if err != nil {
+			return nil, nil, err
+		}
+		payload, contentType = b, ct
 		body = bytes.NewBuffer(b)
 	}
 
@@ -127,7 +124,10 @@ func BuildCommonHttpRequest(ctx context.Context, parameters Parameters, meta Req
 			request.Header.Add(k, v)
 		}
 	}
-	log.Printf("request: %v", payload)
+	// multipart 的 boundary 由编码生成，必须覆盖参数中的 Content-Type
+	if contentType != "" && (request.Header.Get("Content-Type") == "" || meta.BodyEncoding == BodyEncodingMultipart) {
+		request.Header.Set("Content-Type", contentType)
+	}
 	return request, payload, nil
 }
 
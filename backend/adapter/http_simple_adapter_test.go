@@ -414,6 +414,37 @@ func TestBuildCommonHttpRequest(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "expand environment variables in URL",
+			parameters: Parameters{
+				PathParams: map[string]any{
+					"id": "7",
+				},
+			},
+			meta: RequestMeta{
+				URL:    "${BASE_URL}/users/{id}?region=${REGION}",
+				Method: http.MethodGet,
+				Env:    map[string]string{"BASE_URL": "https://dev.example.com/v1", "REGION": "ap-guangzhou"},
+			},
+			expectedError: false,
+			validate: func(t *testing.T, req *http.Request, payload []byte) {
+				if req.URL.Host != "dev.example.com" || req.URL.Path != "/v1/users/7" {
+					t.Errorf("Expected https://dev.example.com/v1/users/7, got %s", req.URL.String())
+				}
+				if req.URL.Query().Get("region") != "ap-guangzhou" {
+					t.Errorf("Expected query region=ap-guangzhou, got %s", req.URL.Query().Get("region"))
+				}
+			},
+		},
+		{
+			name:       "unknown environment variable in URL",
+			parameters: Parameters{},
+			meta: RequestMeta{
+				URL:    "${BASE_URL}/users",
+				Method: http.MethodGet,
+			},
+			expectedError: true,
+		},
 		{
 			name:       "invalid URL",
 			parameters: Parameters{},
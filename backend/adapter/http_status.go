@@ -0,0 +1,94 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxErrorBodyLength 错误结果中保留的响应体长度 (字节)
+const maxErrorBodyLength = 4096
+
+// defaultSuccessStatus 未配置 success_status 时视为成功的状态码
+var defaultSuccessStatus = []string{"2xx"}
+
+// errorResponseHeaders 错误结果中保留的响应头，便于模型判断是否需要重试或重新鉴权
+var errorResponseHeaders = []string{
+	"Content-Type",
+	"Location",
+	"Retry-After",
+	"Www-Authenticate",
+	"X-Request-Id",
+}
+
+// errorResponseHeaderPrefixes 限流相关的响应头前缀
+var errorResponseHeaderPrefixes = []string{"Ratelimit", "X-Ratelimit"}
+
+// validSuccessStatus 状态码 (如 404) 或状态码段 (如 2xx)
+func validSuccessStatus(s string) bool {
+	if len(s) == 3 && s[0] >= '1' && s[0] <= '5' && strings.ToLower(s[1:]) == "xx" {
+		return true
+	}
+	code, err := strconv.Atoi(s)
+	return err == nil && code >= 100 && code <= 599
+}
+
+// isSuccessStatus 判断状态码是否命中成功策略
+func isSuccessStatus(policy []string, status int) bool {
+	if len(policy) == 0 {
+		policy = defaultSuccessStatus
+	}
+	code := strconv.Itoa(status)
+	for _, s := range policy {
+		if s == code || (len(s) == 3 && strings.ToLower(s[1:]) == "xx" && s[0] == code[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPStatusError 上游返回了非成功状态码，保留状态码、部分响应头和截断后的响应体
+type HTTPStatusError struct {
+	StatusCode int               `json:"status_code"`
+	Status     string            `json:"status"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body"`
+	Truncated  bool              `json:"truncated,omitempty"`
+}
+
+func newHTTPStatusError(resp *http.Response, data []byte) *HTTPStatusError {
+	e := &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     http.StatusText(resp.StatusCode),
+		Headers:    make(map[string]string),
+	}
+	for _, name := range errorResponseHeaders {
+		if v := resp.Header.Get(name); v != "" {
+			e.Headers[name] = v
+		}
+	}
+	for name, vs := range resp.Header {
+		for _, prefix := range errorResponseHeaderPrefixes {
+			if strings.HasPrefix(name, prefix) && len(vs) > 0 {
+				e.Headers[name] = vs[0]
+			}
+		}
+	}
+	e.Body, e.Truncated = truncateText(data, maxErrorBodyLength)
+	return e
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("http request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// ToolResultText 以 JSON 形式返回给模型，便于区分 404、429、500 等情况
+func (e *HTTPStatusError) ToolResultText() string {
+	data, err := json.Marshal(map[string]any{"error": e})
+	if err != nil {
+		return e.Error()
+	}
+	return string(data)
+}
@@ -0,0 +1,135 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestIsSuccessStatus(t *testing.T) {
+	tests := []struct {
+		policy []string
+		status int
+		want   bool
+	}{
+		{nil, http.StatusOK, true},
+		{nil, http.StatusCreated, true},
+		{nil, http.StatusNoContent, true},
+		{nil, http.StatusNotModified, false},
+		{nil, http.StatusNotFound, false},
+		{[]string{"200"}, http.StatusCreated, false},
+		{[]string{"2XX", "404"}, http.StatusNotFound, true},
+		{[]string{"3xx"}, http.StatusNotModified, true},
+	}
+	for _, tt := range tests {
+		if got := isSuccessStatus(tt.policy, tt.status); got != tt.want {
+			t.Errorf("isSuccessStatus(%v, %d) = %v, want %v", tt.policy, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestParseHTTPConfig_SuccessStatus(t *testing.T) {
+	cfg, err := ParseHTTPConfig(`{"success_status":["2xx","404"]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(cfg.SuccessStatus, ",") != "2xx,404" {
+		t.Errorf("unexpected success_status: %v", cfg.SuccessStatus)
+	}
+	for _, raw := range []string{`{"success_status":["6xx"]}`, `{"success_status":["20"]}`, `{"success_status":["ok"]}`} {
+		if _, err := ParseHTTPConfig(raw); err == nil || !strings.Contains(err.Error(), "success_status contains invalid status") {
+			t.Errorf("expected invalid success_status error for %s, got %v", raw, err)
+		}
+	}
+	merged := MergeHTTPConfig(HTTPClientConfig{SuccessStatus: []string{"200"}}, HTTPClientConfig{})
+	if strings.Join(merged.SuccessStatus, ",") != "200" {
+		t.Errorf("application success_status should be kept, got %v", merged.SuccessStatus)
+	}
+}
+
+func TestHTTPSimpleAdapter_SuccessStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":1}`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	call := func(path string, cfg HTTPClientConfig) ([]byte, error) {
+		return HTTPSimpleAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{},
+			RequestMeta{URL: server.URL + path, Method: http.MethodPost, Protocol: "http", AuthType: "none", HTTPConfig: cfg})
+	}
+	data, err := call("/created", HTTPClientConfig{})
+	if err != nil || string(data) != `{"id":1}` {
+		t.Errorf("201 should succeed, got %s %v", data, err)
+	}
+	data, err = call("/empty", HTTPClientConfig{})
+	if err != nil || len(data) != 0 {
+		t.Errorf("204 should succeed with empty body, got %s %v", data, err)
+	}
+	if _, err := call("/missing", HTTPClientConfig{}); err == nil {
+		t.Error("404 should fail by default")
+	}
+	data, err = call("/missing", HTTPClientConfig{SuccessStatus: []string{"2xx", "404"}})
+	if err != nil || string(data) != `{"message":"not found"}` {
+		t.Errorf("404 should succeed when configured, got %s %v", data, err)
+	}
+	if _, err := call("/created", HTTPClientConfig{SuccessStatus: []string{"200"}}); err == nil {
+		t.Error("201 should fail when only 200 is configured")
+	}
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	body := strings.Repeat("a", maxErrorBodyLength-1) + "中文"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	_, err := HTTPSimpleAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{},
+		RequestMeta{URL: server.URL, Method: http.MethodGet, Protocol: "http", AuthType: "none"})
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected *HTTPStatusError, got %v", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests || statusErr.Status != "Too Many Requests" {
+		t.Errorf("unexpected status: %d %s", statusErr.StatusCode, statusErr.Status)
+	}
+	if statusErr.Headers["Retry-After"] != "30" || statusErr.Headers["X-Ratelimit-Remaining"] != "0" {
+		t.Errorf("expected rate limit headers, got %v", statusErr.Headers)
+	}
+	if _, ok := statusErr.Headers["Set-Cookie"]; ok {
+		t.Error("unrelated headers should not be exposed")
+	}
+	// 截断时不能拆开多字节字符
+	if !statusErr.Truncated || statusErr.Body != strings.Repeat("a", maxErrorBodyLength-1) {
+		t.Errorf("expected body truncated at rune boundary, got %d bytes", len(statusErr.Body))
+	}
+
+	var result struct {
+		Error HTTPStatusError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(statusErr.ToolResultText()), &result); err != nil {
+		t.Fatalf("tool result should be JSON: %v", err)
+	}
+	if result.Error.StatusCode != http.StatusTooManyRequests || !result.Error.Truncated {
+		t.Errorf("unexpected tool result: %+v", result.Error)
+	}
+}
@@ -0,0 +1,450 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultInvocationRetention    = 7 * 24 * time.Hour
+	defaultInvocationMaxBodyBytes = 4096
+	maxInvocationBodyBytes        = 1 << 20
+	invocationPruneInterval       = time.Hour
+	invocationQueueSize           = 4096
+	invocationBatchSize           = 100
+	invocationFlushInterval       = time.Second
+)
+
+// redactedValue 脱敏后保存的值
+const redactedValue = "[REDACTED]"
+
+// defaultRedactPattern 始终脱敏的参数名、请求头名和查询参数名
+var defaultRedactPattern = regexp.MustCompile(`(?i)authorization|cookie|secret|token|password|passwd|api[-_]?key|signature|credential`)
+
+// InvocationLogConfig 应用的调用日志配置，空字符串表示使用默认配置
+type InvocationLogConfig struct {
+	Enabled      *bool    `json:"enabled,omitempty"`        // 是否记录，默认 true
+	Retention    string   `json:"retention,omitempty"`      // 保留时间，Go duration 格式，默认 168h
+	MaxBodyBytes int      `json:"max_body_bytes,omitempty"` // 保存的参数和响应体长度上限，默认 4096
+	Redact       []string `json:"redact,omitempty"`         // 额外的脱敏规则 (正则，不区分大小写)，匹配参数名、请求头名和查询参数名
+}
+
+// ParseInvocationLogConfig 解析并校验调用日志配置，未设置的字段使用默认值
+func ParseInvocationLogConfig(raw string) (InvocationLogConfig, error) {
+	var cfg InvocationLogConfig
+	if strings.TrimSpace(raw) != "" {
+		decoder := json.NewDecoder(strings.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("invalid invocation_log: %v", err)
+		}
+	}
+	if cfg.Retention != "" {
+		if d, err := time.ParseDuration(cfg.Retention); err != nil || d <= 0 {
+			return cfg, errors.New("invocation_log retention must be a positive duration such as 24h")
+		}
+	}
+	if cfg.MaxBodyBytes < 0 || cfg.MaxBodyBytes > maxInvocationBodyBytes {
+		return cfg, fmt.Errorf("invocation_log max_body_bytes must be between 0 and %d", maxInvocationBodyBytes)
+	}
+	for _, pattern := range cfg.Redact {
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			return cfg, fmt.Errorf("invocation_log redact pattern %q is invalid: %v", pattern, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (c InvocationLogConfig) enabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+func (c InvocationLogConfig) retention() time.Duration {
+	if d, err := time.ParseDuration(c.Retention); err == nil && d > 0 {
+		return d
+	}
+	return defaultInvocationRetention
+}
+
+func (c InvocationLogConfig) maxBodyBytes() int {
+	if c.MaxBodyBytes == 0 {
+		return defaultInvocationMaxBodyBytes
+	}
+	return c.MaxBodyBytes
+}
+
+// redactor 按默认规则和应用配置的规则判断名称是否需要脱敏
+type redactor []*regexp.Regexp
+
+func newRedactor(patterns []string) redactor {
+	r := redactor{defaultRedactPattern}
+	for _, pattern := range patterns {
+		r = append(r, regexp.MustCompile("(?i)"+pattern))
+	}
+	return r
+}
+
+func (r redactor) match(name string) bool {
+	for _, re := range r {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// value 递归脱敏 JSON 值中名称匹配的字段
+func (r redactor) value(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for k, item := range val {
+			if r.match(k) {
+				result[k] = redactedValue
+			} else {
+				result[k] = r.value(item)
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, len(val))
+		for i, item := range val {
+			result[i] = r.value(item)
+		}
+		return result
+	}
+	return v
+}
+
+func (r redactor) headers(h http.Header) map[string]string {
+	result := make(map[string]string, len(h))
+	for name, values := range h {
+		if r.match(name) {
+			result[name] = redactedValue
+		} else {
+			result[name] = strings.Join(values, ", ")
+		}
+	}
+	return result
+}
+
+// url 脱敏 URL 中的密码和名称匹配的查询参数
+func (r redactor) url(u *url.URL) string {
+	copied := *u
+	query := copied.Query()
+	for name := range query {
+		if r.match(name) {
+			query[name] = []string{redactedValue}
+		}
+	}
+	copied.RawQuery = query.Encode()
+	return copied.Redacted()
+}
+
+// truncateText 按字节截断，不切断 UTF-8 字符
+func truncateText(data []byte, limit int) (string, bool) {
+	if len(data) <= limit {
+		return string(data), false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return string(data[:cut]), true
+}
+
+// invocationRecorder 一次工具调用中最终发往上游的请求，由 executeHTTPRequest 记录
+type invocationRecorder struct {
+	mu       sync.Mutex
+	redact   redactor
+	method   string
+	url      string
+	headers  map[string]string
+	status   int
+	attempts int
+	body     []byte
+	secrets  []string // 本次调用解析出的密钥明文
+}
+
+type invocationRecorderKey struct{}
+
+func invocationRecorderFromContext(ctx context.Context) *invocationRecorder {
+	rec, _ := ctx.Value(invocationRecorderKey{}).(*invocationRecorder)
+	return rec
+}
+
+// recordUpstreamRequest 记录发往上游的请求，重试时覆盖之前的记录
+func recordUpstreamRequest(request *http.Request, attempt int) {
+	rec := invocationRecorderFromContext(request.Context())
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.method = request.Method
+	rec.url = rec.redact.url(request.URL)
+	rec.headers = rec.redact.headers(request.Header)
+	rec.attempts = attempt + 1
+	rec.status, rec.body = 0, nil
+}
+
+// recordUpstreamResponse 记录上游响应的状态码和响应体
+func recordUpstreamResponse(ctx context.Context, status int, body []byte) {
+	rec := invocationRecorderFromContext(ctx)
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.status, rec.body = status, body
+}
+
+// recordSecret 记录本次调用中解析出的密钥明文，写入调用日志前按值替换
+func recordSecret(ctx context.Context, value string) {
+	rec := invocationRecorderFromContext(ctx)
+	if rec == nil || value == "" {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.secrets = append(rec.secrets, value)
+}
+
+// secretMasker 把密钥明文替换为脱敏值，同时处理 URL 编码和 JSON 转义后的形式；
+// 名称规则无法覆盖的位置 (如名为 X-App-Ak 的固定参数、响应中回显的凭证) 也不会以明文写入
+func secretMasker(secrets []string) *strings.Replacer {
+	forms := make(map[string]bool)
+	for _, secret := range secrets {
+		escaped, _ := json.Marshal(secret)
+		for _, form := range []string{secret, url.QueryEscape(secret), url.PathEscape(secret), string(escaped[1 : len(escaped)-1])} {
+			if form != "" {
+				forms[form] = true
+			}
+		}
+	}
+	if len(forms) == 0 {
+		return nil
+	}
+	// 较长的形式优先匹配，避免只替换其中一部分
+	sorted := make([]string, 0, len(forms))
+	for form := range forms {
+		sorted = append(sorted, form)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, form := range sorted {
+		pairs = append(pairs, form, redactedValue)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// invocationWriter 在后台批量写入调用日志，工具调用只把记录放入队列，不等待写库
+// 队列满时丢弃记录并打印日志，不阻塞调用
+type invocationWriter struct {
+	once    sync.Once
+	queue   chan models.ToolInvocation
+	flushes chan chan struct{}
+	dropped atomic.Int64
+}
+
+var invocations = &invocationWriter{
+	queue:   make(chan models.ToolInvocation, invocationQueueSize),
+	flushes: make(chan chan struct{}),
+}
+
+func (w *invocationWriter) enqueue(invocation models.ToolInvocation) {
+	w.once.Do(func() { go w.run() })
+	select {
+	case w.queue <- invocation:
+	default:
+		if dropped := w.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			log.Printf("Invocation log queue is full, %d invocations dropped", dropped)
+		}
+	}
+}
+
+// run 攒满一批或每隔 invocationFlushInterval 写入一次
+func (w *invocationWriter) run() {
+	ticker := time.NewTicker(invocationFlushInterval)
+	defer ticker.Stop()
+	batch := make([]models.ToolInvocation, 0, invocationBatchSize)
+	write := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := database.GetDB().CreateInBatches(batch, invocationBatchSize).Error; err != nil {
+			log.Printf("Error saving %d tool invocations: %v", len(batch), err)
+		}
+		batch = make([]models.ToolInvocation, 0, invocationBatchSize)
+	}
+	for {
+		select {
+		case invocation := <-w.queue:
+			batch = append(batch, invocation)
+			if len(batch) >= invocationBatchSize {
+				write()
+			}
+		case <-ticker.C:
+			write()
+		case done := <-w.flushes:
+			for drained := false; !drained; {
+				select {
+				case invocation := <-w.queue:
+					batch = append(batch, invocation)
+				default:
+					drained = true
+				}
+			}
+			write()
+			close(done)
+		}
+	}
+}
+
+// FlushInvocations 写入队列中所有的调用日志，服务关闭前调用
+func FlushInvocations() {
+	invocations.once.Do(func() { go invocations.run() })
+	done := make(chan struct{})
+	invocations.flushes <- done
+	<-done
+}
+
+// logInvocations 包装工具处理函数，每次调用结束后把一条 ToolInvocation 放入写入队列
+func logInvocations(cfg InvocationLogConfig, app *models.Application, iface *models.Interface, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if !cfg.enabled() {
+		return handler
+	}
+	redact := newRedactor(cfg.Redact)
+	limit := cfg.maxBodyBytes()
+	appID, interfaceID, tool := app.ID, iface.ID, iface.Name
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		rec := &invocationRecorder{redact: redact}
+		start := time.Now()
+		result, err := handler(context.WithValue(ctx, invocationRecorderKey{}, rec), req)
+
+		invocation := models.ToolInvocation{
+			AppID:       appID,
+			InterfaceID: interfaceID,
+			Tool:        tool,
+			Status:      "success",
+			LatencyMs:   time.Since(start).Milliseconds(),
+			CreatedAt:   start,
+		}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			invocation.SessionID = session.SessionID()
+		}
+		if args, marshalErr := json.Marshal(redact.value(req.GetArguments())); marshalErr == nil {
+			invocation.Arguments, _ = truncateText(args, limit)
+		}
+		rec.mu.Lock()
+		mask := secretMasker(rec.secrets)
+		invocation.Method, invocation.URL, invocation.StatusCode, invocation.Attempts = rec.method, rec.url, rec.status, rec.attempts
+		if rec.headers != nil {
+			headers, _ := json.Marshal(rec.headers)
+			invocation.RequestHeaders = string(headers)
+		}
+		invocation.ResponseSize = int64(len(rec.body))
+		body := rec.body
+		if mask != nil {
+			invocation.URL = mask.Replace(invocation.URL)
+			invocation.RequestHeaders = mask.Replace(invocation.RequestHeaders)
+			// 先替换再截断，避免截断后残留密钥的一部分
+			body = []byte(mask.Replace(string(body)))
+		}
+		invocation.ResponseBody, invocation.Truncated = truncateText(body, limit)
+		rec.mu.Unlock()
+
+		switch {
+		case err != nil:
+			invocation.Status, invocation.Error = "error", err.Error()
+		case result != nil && result.IsError:
+			invocation.Status = "error"
+			if len(result.Content) > 0 {
+				if text, ok := result.Content[0].(mcp.TextContent); ok {
+					invocation.Error = text.Text
+				}
+			}
+		}
+		if mask != nil {
+			invocation.Error = mask.Replace(invocation.Error)
+		}
+		if result != nil && result.Meta != nil && result.Meta.AdditionalFields["cache"] == "hit" {
+			invocation.CacheHit = true
+		}
+		invocations.enqueue(invocation)
+		return result, err
+	}
+}
+
+// PruneInvocations 按各应用的保留时间删除过期的调用日志，已删除应用的日志使用默认保留时间
+func PruneInvocations(now time.Time) (int64, error) {
+	db := database.GetDB()
+	var apps []models.Application
+	if err := db.Select("id", "invocation_log").Find(&apps).Error; err != nil {
+		return 0, err
+	}
+	var pruned int64
+	appIDs := make([]int64, 0, len(apps))
+	for _, app := range apps {
+		appIDs = append(appIDs, app.ID)
+		cfg, err := ParseInvocationLogConfig(app.InvocationLog)
+		if err != nil {
+			cfg = InvocationLogConfig{}
+		}
+		result := db.Where("app_id = ? AND created_at < ?", app.ID, now.Add(-cfg.retention())).Delete(&models.ToolInvocation{})
+		if result.Error != nil {
+			return pruned, result.Error
+		}
+		pruned += result.RowsAffected
+	}
+	query := db.Where("created_at < ?", now.Add(-defaultInvocationRetention))
+	if len(appIDs) > 0 {
+		query = query.Where("app_id NOT IN ?", appIDs)
+	}
+	result := query.Delete(&models.ToolInvocation{})
+	if result.Error != nil {
+		return pruned, result.Error
+	}
+	return pruned + result.RowsAffected, nil
+}
+
+// invocationPruneLoop 定时清理过期的调用日志
+func (sm *ServerManager) invocationPruneLoop() {
+	defer sm.wg.Done()
+	ticker := time.NewTicker(invocationPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sm.ctx.Done():
+			return
+		case <-ticker.C:
+			if pruned, err := PruneInvocations(time.Now()); err != nil {
+				log.Printf("Error pruning tool invocations: %v", err)
+			} else if pruned > 0 {
+				log.Printf("Pruned %d expired tool invocations", pruned)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package adapter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment JSONPath 的一段：字段名、下标、切片、通配符或递归查找
+type jsonPathSegment struct {
+	name      string
+	index     *int
+	slice     *[2]*int
+	wildcard  bool
+	recursive bool // ..name 或 ..*
+}
+
+// JSONPath 支持的子集：$ . ['name'] [n] [-n] [start:end] [*] .* ..name
+type JSONPath struct {
+	segments []jsonPathSegment
+	definite bool // 不包含通配符、切片和递归时返回单个值，否则返回匹配结果数组
+}
+
+// CompileJSONPath 解析 JSONPath 表达式，必须以 $ 开头
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("jsonpath %q must start with $", expr)
+	}
+	p := &JSONPath{definite: true}
+	rest := expr[1:]
+	for rest != "" {
+		var seg jsonPathSegment
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg.recursive = true
+			rest = rest[2:]
+			name, n := readJSONPathName(rest)
+			if name == "" {
+				return nil, fmt.Errorf("jsonpath %q: missing name after ..", expr)
+			}
+			seg.name, seg.wildcard = name, name == "*"
+			rest = rest[n:]
+		case rest[0] == '.':
+			rest = rest[1:]
+			name, n := readJSONPathName(rest)
+			if name == "" {
+				return nil, fmt.Errorf("jsonpath %q: missing name after .", expr)
+			}
+			seg.name, seg.wildcard = name, name == "*"
+			rest = rest[n:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: missing ]", expr)
+			}
+			if err := parseJSONPathBracket(strings.TrimSpace(rest[1:end]), &seg); err != nil {
+				return nil, fmt.Errorf("jsonpath %q: %v", expr, err)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", expr, rest[:1])
+		}
+		if seg.recursive || seg.wildcard || seg.slice != nil {
+			p.definite = false
+		}
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+func readJSONPathName(s string) (string, int) {
+	n := 0
+	for n < len(s) && s[n] != '.' && s[n] != '[' {
+		n++
+	}
+	return s[:n], n
+}
+
+func parseJSONPathBracket(inner string, seg *jsonPathSegment) error {
+	switch {
+	case inner == "*":
+		seg.wildcard = true
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		seg.name = inner[1 : len(inner)-1]
+	case strings.Contains(inner, ":"):
+		parts := strings.SplitN(inner, ":", 2)
+		var bounds [2]*int
+		for i, part := range parts {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return fmt.Errorf("invalid slice %q", inner)
+			}
+			bounds[i] = &v
+		}
+		seg.slice = &bounds
+	default:
+		v, err := strconv.Atoi(inner)
+		if err != nil {
+			return fmt.Errorf("invalid subscript %q, filters are not supported, use jmespath instead", inner)
+		}
+		seg.index = &v
+	}
+	return nil
+}
+
+// Search 在 JSON 数据中查找匹配的值
+func (p *JSONPath) Search(data any) any {
+	current := []any{data}
+	for _, seg := range p.segments {
+		next := make([]any, 0)
+		for _, v := range current {
+			next = append(next, seg.apply(v)...)
+		}
+		current = next
+	}
+	if p.definite {
+		if len(current) == 0 {
+			return nil
+		}
+		return current[0]
+	}
+	return current
+}
+
+func (seg jsonPathSegment) apply(v any) []any {
+	if seg.recursive {
+		var out []any
+		var walk func(v any)
+		walk = func(v any) {
+			out = append(out, seg.child(v)...)
+			switch val := v.(type) {
+			case map[string]any:
+				for _, k := range sortedKeys(val) {
+					walk(val[k])
+				}
+			case []any:
+				for _, item := range val {
+					walk(item)
+				}
+			}
+		}
+		walk(v)
+		return out
+	}
+	return seg.child(v)
+}
+
+// child 当前节点下匹配该段的子节点
+func (seg jsonPathSegment) child(v any) []any {
+	switch val := v.(type) {
+	case map[string]any:
+		if seg.wildcard {
+			out := make([]any, 0, len(val))
+			for _, k := range sortedKeys(val) {
+				out = append(out, val[k])
+			}
+			return out
+		}
+		if seg.index == nil && seg.slice == nil {
+			if child, ok := val[seg.name]; ok {
+				return []any{child}
+			}
+		}
+	case []any:
+		switch {
+		case seg.wildcard:
+			return val
+		case seg.index != nil:
+			i := *seg.index
+			if i < 0 {
+				i += len(val)
+			}
+			if i >= 0 && i < len(val) {
+				return []any{val[i]}
+			}
+		case seg.slice != nil:
+			start, end := 0, len(val)
+			if b := seg.slice[0]; b != nil {
+				start = clampIndex(*b, len(val))
+			}
+			if b := seg.slice[1]; b != nil {
+				end = clampIndex(*b, len(val))
+			}
+			if start < end {
+				return val[start:end]
+			}
+		}
+	}
+	return nil
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
@@ -3,6 +3,7 @@ package adapter
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"mcp-adapter/backend/database"
@@ -11,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -64,16 +66,26 @@ type Parameters struct {
 }
 
 type RequestMeta struct {
-	URL      string
-	Method   string
-	AuthType string
-	Protocol string
-	Ext      map[string]string
+	URL            string
+	Method         string
+	AuthType       string
+	Protocol       string
+	Env            map[string]string // 应用环境变量，用于展开 URL 中的 ${VAR}
+	Ext            map[string]string
+	ForwardHeaders map[string]string  // 需要透传的 MCP 客户端请求头 -> 上游请求头
+	HTTPConfig     HTTPClientConfig   // 合并应用默认值后的 HTTP 客户端配置
+	BodyEncoding   string             // 请求体编码: json, form, multipart, raw
+	BodyTemplate   *template.Template // 请求体模板，为空时按参数名生成扁平的请求体
+	Query          string             // GraphQL 查询文档、SQL 语句、命令参数模板或 stdio MCP 服务器启动参数
+	DescriptorSet  string             // gRPC 描述集 (base64)，为空时通过服务端反射解析方法
+	SQLColumns     map[string]string  // sql 接口结果列 -> 出参 rows 中定义的类型，为空时返回所有列
 }
 
 type PostProcessMeta struct {
 	TruncateFields   map[string]int `json:"truncate_fields"`
 	StructuredOutput bool           `json:"structured_output"`
+	Pipeline         []PipelineStep `json:"pipeline,omitempty"` // 在截取字段之后依次执行的响应处理步骤
+	Format           string         `json:"format,omitempty"`   // 文本输出格式: json, markdown, yaml
 }
 
 // AddCleanup 添加清理函数
@@ -164,6 +176,13 @@ func InitServer() {
 		// 添加处理器
 		serverManager.handles = append(serverManager.handles, HTTPSimpleAdapter{})
 		serverManager.handles = append(serverManager.handles, HTTPCAPIAdapter{})
+		serverManager.handles = append(serverManager.handles, HTTPAuthAdapter{})
+		serverManager.handles = append(serverManager.handles, HTTPSigV4Adapter{})
+		serverManager.handles = append(serverManager.handles, GraphQLAdapter{})
+		serverManager.handles = append(serverManager.handles, GRPCAdapter{})
+		serverManager.handles = append(serverManager.handles, SQLAdapter{})
+		serverManager.handles = append(serverManager.handles, CommandAdapter{})
+		serverManager.handles = append(serverManager.handles, MCPAdapter{})
 
 		// 加载现有应用
 		serverManager.loadExistingApplications()
@@ -187,6 +206,14 @@ func InitServer() {
 		serverManager.wg.Add(1)
 		go serverManager.eventLoop()
 
+		// 定时清理过期的调用日志
+		serverManager.wg.Add(1)
+		go serverManager.invocationPruneLoop()
+
+		// 定时回收空闲的 stdio MCP 服务器子进程
+		serverManager.wg.Add(1)
+		go serverManager.stdioIdleLoop()
+
 		log.Println("ServerManager initialized successfully")
 	})
 }
@@ -333,6 +360,12 @@ func Shutdown() {
 	// 清理所有服务器
 	serverManager.cleanupAllServers()
 
+	// 关闭 stdio MCP 服务器子进程
+	CloseStdioServers()
+
+	// 写入队列中剩余的调用日志
+	FlushInvocations()
+
 	serverManager = nil
 	initOnce = sync.Once{}
 
@@ -370,6 +403,15 @@ func GetServerImpl(path, protocol string) http.Handler {
 
 // addTool 添加工具到指定应用
 func (sm *ServerManager) addTool(iface *models.Interface, app *models.Application) error {
+	// 禁用的接口和禁用应用下的接口不对外暴露
+	if !iface.Enabled {
+		log.Printf("Tool %s is disabled, skipped", iface.Name)
+		return nil
+	}
+	if !app.Enabled {
+		log.Printf("Application %s is disabled, tool %s skipped", app.Name, iface.Name)
+		return nil
+	}
 	if s, ok := sm.sseServers.Load(app.Path); ok {
 		srv := s.(*Server)
 		tool := srv.server.GetTool(iface.Name)
@@ -389,6 +431,65 @@ func (sm *ServerManager) addTool(iface *models.Interface, app *models.Applicatio
 			return fmt.Errorf("error getting interface output parameters for tool %s", iface.Name)
 		}
 
+		// 检查接口引用的环境变量都已定义
+		env, err := ParseEnvironment(app.Environment)
+		if err != nil {
+			return fmt.Errorf("invalid environment of application %s: %w", app.Name, err)
+		}
+		if err := checkEnvironmentReferences(iface, params, env); err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
+		}
+		if _, err := ParseAuthConfig(iface.AuthType, iface.AuthConfig); err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
+		}
+		forwardHeaders, err := ParseForwardHeaders(app.ForwardHeaders)
+		if err != nil {
+			return fmt.Errorf("invalid forward headers of application %s: %w", app.Name, err)
+		}
+		appHTTPConfig, err := ParseHTTPConfig(app.HTTPConfig)
+		if err != nil {
+			return fmt.Errorf("invalid http config of application %s: %w", app.Name, err)
+		}
+		ifaceHTTPConfig, err := ParseHTTPConfig(iface.HTTPConfig)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
+		}
+		bodyTemplate, err := ParseBodyTemplate(iface.BodyTemplate, iface.BodyEncoding)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
+		}
+		if iface.Protocol == "graphql" {
+			if err := ValidateGraphQLQuery(iface.Query, params); err != nil {
+				return fmt.Errorf("tool %s: %w", iface.Name, err)
+			}
+		}
+		if iface.Protocol == "grpc" {
+			if err := ValidateGRPCInterface(iface.URL, iface.DescriptorSet, params); err != nil {
+				return fmt.Errorf("tool %s: %w", iface.Name, err)
+			}
+		}
+		if iface.Protocol == "command" {
+			if err := ValidateCommandInterface(iface.URL, iface.Query, params); err != nil {
+				return fmt.Errorf("tool %s: %w", iface.Name, err)
+			}
+		}
+		if iface.Protocol == "mcp" {
+			if err := ValidateMCPInterface(iface.URL, params); err != nil {
+				return fmt.Errorf("tool %s: %w", iface.Name, err)
+			}
+		}
+		var sqlColumns map[string]string
+		if iface.Protocol == "sql" {
+			if err := ValidateSQLInterface(iface.URL, iface.Query, params); err != nil {
+				return fmt.Errorf("tool %s: %w", iface.Name, err)
+			}
+			rowSchema, err := BuildMcpOutputSchemaByInterface(iface.ID)
+			if err != nil {
+				return err
+			}
+			sqlColumns = sqlRowColumns(rowSchema)
+		}
+
 		schema, err := BuildMcpInputSchemaByInterface(iface.ID)
 		if err != nil {
 			return err
@@ -402,16 +503,45 @@ func (sm *ServerManager) addTool(iface *models.Interface, app *models.Applicatio
 		log.Printf("Input schema for tool %s: %s", iface.Name, string(marshal))
 		newTool := mcp.NewToolWithRawSchema(iface.Name, iface.Description, marshal)
 
-		postProcessMeta := PostProcessMeta{
-			TruncateFields:   make(map[string]int),
-			StructuredOutput: false,
+		appScript, err := CompileScript("app:"+app.Name, app.PostProcess)
+		if err != nil {
+			return fmt.Errorf("application %s: %w", app.Name, err)
+		}
+		ifaceScript, err := CompileScript(iface.Name, iface.Script)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
+		}
+
+		cacheConfig, err := ParseCacheConfig(iface.CacheConfig)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
+		}
+
+		appRateLimit, err := ParseRateLimitConfig(app.RateLimit)
+		if err != nil {
+			return fmt.Errorf("application %s: %w", app.Name, err)
+		}
+		ifaceRateLimit, err := ParseRateLimitConfig(iface.RateLimit)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
+		}
+
+		invocationLog, err := ParseInvocationLogConfig(app.InvocationLog)
+		if err != nil {
+			return fmt.Errorf("application %s: %w", app.Name, err)
+		}
+
+		postProcessMeta, err := ParsePostProcess(iface.PostProcess)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
 		}
 		if iface.PostProcess != "" {
-			if err := json.Unmarshal([]byte(iface.PostProcess), &postProcessMeta); err != nil {
-				log.Printf("Error unmarshalling post process meta: %v, tool id %d", err, iface.ID)
-			}
 			log.Printf("Post process meta for tool %s: %+v", iface.Name, postProcessMeta)
 		}
+		pipeline, err := compilePipeline(postProcessMeta.Pipeline)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", iface.Name, err)
+		}
 
 		var outputSchema map[string]any
 		if postProcessMeta.StructuredOutput {
@@ -437,24 +567,47 @@ func (sm *ServerManager) addTool(iface *models.Interface, app *models.Applicatio
 		// 创建 outputSchema 的副本
 		outputSchemaCopy := outputSchema
 		inputSchemaCopy := schema
+		httpConfig := MergeHTTPConfig(appHTTPConfig, ifaceHTTPConfig)
+		httpConfig.clientKey = interfaceHTTPClientKey(app.ID, iface.ID)
 		meta := RequestMeta{
-			URL:      iface.URL,
-			Method:   iface.Method,
-			AuthType: iface.AuthType,
-			Protocol: iface.Protocol,
-			Ext:      make(map[string]string),
+			URL:            iface.URL,
+			Method:         iface.Method,
+			AuthType:       iface.AuthType,
+			Protocol:       iface.Protocol,
+			Env:            env,
+			Ext:            map[string]string{ExtAuthConfig: iface.AuthConfig},
+			ForwardHeaders: forwardHeaders,
+			HTTPConfig:     httpConfig,
+			BodyEncoding:   iface.BodyEncoding,
+			BodyTemplate:   bodyTemplate,
+			Query:          iface.Query,
+			DescriptorSet:  iface.DescriptorSet,
+			SQLColumns:     sqlColumns,
 		}
-		srv.server.AddTool(newTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cache := newResponseCache(cacheConfig, iface, app)
+		appLimiter := applicationRateLimiter(appRateLimit, app)
+		ifaceLimiter := interfaceRateLimiter(ifaceRateLimit, iface)
+		breaker := circuitBreakerFor(meta.HTTPConfig.CircuitBreaker, iface, env)
+		srv.server.AddTool(newTool, logInvocations(invocationLog, app, iface, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 
 			if ok := SatisfySchema(inputSchemaCopy, req.GetArguments()); !ok {
 				return mcp.NewToolResultError(fmt.Sprintf("invalid input schema: %v", err)), nil
 			}
 
-			finalParams, err := rearrangeParametersAndValidate(req.GetArguments(), paramsCopy)
+			finalParams, err := rearrangeParametersAndValidate(req.GetArguments(), paramsCopy, env)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			// 脚本钩子：请求前先执行应用脚本再执行接口脚本，响应后顺序相反
+			for _, script := range []*Script{appScript, ifaceScript} {
+				params, err := script.PreRequest(ctx, *finalParams, nil)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				finalParams = &params
+			}
+
 			var handle RequestHandle = nil
 			for _, h := range sm.handles {
 				if h.Compatible(meta) {
@@ -465,9 +618,64 @@ func (sm *ServerManager) addTool(iface *models.Interface, app *models.Applicatio
 			if handle == nil {
 				return mcp.NewToolResultError(fmt.Sprintf("no compatible handle found for tool %s", iface.Name)), nil
 			}
-			data, err := handle.DoRequest(ctx, req, *finalParams, meta)
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
+			// 响应缓存：命中时不请求上游，只缓存成功的原始响应，脚本和后处理每次都会执行
+			var data []byte
+			var cacheKey string
+			cacheHit := false
+			if cache != nil {
+				cacheKey = cache.key(iface.Name, *finalParams, req, meta)
+				data, cacheHit = cache.store.get(cacheKey)
+			}
+			if !cacheHit {
+				// 熔断：上游不可用时直接返回，不等待请求超时
+				record, err := breaker.allow(meta.HTTPConfig.CircuitBreaker)
+				if err != nil {
+					var openErr *CircuitOpenError
+					if errors.As(err, &openErr) {
+						return mcp.NewToolResultError(openErr.ToolResultText()), nil
+					}
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				// 限流：缓存命中的调用不占用额度
+				release, err := acquireRateLimits(ctx, req.Header, appLimiter, ifaceLimiter)
+				if err != nil {
+					// 没有发送请求，不影响熔断状态
+					record(ctx, err)
+					var limitErr *RateLimitError
+					if errors.As(err, &limitErr) {
+						return mcp.NewToolResultError(limitErr.ToolResultText()), nil
+					}
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				data, err = handle.DoRequest(ctx, req, *finalParams, meta)
+				release()
+				record(ctx, err)
+				if err != nil {
+					var graphQLErr *GraphQLError
+					if errors.As(err, &graphQLErr) {
+						return mcp.NewToolResultError(graphQLErr.ToolResultText()), nil
+					}
+					var grpcErr *GRPCStatusError
+					if errors.As(err, &grpcErr) {
+						return mcp.NewToolResultError(grpcErr.ToolResultText()), nil
+					}
+					var statusErr *HTTPStatusError
+					if errors.As(err, &statusErr) {
+						return mcp.NewToolResultError(statusErr.ToolResultText()), nil
+					}
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				if cache != nil {
+					cache.store.set(cacheKey, data)
+				}
+			} else {
+				recordUpstreamResponse(ctx, 0, data)
+			}
+
+			for _, script := range []*Script{ifaceScript, appScript} {
+				if data, err = script.PostResponse(ctx, data, nil); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
 			}
 
 			// 后处理：截取字段
@@ -480,12 +688,18 @@ func (sm *ServerManager) addTool(iface *models.Interface, app *models.Applicatio
 				}
 			}
 
-			if postProcessMeta.StructuredOutput {
-				// 解析 JSON 数据
-				var result any
+			// 后处理：响应处理管道和输出格式
+			var result any
+			if len(pipeline) > 0 || postProcessMeta.StructuredOutput || (postProcessMeta.Format != "" && postProcessMeta.Format != OutputFormatJSON) {
 				if err := json.Unmarshal(data, &result); err != nil {
 					return mcp.NewToolResultError(fmt.Sprintf("failed to parse JSON: %v", err)), nil
 				}
+				if result, err = runPipeline(pipeline, result); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+
+			if postProcessMeta.StructuredOutput {
 				// 使用 SatisfySchema 进行验证
 				if !SatisfySchema(outputSchemaCopy, result) {
 					return mcp.NewToolResultError("output does not satisfy schema"), nil
@@ -496,10 +710,15 @@ func (sm *ServerManager) addTool(iface *models.Interface, app *models.Applicatio
 				if err != nil {
 					return mcp.NewToolResultError(fmt.Sprintf("failed to marshal filtered output: %v", err)), nil
 				}
-				return mcp.NewToolResultStructured(filtered, string(bytes)), nil
+				return withCacheMeta(mcp.NewToolResultStructured(filtered, string(bytes)), cacheHit), nil
+			}
+			if result != nil || len(pipeline) > 0 {
+				if data, err = renderOutput(postProcessMeta.Format, result); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to render output: %v", err)), nil
+				}
 			}
-			return mcp.NewToolResultText(string(data)), nil
-		})
+			return withCacheMeta(mcp.NewToolResultText(string(data)), cacheHit), nil
+		}))
 
 		log.Printf("Added tool: %s", iface.Name)
 		return nil
@@ -654,7 +873,8 @@ func truncate(key string, length int, data []byte) ([]byte, error) {
 }
 
 // rearrangeParametersAndValidate 应用默认值并验证参数
-func rearrangeParametersAndValidate(rawParams map[string]any, params []models.InterfaceParameter) (*Parameters, error) {
+// env 只用于展开 fixed 参数默认值中的 ${VAR}，调用方传入的参数不做替换
+func rearrangeParametersAndValidate(rawParams map[string]any, params []models.InterfaceParameter, env map[string]string) (*Parameters, error) {
 
 	headerParams := make(map[string]any)
 	bodyParams := make(map[string]any)
@@ -710,7 +930,16 @@ func rearrangeParametersAndValidate(rawParams map[string]any, params []models.In
 			log.Printf("Warning: fixed parameter %s has no default value", p.Name)
 			continue
 		}
-		convertedVal, err := ConvertDefaultValue(*p.DefaultValue, p.Type)
+		defaultValue, err := ExpandEnvironment(*p.DefaultValue, env)
+		if err != nil {
+			return nil, fmt.Errorf("fixed parameter %s: %w", p.Name, err)
+		}
+		// 密钥引用 (包括值为密钥引用的环境变量) 延迟到 DoRequest 中解析
+		if name, ok := ParseSecretRef(defaultValue); ok {
+			setVal(p, SecretRef{Name: name})
+			continue
+		}
+		convertedVal, err := ConvertDefaultValue(defaultValue, p.Type)
 		if err != nil {
 			log.Printf("Warning: failed to convert fixed parameter %s: %v", p.Name, err)
 			continue
@@ -764,6 +993,10 @@ func (sm *ServerManager) removeTool(iface *models.Interface, app *models.Applica
 
 	if s, ok := sm.sseServers.Load(app.Path); ok {
 		s.(*Server).server.DeleteTools(iface.Name)
+		dropResponseCache(iface.ID)
+		dropRateLimiters(iface.ID)
+		dropCircuitBreakers(iface.ID)
+		dropInterfaceHTTPClient(app.ID, iface.ID)
 		log.Printf("Removed tool: %s", iface.Name)
 	}
 	return nil
@@ -790,6 +1023,10 @@ func (sm *ServerManager) addApplication(app *models.Application) error {
 	if app.Protocol != "sse" && app.Protocol != "streamable" {
 		return fmt.Errorf("unsupported protocol: %s", app.Protocol)
 	}
+	if !app.Enabled {
+		log.Printf("Application %s is disabled, skipping", app.Name)
+		return nil
+	}
 
 	// 检查是否已存在
 	if _, exists := sm.sseServers.Load(app.Path); exists {
@@ -802,7 +1039,8 @@ func (sm *ServerManager) addApplication(app *models.Application) error {
 	if err := query.Find(&interfaces).Error; err != nil {
 		return fmt.Errorf("error getting interfaces: %v", err)
 	}
-	mcpServer := server.NewMCPServer(app.Name, "1.0.0")
+	// 显式声明 listChanged，接口启用/禁用时客户端会收到 tools/list_changed 通知
+	mcpServer := server.NewMCPServer(app.Name, "1.0.0", server.WithToolCapabilities(true))
 	var srv *Server = nil
 	if app.Protocol == "sse" {
 		srv = &Server{
@@ -832,6 +1070,10 @@ func (sm *ServerManager) addApplication(app *models.Application) error {
 	// 添加清理函数：清理所有工具
 	srv.AddCleanup(func() {
 		log.Printf("Cleaning up tools for application: %s", app.Name)
+		dropApplicationCaches(app.ID)
+		dropApplicationRateLimiters(app.ID)
+		dropApplicationCircuitBreakers(app.ID)
+		dropApplicationHTTPClients(app.ID)
 		// 如果 MCPServer 有 Close/Shutdown 方法，在此调用
 		// mcpServer.Close()
 	})
@@ -845,6 +1087,19 @@ func (sm *ServerManager) addApplication(app *models.Application) error {
 			continue
 		}
 	}
+	// 挂载远程 MCP 服务器：本地接口先注册，与之重名的远程工具会被跳过
+	upstreamConfig, err := ParseUpstreamConfig(app.Upstream)
+	if err != nil {
+		log.Printf("Error mounting upstream of application %s: %v", app.Name, err)
+	} else if upstreamConfig != nil {
+		upstream, err := newUpstreamServer(app, upstreamConfig, srv)
+		if err != nil {
+			log.Printf("Error mounting upstream of application %s: %v", app.Name, err)
+		} else {
+			srv.AddCleanup(upstream.close)
+			upstream.start()
+		}
+	}
 	log.Printf("Added MCP server: %s, protocol: %s, tools: %d", app.Name, app.Protocol, len(interfaces))
 	return nil
 }
@@ -1,10 +1,15 @@
 package adapter
 
 import (
+	"context"
 	"encoding/json"
+	"mcp-adapter/backend/database"
 	"mcp-adapter/backend/models"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func TestTruncate(t *testing.T) {
@@ -1036,7 +1041,7 @@ func TestRearrangeParametersAndValidate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := rearrangeParametersAndValidate(tt.rawParams, tt.params)
+			result, err := rearrangeParametersAndValidate(tt.rawParams, tt.params, nil)
 
 			if tt.expectedError {
 				if err == nil {
@@ -1061,6 +1066,34 @@ func TestRearrangeParametersAndValidate(t *testing.T) {
 	}
 }
 
+func TestRearrangeParametersExpandsEnvironment(t *testing.T) {
+	params := []models.InterfaceParameter{
+		{Name: "X-Api-Host", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("${HOST}")},
+		{Name: "limit", Type: "number", Location: "query", Group: "fixed", DefaultValue: stringPtr("${LIMIT}")},
+		{Name: "note", Type: "string", Location: "body", Group: "input"},
+	}
+	env := map[string]string{"HOST": "api.example.com", "LIMIT": "20"}
+
+	result, err := rearrangeParametersAndValidate(map[string]any{"note": "${HOST}"}, params, env)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.HeaderParams["X-Api-Host"] != "api.example.com" {
+		t.Errorf("expected expanded header, got %v", result.HeaderParams["X-Api-Host"])
+	}
+	if result.QueryParams["limit"] != 20.0 {
+		t.Errorf("expected expanded number 20, got %v", result.QueryParams["limit"])
+	}
+	// 调用方传入的值不做替换
+	if result.BodyParams["note"] != "${HOST}" {
+		t.Errorf("input value should not be expanded, got %v", result.BodyParams["note"])
+	}
+
+	if _, err := rearrangeParametersAndValidate(map[string]any{}, params, map[string]string{"HOST": "h"}); err == nil {
+		t.Error("expected error for unknown environment variable")
+	}
+}
+
 // Helper function for tests
 func stringPtr(s string) *string {
 	return &s
@@ -1085,3 +1118,210 @@ func BenchmarkConvertDefaultValue(b *testing.B) {
 		}
 	})
 }
+
+// notifySession 记录服务端推送通知的测试会话
+type notifySession struct {
+	ch chan mcp.JSONRPCNotification
+}
+
+func (s *notifySession) Initialize()                                         {}
+func (s *notifySession) Initialized() bool                                   { return true }
+func (s *notifySession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s.ch }
+func (s *notifySession) SessionID() string                                   { return "enabled-test-session" }
+
+// setupEnabledTestManager 初始化内存数据库和不启动事件循环的 ServerManager
+func setupEnabledTestManager(t *testing.T) *ServerManager {
+	database.InitDatabase(":memory:")
+	sm := &ServerManager{handles: []RequestHandle{HTTPSimpleAdapter{}}}
+	previous := serverManager
+	serverManager = sm
+	t.Cleanup(func() {
+		sm.cleanupAllServers()
+		serverManager = previous
+		FlushInvocations()
+		db := database.GetDB()
+		db.Exec("DELETE FROM tool_invocations")
+		db.Exec("DELETE FROM interfaces")
+		db.Exec("DELETE FROM applications")
+		db.Exec("DELETE FROM event_logs")
+	})
+	return sm
+}
+
+// createWithEnabled 创建记录并写入 enabled，避免 gorm 使用默认值 true
+func createWithEnabled(t *testing.T, value any, enabled bool) {
+	db := database.GetDB()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if err := db.Model(value).Update("enabled", enabled).Error; err != nil {
+		t.Fatalf("update enabled failed: %v", err)
+	}
+}
+
+// dispatch 通过事件表投递事件并立即处理，与服务层的调用路径一致
+func dispatch(sm *ServerManager, events ...Event) {
+	for _, evt := range events {
+		SendEvent(evt)
+	}
+	sm.pollAndProcessEvents()
+}
+
+func toolNames(sm *ServerManager, path string) []string {
+	s, ok := sm.sseServers.Load(path)
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0)
+	for name := range s.(*Server).server.ListTools() {
+		names = append(names, name)
+	}
+	return names
+}
+
+func waitToolListChanged(t *testing.T, session *notifySession) {
+	select {
+	case n := <-session.ch:
+		if n.Method != mcp.MethodNotificationToolsListChanged {
+			t.Fatalf("unexpected notification: %s", n.Method)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected tools/list_changed notification")
+	}
+}
+
+func TestLoadExistingApplicationsHonorsEnabled(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+
+	enabledApp := models.Application{Name: "Enabled", Path: "enabled-app", Protocol: "sse"}
+	createWithEnabled(t, &enabledApp, true)
+	disabledApp := models.Application{Name: "Disabled", Path: "disabled-app", Protocol: "streamable"}
+	createWithEnabled(t, &disabledApp, false)
+
+	for _, iface := range []struct {
+		appID   int64
+		name    string
+		enabled bool
+	}{
+		{enabledApp.ID, "visible", true},
+		{enabledApp.ID, "hidden", false},
+		{disabledApp.ID, "orphan", true},
+	} {
+		createWithEnabled(t, &models.Interface{
+			AppID: iface.appID, Name: iface.name, Protocol: "http",
+			URL: "http://localhost/" + iface.name, Method: "GET", AuthType: "none",
+		}, iface.enabled)
+	}
+
+	sm.loadExistingApplications()
+
+	if GetServerImpl("enabled-app", "sse") == nil {
+		t.Fatal("enabled application should be served")
+	}
+	if GetServerImpl("disabled-app", "streamable") != nil {
+		t.Fatal("disabled application should not be served")
+	}
+	if names := toolNames(sm, "enabled-app"); len(names) != 1 || names[0] != "visible" {
+		t.Fatalf("expected only the enabled tool, got %v", names)
+	}
+}
+
+func TestToggleEnabledUpdatesTools(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+	db := database.GetDB()
+
+	app := models.Application{Name: "Toggle", Path: "toggle-app", Protocol: "sse"}
+	createWithEnabled(t, &app, true)
+	iface := models.Interface{AppID: app.ID, Name: "tool", Protocol: "http", URL: "http://localhost/tool", Method: "GET", AuthType: "none"}
+	createWithEnabled(t, &iface, true)
+
+	dispatch(sm, Event{App: &app, Code: AddApplicationEvent})
+	if names := toolNames(sm, "toggle-app"); len(names) != 1 {
+		t.Fatalf("expected 1 tool, got %v", names)
+	}
+
+	s, _ := sm.sseServers.Load("toggle-app")
+	session := &notifySession{ch: make(chan mcp.JSONRPCNotification, 10)}
+	if err := s.(*Server).server.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("register session failed: %v", err)
+	}
+
+	// 禁用接口：与 UpdateInterface 相同，先移除再按新状态添加
+	db.Model(&iface).Update("enabled", false)
+	dispatch(sm,
+		Event{Interface: &models.Interface{Name: iface.Name}, App: &app, Code: RemoveToolEvent},
+		Event{Interface: &iface, App: &app, Code: AddToolEvent},
+	)
+	if names := toolNames(sm, "toggle-app"); len(names) != 0 {
+		t.Fatalf("disabled tool should be removed, got %v", names)
+	}
+	waitToolListChanged(t, session)
+
+	// 重新启用接口
+	db.Model(&iface).Update("enabled", true)
+	dispatch(sm,
+		Event{Interface: &models.Interface{Name: iface.Name}, App: &app, Code: RemoveToolEvent},
+		Event{Interface: &iface, App: &app, Code: AddToolEvent},
+	)
+	if names := toolNames(sm, "toggle-app"); len(names) != 1 {
+		t.Fatalf("enabled tool should be added back, got %v", names)
+	}
+	waitToolListChanged(t, session)
+
+	// 禁用应用：与 UpdateApplication 相同，先移除再按新状态添加
+	db.Model(&app).Update("enabled", false)
+	dispatch(sm,
+		Event{App: &models.Application{Name: app.Name, Path: app.Path}, Code: RemoveApplicationEvent},
+		Event{App: &app, Code: AddApplicationEvent},
+	)
+	if GetServerImpl("toggle-app", "sse") != nil {
+		t.Fatal("disabled application should not be served")
+	}
+
+	// 重新启用应用
+	db.Model(&app).Update("enabled", true)
+	dispatch(sm,
+		Event{App: &models.Application{Name: app.Name, Path: app.Path}, Code: RemoveApplicationEvent},
+		Event{App: &app, Code: AddApplicationEvent},
+	)
+	if GetServerImpl("toggle-app", "sse") == nil {
+		t.Fatal("re-enabled application should be served")
+	}
+	if names := toolNames(sm, "toggle-app"); len(names) != 1 {
+		t.Fatalf("expected 1 tool after re-enabling, got %v", names)
+	}
+}
+
+func TestAddToolChecksEnvironment(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+
+	app := models.Application{Name: "Env", Path: "env-app", Protocol: "sse", Environment: `{"BASE_URL":"https://dev.example.com"}`}
+	createWithEnabled(t, &app, true)
+	known := models.Interface{AppID: app.ID, Name: "known", Protocol: "http", URL: "${BASE_URL}/known", Method: "GET", AuthType: "none"}
+	createWithEnabled(t, &known, true)
+	unknown := models.Interface{AppID: app.ID, Name: "unknown", Protocol: "http", URL: "${API_HOST}/unknown", Method: "GET", AuthType: "none"}
+	createWithEnabled(t, &unknown, true)
+	fixed := models.Interface{AppID: app.ID, Name: "fixed", Protocol: "http", URL: "${BASE_URL}/fixed", Method: "GET", AuthType: "none"}
+	createWithEnabled(t, &fixed, true)
+	database.GetDB().Create(&models.InterfaceParameter{
+		AppID: app.ID, InterfaceID: fixed.ID, Name: "token", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("${TOKEN}"),
+	})
+
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatalf("add application failed: %v", err)
+	}
+	if names := toolNames(sm, "env-app"); len(names) != 1 || names[0] != "known" {
+		t.Fatalf("expected only the tool with defined variables, got %v", names)
+	}
+
+	err := sm.addTool(&unknown, &app)
+	if err == nil || !strings.Contains(err.Error(), "API_HOST") {
+		t.Fatalf("expected unknown variable error, got %v", err)
+	}
+
+	invalid := app
+	invalid.Environment = `["not", "an", "object"]`
+	if err := sm.addTool(&fixed, &invalid); err == nil {
+		t.Fatal("expected invalid environment error")
+	}
+}
@@ -0,0 +1,367 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// 限流作用范围
+const (
+	RateLimitScopeApplication = "application"
+	RateLimitScopeInterface   = "interface"
+)
+
+// 限流的调用方维度
+const (
+	RateLimitKeyGlobal  = "global"  // 所有调用共享一个限额 (默认)
+	RateLimitKeySession = "session" // 按 MCP 会话 ID 分别计算
+	rateLimitKeyHeader  = "header:" // header:<name> 按客户端请求头 (如调用方身份) 分别计算
+)
+
+// anonymousCaller 没有会话 ID 或身份请求头的调用共享的限额
+const anonymousCaller = "anonymous"
+
+// maxRateLimitCallers 单个限流器跟踪的调用方超过该数量时，清理空闲的调用方
+const maxRateLimitCallers = 1024
+
+// RateLimitConfig 令牌桶限流和并发限制配置，应用级配置对应用下所有工具的调用合计生效
+type RateLimitConfig struct {
+	Requests    int    `json:"requests,omitempty"`      // 每个周期补充的令牌数
+	Per         string `json:"per,omitempty"`           // 周期，Go duration 格式，默认 1s
+	Burst       int    `json:"burst,omitempty"`         // 桶容量，默认等于 requests
+	MaxInFlight int    `json:"max_in_flight,omitempty"` // 同时进行中的调用数上限
+	Key         string `json:"key,omitempty"`           // global, session 或 header:<name>
+}
+
+// ParseRateLimitConfig 解析并校验限流配置，空字符串表示不限流，返回 nil
+func ParseRateLimitConfig(raw string) (*RateLimitConfig, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var cfg RateLimitConfig
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid rate_limit: %v", err)
+	}
+	if cfg.Requests < 0 || cfg.Burst < 0 || cfg.MaxInFlight < 0 {
+		return nil, errors.New("rate_limit requests, burst and max_in_flight must not be negative")
+	}
+	if cfg.Requests == 0 && cfg.MaxInFlight == 0 {
+		return nil, errors.New("rate_limit requires requests or max_in_flight")
+	}
+	if cfg.Per == "" {
+		cfg.Per = "1s"
+	}
+	if d, err := time.ParseDuration(cfg.Per); err != nil || d <= 0 {
+		return nil, errors.New("rate_limit per must be a positive duration such as 1s or 1m")
+	}
+	if cfg.Burst == 0 {
+		cfg.Burst = cfg.Requests
+	}
+	switch {
+	case cfg.Key == "":
+		cfg.Key = RateLimitKeyGlobal
+	case cfg.Key == RateLimitKeyGlobal, cfg.Key == RateLimitKeySession:
+	case strings.HasPrefix(cfg.Key, rateLimitKeyHeader) && len(cfg.Key) > len(rateLimitKeyHeader):
+	default:
+		return nil, fmt.Errorf("rate_limit key must be %s, %s or header:<name>", RateLimitKeyGlobal, RateLimitKeySession)
+	}
+	return &cfg, nil
+}
+
+// RateLimitError 超出限流或并发限制，ToolResultText 返回给 LLM 的错误包含重试等待时间
+type RateLimitError struct {
+	Scope      string        // application 或 interface
+	Name       string        // 应用名或工具名
+	Reason     string        // rate 或 concurrency
+	RetryAfter time.Duration // 建议的重试等待时间
+}
+
+func (e *RateLimitError) Error() string {
+	limit := "rate limit"
+	if e.Reason == "concurrency" {
+		limit = "concurrency limit"
+	}
+	return fmt.Sprintf("%s %s of %s exceeded, retry after %s", e.Scope, limit, e.Name, e.RetryAfter)
+}
+
+// ToolResultText 以 JSON 返回错误，retry_after_seconds 向上取整
+func (e *RateLimitError) ToolResultText() string {
+	data, _ := json.Marshal(map[string]any{
+		"error":               e.Error(),
+		"scope":               e.Scope,
+		"reason":              e.Reason,
+		"retry_after_seconds": int(math.Ceil(e.RetryAfter.Seconds())),
+	})
+	return string(data)
+}
+
+// callerState 一个调用方的令牌桶和进行中的调用数
+type callerState struct {
+	tokens   float64
+	last     time.Time
+	inFlight int
+}
+
+// rateLimiter 一个应用或接口的限流器
+type rateLimiter struct {
+	mu      sync.Mutex
+	scope   string
+	id      int64
+	appID   int64
+	name    string
+	version string // 配置版本，应用更新后重新创建
+	cfg     RateLimitConfig
+	rate    float64 // 每秒补充的令牌数
+	callers map[string]*callerState
+	now     func() time.Time
+}
+
+// rateLimiters 已注册的限流器，"<scope>:<id>" -> *rateLimiter
+var rateLimiters sync.Map
+
+func newRateLimiter(cfg *RateLimitConfig, scope string, id, appID int64, name, version string) *rateLimiter {
+	per, _ := time.ParseDuration(cfg.Per)
+	return &rateLimiter{
+		scope:   scope,
+		id:      id,
+		appID:   appID,
+		name:    name,
+		version: version,
+		cfg:     *cfg,
+		rate:    float64(cfg.Requests) / per.Seconds(),
+		callers: make(map[string]*callerState),
+		now:     time.Now,
+	}
+}
+
+// interfaceRateLimiter 为工具创建限流器并替换之前注册的，cfg 为 nil 时移除
+func interfaceRateLimiter(cfg *RateLimitConfig, iface *models.Interface) *rateLimiter {
+	key := fmt.Sprintf("%s:%d", RateLimitScopeInterface, iface.ID)
+	if cfg == nil {
+		rateLimiters.Delete(key)
+		return nil
+	}
+	l := newRateLimiter(cfg, RateLimitScopeInterface, iface.ID, iface.AppID, iface.Name, "")
+	rateLimiters.Store(key, l)
+	return l
+}
+
+// applicationRateLimiter 应用下的所有工具共享同一个限流器，应用配置变更后重新创建
+func applicationRateLimiter(cfg *RateLimitConfig, app *models.Application) *rateLimiter {
+	key := fmt.Sprintf("%s:%d", RateLimitScopeApplication, app.ID)
+	if cfg == nil {
+		rateLimiters.Delete(key)
+		return nil
+	}
+	version := fmt.Sprintf("%d", app.UpdatedAt.UnixNano())
+	if v, ok := rateLimiters.Load(key); ok && v.(*rateLimiter).version == version {
+		return v.(*rateLimiter)
+	}
+	l := newRateLimiter(cfg, RateLimitScopeApplication, app.ID, app.ID, app.Name, version)
+	rateLimiters.Store(key, l)
+	return l
+}
+
+// dropRateLimiters 移除接口的限流器，interfaceID 为 0 时忽略
+func dropRateLimiters(interfaceID int64) {
+	if interfaceID == 0 {
+		return
+	}
+	rateLimiters.Delete(fmt.Sprintf("%s:%d", RateLimitScopeInterface, interfaceID))
+}
+
+// dropApplicationRateLimiters 移除应用及其下所有接口的限流器，appID 为 0 时忽略
+func dropApplicationRateLimiters(appID int64) {
+	if appID == 0 {
+		return
+	}
+	rateLimiters.Range(func(k, v any) bool {
+		if v.(*rateLimiter).appID == appID {
+			rateLimiters.Delete(k)
+		}
+		return true
+	})
+}
+
+// callerKey 按配置的维度确定调用方
+func (l *rateLimiter) callerKey(ctx context.Context, header http.Header) string {
+	var caller string
+	switch {
+	case l.cfg.Key == RateLimitKeySession:
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			caller = session.SessionID()
+		}
+	case strings.HasPrefix(l.cfg.Key, rateLimitKeyHeader):
+		if header != nil {
+			caller = header.Get(strings.TrimPrefix(l.cfg.Key, rateLimitKeyHeader))
+		}
+	default:
+		return RateLimitKeyGlobal
+	}
+	if caller == "" {
+		return anonymousCaller
+	}
+	return caller
+}
+
+// refill 按经过的时间补充令牌，调用方需要持有锁
+func (l *rateLimiter) refill(state *callerState, now time.Time) {
+	if l.cfg.Requests == 0 {
+		return
+	}
+	elapsed := now.Sub(state.last).Seconds()
+	state.tokens = math.Min(float64(l.cfg.Burst), state.tokens+elapsed*l.rate)
+	state.last = now
+}
+
+// acquire 检查并发和令牌，成功时返回释放函数，失败时返回 RateLimitError
+func (l *rateLimiter) acquire(caller string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	state, ok := l.callers[caller]
+	if !ok {
+		l.pruneIdle(now)
+		state = &callerState{tokens: float64(l.cfg.Burst), last: now}
+		l.callers[caller] = state
+	}
+	if l.cfg.MaxInFlight > 0 && state.inFlight >= l.cfg.MaxInFlight {
+		// 无法预知进行中的调用何时结束，给出 1 秒的重试提示
+		return nil, &RateLimitError{Scope: l.scope, Name: l.name, Reason: "concurrency", RetryAfter: time.Second}
+	}
+	l.refill(state, now)
+	if l.cfg.Requests > 0 {
+		if state.tokens < 1 {
+			wait := time.Duration((1 - state.tokens) / l.rate * float64(time.Second))
+			return nil, &RateLimitError{Scope: l.scope, Name: l.name, Reason: "rate", RetryAfter: wait}
+		}
+		state.tokens--
+	}
+	state.inFlight++
+	released := false
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !released {
+			released = true
+			state.inFlight--
+		}
+	}, nil
+}
+
+// refund 归还令牌，用于后续的限流器拒绝了调用的情况
+func (l *rateLimiter) refund(caller string) {
+	if l == nil || l.cfg.Requests == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state, ok := l.callers[caller]; ok {
+		state.tokens = math.Min(float64(l.cfg.Burst), state.tokens+1)
+	}
+}
+
+// pruneIdle 清理令牌已满且没有进行中调用的调用方，调用方需要持有锁
+func (l *rateLimiter) pruneIdle(now time.Time) {
+	if len(l.callers) < maxRateLimitCallers {
+		return
+	}
+	for key, state := range l.callers {
+		l.refill(state, now)
+		if state.inFlight == 0 && state.tokens >= float64(l.cfg.Burst) {
+			delete(l.callers, key)
+		}
+	}
+}
+
+// acquireRateLimits 依次检查应用级和接口级限制，任一失败时撤销已经占用的额度
+func acquireRateLimits(ctx context.Context, header http.Header, limiters ...*rateLimiter) (func(), error) {
+	releases := make([]func(), 0, len(limiters))
+	releaseAll := func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+	acquired := make([]*rateLimiter, 0, len(limiters))
+	callers := make([]string, 0, len(limiters))
+	for _, l := range limiters {
+		if l == nil {
+			continue
+		}
+		caller := l.callerKey(ctx, header)
+		release, err := l.acquire(caller)
+		if err != nil {
+			releaseAll()
+			for i, prev := range acquired {
+				prev.refund(callers[i])
+			}
+			return nil, err
+		}
+		releases = append(releases, release)
+		acquired = append(acquired, l)
+		callers = append(callers, caller)
+	}
+	return releaseAll, nil
+}
+
+// RateLimitCallerStatus 一个调用方当前的限流状态
+type RateLimitCallerStatus struct {
+	Caller   string  `json:"caller"`
+	Tokens   float64 `json:"tokens"` // 当前可用的令牌数，未配置 requests 时为 0
+	InFlight int     `json:"in_flight"`
+}
+
+// RateLimitStatus 一个限流器的配置和各调用方的状态
+type RateLimitStatus struct {
+	Scope   string                  `json:"scope"`
+	ID      int64                   `json:"id"`
+	AppID   int64                   `json:"app_id"`
+	Name    string                  `json:"name"`
+	Config  RateLimitConfig         `json:"config"`
+	Callers []RateLimitCallerStatus `json:"callers"`
+}
+
+// RateLimitStatuses 返回当前实例中所有限流器的状态，按作用范围和 ID 排序
+func RateLimitStatuses() []RateLimitStatus {
+	statuses := make([]RateLimitStatus, 0)
+	rateLimiters.Range(func(_, v any) bool {
+		statuses = append(statuses, v.(*rateLimiter).status())
+		return true
+	})
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Scope != statuses[j].Scope {
+			return statuses[i].Scope < statuses[j].Scope
+		}
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
+func (l *rateLimiter) status() RateLimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	callers := make([]RateLimitCallerStatus, 0, len(l.callers))
+	for key, state := range l.callers {
+		l.refill(state, now)
+		callers = append(callers, RateLimitCallerStatus{Caller: key, Tokens: math.Floor(state.tokens*100) / 100, InFlight: state.inFlight})
+	}
+	sort.Slice(callers, func(i, j int) bool { return callers[i].Caller < callers[j].Caller })
+	return RateLimitStatus{Scope: l.scope, ID: l.id, AppID: l.appID, Name: l.name, Config: l.cfg, Callers: callers}
+}
@@ -0,0 +1,256 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestParseRateLimitConfig(t *testing.T) {
+	cfg, err := ParseRateLimitConfig("")
+	if err != nil || cfg != nil {
+		t.Fatalf("empty config should disable rate limit, got %v %v", cfg, err)
+	}
+	cfg, err = ParseRateLimitConfig(`{"requests": 10}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Per != "1s" || cfg.Burst != 10 || cfg.Key != RateLimitKeyGlobal {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+	if _, err := ParseRateLimitConfig(`{"max_in_flight": 2, "key": "header:X-User"}`); err != nil {
+		t.Error(err)
+	}
+
+	tests := map[string]string{
+		`{}`:                                "requires requests or max_in_flight",
+		`{"requests": -1}`:                  "must not be negative",
+		`{"requests": 1, "per": "soon"}`:    "per must be a positive duration",
+		`{"requests": 1, "key": "ip"}`:      "key must be global, session or header:<name>",
+		`{"requests": 1, "key": "header:"}`: "key must be global, session or header:<name>",
+		`{"requests": 1, "window": "1s"}`:   "invalid rate_limit",
+		`[]`:                                "invalid rate_limit",
+	}
+	for raw, wantErr := range tests {
+		if _, err := ParseRateLimitConfig(raw); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", raw, wantErr, err)
+		}
+	}
+}
+
+func testRateLimiter(t *testing.T, raw string, now *time.Time) *rateLimiter {
+	cfg, err := ParseRateLimitConfig(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newRateLimiter(cfg, RateLimitScopeInterface, 1, 1, "search", "")
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	now := time.Now()
+	l := testRateLimiter(t, `{"requests": 2, "per": "1s", "burst": 3}`, &now)
+
+	for i := 0; i < 3; i++ {
+		release, err := l.acquire(RateLimitKeyGlobal)
+		if err != nil {
+			t.Fatalf("call %d within burst should pass: %v", i, err)
+		}
+		release()
+	}
+	_, err := l.acquire(RateLimitKeyGlobal)
+	limitErr, ok := err.(*RateLimitError)
+	if !ok || limitErr.Reason != "rate" || limitErr.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("expected rate limit error with 500ms retry, got %v", err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(limitErr.ToolResultText()), &payload); err != nil || payload["retry_after_seconds"] != float64(1) {
+		t.Errorf("expected retry_after_seconds rounded up to 1, got %s", limitErr.ToolResultText())
+	}
+
+	// 经过 500ms 补充一个令牌，补充不超过桶容量
+	now = now.Add(500 * time.Millisecond)
+	if _, err := l.acquire(RateLimitKeyGlobal); err != nil {
+		t.Errorf("expected token to be refilled: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if status := l.status(); len(status.Callers) != 1 || status.Callers[0].Tokens != 3 {
+		t.Errorf("expected tokens capped at burst, got %+v", status.Callers)
+	}
+}
+
+func TestRateLimiterConcurrency(t *testing.T) {
+	now := time.Now()
+	l := testRateLimiter(t, `{"max_in_flight": 1}`, &now)
+
+	release, err := l.acquire(RateLimitKeyGlobal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(RateLimitKeyGlobal); err == nil || err.(*RateLimitError).Reason != "concurrency" {
+		t.Fatalf("expected concurrency limit error, got %v", err)
+	}
+	release()
+	release() // 重复释放不影响计数
+	if l.callers[RateLimitKeyGlobal].inFlight != 0 {
+		t.Errorf("expected no calls in flight, got %d", l.callers[RateLimitKeyGlobal].inFlight)
+	}
+	if _, err := l.acquire(RateLimitKeyGlobal); err != nil {
+		t.Errorf("expected call after release to pass: %v", err)
+	}
+}
+
+type testClientSession struct{ id string }
+
+func (s testClientSession) Initialize()                                         {}
+func (s testClientSession) Initialized() bool                                   { return true }
+func (s testClientSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s testClientSession) SessionID() string                                   { return s.id }
+
+func TestRateLimiterCallerKey(t *testing.T) {
+	now := time.Now()
+	srv := server.NewMCPServer("test", "1.0.0")
+	ctx := srv.WithContext(context.Background(), testClientSession{id: "session-1"})
+
+	session := testRateLimiter(t, `{"requests": 1, "key": "session"}`, &now)
+	if caller := session.callerKey(ctx, nil); caller != "session-1" {
+		t.Errorf("expected session caller, got %s", caller)
+	}
+	if caller := session.callerKey(context.Background(), nil); caller != anonymousCaller {
+		t.Errorf("expected anonymous caller without session, got %s", caller)
+	}
+
+	header := testRateLimiter(t, `{"requests": 1, "key": "header:X-User"}`, &now)
+	h := http.Header{}
+	h.Set("X-User", "alice")
+	if caller := header.callerKey(ctx, h); caller != "alice" {
+		t.Errorf("expected header caller, got %s", caller)
+	}
+
+	// 不同调用方的额度相互独立
+	if _, err := header.acquire("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := header.acquire("bob"); err != nil {
+		t.Errorf("expected separate bucket per caller: %v", err)
+	}
+	if _, err := header.acquire("alice"); err == nil {
+		t.Error("expected alice to be limited")
+	}
+}
+
+func TestAcquireRateLimitsRefunds(t *testing.T) {
+	now := time.Now()
+	app := testRateLimiter(t, `{"requests": 1, "max_in_flight": 5}`, &now)
+	iface := testRateLimiter(t, `{"max_in_flight": 1}`, &now)
+
+	release, err := acquireRateLimits(context.Background(), nil, app, nil, iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 接口级拒绝时归还应用级的令牌和并发额度
+	now = now.Add(time.Second)
+	if _, err := acquireRateLimits(context.Background(), nil, app, iface); err == nil {
+		t.Fatal("expected interface concurrency limit")
+	}
+	state := app.callers[RateLimitKeyGlobal]
+	if state.inFlight != 1 || state.tokens != 1 {
+		t.Errorf("expected application quota to be refunded, got %+v", state)
+	}
+	release()
+	if state.inFlight != 0 || iface.callers[RateLimitKeyGlobal].inFlight != 0 {
+		t.Error("expected release to free both limiters")
+	}
+}
+
+func TestAddToolEnforcesRateLimits(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstream.Close()
+
+	app := models.Application{Name: "Limited", Path: "limited-app", Protocol: "sse", RateLimit: `{"requests": 3, "per": "1h"}`}
+	createWithEnabled(t, &app, true)
+	var interfaces []models.Interface
+	for _, name := range []string{"first", "second"} {
+		iface := models.Interface{AppID: app.ID, Name: name, Protocol: "http", URL: upstream.URL, Method: "GET", AuthType: "none",
+			RateLimit: `{"requests": 2, "per": "1h", "key": "header:X-User"}`}
+		createWithEnabled(t, &iface, true)
+		interfaces = append(interfaces, iface)
+	}
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := sm.sseServers.Load("limited-app")
+	call := func(tool, user string) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{Header: http.Header{}}
+		req.Header.Set("X-User", user)
+		result, err := s.(*Server).server.GetTool(tool).Handler(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	call("first", "alice")
+	call("first", "alice")
+	result := call("first", "alice")
+	text := result.Content[0].(mcp.TextContent).Text
+	if !result.IsError || !strings.Contains(text, `"retry_after_seconds"`) || !strings.Contains(text, "interface rate limit of first") {
+		t.Fatalf("expected interface rate limit error, got %s", text)
+	}
+	// 其他调用方不受接口级限制，但应用级额度是所有工具合计的
+	if r := call("first", "bob"); r.IsError {
+		t.Errorf("expected bob to pass, got %+v", r.Content)
+	}
+	result = call("second", "bob")
+	text = result.Content[0].(mcp.TextContent).Text
+	if !result.IsError || !strings.Contains(text, "application rate limit of Limited") {
+		t.Errorf("expected application rate limit error, got %s", text)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("expected limited calls not to reach upstream, got %d calls", n)
+	}
+
+	countLimiters := func() int {
+		found := 0
+		for _, status := range RateLimitStatuses() {
+			if status.AppID == app.ID {
+				found++
+			}
+		}
+		return found
+	}
+	if found := countLimiters(); found != 3 {
+		t.Errorf("expected application and two interface limiters, got %+v", RateLimitStatuses())
+	}
+	// 没有 ID 的接口不会移除任何限流器，按 ID 移除只影响该接口
+	sm.removeTool(&models.Interface{AppID: app.ID, Name: "first"}, &app)
+	if found := countLimiters(); found != 3 {
+		t.Errorf("expected limiters to be kept for an interface without ID, got %d", found)
+	}
+	sm.removeTool(&interfaces[1], &app)
+	if found := countLimiters(); found != 2 {
+		t.Errorf("expected only the removed interface limiter to be dropped, got %d", found)
+	}
+	sm.removeApplication(&app)
+	for _, status := range RateLimitStatuses() {
+		if status.AppID == app.ID {
+			t.Errorf("expected limiters to be dropped with the application, got %+v", status)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package adapter
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gorm.io/gorm/clause"
+)
+
+// 响应缓存后端
+const (
+	CacheBackendMemory   = "memory"   // 进程内 LRU，每个实例独立
+	CacheBackendDatabase = "database" // 存储在数据库中，多个实例共享
+)
+
+const (
+	defaultCacheMaxEntries = 1000
+	defaultCacheMaxBytes   = 16 << 20
+)
+
+// CacheConfig 接口的响应缓存配置，只应该用于只读、幂等的接口
+type CacheConfig struct {
+	TTL        string `json:"ttl"`                   // 缓存有效期，Go duration 格式 (如 30s, 5m)
+	MaxEntries int    `json:"max_entries,omitempty"` // 最多缓存的条目数，默认 1000
+	MaxBytes   int64  `json:"max_bytes,omitempty"`   // 缓存的响应体总大小上限，默认 16MiB
+	Backend    string `json:"backend,omitempty"`     // memory (默认) 或 database
+}
+
+// ParseCacheConfig 解析并校验缓存配置，空字符串表示不缓存，返回 nil
+func ParseCacheConfig(raw string) (*CacheConfig, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var cfg CacheConfig
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid cache_config: %v", err)
+	}
+	if d, err := time.ParseDuration(cfg.TTL); err != nil || d <= 0 {
+		return nil, errors.New("cache_config ttl must be a positive duration such as 30s or 5m")
+	}
+	if cfg.MaxEntries < 0 || cfg.MaxBytes < 0 {
+		return nil, errors.New("cache_config max_entries and max_bytes must not be negative")
+	}
+	switch cfg.Backend {
+	case "", CacheBackendMemory, CacheBackendDatabase:
+	default:
+		return nil, fmt.Errorf("cache_config backend must be %s or %s", CacheBackendMemory, CacheBackendDatabase)
+	}
+	if cfg.MaxEntries == 0 {
+		cfg.MaxEntries = defaultCacheMaxEntries
+	}
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = defaultCacheMaxBytes
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = CacheBackendMemory
+	}
+	return &cfg, nil
+}
+
+func (c *CacheConfig) ttl() time.Duration {
+	d, _ := time.ParseDuration(c.TTL)
+	return d
+}
+
+// cacheStore 缓存存储，key 已经包含接口 ID
+type cacheStore interface {
+	get(key string) ([]byte, bool)
+	set(key string, data []byte)
+}
+
+// responseCache 一个工具的响应缓存
+type responseCache struct {
+	appID       int64
+	interfaceID int64
+	version     string // 接口和应用的更新时间，配置变更后旧的缓存不再命中
+	store       cacheStore
+}
+
+// responseCaches 已注册工具的缓存，interface ID -> *responseCache
+var responseCaches sync.Map
+
+// newResponseCache 为工具创建缓存并替换之前注册的缓存，cfg 为 nil 时移除缓存
+func newResponseCache(cfg *CacheConfig, iface *models.Interface, app *models.Application) *responseCache {
+	if cfg == nil {
+		responseCaches.Delete(iface.ID)
+		return nil
+	}
+	c := &responseCache{
+		appID:       app.ID,
+		interfaceID: iface.ID,
+		version:     fmt.Sprintf("%d.%d", iface.UpdatedAt.UnixNano(), app.UpdatedAt.UnixNano()),
+	}
+	if cfg.Backend == CacheBackendDatabase {
+		c.store = &databaseCache{appID: app.ID, interfaceID: iface.ID, ttl: cfg.ttl(), maxEntries: cfg.MaxEntries, maxBytes: cfg.MaxBytes}
+	} else {
+		c.store = newLRUCache(cfg.ttl(), cfg.MaxEntries, cfg.MaxBytes)
+	}
+	responseCaches.Store(iface.ID, c)
+	return c
+}
+
+// dropResponseCache 工具移除时释放内存缓存，interfaceID 为 0 时忽略
+func dropResponseCache(interfaceID int64) {
+	if interfaceID == 0 {
+		return
+	}
+	responseCaches.Delete(interfaceID)
+}
+
+// dropApplicationCaches 应用移除时释放其下所有工具的内存缓存，appID 为 0 时忽略
+func dropApplicationCaches(appID int64) {
+	if appID == 0 {
+		return
+	}
+	responseCaches.Range(func(k, v any) bool {
+		if v.(*responseCache).appID == appID {
+			responseCaches.Delete(k)
+		}
+		return true
+	})
+}
+
+// credentialHeaders 鉴权方式从 MCP 客户端请求头读取的凭证，不同凭证的调用方不能共享缓存
+func credentialHeaders(authType string) []string {
+	switch authType {
+	case "capi":
+		return []string{capiSecretIdHeader, capiSecretKeyHeader, capiTokenHeader}
+	case "aws_sigv4":
+		return []string{awsAccessKeyIDHeader, awsSecretAccessKeyHeader, awsSessionTokenHeader}
+	}
+	return nil
+}
+
+// key 由工具名、配置版本、最终请求参数、透传的客户端请求头和客户端凭证计算，json.Marshal 会对 map 的键排序；
+// 整个 key 是哈希值，凭证不会以明文保存在缓存中
+func (c *responseCache) key(tool string, params Parameters, req mcp.CallToolRequest, meta RequestMeta) string {
+	forwarded := make(map[string][]string)
+	credentials := make(map[string]string)
+	if req.Header != nil {
+		for from := range meta.ForwardHeaders {
+			if values := req.Header.Values(from); len(values) > 0 {
+				forwarded[from] = values
+			}
+		}
+		for _, name := range credentialHeaders(meta.AuthType) {
+			if value := req.Header.Get(name); value != "" {
+				credentials[name] = value
+			}
+		}
+	}
+	data, _ := json.Marshal(map[string]any{
+		"tool":        tool,
+		"version":     c.version,
+		"path":        params.PathParams,
+		"query":       params.QueryParams,
+		"header":      params.HeaderParams,
+		"body":        params.BodyParams,
+		"forwarded":   forwarded,
+		"credentials": credentials,
+	})
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%d:%s", c.interfaceID, hex.EncodeToString(sum[:]))
+}
+
+// withCacheMeta 命中缓存时在工具结果的 _meta 中标记 "cache": "hit"
+func withCacheMeta(result *mcp.CallToolResult, hit bool) *mcp.CallToolResult {
+	if hit {
+		result.Meta = mcp.NewMetaFromMap(map[string]any{"cache": "hit"})
+	}
+	return result
+}
+
+// PurgeResponseCache 清除应用或接口的缓存，返回清除的条目数
+func PurgeResponseCache(appID, interfaceID int64) (int64, error) {
+	if appID == 0 && interfaceID == 0 {
+		return 0, errors.New("app_id or interface_id is required")
+	}
+	var purged int64
+	responseCaches.Range(func(_, v any) bool {
+		c := v.(*responseCache)
+		if (appID == 0 || c.appID == appID) && (interfaceID == 0 || c.interfaceID == interfaceID) {
+			if lru, ok := c.store.(*lruCache); ok {
+				purged += lru.purge()
+			}
+		}
+		return true
+	})
+	query := database.GetDB().Model(&models.ResponseCacheEntry{})
+	if appID != 0 {
+		query = query.Where("app_id = ?", appID)
+	}
+	if interfaceID != 0 {
+		query = query.Where("interface_id = ?", interfaceID)
+	}
+	result := query.Delete(&models.ResponseCacheEntry{})
+	if result.Error != nil {
+		return purged, result.Error
+	}
+	return purged + result.RowsAffected, nil
+}
+
+// lruEntry LRU 链表中的条目
+type lruEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// lruCache 进程内 LRU 缓存，超出条目数或总大小时淘汰最久未使用的条目
+type lruCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	order      *list.List // 头部为最近使用
+	items      map[string]*list.Element
+	now        func() time.Time
+}
+
+func newLRUCache(ttl time.Duration, maxEntries int, maxBytes int64) *lruCache {
+	return &lruCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+func (c *lruCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.data, true
+}
+
+func (c *lruCache) set(key string, data []byte) {
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, data: data, expiresAt: c.now().Add(c.ttl)})
+	c.bytes += size
+	for c.order.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lruCache) remove(el *list.Element) {
+	entry := c.order.Remove(el).(*lruEntry)
+	delete(c.items, entry.key)
+	c.bytes -= int64(len(entry.data))
+}
+
+func (c *lruCache) purge() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := int64(c.order.Len())
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+	return n
+}
+
+// databaseCache 数据库缓存，写入时清理该接口过期的记录，并按写入时间淘汰超出限制的记录
+type databaseCache struct {
+	appID       int64
+	interfaceID int64
+	ttl         time.Duration
+	maxEntries  int
+	maxBytes    int64
+}
+
+func (c *databaseCache) get(key string) ([]byte, bool) {
+	var entry models.ResponseCacheEntry
+	err := database.GetDB().Where("`key` = ? AND expires_at > ?", key, time.Now()).Limit(1).Find(&entry).Error
+	if err != nil || entry.ID == 0 {
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+func (c *databaseCache) set(key string, data []byte) {
+	if int64(len(data)) > c.maxBytes {
+		return
+	}
+	db := database.GetDB()
+	now := time.Now()
+	entry := models.ResponseCacheEntry{
+		Key:         key,
+		AppID:       c.appID,
+		InterfaceID: c.interfaceID,
+		Value:       data,
+		Size:        int64(len(data)),
+		ExpiresAt:   now.Add(c.ttl),
+		CreatedAt:   now,
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "size", "expires_at", "created_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("Error writing response cache for interface %d: %v", c.interfaceID, err)
+		return
+	}
+	db.Where("interface_id = ? AND expires_at <= ?", c.interfaceID, now).Delete(&models.ResponseCacheEntry{})
+
+	var entries []models.ResponseCacheEntry
+	if err := db.Select("id", "size").Where("interface_id = ?", c.interfaceID).Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		return
+	}
+	var total int64
+	evict := make([]int64, 0)
+	for i, e := range entries {
+		total += e.Size
+		if i >= c.maxEntries || total > c.maxBytes {
+			evict = append(evict, e.ID)
+		}
+	}
+	if len(evict) > 0 {
+		db.Where("id IN ?", evict).Delete(&models.ResponseCacheEntry{})
+	}
+}
@@ -0,0 +1,232 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseCacheConfig(t *testing.T) {
+	cfg, err := ParseCacheConfig("")
+	if err != nil || cfg != nil {
+		t.Fatalf("empty config should disable cache, got %v %v", cfg, err)
+	}
+	cfg, err = ParseCacheConfig(`{"ttl": "30s"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ttl() != 30*time.Second || cfg.MaxEntries != defaultCacheMaxEntries || cfg.MaxBytes != defaultCacheMaxBytes || cfg.Backend != CacheBackendMemory {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+
+	tests := map[string]string{
+		`{}`:                                "ttl must be a positive duration",
+		`{"ttl": "-1s"}`:                    "ttl must be a positive duration",
+		`{"ttl": "1m", "max_entries": -1}`:  "must not be negative",
+		`{"ttl": "1m", "backend": "redis"}`: "backend must be memory or database",
+		`{"ttl": "1m", "size": 1}`:          "invalid cache_config",
+	}
+	for raw, wantErr := range tests {
+		if _, err := ParseCacheConfig(raw); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", raw, wantErr, err)
+		}
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	now := time.Now()
+	c := newLRUCache(time.Minute, 2, 10)
+	c.now = func() time.Time { return now }
+
+	c.set("a", []byte("1"))
+	c.set("b", []byte("2"))
+	c.get("a") // a 成为最近使用
+	c.set("c", []byte("3"))
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used entry should be evicted")
+	}
+	if data, ok := c.get("a"); !ok || string(data) != "1" {
+		t.Errorf("expected a to be cached, got %s %v", data, ok)
+	}
+
+	// 超出总大小时淘汰，超过上限的单个响应不缓存
+	c.set("d", []byte("1234567890"))
+	if c.order.Len() != 1 || c.bytes != 10 {
+		t.Errorf("expected only d to remain, got %d entries %d bytes", c.order.Len(), c.bytes)
+	}
+	c.set("e", []byte("12345678901"))
+	if _, ok := c.get("e"); ok {
+		t.Error("oversized entry should not be cached")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.get("d"); ok {
+		t.Error("expired entry should not be returned")
+	}
+	if c.order.Len() != 0 || c.bytes != 0 {
+		t.Errorf("expired entry should be removed, got %d entries %d bytes", c.order.Len(), c.bytes)
+	}
+}
+
+func TestDatabaseCache(t *testing.T) {
+	database.InitDatabase(":memory:")
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM response_cache_entries") })
+
+	c := &databaseCache{appID: 1, interfaceID: 2, ttl: time.Minute, maxEntries: 2, maxBytes: 10}
+	c.set("2:a", []byte("first"))
+	c.set("2:a", []byte("second"))
+	if data, ok := c.get("2:a"); !ok || string(data) != "second" {
+		t.Errorf("expected updated entry, got %s %v", data, ok)
+	}
+	c.set("2:b", []byte("b"))
+	c.set("2:c", []byte("c"))
+	if _, ok := c.get("2:a"); ok {
+		t.Error("oldest entry should be evicted when max_entries is exceeded")
+	}
+	c.set("2:d", []byte("1234567890"))
+	var count int64
+	database.GetDB().Model(&models.ResponseCacheEntry{}).Where("interface_id = ?", 2).Count(&count)
+	if count != 1 {
+		t.Errorf("expected entries over max_bytes to be evicted, got %d", count)
+	}
+
+	database.GetDB().Model(&models.ResponseCacheEntry{}).Where("`key` = ?", "2:d").Update("expires_at", time.Now().Add(-time.Second))
+	if _, ok := c.get("2:d"); ok {
+		t.Error("expired entry should not be returned")
+	}
+
+	c.set("2:e", []byte("e"))
+	other := &databaseCache{appID: 1, interfaceID: 3, ttl: time.Minute, maxEntries: 2, maxBytes: 10}
+	other.set("3:a", []byte("a"))
+	purged, err := PurgeResponseCache(0, 2)
+	if err != nil || purged != 1 {
+		t.Errorf("expected one entry purged, got %d %v", purged, err)
+	}
+	if _, ok := other.get("3:a"); !ok {
+		t.Error("other interface should keep its cache")
+	}
+	if _, err := PurgeResponseCache(0, 0); err == nil {
+		t.Error("expected error without app_id or interface_id")
+	}
+}
+
+func TestResponseCacheKeyCredentials(t *testing.T) {
+	c := &responseCache{interfaceID: 1, version: "1"}
+	params := Parameters{QueryParams: map[string]any{"q": "go"}}
+	key := func(authType string, headers map[string]string) string {
+		req := mcp.CallToolRequest{Header: http.Header{}}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return c.key("search", params, req, RequestMeta{AuthType: authType})
+	}
+
+	// 客户端请求头中的凭证不同，或者没有提供凭证，都不能命中其他调用方的缓存
+	alice := map[string]string{"TC-API-SecretId": "alice", "TC-API-SecretKey": "alice-key"}
+	bob := map[string]string{"TC-API-SecretId": "bob", "TC-API-SecretKey": "bob-key"}
+	if key("capi", alice) == key("capi", bob) || key("capi", alice) == key("capi", nil) {
+		t.Error("expected capi callers with different credentials to use different keys")
+	}
+	if key("capi", alice) != key("capi", alice) {
+		t.Error("expected the same credentials to share a key")
+	}
+	aws := map[string]string{"AWS-Access-Key-Id": "AKID", "AWS-Secret-Access-Key": "secret"}
+	if key("aws_sigv4", aws) == key("aws_sigv4", nil) {
+		t.Error("expected aws_sigv4 callers with different credentials to use different keys")
+	}
+	// 其他鉴权方式不读取这些请求头
+	if key("none", alice) != key("none", nil) {
+		t.Error("expected credential headers to be ignored for auth type none")
+	}
+	if strings.Contains(key("capi", alice), "alice") {
+		t.Error("expected credentials to be hashed in the key")
+	}
+}
+
+func TestAddToolCachesResponses(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM response_cache_entries") })
+
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("q") == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintf(w, `{"q": %q, "call": %d}`, r.URL.Query().Get("q"), n)
+	}))
+	defer upstream.Close()
+
+	app := models.Application{Name: "Cached", Path: "cached-app", Protocol: "sse", ForwardHeaders: `{"X-User": "X-User"}`}
+	createWithEnabled(t, &app, true)
+	for _, backend := range []string{CacheBackendMemory, CacheBackendDatabase} {
+		iface := models.Interface{AppID: app.ID, Name: "search_" + backend, Protocol: "http", URL: upstream.URL, Method: "GET", AuthType: "none",
+			CacheConfig: `{"ttl": "1m", "backend": "` + backend + `"}`}
+		createWithEnabled(t, &iface, true)
+		database.GetDB().Create(&models.InterfaceParameter{AppID: app.ID, InterfaceID: iface.ID, Name: "q", Type: "string", Location: "query", Group: "input"})
+	}
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := sm.sseServers.Load("cached-app")
+
+	for _, backend := range []string{CacheBackendMemory, CacheBackendDatabase} {
+		t.Run(backend, func(t *testing.T) {
+			tool := s.(*Server).server.GetTool("search_" + backend)
+			call := func(q, user string) *mcp.CallToolResult {
+				req := mcp.CallToolRequest{Header: http.Header{}}
+				req.Params.Arguments = map[string]any{"q": q}
+				req.Header.Set("X-User", user)
+				result, err := tool.Handler(context.Background(), req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result
+			}
+			text := func(r *mcp.CallToolResult) string { return r.Content[0].(mcp.TextContent).Text }
+
+			atomic.StoreInt32(&calls, 0)
+			first := call("go", "alice")
+			second := call("go", "alice")
+			if text(first) != text(second) || atomic.LoadInt32(&calls) != 1 {
+				t.Errorf("expected second call to hit cache, got %s %s after %d calls", text(first), text(second), atomic.LoadInt32(&calls))
+			}
+			if first.Meta != nil || second.Meta == nil || second.Meta.AdditionalFields["cache"] != "hit" {
+				t.Errorf("expected cache hit flagged in _meta, got %+v %+v", first.Meta, second.Meta)
+			}
+
+			// 参数或透传头不同不命中缓存
+			call("rust", "alice")
+			call("go", "bob")
+			if atomic.LoadInt32(&calls) != 3 {
+				t.Errorf("expected different arguments and callers to miss cache, got %d calls", atomic.LoadInt32(&calls))
+			}
+
+			// 失败的响应不缓存
+			call("fail", "alice")
+			if r := call("fail", "alice"); !r.IsError || atomic.LoadInt32(&calls) != 5 {
+				t.Errorf("expected failed responses not to be cached, got %d calls", atomic.LoadInt32(&calls))
+			}
+
+			purged, err := PurgeResponseCache(app.ID, 0)
+			if err != nil || purged != 3 {
+				t.Errorf("expected 3 entries purged, got %d %v", purged, err)
+			}
+			call("go", "alice")
+			if atomic.LoadInt32(&calls) != 6 {
+				t.Errorf("expected purged entry to miss cache, got %d calls", atomic.LoadInt32(&calls))
+			}
+			PurgeResponseCache(app.ID, 0)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmespath/go-jmespath"
+	"gopkg.in/yaml.v3"
+)
+
+// 响应输出格式
+const (
+	OutputFormatJSON     = "json"
+	OutputFormatMarkdown = "markdown"
+	OutputFormatYAML     = "yaml"
+)
+
+// PipelineStep 响应处理步骤，每一步只能设置一个操作
+type PipelineStep struct {
+	JSONPath string            `json:"jsonpath,omitempty"` // 用 JSONPath 选择子文档，如 $.data.items[*]
+	JMESPath string            `json:"jmespath,omitempty"` // 用 JMESPath 选择或投影，如 data.items[].{id: id, name: name}
+	Rename   map[string]string `json:"rename,omitempty"`   // 重命名对象字段 (数组时作用于每个元素)
+	Drop     []string          `json:"drop,omitempty"`     // 删除字段，路径语法与 truncate_fields 相同
+	Flatten  int               `json:"flatten,omitempty"`  // 展开嵌套数组的层数
+	Limit    int               `json:"limit,omitempty"`    // 所有数组最多保留的元素个数
+}
+
+// pipelineOp 编译后的处理步骤
+type pipelineOp func(data any) (any, error)
+
+// ParsePostProcess 解析并校验接口的后处理配置，空字符串使用默认配置
+func ParsePostProcess(raw string) (PostProcessMeta, error) {
+	meta := PostProcessMeta{TruncateFields: make(map[string]int)}
+	if strings.TrimSpace(raw) == "" {
+		return meta, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return meta, fmt.Errorf("invalid post_process: %v", err)
+	}
+	switch meta.Format {
+	case "", OutputFormatJSON:
+	case OutputFormatMarkdown, OutputFormatYAML:
+		if meta.StructuredOutput {
+			return meta, fmt.Errorf("post_process format %s cannot be used with structured_output", meta.Format)
+		}
+	default:
+		return meta, fmt.Errorf("post_process format must be json, markdown or yaml")
+	}
+	if _, err := compilePipeline(meta.Pipeline); err != nil {
+		return meta, err
+	}
+	return meta, nil
+}
+
+func compilePipeline(steps []PipelineStep) ([]pipelineOp, error) {
+	ops := make([]pipelineOp, 0, len(steps))
+	for i, step := range steps {
+		op, err := compilePipelineStep(step)
+		if err != nil {
+			return nil, fmt.Errorf("post_process pipeline step %d: %v", i+1, err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func compilePipelineStep(step PipelineStep) (pipelineOp, error) {
+	var ops []pipelineOp
+	if step.JSONPath != "" {
+		path, err := CompileJSONPath(step.JSONPath)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, func(data any) (any, error) { return path.Search(data), nil })
+	}
+	if step.JMESPath != "" {
+		expr, err := jmespath.Compile(step.JMESPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jmespath %q: %v", step.JMESPath, err)
+		}
+		ops = append(ops, func(data any) (any, error) {
+			result, err := expr.Search(data)
+			if err != nil {
+				return nil, fmt.Errorf("jmespath %q failed: %v", step.JMESPath, err)
+			}
+			return result, nil
+		})
+	}
+	if len(step.Rename) > 0 {
+		ops = append(ops, func(data any) (any, error) { return renameFields(data, step.Rename), nil })
+	}
+	if len(step.Drop) > 0 {
+		ops = append(ops, func(data any) (any, error) {
+			for _, path := range step.Drop {
+				data = truncateByPath(data, strings.Split(path, "."), 0)
+			}
+			return data, nil
+		})
+	}
+	if step.Flatten < 0 || step.Limit < 0 {
+		return nil, errors.New("flatten and limit must be positive")
+	}
+	if step.Flatten > 0 {
+		ops = append(ops, func(data any) (any, error) { return flattenArray(data, step.Flatten), nil })
+	}
+	if step.Limit > 0 {
+		ops = append(ops, func(data any) (any, error) { return limitArrays(data, step.Limit), nil })
+	}
+	if len(ops) != 1 {
+		return nil, errors.New("exactly one of jsonpath, jmespath, rename, drop, flatten, limit must be set")
+	}
+	return ops[0], nil
+}
+
+// renameFields 重命名对象的字段，数组时作用于每个对象元素
+func renameFields(data any, names map[string]string) any {
+	switch val := data.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for k, v := range val {
+			if to, ok := names[k]; ok {
+				k = to
+			}
+			result[k] = v
+		}
+		return result
+	case []any:
+		result := make([]any, 0, len(val))
+		for _, item := range val {
+			result = append(result, renameFields(item, names))
+		}
+		return result
+	}
+	return data
+}
+
+// flattenArray 把嵌套数组展开 depth 层
+func flattenArray(data any, depth int) any {
+	arr, ok := data.([]any)
+	if !ok {
+		return data
+	}
+	for ; depth > 0; depth-- {
+		result := make([]any, 0, len(arr))
+		nested := false
+		for _, item := range arr {
+			if inner, ok := item.([]any); ok {
+				result = append(result, inner...)
+				nested = true
+			} else {
+				result = append(result, item)
+			}
+		}
+		arr = result
+		if !nested {
+			break
+		}
+	}
+	return arr
+}
+
+// limitArrays 递归截断所有数组
+func limitArrays(data any, limit int) any {
+	switch val := data.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for k, v := range val {
+			result[k] = limitArrays(v, limit)
+		}
+		return result
+	case []any:
+		if len(val) > limit {
+			val = val[:limit]
+		}
+		result := make([]any, 0, len(val))
+		for _, item := range val {
+			result = append(result, limitArrays(item, limit))
+		}
+		return result
+	}
+	return data
+}
+
+// runPipeline 依次执行处理步骤
+func runPipeline(ops []pipelineOp, data any) (any, error) {
+	var err error
+	for _, op := range ops {
+		if data, err = op(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// renderOutput 按格式输出处理结果，json 为紧凑格式
+func renderOutput(format string, data any) ([]byte, error) {
+	switch format {
+	case OutputFormatMarkdown:
+		return []byte(renderMarkdown(data)), nil
+	case OutputFormatYAML:
+		return yaml.Marshal(data)
+	}
+	return json.Marshal(data)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// markdownCell 标量直接输出，对象和数组输出紧凑 JSON，转义竖线和换行
+func markdownCell(v any) string {
+	var s string
+	switch val := v.(type) {
+	case nil:
+		s = ""
+	case string:
+		s = val
+	case map[string]any, []any:
+		data, _ := json.Marshal(val)
+		s = string(data)
+	default:
+		s = formatFormScalar(val)
+	}
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "<br>"), "\n", "<br>")
+}
+
+func markdownTable(header []string, rows [][]string) string {
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+// renderMarkdown 对象数组输出为表格 (列为所有字段的并集)，对象输出为字段/值两列的表格，其他值输出为列表或文本
+func renderMarkdown(data any) string {
+	switch val := data.(type) {
+	case []any:
+		if len(val) == 0 {
+			return ""
+		}
+		columns := make(map[string]bool)
+		objects := true
+		for _, item := range val {
+			obj, ok := item.(map[string]any)
+			if !ok {
+				objects = false
+				break
+			}
+			for k := range obj {
+				columns[k] = true
+			}
+		}
+		if !objects {
+			var b strings.Builder
+			for _, item := range val {
+				b.WriteString("- " + markdownCell(item) + "\n")
+			}
+			return b.String()
+		}
+		header := make([]string, 0, len(columns))
+		for k := range columns {
+			header = append(header, k)
+		}
+		sort.Strings(header)
+		rows := make([][]string, 0, len(val))
+		for _, item := range val {
+			obj := item.(map[string]any)
+			row := make([]string, 0, len(header))
+			for _, k := range header {
+				row = append(row, markdownCell(obj[k]))
+			}
+			rows = append(rows, row)
+		}
+		escaped := make([]string, 0, len(header))
+		for _, k := range header {
+			escaped = append(escaped, markdownCell(k))
+		}
+		return markdownTable(escaped, rows)
+	case map[string]any:
+		rows := make([][]string, 0, len(val))
+		for _, k := range sortedKeys(val) {
+			rows = append(rows, []string{markdownCell(k), markdownCell(val[k])})
+		}
+		return markdownTable([]string{"field", "value"}, rows)
+	}
+	return markdownCell(data)
+}
@@ -0,0 +1,167 @@
+package adapter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const pipelineSample = `{
+	"code": 0,
+	"data": {
+		"total": 3,
+		"items": [
+			{"id": 1, "name": "alpha", "internal": {"shard": 7}, "tags": ["a", "b"]},
+			{"id": 2, "name": "be|ta", "internal": {"shard": 8}, "tags": ["c"]},
+			{"id": 3, "name": "gamma", "internal": {"shard": 9}, "tags": []}
+		]
+	}
+}`
+
+func decodeSample(t *testing.T) any {
+	t.Helper()
+	var data any
+	if err := json.Unmarshal([]byte(pipelineSample), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestJSONPath(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"$.code", `0`},
+		{"$.data.total", `3`},
+		{"$['data']['items'][0].name", `"alpha"`},
+		{"$.data.items[-1].id", `3`},
+		{"$.data.items[*].id", `[1,2,3]`},
+		{"$.data.items[1:].name", `["be|ta","gamma"]`},
+		{"$..shard", `[7,8,9]`},
+		{"$.data.items[0].tags.*", `["a","b"]`},
+		{"$.data.missing", `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			path, err := CompileJSONPath(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := json.Marshal(path.Search(decodeSample(t)))
+			if string(data) != tt.expected {
+				t.Errorf("got %s, want %s", data, tt.expected)
+			}
+		})
+	}
+
+	for _, expr := range []string{"data.items", "$.data[?(@.id > 1)]", "$.data[", "$."} {
+		if _, err := CompileJSONPath(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []PipelineStep
+		expected string
+	}{
+		{
+			name:     "jsonpath select, drop and rename",
+			steps:    []PipelineStep{{JSONPath: "$.data.items"}, {Drop: []string{"*.internal", "*.tags"}}, {Rename: map[string]string{"name": "title"}}},
+			expected: `[{"id":1,"title":"alpha"},{"id":2,"title":"be|ta"},{"id":3,"title":"gamma"}]`,
+		},
+		{
+			name:     "jmespath projection",
+			steps:    []PipelineStep{{JMESPath: "data.items[?id > `1`].{id: id, shard: internal.shard}"}},
+			expected: `[{"id":2,"shard":8},{"id":3,"shard":9}]`,
+		},
+		{
+			name:     "flatten",
+			steps:    []PipelineStep{{JSONPath: "$.data.items[*].tags"}, {Flatten: 1}},
+			expected: `["a","b","c"]`,
+		},
+		{
+			name:     "limit nested arrays",
+			steps:    []PipelineStep{{JSONPath: "$.data"}, {Limit: 1}, {Drop: []string{"items.*.internal"}}},
+			expected: `{"items":[{"id":1,"name":"alpha","tags":["a"]}],"total":3}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := compilePipeline(tt.steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := runPipeline(ops, decodeSample(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := json.Marshal(result)
+			if string(data) != tt.expected {
+				t.Errorf("got %s, want %s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePostProcess(t *testing.T) {
+	meta, err := ParsePostProcess(`{"truncate_fields": {"data.items.*.name": 3}, "pipeline": [{"jsonpath": "$.data.items"}], "format": "yaml"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Format != OutputFormatYAML || len(meta.Pipeline) != 1 || meta.TruncateFields["data.items.*.name"] != 3 {
+		t.Errorf("unexpected meta %+v", meta)
+	}
+
+	tests := map[string]string{
+		`not json`:          "invalid post_process",
+		`{"format": "csv"}`: "format must be json, markdown or yaml",
+		`{"format": "markdown", "structured_output": true}`: "cannot be used with structured_output",
+		`{"pipeline": [{}]}`: "pipeline step 1: exactly one of",
+		`{"pipeline": [{"jsonpath": "$.a"}, {"jsonpath": "$.a[?]"}]}`: "pipeline step 2",
+		`{"pipeline": [{"jmespath": "a.["}]}`:                         "invalid jmespath",
+		`{"pipeline": [{"limit": -1}]}`:                               "must be positive",
+	}
+	for raw, wantErr := range tests {
+		if _, err := ParsePostProcess(raw); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", raw, wantErr, err)
+		}
+	}
+}
+
+func TestRenderOutput(t *testing.T) {
+	items := []any{
+		map[string]any{"id": float64(1), "name": "alpha", "tags": []any{"a", "b"}},
+		map[string]any{"id": float64(2), "name": "be|ta\nnext"},
+	}
+	data, err := renderOutput(OutputFormatMarkdown, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "| id | name | tags |\n| --- | --- | --- |\n| 1 | alpha | [\"a\",\"b\"] |\n| 2 | be\\|ta<br>next |  |\n"
+	if string(data) != expected {
+		t.Errorf("unexpected markdown table\n got: %q\nwant: %q", data, expected)
+	}
+
+	data, _ = renderOutput(OutputFormatMarkdown, map[string]any{"total": float64(3), "ok": true})
+	if string(data) != "| field | value |\n| --- | --- |\n| ok | true |\n| total | 3 |\n" {
+		t.Errorf("unexpected markdown object %q", data)
+	}
+	data, _ = renderOutput(OutputFormatMarkdown, []any{"x", float64(1.5)})
+	if string(data) != "- x\n- 1.5\n" {
+		t.Errorf("unexpected markdown list %q", data)
+	}
+
+	data, _ = renderOutput(OutputFormatYAML, items[:1])
+	if string(data) != "- id: 1\n  name: alpha\n  tags:\n    - a\n    - b\n" {
+		t.Errorf("unexpected yaml %q", data)
+	}
+
+	data, _ = renderOutput("", map[string]any{"b": []any{}, "a": nil})
+	if string(data) != `{"a":null,"b":[]}` {
+		t.Errorf("unexpected json %s", data)
+	}
+}
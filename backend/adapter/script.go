@@ -0,0 +1,420 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+	"go.starlark.net/syntax"
+)
+
+// 脚本钩子名称，脚本通过定义同名函数启用对应钩子
+const (
+	ScriptHookPreRequest   = "pre_request"   // def pre_request(params): 请求上游前修改参数
+	ScriptHookPostResponse = "post_response" // def post_response(body): 收到响应后修改响应体
+)
+
+// 脚本运行限制：Starlark 没有内存统计，内存通过执行步数、输入输出大小和拼接重复结果的大小 (见 limitScriptOperators) 限制
+const (
+	scriptMaxSteps    = 1000000
+	scriptTimeout     = time.Second
+	scriptMaxDataSize = 4 << 20
+	scriptMaxLogLines = 100
+)
+
+// scriptFileOptions 允许 while、递归和顶层控制语句，死循环由执行步数限制
+var scriptFileOptions = &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, Recursion: true}
+
+// scriptPredeclared 脚本可用的内置模块，不提供 load、文件和网络访问
+var scriptPredeclared = func() starlark.StringDict {
+	predeclared := starlark.StringDict{"json": starlarkjson.Module}
+	for name, value := range scriptLimitPredeclared {
+		predeclared[name] = value
+	}
+	return predeclared
+}()
+
+// Script 编译后的 Starlark 脚本，全局变量在编译后冻结，可以并发调用
+type Script struct {
+	name  string
+	hooks map[string]*starlark.Function
+}
+
+// CompileScript 编译脚本并检查至少定义了一个钩子函数，空脚本返回 nil
+func CompileScript(name, src string) (*Script, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	f, err := scriptFileOptions.Parse(name, src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %v", err)
+	}
+	limitScriptOperators(f)
+	program, err := starlark.FileProgram(f, scriptPredeclared.Has)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %v", err)
+	}
+	thread, cancel := newScriptThread(context.Background(), name, nil)
+	defer cancel()
+	globals, err := program.Init(thread, scriptPredeclared)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %v", scriptError(err))
+	}
+	globals.Freeze()
+	s := &Script{name: name, hooks: make(map[string]*starlark.Function)}
+	for _, hook := range []string{ScriptHookPreRequest, ScriptHookPostResponse} {
+		v, ok := globals[hook]
+		if !ok {
+			continue
+		}
+		fn, ok := v.(*starlark.Function)
+		if !ok || fn.NumParams() != 1 {
+			return nil, fmt.Errorf("invalid script: %s must be a function with one parameter", hook)
+		}
+		s.hooks[hook] = fn
+	}
+	if len(s.hooks) == 0 {
+		return nil, fmt.Errorf("invalid script: define %s or %s", ScriptHookPreRequest, ScriptHookPostResponse)
+	}
+	return s, nil
+}
+
+// HasHook 脚本是否定义了指定钩子
+func (s *Script) HasHook(hook string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.hooks[hook]
+	return ok
+}
+
+// newScriptThread 创建带执行步数和超时限制的线程，print 输出写入 logs
+func newScriptThread(ctx context.Context, name string, logs *[]string) (*starlark.Thread, func()) {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			if logs != nil && len(*logs) < scriptMaxLogLines {
+				*logs = append(*logs, msg)
+			}
+		},
+	}
+	thread.SetMaxExecutionSteps(scriptMaxSteps)
+	ctx, cancel := context.WithTimeout(ctx, scriptTimeout)
+	if err := ctx.Err(); err != nil {
+		// 已经取消的调用不再执行，AfterFunc 在单独的 goroutine 中运行，可能晚于脚本开始
+		thread.Cancel(err.Error())
+	}
+	stop := context.AfterFunc(ctx, func() { thread.Cancel(ctx.Err().Error()) })
+	return thread, func() {
+		stop()
+		cancel()
+	}
+}
+
+// scriptError 去掉 Starlark 的内部调用栈，保留出错位置
+func scriptError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+	return err
+}
+
+// call 执行钩子函数，input 为 JSON 兼容的 Go 值，返回值为 None 时使用 (可能被原地修改的) 参数
+func (s *Script) call(ctx context.Context, hook string, input any, logs *[]string) (any, error) {
+	fn := s.hooks[hook]
+	arg, err := toStarlark(input)
+	if err != nil {
+		return nil, err
+	}
+	thread, cancel := newScriptThread(ctx, s.name, logs)
+	defer cancel()
+	result, err := starlark.Call(thread, fn, starlark.Tuple{arg}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("script %s %s failed: %v", s.name, hook, scriptError(err))
+	}
+	if result == starlark.None {
+		result = arg
+	}
+	output, err := fromStarlark(result)
+	if err != nil {
+		return nil, fmt.Errorf("script %s %s returned %v", s.name, hook, err)
+	}
+	return output, nil
+}
+
+// parametersToScript 参数按位置分组传给脚本：{"path": {}, "query": {}, "header": {}, "body": {}}
+func parametersToScript(params Parameters) map[string]any {
+	section := func(m map[string]any) map[string]any {
+		if m == nil {
+			return map[string]any{}
+		}
+		return m
+	}
+	return map[string]any{
+		"path":   section(params.PathParams),
+		"query":  section(params.QueryParams),
+		"header": section(params.HeaderParams),
+		"body":   section(params.BodyParams),
+	}
+}
+
+func parametersFromScript(v any) (Parameters, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return Parameters{}, errors.New("params must be a dict of path, query, header and body")
+	}
+	var params Parameters
+	targets := map[string]*map[string]any{
+		"path":   &params.PathParams,
+		"query":  &params.QueryParams,
+		"header": &params.HeaderParams,
+		"body":   &params.BodyParams,
+	}
+	for key, value := range m {
+		target, ok := targets[key]
+		if !ok {
+			return Parameters{}, fmt.Errorf("unknown parameter location %q in params", key)
+		}
+		section, ok := value.(map[string]any)
+		if !ok && value != nil {
+			return Parameters{}, fmt.Errorf("params %s must be a dict", key)
+		}
+		if len(section) > 0 {
+			*target = section
+		}
+	}
+	return params, nil
+}
+
+// restoreSecretRefs 脚本没有修改的密钥引用恢复为 SecretRef；
+// 脚本新写入或移动到其他位置的 secret:// 字符串不会被解析，避免脚本和调用方借此读取密钥
+func restoreSecretRefs(before Parameters, after *Parameters) {
+	sections := []struct{ before, after map[string]any }{
+		{before.PathParams, after.PathParams},
+		{before.QueryParams, after.QueryParams},
+		{before.HeaderParams, after.HeaderParams},
+		{before.BodyParams, after.BodyParams},
+	}
+	for _, section := range sections {
+		for key, value := range section.before {
+			ref, ok := value.(SecretRef)
+			if !ok || section.after == nil {
+				continue
+			}
+			if current, ok := section.after[key].(string); ok && current == ref.String() {
+				section.after[key] = ref
+			}
+		}
+	}
+}
+
+// PreRequest 执行 pre_request 钩子，未定义时原样返回参数
+func (s *Script) PreRequest(ctx context.Context, params Parameters, logs *[]string) (Parameters, error) {
+	if !s.HasHook(ScriptHookPreRequest) {
+		return params, nil
+	}
+	input := parametersToScript(params)
+	if data, _ := json.Marshal(input); len(data) > scriptMaxDataSize {
+		return params, fmt.Errorf("script %s: params exceed %d bytes", s.name, scriptMaxDataSize)
+	}
+	output, err := s.call(ctx, ScriptHookPreRequest, input, logs)
+	if err != nil {
+		return params, err
+	}
+	if data, _ := json.Marshal(output); len(data) > scriptMaxDataSize {
+		return params, fmt.Errorf("script %s: pre_request result exceeds %d bytes", s.name, scriptMaxDataSize)
+	}
+	result, err := parametersFromScript(output)
+	if err != nil {
+		return params, fmt.Errorf("script %s %s returned invalid params: %v", s.name, ScriptHookPreRequest, err)
+	}
+	restoreSecretRefs(params, &result)
+	return result, nil
+}
+
+// PostResponse 执行 post_response 钩子：响应体是 JSON 时传入解析后的值，否则传入字符串；
+// 返回字符串时原样作为响应体，其他值编码为 JSON
+func (s *Script) PostResponse(ctx context.Context, body []byte, logs *[]string) ([]byte, error) {
+	if !s.HasHook(ScriptHookPostResponse) {
+		return body, nil
+	}
+	if len(body) > scriptMaxDataSize {
+		return nil, fmt.Errorf("script %s: response body exceeds %d bytes", s.name, scriptMaxDataSize)
+	}
+	var input any = string(body)
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		input = decoded
+	}
+	output, err := s.call(ctx, ScriptHookPostResponse, input, logs)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if str, ok := output.(string); ok {
+		data = []byte(str)
+	} else if data, err = json.Marshal(output); err != nil {
+		return nil, fmt.Errorf("script %s: marshal post_response result failed: %v", s.name, err)
+	}
+	if len(data) > scriptMaxDataSize {
+		return nil, fmt.Errorf("script %s: post_response result exceeds %d bytes", s.name, scriptMaxDataSize)
+	}
+	return data, nil
+}
+
+// RunScriptHook 用示例输入执行一次钩子，供脚本调试使用。
+// pre_request 的输入为 {"path": {}, "query": {}, "header": {}, "body": {}}，post_response 的输入为响应体
+func RunScriptHook(ctx context.Context, src, hook string, input any) (any, []string, error) {
+	logs := make([]string, 0)
+	script, err := CompileScript("test", src)
+	if err != nil {
+		return nil, logs, err
+	}
+	if script == nil {
+		return nil, logs, errors.New("script is empty")
+	}
+	if !script.HasHook(hook) {
+		return nil, logs, fmt.Errorf("script does not define %s", hook)
+	}
+	switch hook {
+	case ScriptHookPreRequest:
+		var params Parameters
+		if input != nil {
+			if params, err = parametersFromScript(input); err != nil {
+				return nil, logs, err
+			}
+		}
+		params, err = script.PreRequest(ctx, params, &logs)
+		if err != nil {
+			return nil, logs, err
+		}
+		return parametersToScript(params), logs, nil
+	default:
+		body, ok := input.(string)
+		if !ok {
+			data, err := json.Marshal(input)
+			if err != nil {
+				return nil, logs, err
+			}
+			body = string(data)
+		}
+		data, err := script.PostResponse(ctx, []byte(body), &logs)
+		if err != nil {
+			return nil, logs, err
+		}
+		var output any
+		if err := json.Unmarshal(data, &output); err != nil {
+			return string(data), logs, nil
+		}
+		return output, logs, nil
+	}
+}
+
+// toStarlark 把 JSON 兼容的 Go 值转换为 Starlark 值，整数值的数字转换为 int
+func toStarlark(v any) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case SecretRef:
+		// 脚本只能看到引用，明文在 DoRequest 中才解析
+		return starlark.String(val.String()), nil
+	case int:
+		return starlark.MakeInt(val), nil
+	case int64:
+		return starlark.MakeInt64(val), nil
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1<<53 {
+			return starlark.MakeInt64(int64(val)), nil
+		}
+		return starlark.Float(val), nil
+	case []any:
+		items := make([]starlark.Value, 0, len(val))
+		for _, item := range val {
+			sv, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, sv)
+		}
+		return starlark.NewList(items), nil
+	case map[string]any:
+		dict := starlark.NewDict(len(val))
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sv, err := toStarlark(val[k])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(k), sv); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+// fromStarlark 把脚本返回的 Starlark 值转换回 JSON 兼容的 Go 值
+func fromStarlark(v starlark.Value) (any, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.String:
+		return string(val), nil
+	case starlark.Int:
+		if i, ok := val.Int64(); ok {
+			return i, nil
+		}
+		f, _ := new(big.Float).SetInt(val.BigInt()).Float64()
+		return f, nil
+	case starlark.Float:
+		return float64(val), nil
+	case *starlark.List, starlark.Tuple:
+		iter := starlark.Iterate(val)
+		defer iter.Done()
+		items := make([]any, 0)
+		var item starlark.Value
+		for iter.Next(&item) {
+			gv, err := fromStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, gv)
+		}
+		return items, nil
+	case *starlark.Dict:
+		result := make(map[string]any, val.Len())
+		for _, kv := range val.Items() {
+			key, ok := kv[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("unsupported dict key %s, keys must be strings", kv[0].Type())
+			}
+			gv, err := fromStarlark(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			result[string(key)] = gv
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %s", v.Type())
+}
@@ -0,0 +1,124 @@
+package adapter
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// 编译时插入的内置函数名，包含 $ 的名称不能出现在脚本中
+const (
+	scriptOperandBuiltin = "$operand"
+	scriptInplaceBuiltin = "$inplace"
+)
+
+// scriptLimitPredeclared 检查拼接和重复结果大小的内置函数
+var scriptLimitPredeclared = starlark.StringDict{
+	scriptOperandBuiltin: starlark.NewBuiltin(scriptOperandBuiltin, func(_ *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+		return scriptOperand{value: args[0]}, nil
+	}),
+	scriptInplaceBuiltin: starlark.NewBuiltin(scriptInplaceBuiltin, func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+		return scriptOperand{value: args[0], inplace: thread}, nil
+	}),
+}
+
+// limitScriptOperators 改写语法树，让 + 和 * 以及 += 和 *= 经过 scriptOperand 计算：
+// Starlark 的单次分配上限是 1GiB，"x" * (1 << 29) 这样的表达式一步就能分配数百 MiB，
+// 改写后字符串、bytes、列表和元组的拼接或重复结果超过 scriptMaxDataSize 时直接报错
+func limitScriptOperators(f *syntax.File) {
+	wrap := func(name string, x syntax.Expr) syntax.Expr {
+		pos := syntax.Start(x)
+		return &syntax.CallExpr{Fn: &syntax.Ident{NamePos: pos, Name: name}, Lparen: pos, Args: []syntax.Expr{x}, Rparen: pos}
+	}
+	syntax.Walk(f, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.BinaryExpr:
+			// 只包装左操作数，求值顺序不变；右操作数不是内置类型时 Starlark 才会交给左操作数计算
+			if n.Op == syntax.PLUS || n.Op == syntax.STAR {
+				n.X = wrap(scriptOperandBuiltin, n.X)
+			}
+		case *syntax.AssignStmt:
+			// 增量赋值的左侧可能带索引，不能重复求值，改为包装右侧
+			if n.Op == syntax.PLUS_EQ || n.Op == syntax.STAR_EQ {
+				n.RHS = wrap(scriptInplaceBuiltin, n.RHS)
+			}
+		}
+		return true
+	})
+}
+
+// scriptOperand 包装运算的一个操作数，计算前检查结果大小，再交给 starlark.Binary
+type scriptOperand struct {
+	value   starlark.Value
+	inplace *starlark.Thread // 非空时是 += 的右侧，左侧是列表时原地追加
+}
+
+var _ starlark.HasBinary = scriptOperand{}
+
+func (o scriptOperand) String() string        { return o.value.String() }
+func (o scriptOperand) Type() string          { return o.value.Type() }
+func (o scriptOperand) Freeze()               { o.value.Freeze() }
+func (o scriptOperand) Truth() starlark.Bool  { return o.value.Truth() }
+func (o scriptOperand) Hash() (uint32, error) { return o.value.Hash() }
+
+func (o scriptOperand) Binary(op syntax.Token, y starlark.Value, side starlark.Side) (starlark.Value, error) {
+	x := o.value
+	if side == starlark.Right {
+		x, y = y, x
+	}
+	if size, ok := scriptOperationSize(op, x, y); ok && size > scriptMaxDataSize {
+		return nil, fmt.Errorf("result of %s %s %s exceeds %d", x.Type(), op, y.Type(), scriptMaxDataSize)
+	}
+	if list, ok := x.(*starlark.List); ok && o.inplace != nil && op == syntax.PLUS {
+		if _, ok := y.(starlark.Iterable); ok {
+			extend, _ := list.Attr("extend")
+			if _, err := starlark.Call(o.inplace, extend, starlark.Tuple{y}, nil); err != nil {
+				return nil, err
+			}
+			return list, nil
+		}
+	}
+	return starlark.Binary(op, x, y)
+}
+
+// scriptOperationSize 计算拼接或重复结果的长度：字符串和 bytes 按字节，列表和元组按元素个数
+func scriptOperationSize(op syntax.Token, x, y starlark.Value) (int64, bool) {
+	switch op {
+	case syntax.PLUS:
+		xn, xok := scriptValueLen(x)
+		yn, yok := scriptValueLen(y)
+		if _, isList := x.(*starlark.List); isList && !yok {
+			// 列表 += 可迭代对象
+			if sized, ok := y.(starlark.Sequence); ok {
+				yn, yok = int64(sized.Len()), true
+			}
+		}
+		return xn + yn, xok && yok
+	case syntax.STAR:
+		if _, ok := x.(starlark.Int); ok {
+			x, y = y, x
+		}
+		n, ok := scriptValueLen(x)
+		count, err := starlark.AsInt32(y)
+		if !ok || err != nil || count <= 0 {
+			return 0, false
+		}
+		return n * int64(count), true
+	}
+	return 0, false
+}
+
+func scriptValueLen(v starlark.Value) (int64, bool) {
+	switch v := v.(type) {
+	case starlark.String:
+		return int64(len(v)), true
+	case starlark.Bytes:
+		return int64(len(v)), true
+	case *starlark.List:
+		return int64(v.Len()), true
+	case starlark.Tuple:
+		return int64(v.Len()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,318 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCompileScript(t *testing.T) {
+	script, err := CompileScript("empty", "  \n")
+	if err != nil || script != nil {
+		t.Fatalf("empty script should be ignored, got %v %v", script, err)
+	}
+
+	tests := map[string]string{
+		"def pre_request(:":                     "invalid script",
+		"x = 1":                                 "define pre_request or post_response",
+		"pre_request = 1":                       "pre_request must be a function with one parameter",
+		"def post_response(body, extra): pass":  "post_response must be a function with one parameter",
+		"load('os', 'system')":                  "invalid script",
+		"while True:\n    pass":                 "too many steps",
+		"def pre_request(p):\n    return undef": "undefined: undef",
+	}
+	for src, wantErr := range tests {
+		if _, err := CompileScript("t", src); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%q: expected error containing %q, got %v", src, wantErr, err)
+		}
+	}
+
+	script, err = CompileScript("t", "def post_response(body):\n    return body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !script.HasHook(ScriptHookPostResponse) || script.HasHook(ScriptHookPreRequest) {
+		t.Errorf("unexpected hooks %v", script.hooks)
+	}
+}
+
+func TestScriptPreRequest(t *testing.T) {
+	script, err := CompileScript("t", `
+def pre_request(params):
+    params["query"]["page"] = params["query"].get("page", 0) + 1
+    params["header"]["X-Trace"] = "t-" + params["path"]["id"]
+    params["body"]["tags"] = [t.upper() for t in params["body"]["tags"]]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := script.PreRequest(context.Background(), Parameters{
+		PathParams:  map[string]any{"id": "42"},
+		QueryParams: map[string]any{"page": float64(1)},
+		BodyParams:  map[string]any{"tags": []any{"a", "b"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.QueryParams["page"] != int64(2) || params.HeaderParams["X-Trace"] != "t-42" {
+		t.Errorf("unexpected params %+v", params)
+	}
+	if tags, _ := json.Marshal(params.BodyParams["tags"]); string(tags) != `["A","B"]` {
+		t.Errorf("unexpected tags %s", tags)
+	}
+
+	// 返回值替换全部参数
+	script, _ = CompileScript("t", `def pre_request(params): return {"body": {"wrapped": params["body"]}}`)
+	params, err = script.PreRequest(context.Background(), Parameters{BodyParams: map[string]any{"a": "b"}}, nil)
+	if err != nil || params.QueryParams != nil || params.BodyParams["wrapped"].(map[string]any)["a"] != "b" {
+		t.Errorf("unexpected params %+v %v", params, err)
+	}
+
+	script, _ = CompileScript("t", `def pre_request(params): return {"cookie": {}}`)
+	if _, err := script.PreRequest(context.Background(), Parameters{}, nil); err == nil || !strings.Contains(err.Error(), `unknown parameter location "cookie"`) {
+		t.Errorf("expected invalid params error, got %v", err)
+	}
+}
+
+func TestScriptPostResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		body     string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "reshape json",
+			src:      "def post_response(body):\n    return {\"names\": [u[\"name\"] for u in body[\"users\"]], \"count\": len(body[\"users\"])}",
+			body:     `{"users": [{"name": "a", "id": 1}, {"name": "b", "id": 2}]}`,
+			expected: `{"count":2,"names":["a","b"]}`,
+		},
+		{
+			name:     "mutate in place",
+			src:      "def post_response(body):\n    body.pop(\"debug\")",
+			body:     `{"ok": true, "debug": {"trace": "x"}}`,
+			expected: `{"ok":true}`,
+		},
+		{
+			name:     "text body",
+			src:      "def post_response(body):\n    return body.strip().upper()",
+			body:     " plain text ",
+			expected: "PLAIN TEXT",
+		},
+		{
+			name:     "json module",
+			src:      "def post_response(body):\n    return json.encode(json.decode(body[\"raw\"]))",
+			body:     `{"raw": "{\"a\": 1.5}"}`,
+			expected: `{"a":1.5}`,
+		},
+		{
+			name:    "runtime error",
+			src:     "def post_response(body):\n    return body[\"missing\"]",
+			body:    `{}`,
+			wantErr: `key "missing" not in dict`,
+		},
+		{
+			name:    "step limit",
+			src:     "def post_response(body):\n    while True:\n        pass",
+			body:    `{}`,
+			wantErr: "too many steps",
+		},
+		{
+			name:    "unsupported value",
+			src:     "def post_response(body):\n    return {1: 2}",
+			body:    `{}`,
+			wantErr: "keys must be strings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := CompileScript("t", tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := script.PostResponse(context.Background(), []byte(tt.body), nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("got %s, want %s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScriptSizeLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "string repeat", src: "def post_response(body):\n    return \"x\" * (1 << 29)", wantErr: "result of string * int exceeds 4194304"},
+		{name: "repeat on the right", src: "def post_response(body):\n    return (1 << 29) * b\"x\"", wantErr: "result of int * bytes exceeds 4194304"},
+		{name: "list repeat", src: "def post_response(body):\n    return len([0] * (1 << 25))", wantErr: "result of list * int exceeds 4194304"},
+		{name: "doubling concat", src: "def post_response(body):\n    s = \"x\"\n    for _ in range(30):\n        s = s + s\n    return s", wantErr: "result of string + string exceeds 4194304"},
+		{name: "augmented repeat", src: "def post_response(body):\n    s = \"xy\"\n    s *= 1 << 28\n    return s", wantErr: "result of string * int exceeds 4194304"},
+		{name: "augmented list extend", src: "def post_response(body):\n    l = [0]\n    for _ in range(30):\n        l += l\n    return len(l)", wantErr: "result of list + list exceeds 4194304"},
+		{name: "top level", src: "big = \"x\" * (1 << 29)\ndef post_response(body):\n    return big", wantErr: "invalid script"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := CompileScript("t", tt.src)
+			if err == nil {
+				_, err = script.PostResponse(context.Background(), []byte(`{}`), nil)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// 限制以内的运算不受影响，+= 仍然原地修改列表
+	script, err := CompileScript("t", `
+def post_response(body):
+    items = body["items"]
+    alias = items
+    items += [3]
+    items *= 2
+    return {"alias": alias, "items": items, "text": "ab" * 2 + "c", "sum": 1 + 2 * 3, "tuple": (1,) + (2,)}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := script.PostResponse(context.Background(), []byte(`{"items": [1, 2]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"alias":[1,2,3],"items":[1,2,3,1,2,3],"sum":7,"text":"ababc","tuple":[1,2]}` {
+		t.Errorf("unexpected result %s", data)
+	}
+
+	big := strings.Repeat("x", scriptMaxDataSize)
+	script, _ = CompileScript("t", "def pre_request(params):\n    pass")
+	if _, err := script.PreRequest(context.Background(), Parameters{BodyParams: map[string]any{"data": big}}, nil); err == nil || !strings.Contains(err.Error(), "params exceed") {
+		t.Errorf("expected params size error, got %v", err)
+	}
+}
+
+func TestScriptCanceledByContext(t *testing.T) {
+	script, _ := CompileScript("t", "def post_response(body):\n    while True:\n        pass")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := script.PostResponse(ctx, []byte(`{}`), nil); err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("expected cancellation error, got %v", err)
+	}
+}
+
+func TestRunScriptHook(t *testing.T) {
+	src := "def pre_request(params):\n    print(\"query\", params[\"query\"])\n    params[\"query\"][\"limit\"] = 10"
+	output, logs, err := RunScriptHook(context.Background(), src, ScriptHookPreRequest, map[string]any{"query": map[string]any{"q": "go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(output)
+	if string(data) != `{"body":{},"header":{},"path":{},"query":{"limit":10,"q":"go"}}` {
+		t.Errorf("unexpected output %s", data)
+	}
+	if len(logs) != 1 || logs[0] != `query {"q": "go"}` {
+		t.Errorf("unexpected logs %v", logs)
+	}
+
+	if _, _, err := RunScriptHook(context.Background(), src, ScriptHookPostResponse, "x"); err == nil || !strings.Contains(err.Error(), "does not define post_response") {
+		t.Errorf("expected missing hook error, got %v", err)
+	}
+}
+
+func TestAddToolRunsScripts(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"echo": ` + string(body) + `, "tenant": "` + r.Header.Get("X-Tenant") + `", "internal": 1}`))
+	}))
+	defer upstream.Close()
+
+	app := models.Application{Name: "Scripted", Path: "scripted-app", Protocol: "sse",
+		PostProcess: "def pre_request(params):\n    params[\"header\"][\"X-Tenant\"] = \"acme\"\n\ndef post_response(body):\n    body.pop(\"internal\")"}
+	createWithEnabled(t, &app, true)
+	iface := models.Interface{AppID: app.ID, Name: "echo", Protocol: "http", URL: upstream.URL, Method: "POST", AuthType: "none",
+		Script: "def pre_request(params):\n    params[\"body\"][\"name\"] = params[\"body\"][\"name\"].title()\n\ndef post_response(body):\n    body[\"tenant\"] = body[\"tenant\"].upper()"}
+	createWithEnabled(t, &iface, true)
+	database.GetDB().Create(&models.InterfaceParameter{AppID: app.ID, InterfaceID: iface.ID, Name: "name", Type: "string", Location: "body", Group: "input"})
+
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatalf("add application failed: %v", err)
+	}
+	s, _ := sm.sseServers.Load("scripted-app")
+	tool := s.(*Server).server.GetTool("echo")
+	if tool == nil {
+		t.Fatal("tool not registered")
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "echo"
+	req.Params.Arguments = map[string]any{"name": "alice"}
+	result, err := tool.Handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected result %+v %v", result, err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if text != `{"echo":{"name":"Alice"},"tenant":"ACME"}` {
+		t.Errorf("unexpected result %s", text)
+	}
+
+	invalid := iface
+	invalid.Name, invalid.Script = "invalid", "def post_response("
+	if err := sm.addTool(&invalid, &app); err == nil || !strings.Contains(err.Error(), "invalid script") {
+		t.Errorf("expected invalid script error, got %v", err)
+	}
+}
+
+func TestAddToolScriptWithSecretParams(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "test-master-key")
+	sm := setupEnabledTestManager(t)
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM secrets") })
+	createTestSecret(t, "api-key", "key-123")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		data, _ := json.Marshal(map[string]string{"key": r.Header.Get("X-Api-Key"), "copy": r.Header.Get("X-Copy"), "tenant": r.Header.Get("X-Tenant")})
+		w.Write(data)
+	}))
+	defer upstream.Close()
+
+	// 脚本看到的是引用，复制到其他位置的引用不会被解析
+	app := models.Application{Name: "ScriptedSecret", Path: "scripted-secret", Protocol: "sse",
+		PostProcess: "def pre_request(params):\n    params[\"header\"][\"X-Tenant\"] = \"acme\"\n    params[\"header\"][\"X-Copy\"] = params[\"header\"][\"X-Api-Key\"]"}
+	createWithEnabled(t, &app, true)
+	iface := models.Interface{AppID: app.ID, Name: "whoami", Protocol: "http", URL: upstream.URL, Method: "GET", AuthType: "none"}
+	createWithEnabled(t, &iface, true)
+	database.GetDB().Create(&models.InterfaceParameter{AppID: app.ID, InterfaceID: iface.ID, Name: "X-Api-Key", Type: "string",
+		Location: "header", Group: "fixed", DefaultValue: stringPtr("secret://api-key")})
+
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatalf("add application failed: %v", err)
+	}
+	s, _ := sm.sseServers.Load("scripted-secret")
+	result, err := s.(*Server).server.GetTool("whoami").Handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected result %+v %v", result, err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != `{"copy":"secret://api-key","key":"key-123","tenant":"acme"}` {
+		t.Errorf("unexpected result %s", text)
+	}
+}
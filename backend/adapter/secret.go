@@ -0,0 +1,155 @@
+package adapter
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"os"
+	"strings"
+)
+
+// SecretKeyEnv 主密钥环境变量，密钥值使用其 SHA-256 作为 AES-256-GCM 密钥加密
+const SecretKeyEnv = "MCP_ADAPTER_SECRET_KEY"
+
+// SecretScheme 密钥引用前缀
+const SecretScheme = "secret://"
+
+// SecretRef fixed 参数中的密钥引用，在 DoRequest 中才解析为明文
+// 调用方传入的 JSON 参数无法构造出该类型，避免通过工具参数读取密钥
+type SecretRef struct {
+	Name string
+}
+
+// String 未解析时只输出引用，不会泄露密钥
+func (r SecretRef) String() string {
+	return SecretScheme + r.Name
+}
+
+// ParseSecretRef 判断值是否为 secret://name 形式的引用
+func ParseSecretRef(value string) (string, bool) {
+	if !strings.HasPrefix(value, SecretScheme) {
+		return "", false
+	}
+	name := strings.TrimPrefix(value, SecretScheme)
+	return name, name != ""
+}
+
+func secretCipher() (cipher.AEAD, error) {
+	master := os.Getenv(SecretKeyEnv)
+	if master == "" {
+		return nil, fmt.Errorf("secret store is disabled: %s is not set", SecretKeyEnv)
+	}
+	key := sha256.Sum256([]byte(master))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret 加密密钥值，返回 base64(nonce + 密文)
+func EncryptSecret(plain string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 的结果
+func DecryptSecret(encoded string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt secret, master key may have changed")
+	}
+	return string(plain), nil
+}
+
+// LookupSecret 按名称读取并解密密钥
+func LookupSecret(name string) (string, error) {
+	var secret models.Secret
+	if err := database.GetDB().Where("name = ?", name).First(&secret).Error; err != nil {
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+	value, err := DecryptSecret(secret.Value)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// ResolveSecretValue 解析配置中的 secret://name，普通字符串原样返回
+func ResolveSecretValue(value string) (string, error) {
+	if name, ok := ParseSecretRef(value); ok {
+		return LookupSecret(name)
+	}
+	return value, nil
+}
+
+// resolveCallSecret 解析调用中使用的 secret://name，明文记录到本次调用，写入调用日志前替换
+func resolveCallSecret(ctx context.Context, value string) (string, error) {
+	if _, ok := ParseSecretRef(value); !ok {
+		return value, nil
+	}
+	resolved, err := ResolveSecretValue(value)
+	if err != nil {
+		return "", err
+	}
+	recordSecret(ctx, resolved)
+	return resolved, nil
+}
+
+// ResolveSecrets 将参数中的 SecretRef 替换为明文，只应在 RequestHandle.DoRequest 中调用
+// 解析出的明文记录到本次调用，写入调用日志前替换
+func ResolveSecrets(ctx context.Context, parameters Parameters) (Parameters, error) {
+	resolved := Parameters{}
+	groups := []struct {
+		src map[string]any
+		dst *map[string]any
+	}{
+		{parameters.HeaderParams, &resolved.HeaderParams},
+		{parameters.QueryParams, &resolved.QueryParams},
+		{parameters.PathParams, &resolved.PathParams},
+		{parameters.BodyParams, &resolved.BodyParams},
+	}
+	for _, g := range groups {
+		if g.src == nil {
+			continue
+		}
+		m := make(map[string]any, len(g.src))
+		for k, v := range g.src {
+			if ref, ok := v.(SecretRef); ok {
+				value, err := LookupSecret(ref.Name)
+				if err != nil {
+					return Parameters{}, err
+				}
+				recordSecret(ctx, value)
+				m[k] = value
+				continue
+			}
+			m[k] = v
+		}
+		*g.dst = m
+	}
+	return resolved, nil
+}
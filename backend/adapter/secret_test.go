@@ -0,0 +1,186 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestEncryptDecryptSecret(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "test-master-key")
+
+	encrypted, err := EncryptSecret("s3cr3t-value")
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if encrypted == "s3cr3t-value" {
+		t.Fatal("secret should not be stored in plaintext")
+	}
+	again, _ := EncryptSecret("s3cr3t-value")
+	if again == encrypted {
+		t.Error("encryption should use a random nonce")
+	}
+	plain, err := DecryptSecret(encrypted)
+	if err != nil || plain != "s3cr3t-value" {
+		t.Fatalf("expected s3cr3t-value, got %q (%v)", plain, err)
+	}
+
+	if _, err := DecryptSecret("not-base64!"); err == nil {
+		t.Error("expected error for invalid ciphertext")
+	}
+
+	t.Setenv(SecretKeyEnv, "another-key")
+	if _, err := DecryptSecret(encrypted); err == nil {
+		t.Error("expected error when master key changes")
+	}
+
+	t.Setenv(SecretKeyEnv, "")
+	if _, err := EncryptSecret("x"); err == nil {
+		t.Error("expected error when master key is not set")
+	}
+}
+
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		value string
+		name  string
+		ok    bool
+	}{
+		{"secret://tc-key", "tc-key", true},
+		{"secret://", "", false},
+		{"Bearer secret://tc-key", "", false},
+		{"plain", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := ParseSecretRef(tt.value)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("ParseSecretRef(%q) = %q, %v; want %q, %v", tt.value, name, ok, tt.name, tt.ok)
+		}
+	}
+	if fmt.Sprintf("%v", SecretRef{Name: "tc-key"}) != "secret://tc-key" {
+		t.Error("unresolved SecretRef should only print the reference")
+	}
+}
+
+// createTestSecret 写入加密后的密钥
+func createTestSecret(t *testing.T, name, value string) {
+	encrypted, err := EncryptSecret(value)
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if err := database.GetDB().Create(&models.Secret{Name: name, Value: encrypted}).Error; err != nil {
+		t.Fatalf("create secret failed: %v", err)
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "test-master-key")
+	database.InitDatabase(":memory:")
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM secrets") })
+	createTestSecret(t, "api-token", "token-123")
+
+	params := []models.InterfaceParameter{
+		{Name: "Authorization", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("secret://api-token")},
+		{Name: "note", Type: "string", Location: "body", Group: "input"},
+	}
+	// 调用方传入的 secret:// 字符串不会被解析
+	arranged, err := rearrangeParametersAndValidate(map[string]any{"note": "secret://api-token"}, params, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := arranged.HeaderParams["Authorization"].(SecretRef); !ok {
+		t.Fatalf("fixed secret should stay unresolved until DoRequest, got %v", arranged.HeaderParams["Authorization"])
+	}
+
+	resolved, err := ResolveSecrets(context.Background(), *arranged)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if resolved.HeaderParams["Authorization"] != "token-123" {
+		t.Errorf("expected resolved secret, got %v", resolved.HeaderParams["Authorization"])
+	}
+	if resolved.BodyParams["note"] != "secret://api-token" {
+		t.Errorf("input value should not be resolved, got %v", resolved.BodyParams["note"])
+	}
+	if _, ok := arranged.HeaderParams["Authorization"].(SecretRef); !ok {
+		t.Error("ResolveSecrets should not modify the cached parameters")
+	}
+
+	if _, err := ResolveSecrets(context.Background(), Parameters{QueryParams: map[string]any{"k": SecretRef{Name: "missing"}}}); err == nil {
+		t.Error("expected error for missing secret")
+	}
+
+	value, err := ResolveSecretValue("secret://api-token")
+	if err != nil || value != "token-123" {
+		t.Errorf("expected token-123, got %q (%v)", value, err)
+	}
+	value, _ = ResolveSecretValue("plain")
+	if value != "plain" {
+		t.Errorf("plain value should be returned as is, got %q", value)
+	}
+}
+
+func TestHTTPSimpleAdapterResolvesSecrets(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "test-master-key")
+	database.InitDatabase(":memory:")
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM secrets") })
+	createTestSecret(t, "upstream-key", "key-456")
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("X-Api-Key")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	_, err := HTTPSimpleAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{},
+		Parameters{HeaderParams: map[string]any{"X-Api-Key": SecretRef{Name: "upstream-key"}}},
+		RequestMeta{URL: server.URL, Method: http.MethodGet, Protocol: "http", AuthType: "none"})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if received != "key-456" {
+		t.Errorf("expected upstream to receive decrypted secret, got %q", received)
+	}
+}
+
+func TestHTTPSimpleAdapterResolvesEnvironmentSecrets(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "test-master-key")
+	database.InitDatabase(":memory:")
+	t.Cleanup(func() { database.GetDB().Exec("DELETE FROM secrets") })
+	createTestSecret(t, "path-token", "tok-789")
+	createTestSecret(t, "header-key", "key-456")
+
+	var path, received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		received = r.Header.Get("X-Api-Key")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	env := map[string]string{"BASE_URL": server.URL, "TOKEN": "secret://path-token", "API_KEY": "secret://header-key"}
+	params := []models.InterfaceParameter{{Name: "X-Api-Key", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("${API_KEY}")}}
+	arranged, err := rearrangeParametersAndValidate(map[string]any{}, params, env)
+	if err != nil {
+		t.Fatalf("rearrange failed: %v", err)
+	}
+	if ref, ok := arranged.HeaderParams["X-Api-Key"].(SecretRef); !ok || ref.Name != "header-key" {
+		t.Fatalf("expected fixed parameter to become a secret ref, got %v", arranged.HeaderParams["X-Api-Key"])
+	}
+
+	_, err = HTTPSimpleAdapter{}.DoRequest(context.Background(), mcp.CallToolRequest{}, *arranged,
+		RequestMeta{URL: "${BASE_URL}/tokens/${TOKEN}", Method: http.MethodGet, Protocol: "http", AuthType: "none", Env: env})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if path != "/tokens/tok-789" || received != "key-456" {
+		t.Errorf("expected environment secrets to be resolved, got path %q and header %q", path, received)
+	}
+}
@@ -0,0 +1,559 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/mark3labs/mcp-go/mcp"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	defaultSQLMaxRows = 100
+	maxSQLMaxRows     = 10000
+	defaultSQLTimeout = 10 * time.Second
+	sqlMaxOpenConns   = 10
+)
+
+// sqlDrivers 数据源驱动 -> database/sql 注册的驱动名
+var sqlDrivers = map[string]string{
+	"sqlite": "sqlite",
+	"mysql":  "mysql",
+}
+
+// forbiddenSQLKeywords 只读查询中不允许出现的关键字，字符串、引号标识符和注释中的内容不检查。
+// 这只是保存时的检查，执行时 sqlite 连接设置 query_only，mysql 使用只读事务
+var forbiddenSQLKeywords = map[string]bool{
+	"insert": true, "update": true, "delete": true, "replace": true, "merge": true, "upsert": true,
+	"create": true, "drop": true, "alter": true, "truncate": true, "rename": true,
+	"grant": true, "revoke": true, "attach": true, "detach": true, "pragma": true, "vacuum": true,
+	"reindex": true, "analyze": true, "lock": true, "unlock": true, "call": true, "handler": true, "into": true,
+}
+
+// parseSQLURL 解析 sql://datasource 格式的地址，返回数据源名称
+func parseSQLURL(raw string) (string, error) {
+	name, ok := strings.CutPrefix(raw, "sql://")
+	if !ok || name == "" || strings.ContainsAny(name, "/?#") {
+		return "", errors.New("sql url must be sql://datasource")
+	}
+	return name, nil
+}
+
+// SQLDatasourceName 返回 sql 接口地址引用的数据源名称，地址使用环境变量时返回 false
+func SQLDatasourceName(rawURL string) (string, bool) {
+	name, err := parseSQLURL(rawURL)
+	if err != nil || strings.Contains(name, "${") {
+		return "", false
+	}
+	return name, true
+}
+
+func datasourceTimeout(ds models.Datasource) (time.Duration, error) {
+	if ds.Timeout == "" {
+		return defaultSQLTimeout, nil
+	}
+	d, err := time.ParseDuration(ds.Timeout)
+	if err != nil || d <= 0 {
+		return 0, errors.New("datasource timeout must be a positive duration such as 500ms or 10s")
+	}
+	return d, nil
+}
+
+// ValidateDatasource 校验数据源的驱动、连接串、行数限制和超时
+func ValidateDatasource(ds models.Datasource) error {
+	if _, ok := sqlDrivers[ds.Driver]; !ok {
+		return fmt.Errorf("unsupported datasource driver %s", ds.Driver)
+	}
+	if strings.TrimSpace(ds.DSN) == "" {
+		return errors.New("datasource dsn is required")
+	}
+	if ds.MaxRows < 0 || ds.MaxRows > maxSQLMaxRows {
+		return fmt.Errorf("datasource max_rows must be between 0 and %d", maxSQLMaxRows)
+	}
+	_, err := datasourceTimeout(ds)
+	return err
+}
+
+// sqlStatement 编译后的语句，parts 之间依次是 names 中的占位符
+type sqlStatement struct {
+	parts []string
+	names []string
+}
+
+func isSQLIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSQLIdentChar(c byte) bool {
+	return isSQLIdentStart(c) || (c >= '0' && c <= '9') || c == '$'
+}
+
+// skipSQLQuoted 返回引号内容结束后的位置，未闭合时返回 -1。连续两个引号表示引号本身，
+// 字符串中的反斜杠转义按 mysql 处理
+func skipSQLQuoted(s string, i int) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch {
+		case s[j] == '\\' && q != '`':
+			j++
+		case s[j] == q:
+			if j+1 < len(s) && s[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return -1
+}
+
+// compileSQL 检查语句是单条只读查询 (SELECT 或 WITH 开头)，把 :name 占位符拆分出来。
+// 不支持 ? 占位符，参数值只通过驱动绑定，不拼接到语句中
+func compileSQL(statement string) (*sqlStatement, error) {
+	s := strings.TrimSpace(statement)
+	if s == "" {
+		return nil, errors.New("sql interfaces require a query")
+	}
+	st := &sqlStatement{}
+	var part strings.Builder
+	first := ""
+	ended := false // 已遇到语句末尾的分号，之后只能有空白和注释
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			j := strings.IndexByte(s[i:], '\n')
+			if j < 0 {
+				j = len(s)
+			} else {
+				j += i
+			}
+			part.WriteString(s[i:j])
+			i = j
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			j := strings.Index(s[i+2:], "*/")
+			if j < 0 {
+				return nil, errors.New("unterminated comment in sql query")
+			}
+			j += i + 4
+			part.WriteString(s[i:j])
+			i = j
+		case ended && c != ';' && c != ' ' && c != '\t' && c != '\r' && c != '\n':
+			return nil, errors.New("sql query must contain a single statement")
+		case c == ';':
+			ended = true
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			j := skipSQLQuoted(s, i)
+			if j < 0 {
+				return nil, errors.New("unterminated quoted string in sql query")
+			}
+			part.WriteString(s[i:j])
+			i = j
+		case c == '?':
+			return nil, errors.New("sql query must use named placeholders such as :id instead of ?")
+		case c == ':' && i+1 < len(s) && isSQLIdentStart(s[i+1]) && (i == 0 || s[i-1] != ':'):
+			j := i + 1
+			for j < len(s) && isSQLIdentChar(s[j]) {
+				j++
+			}
+			st.parts = append(st.parts, part.String())
+			st.names = append(st.names, s[i+1:j])
+			part.Reset()
+			i = j
+		case isSQLIdentStart(c):
+			j := i
+			for j < len(s) && isSQLIdentChar(s[j]) {
+				j++
+			}
+			word := strings.ToLower(s[i:j])
+			if first == "" {
+				first = word
+			}
+			// replace(...) 是字符串函数
+			isFunction := strings.HasPrefix(strings.TrimLeft(s[j:], " \t\r\n"), "(")
+			if forbiddenSQLKeywords[word] && !(word == "replace" && isFunction) {
+				return nil, fmt.Errorf("sql query must be read-only, %s is not allowed", strings.ToUpper(word))
+			}
+			part.WriteString(s[i:j])
+			i = j
+		default:
+			part.WriteByte(c)
+			i++
+		}
+	}
+	if first != "select" && first != "with" {
+		return nil, errors.New("sql query must start with SELECT or WITH")
+	}
+	st.parts = append(st.parts, part.String())
+	return st, nil
+}
+
+// sqlArg 将参数值转换为驱动参数，整数值的 number 按整数绑定
+func sqlArg(name string, v any) (any, error) {
+	switch x := v.(type) {
+	case nil, string, bool, int, int64:
+		return x, nil
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			return int64(x), nil
+		}
+		return x, nil
+	}
+	return nil, fmt.Errorf("sql parameter %s must be a string, number or boolean", name)
+}
+
+// bind 生成使用 ? 占位符的语句和参数，数组参数展开为多个占位符 (用于 IN (:ids))，空数组绑定为 NULL，
+// 缺少的参数绑定为 NULL
+func (st *sqlStatement) bind(values map[string]any) (string, []any, error) {
+	var b strings.Builder
+	args := make([]any, 0, len(st.names))
+	for i, name := range st.names {
+		b.WriteString(st.parts[i])
+		items, isList := values[name].([]any)
+		if !isList {
+			items = []any{values[name]}
+		}
+		if len(items) == 0 {
+			b.WriteString("NULL")
+			continue
+		}
+		for j, item := range items {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			arg, err := sqlArg(name, item)
+			if err != nil {
+				return "", nil, err
+			}
+			b.WriteString("?")
+			args = append(args, arg)
+		}
+	}
+	b.WriteString(st.parts[len(st.names)])
+	return b.String(), args, nil
+}
+
+// ValidateSQLInterface 校验 sql 接口：地址为 sql://datasource，语句是单条只读查询，
+// 占位符和 body 参数一一对应，入参只能是基础类型
+func ValidateSQLInterface(rawURL, query string, params []models.InterfaceParameter) error {
+	if _, err := parseSQLURL(rawURL); err != nil {
+		return err
+	}
+	st, err := compileSQL(query)
+	if err != nil {
+		return err
+	}
+	body := make(map[string]bool)
+	for _, p := range params {
+		if p.Group == "output" {
+			continue
+		}
+		if p.Location != "body" {
+			return fmt.Errorf("sql interfaces do not support %s parameter %s", p.Location, p.Name)
+		}
+		if p.Type == "custom" {
+			return fmt.Errorf("sql parameter %s must be a number, string or boolean", p.Name)
+		}
+		body[p.Name] = true
+	}
+	used := make(map[string]bool)
+	for _, name := range st.names {
+		if !body[name] {
+			return fmt.Errorf("placeholder :%s has no matching body parameter", name)
+		}
+		used[name] = true
+	}
+	for _, p := range params {
+		if p.Group != "output" && !used[p.Name] {
+			return fmt.Errorf("parameter %s is not used in the sql query", p.Name)
+		}
+	}
+	return nil
+}
+
+// sqlRowColumns 取出参 rows 数组元素类型的字段类型，未定义时返回 nil，结果保留驱动返回的所有列
+func sqlRowColumns(outputSchema map[string]any) map[string]string {
+	props, _ := outputSchema["properties"].(map[string]any)
+	rows, _ := props["rows"].(map[string]any)
+	items, _ := rows["items"].(map[string]any)
+	fields, _ := items["properties"].(map[string]any)
+	if len(fields) == 0 {
+		return nil
+	}
+	columns := make(map[string]string, len(fields))
+	for name, f := range fields {
+		field, _ := f.(map[string]any)
+		columns[name], _ = field["type"].(string)
+	}
+	return columns
+}
+
+// sqlValue 将驱动返回的值转换为出参类型，typ 为空时只做 JSON 兼容的转换
+func sqlValue(v any, typ string) (any, error) {
+	switch x := v.(type) {
+	case []byte:
+		v = string(x)
+	case time.Time:
+		v = x.Format(time.RFC3339Nano)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch typ {
+	case "string":
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case int64:
+			return strconv.FormatInt(x, 10), nil
+		case float64:
+			return strconv.FormatFloat(x, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(x), nil
+		}
+	case "number":
+		switch x := v.(type) {
+		case int64, float64:
+			return x, nil
+		case bool:
+			if x {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case string:
+			// 保留 DECIMAL 和大整数的精度
+			if _, err := strconv.ParseFloat(x, 64); err == nil {
+				return json.Number(x), nil
+			}
+		}
+	case "boolean":
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case int64:
+			return x != 0, nil
+		case float64:
+			return x != 0, nil
+		case string:
+			if b, err := strconv.ParseBool(x); err == nil {
+				return b, nil
+			}
+		}
+	case "object", "array":
+		// JSON 列以文本返回
+		if s, ok := v.(string); ok {
+			var parsed any
+			if err := json.Unmarshal([]byte(s), &parsed); err == nil {
+				return parsed, nil
+			}
+		} else {
+			return v, nil
+		}
+	default:
+		return v, nil
+	}
+	return nil, fmt.Errorf("cannot convert %v to %s", v, typ)
+}
+
+// lookupDatasource 按名称读取数据源，修改数据源不需要重新注册工具
+func lookupDatasource(name string) (models.Datasource, error) {
+	var ds models.Datasource
+	if err := database.GetDB().Where("name = ?", name).First(&ds).Error; err != nil {
+		return ds, fmt.Errorf("datasource %s not found", name)
+	}
+	return ds, nil
+}
+
+type sqlPool struct {
+	db  *sql.DB
+	key string
+}
+
+var (
+	sqlPools   = make(map[string]*sqlPool)
+	sqlPoolsMu sync.Mutex
+)
+
+// sqliteQueryOnly sqlite 连接设置 query_only，拒绝任何写入
+func sqliteQueryOnly(dsn string) string {
+	if strings.Contains(dsn, "?") {
+		return dsn + "&_pragma=query_only(1)"
+	}
+	return dsn + "?_pragma=query_only(1)"
+}
+
+// sqlPoolFor 按数据源名称复用连接池，驱动或连接串 (包括引用的密钥) 变化时重建
+func sqlPoolFor(ds models.Datasource) (*sql.DB, error) {
+	dsn, err := ResolveSecretValue(ds.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if ds.Driver == "sqlite" {
+		dsn = sqliteQueryOnly(dsn)
+	}
+	sum := sha256.Sum256([]byte(ds.Driver + "\x00" + dsn))
+	key := hex.EncodeToString(sum[:])
+
+	sqlPoolsMu.Lock()
+	defer sqlPoolsMu.Unlock()
+	if pool, ok := sqlPools[ds.Name]; ok {
+		if pool.key == key {
+			return pool.db, nil
+		}
+		// Close 会等待进行中的查询结束
+		go pool.db.Close()
+		delete(sqlPools, ds.Name)
+	}
+	driver, ok := sqlDrivers[ds.Driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported datasource driver %s", ds.Driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("datasource %s: %v", ds.Name, err)
+	}
+	db.SetMaxOpenConns(sqlMaxOpenConns)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	sqlPools[ds.Name] = &sqlPool{db: db, key: key}
+	return db, nil
+}
+
+// runSQLQuery 在只读事务中执行查询，最多读取 maxRows 行，超出时 truncated 为 true
+func runSQLQuery(ctx context.Context, cancel context.CancelFunc, db *sql.DB, query string, args []any, maxRows int, columns map[string]string) ([]byte, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(names))
+	dest := make([]any, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	result := make([]map[string]any, 0)
+	truncated := false
+	for rows.Next() {
+		if len(result) == maxRows {
+			// 取消查询，不等待驱动读完剩余的结果
+			truncated = true
+			cancel()
+			break
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(names))
+		for i, name := range names {
+			typ, declared := columns[name]
+			if columns != nil && !declared {
+				continue
+			}
+			v, err := sqlValue(values[i], typ)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", name, err)
+			}
+			row[name] = v
+		}
+		result = append(result, row)
+	}
+	if !truncated {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(map[string]any{"rows": result, "truncated": truncated})
+}
+
+// SQLAdapter 处理 sql 协议的接口：在数据源上执行只读查询，body 参数绑定到 :name 占位符，
+// 返回 {"rows": [...], "truncated": false}
+type SQLAdapter struct {
+}
+
+func (h SQLAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
+	rawURL, err := expandCallEnvironment(ctx, meta.URL, meta.Env)
+	if err != nil {
+		return nil, err
+	}
+	name, err := parseSQLURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	st, err := compileSQL(meta.Query)
+	if err != nil {
+		return nil, err
+	}
+	parameters, err = ResolveSecrets(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := st.bind(parameters.BodyParams)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := lookupDatasource(name)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := datasourceTimeout(ds)
+	if err != nil {
+		return nil, err
+	}
+	maxRows := ds.MaxRows
+	if maxRows == 0 {
+		maxRows = defaultSQLMaxRows
+	}
+	db, err := sqlPoolFor(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	// request 只用于记录调用日志
+	request, err := http.NewRequestWithContext(ctx, meta.Method, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	recordUpstreamRequest(request, 0)
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	data, err := runSQLQuery(queryCtx, cancel, db, query, args, maxRows, meta.SQLColumns)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("sql query timed out after %s: %w", timeout, context.DeadlineExceeded)
+		} else {
+			err = fmt.Errorf("sql query failed: %w", err)
+		}
+		recordUpstreamResponse(ctx, 0, []byte(err.Error()))
+		return nil, err
+	}
+	recordUpstreamResponse(ctx, http.StatusOK, data)
+	return data, nil
+}
+
+func (h SQLAdapter) Compatible(meta RequestMeta) bool {
+	// 数据库凭证在数据源中配置
+	return meta.Protocol == "sql" && meta.AuthType == "none"
+}
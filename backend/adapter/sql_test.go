@@ -0,0 +1,274 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCompileSQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		wantQuery string
+		wantNames []string
+		wantErr   string
+	}{
+		{name: "named placeholders", sql: "SELECT * FROM users WHERE id = :id AND role = :role;", wantQuery: "SELECT * FROM users WHERE id = ? AND role = ?", wantNames: []string{"id", "role"}},
+		{name: "literals and comments", sql: "-- :skipped\nSELECT ':a', \"b:c\", `d:e` /* :f; drop */ FROM t WHERE x = :x", wantQuery: "-- :skipped\nSELECT ':a', \"b:c\", `d:e` /* :f; drop */ FROM t WHERE x = ?", wantNames: []string{"x"}},
+		{name: "escaped quotes", sql: `SELECT 'it''s', 'a\'b' WHERE y = :y`, wantQuery: `SELECT 'it''s', 'a\'b' WHERE y = ?`, wantNames: []string{"y"}},
+		{name: "cte and replace function", sql: "WITH recent AS (SELECT * FROM orders) SELECT replace(name, 'a', 'b'), deleted_at FROM recent", wantQuery: "WITH recent AS (SELECT * FROM orders) SELECT replace(name, 'a', 'b'), deleted_at FROM recent"},
+		{name: "empty", sql: "  ", wantErr: "sql interfaces require a query"},
+		{name: "not a select", sql: "UPDATE users SET name = :name", wantErr: "UPDATE is not allowed"},
+		{name: "explain", sql: "EXPLAIN SELECT 1", wantErr: "must start with SELECT or WITH"},
+		{name: "multiple statements", sql: "SELECT 1; SELECT 2", wantErr: "single statement"},
+		{name: "write in cte", sql: "WITH x AS (SELECT 1) DELETE FROM users", wantErr: "DELETE is not allowed"},
+		{name: "select into", sql: "SELECT * INTO OUTFILE '/tmp/x' FROM users", wantErr: "INTO is not allowed"},
+		{name: "positional placeholder", sql: "SELECT * FROM users WHERE id = ?", wantErr: "named placeholders"},
+		{name: "unterminated string", sql: "SELECT 'abc", wantErr: "unterminated quoted string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := compileSQL(tt.sql)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, _, err := st.bind(map[string]any{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(query) != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if len(st.names) != len(tt.wantNames) || (len(st.names) > 0 && !reflect.DeepEqual(st.names, tt.wantNames)) {
+				t.Errorf("names = %v, want %v", st.names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestSQLStatementBind(t *testing.T) {
+	st, err := compileSQL("SELECT * FROM users WHERE id IN (:ids) AND active = :active AND score > :score AND name = :name AND team = :team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, args, err := st.bind(map[string]any{"ids": []any{float64(1), float64(2)}, "active": true, "score": 1.5, "name": "ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT * FROM users WHERE id IN (?, ?) AND active = ? AND score > ? AND name = ? AND team = ?" {
+		t.Errorf("unexpected query %s", query)
+	}
+	if want := []any{int64(1), int64(2), true, 1.5, "ada", nil}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+	query, args, _ = st.bind(map[string]any{"ids": []any{}})
+	if !strings.HasPrefix(query, "SELECT * FROM users WHERE id IN (NULL)") || len(args) != 4 {
+		t.Errorf("unexpected query %s with %d args", query, len(args))
+	}
+	if _, _, err := st.bind(map[string]any{"name": map[string]any{"a": 1}}); err == nil {
+		t.Error("expected error for object parameter")
+	}
+}
+
+func TestValidateSQLInterface(t *testing.T) {
+	body := func(name string) models.InterfaceParameter {
+		return models.InterfaceParameter{Name: name, Type: "string", Location: "body", Group: "input"}
+	}
+	query := "SELECT id, name FROM users WHERE team = :team"
+	tests := []struct {
+		name    string
+		url     string
+		params  []models.InterfaceParameter
+		wantErr string
+	}{
+		{name: "valid", url: "sql://reporting", params: []models.InterfaceParameter{body("team"), {Name: "rows", Type: "custom", Location: "body", Group: "output"}}},
+		{name: "environment", url: "sql://${DATASOURCE}", params: []models.InterfaceParameter{body("team")}},
+		{name: "http url", url: "https://reporting", params: []models.InterfaceParameter{body("team")}, wantErr: "sql url must be sql://datasource"},
+		{name: "path in url", url: "sql://reporting/users", params: []models.InterfaceParameter{body("team")}, wantErr: "sql url must be sql://datasource"},
+		{name: "missing parameter", url: "sql://reporting", wantErr: "placeholder :team has no matching body parameter"},
+		{name: "unused parameter", url: "sql://reporting", params: []models.InterfaceParameter{body("team"), body("role")}, wantErr: "parameter role is not used"},
+		{name: "query parameter", url: "sql://reporting", params: []models.InterfaceParameter{{Name: "team", Type: "string", Location: "query", Group: "input"}}, wantErr: "do not support query parameter team"},
+		{name: "custom parameter", url: "sql://reporting", params: []models.InterfaceParameter{{Name: "team", Type: "custom", Location: "body", Group: "input"}}, wantErr: "must be a number, string or boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSQLInterface(tt.url, query, tt.params)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// setupTestDatasource 创建 sqlite 数据源并写入 users 表
+func setupTestDatasource(t *testing.T, name string, maxRows int, timeout string) string {
+	path := filepath.Join(t.TempDir(), name+".db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, team TEXT, score REAL, active INTEGER, tags TEXT, created_at TEXT)",
+		`INSERT INTO users VALUES (1, 'Ada', 'core', 9.5, 1, '["admin"]', '2024-01-01T00:00:00Z'), (2, 'Bob', 'core', 7, 0, '[]', NULL), (3, 'Cy', 'web', 8, 1, '[]', NULL)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds := models.Datasource{Name: name, Driver: "sqlite", DSN: path, MaxRows: maxRows, Timeout: timeout}
+	if err := database.GetDB().Create(&ds).Error; err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSQLAdapterDoRequest(t *testing.T) {
+	database.InitDatabase(":memory:")
+	setupTestDatasource(t, "reporting", 2, "")
+	setupTestDatasource(t, "slow", 0, "50ms")
+
+	meta := RequestMeta{URL: "sql://${DATASOURCE}", Method: "POST", AuthType: "none", Protocol: "sql", Env: map[string]string{"DATASOURCE": "reporting"},
+		Query: "SELECT id, name, score, active, tags, created_at FROM users WHERE team = :team ORDER BY id"}
+	h := SQLAdapter{}
+	if !h.Compatible(meta) || (HTTPSimpleAdapter{}).Compatible(meta) {
+		t.Fatal("expected only the sql handle to accept sql interfaces")
+	}
+	if h.Compatible(RequestMeta{Protocol: "sql", AuthType: "bearer"}) {
+		t.Error("sql handle must not accept auth")
+	}
+	run := func(meta RequestMeta, body map[string]any) (map[string]any, error) {
+		data, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{BodyParams: body}, meta)
+		if err != nil {
+			return nil, err
+		}
+		var result map[string]any
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatal(err)
+		}
+		return result, nil
+	}
+
+	result, err := run(meta, map[string]any{"team": "core"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := result["rows"].([]any)
+	if len(rows) != 2 || result["truncated"] != false {
+		t.Fatalf("unexpected result %v", result)
+	}
+	if first := rows[0].(map[string]any); first["name"] != "Ada" || first["score"] != 9.5 || first["active"] != float64(1) || first["tags"] != `["admin"]` {
+		t.Errorf("unexpected row %v", first)
+	}
+
+	// 出参类型决定列的转换和保留的列
+	typed := meta
+	typed.Query = "SELECT id, name, score, active, tags FROM users ORDER BY id"
+	typed.SQLColumns = map[string]string{"id": "string", "active": "boolean", "tags": "array", "missing": "string"}
+	result, err = run(typed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["truncated"] != true || len(result["rows"].([]any)) != 2 {
+		t.Fatalf("expected 2 rows truncated by max_rows, got %v", result)
+	}
+	want := map[string]any{"id": "1", "active": true, "tags": []any{"admin"}}
+	if first := result["rows"].([]any)[0]; !reflect.DeepEqual(first, want) {
+		t.Errorf("row = %v, want %v", first, want)
+	}
+
+	invalid := typed
+	invalid.SQLColumns = map[string]string{"name": "number"}
+	if _, err := run(invalid, nil); err == nil || !strings.Contains(err.Error(), "column name: cannot convert Ada to number") {
+		t.Errorf("expected conversion error, got %v", err)
+	}
+
+	slow := meta
+	slow.Env = map[string]string{"DATASOURCE": "slow"}
+	slow.Query = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+	if _, err := run(slow, nil); err == nil || !strings.Contains(err.Error(), "timed out after 50ms") || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+
+	missing := meta
+	missing.Env = map[string]string{"DATASOURCE": "unknown"}
+	if _, err := run(missing, map[string]any{"team": "core"}); err == nil || err.Error() != "datasource unknown not found" {
+		t.Errorf("expected missing datasource error, got %v", err)
+	}
+
+	// sqlite 数据源的连接是只读的
+	var ds models.Datasource
+	database.GetDB().Where("name = ?", "reporting").First(&ds)
+	db, err := sqlPoolFor(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("DELETE FROM users"); err == nil {
+		t.Error("expected write to fail on a query_only connection")
+	}
+}
+
+func TestAddToolSQL(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+	sm.handles = append(sm.handles, SQLAdapter{})
+	setupTestDatasource(t, "analytics", 0, "")
+
+	app := models.Application{Name: "SQL", Path: "sql-app", Protocol: "sse"}
+	createWithEnabled(t, &app, true)
+	row := models.CustomType{AppID: app.ID, Name: "UserRow"}
+	database.GetDB().Create(&row)
+	database.GetDB().Create(&[]models.CustomTypeField{
+		{AppID: app.ID, CustomTypeID: row.ID, Name: "id", Type: "number"},
+		{AppID: app.ID, CustomTypeID: row.ID, Name: "name", Type: "string"},
+		{AppID: app.ID, CustomTypeID: row.ID, Name: "active", Type: "boolean"},
+	})
+	iface := models.Interface{AppID: app.ID, Name: "team_members", Protocol: "sql", URL: "sql://analytics", Method: "POST", AuthType: "none",
+		Query: "SELECT id, name, active, score FROM users WHERE team IN (:teams) ORDER BY id"}
+	createWithEnabled(t, &iface, true)
+	database.GetDB().Create(&[]models.InterfaceParameter{
+		{AppID: app.ID, InterfaceID: iface.ID, Name: "teams", Type: "string", IsArray: true, Location: "body", Required: true, Group: "input"},
+		{AppID: app.ID, InterfaceID: iface.ID, Name: "rows", Type: "custom", Ref: &row.ID, IsArray: true, Location: "body", Group: "output"},
+	})
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatal(err)
+	}
+	defer sm.removeApplication(&app)
+
+	s, _ := sm.sseServers.Load("sql-app")
+	tool := s.(*Server).server.GetTool("team_members")
+	if tool == nil {
+		t.Fatal("tool team_members not registered")
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"teams": []any{"core", "web"}}
+	result, err := tool.Handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError || !strings.Contains(text, `{"active":true,"id":1,"name":"Ada"}`) || strings.Contains(text, "score") {
+		t.Errorf("unexpected result %s", text)
+	}
+}
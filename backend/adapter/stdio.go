@@ -0,0 +1,414 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	stdioStartTimeout = time.Minute      // 启动并完成 initialize 的超时，npx 等命令首次运行需要下载
+	stdioCloseTimeout = 2 * time.Second  // 关闭标准输入后等待子进程退出的时间
+	stdioIdleTimeout  = 10 * time.Minute // 空闲超过该时间的子进程会被关闭，接口修改启动参数后旧进程也由此回收
+)
+
+// parseStdioURL 解析 stdio://command/tool 格式的地址，导入时的地址可以不带工具
+func parseStdioURL(raw string) (name, tool string, err error) {
+	rest, ok := strings.CutPrefix(raw, "stdio://")
+	if !ok {
+		return "", "", errors.New("mcp url must be stdio://command/tool")
+	}
+	name, escaped, _ := strings.Cut(rest, "/")
+	if !commandNamePattern.MatchString(name) {
+		return "", "", errors.New("mcp url must be stdio://command/tool")
+	}
+	if tool, err = url.PathUnescape(escaped); err != nil {
+		return "", "", fmt.Errorf("invalid tool name in mcp url: %v", err)
+	}
+	return name, tool, nil
+}
+
+// StdioToolURL 生成代理工具的地址，工具名中的特殊字符会被转义
+func StdioToolURL(command, tool string) string {
+	return "stdio://" + command + "/" + url.PathEscape(tool)
+}
+
+// parseStdioArgs 启动参数每行一个，空行忽略，不支持模板
+func parseStdioArgs(raw string) []string {
+	args := make([]string, 0)
+	for _, line := range strings.Split(raw, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			args = append(args, line)
+		}
+	}
+	return args
+}
+
+// ValidateMCPInterface 校验 mcp 接口的地址和参数位置，地址不使用环境变量时检查命令在允许列表中
+func ValidateMCPInterface(rawURL string, params []models.InterfaceParameter) error {
+	if !strings.Contains(rawURL, "${") {
+		name, tool, err := parseStdioURL(rawURL)
+		if err != nil {
+			return err
+		}
+		if tool == "" {
+			return errors.New("mcp url must be stdio://command/tool")
+		}
+		if _, err := LookupCommand(name); err != nil {
+			return err
+		}
+	}
+	return checkLocalProcessParams("mcp", params)
+}
+
+// stdioServer 常驻的 stdio MCP 服务器子进程，相同命令、参数和环境变量的接口共用一个进程
+type stdioServer struct {
+	name   string
+	ready  chan struct{} // 启动完成后关闭，err 为启动结果
+	err    error
+	client *client.Client
+	cancel context.CancelFunc
+	stderr *tailBuffer
+	exited chan struct{} // 子进程的标准错误输出关闭，视为已退出
+	used   atomic.Int64  // 最近一次使用的时间 (UnixNano)
+}
+
+var (
+	stdioServers   = make(map[string]*stdioServer)
+	stdioServersMu sync.Mutex
+)
+
+// start 启动子进程并完成 initialize，不使用调用方的 context，调用方取消不会中断启动
+func (s *stdioServer) start(path string, args, env []string) {
+	defer close(s.ready)
+	procCtx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	cmdFunc := func(_ context.Context, command string, env []string, args []string) (*exec.Cmd, error) {
+		cmd := exec.CommandContext(procCtx, command, args...)
+		cmd.Env = env
+		cmd.WaitDelay = stdioCloseTimeout
+		return cmd, nil
+	}
+	c, err := client.NewStdioMCPClientWithOptions(path, env, args, transport.WithCommandFunc(cmdFunc))
+	if err != nil {
+		cancel()
+		s.err = fmt.Errorf("start mcp server %s failed: %w", s.name, err)
+		return
+	}
+	s.client = c
+	if stderr, ok := client.GetStderr(c); ok {
+		go func() {
+			// 必须持续读取，否则子进程写满管道后会阻塞
+			io.Copy(s.stderr, stderr)
+			close(s.exited)
+		}()
+	} else {
+		close(s.exited)
+	}
+	ctx, cancelInit := context.WithTimeout(procCtx, stdioStartTimeout)
+	defer cancelInit()
+	initReq := mcp.InitializeRequest{}
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initReq.Params.ClientInfo = mcp.Implementation{Name: "mcp-adapter", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initReq); err != nil {
+		s.err = s.failure(fmt.Errorf("initialize mcp server %s failed: %w", s.name, err))
+		s.close()
+	}
+}
+
+// failure 子进程已退出时在错误中附带标准错误输出
+func (s *stdioServer) failure(err error) error {
+	select {
+	case <-s.exited:
+		if msg := s.stderr.String(); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+	default:
+	}
+	return err
+}
+
+func (s *stdioServer) touch() {
+	s.used.Store(time.Now().UnixNano())
+}
+
+func (s *stdioServer) alive() bool {
+	select {
+	case <-s.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// callTool 调用工具，子进程退出时立即结束等待，不等到超时
+func (s *stdioServer) callTool(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.exited:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	result, err := s.client.CallTool(ctx, req)
+	s.touch()
+	if err != nil && !s.alive() {
+		return nil, s.failure(fmt.Errorf("mcp server %s exited", s.name))
+	}
+	return result, err
+}
+
+// close 关闭标准输入等待子进程退出，超时后强制结束
+func (s *stdioServer) close() {
+	done := make(chan struct{})
+	go func() {
+		s.client.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(stdioCloseTimeout):
+	}
+	s.cancel()
+}
+
+// stdioServerFor 返回已启动的服务器，不存在或已退出时启动新的子进程
+func stdioServerFor(ctx context.Context, name, path string, args, env []string) (*stdioServer, error) {
+	sum := sha256.Sum256([]byte(strings.Join(append(append([]string{path}, args...), env...), "\x00")))
+	key := hex.EncodeToString(sum[:])
+
+	stdioServersMu.Lock()
+	s, ok := stdioServers[key]
+	if ok {
+		select {
+		case <-s.ready:
+			if s.err != nil || !s.alive() {
+				if s.err == nil {
+					go s.close()
+				}
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		s = &stdioServer{name: name, ready: make(chan struct{}), stderr: &tailBuffer{limit: maxCommandStderr}, exited: make(chan struct{})}
+		stdioServers[key] = s
+		go s.start(path, args, env)
+	}
+	s.touch()
+	stdioServersMu.Unlock()
+
+	select {
+	case <-s.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s, nil
+}
+
+// CloseStdioServers 关闭所有 stdio MCP 服务器子进程
+func CloseStdioServers() {
+	stdioServersMu.Lock()
+	servers := stdioServers
+	stdioServers = make(map[string]*stdioServer)
+	stdioServersMu.Unlock()
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Add(1)
+		go func(s *stdioServer) {
+			defer wg.Done()
+			<-s.ready
+			if s.err == nil {
+				s.close()
+			}
+		}(s)
+	}
+	wg.Wait()
+	if len(servers) > 0 {
+		log.Printf("Closed %d stdio mcp servers", len(servers))
+	}
+}
+
+// closeIdleStdioServers 关闭空闲或已退出的子进程，返回关闭的数量
+func closeIdleStdioServers(now time.Time) int {
+	stdioServersMu.Lock()
+	idle := make([]*stdioServer, 0)
+	for key, s := range stdioServers {
+		select {
+		case <-s.ready:
+		default:
+			continue
+		}
+		if s.err != nil || !s.alive() || now.Sub(time.Unix(0, s.used.Load())) > stdioIdleTimeout {
+			delete(stdioServers, key)
+			if s.err == nil {
+				idle = append(idle, s)
+			}
+		}
+	}
+	stdioServersMu.Unlock()
+	for _, s := range idle {
+		go s.close()
+	}
+	return len(idle)
+}
+
+// stdioIdleLoop 定时回收空闲的 stdio MCP 服务器
+func (sm *ServerManager) stdioIdleLoop() {
+	defer sm.wg.Done()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sm.ctx.Done():
+			return
+		case <-ticker.C:
+			if closed := closeIdleStdioServers(time.Now()); closed > 0 {
+				log.Printf("Closed %d idle stdio mcp servers", closed)
+			}
+		}
+	}
+}
+
+// connectStdio 按地址和启动参数获取服务器
+func connectStdio(ctx context.Context, rawURL, rawArgs string, env map[string]string) (*stdioServer, string, error) {
+	name, tool, err := parseStdioURL(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	path, err := LookupCommand(name)
+	if err != nil {
+		return nil, "", err
+	}
+	// 子进程由多次调用共享，环境变量中的密钥不属于某一次调用
+	childEnv, err := commandEnv(context.Background(), env)
+	if err != nil {
+		return nil, "", err
+	}
+	s, err := stdioServerFor(ctx, name, path, parseStdioArgs(rawArgs), childEnv)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, tool, nil
+}
+
+// ListStdioTools 启动 stdio MCP 服务器并列出其工具，用于导入
+func ListStdioTools(ctx context.Context, command string, args []string, env map[string]string) ([]mcp.Tool, error) {
+	s, _, err := connectStdio(ctx, "stdio://"+command, strings.Join(args, "\n"), env)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.client.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return nil, s.failure(fmt.Errorf("list tools of mcp server %s failed: %w", command, err))
+	}
+	return result.Tools, nil
+}
+
+// mcpToolResult 结构化结果优先，其次拼接文本内容，包含图片等其他内容时返回完整的 content 数组
+func mcpToolResult(result *mcp.CallToolResult) ([]byte, error) {
+	if result.StructuredContent != nil {
+		return json.Marshal(result.StructuredContent)
+	}
+	texts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			return json.Marshal(result.Content)
+		}
+		texts = append(texts, text.Text)
+	}
+	return []byte(strings.Join(texts, "\n")), nil
+}
+
+// MCPAdapter 处理 mcp 协议的接口：把调用转发给 stdio MCP 服务器子进程中的同名工具，
+// body 参数作为工具参数，工具返回 isError 时作为工具错误返回
+type MCPAdapter struct {
+}
+
+func (h MCPAdapter) DoRequest(ctx context.Context, req mcp.CallToolRequest, parameters Parameters, meta RequestMeta) ([]byte, error) {
+	rawURL, err := expandCallEnvironment(ctx, meta.URL, meta.Env)
+	if err != nil {
+		return nil, err
+	}
+	if _, tool, err := parseStdioURL(rawURL); err != nil {
+		return nil, err
+	} else if tool == "" {
+		return nil, errors.New("mcp url must be stdio://command/tool")
+	}
+	parameters, err = ResolveSecrets(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+	timeout := commandTimeout(meta.HTTPConfig)
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	s, tool, err := connectStdio(callCtx, rawURL, meta.Query, meta.Env)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("mcp server did not start within %s: %w", timeout, context.DeadlineExceeded)
+		}
+		return nil, err
+	}
+
+	// request 只用于记录调用日志
+	request, err := http.NewRequestWithContext(ctx, meta.Method, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	recordUpstreamRequest(request, 0)
+	callReq := mcp.CallToolRequest{}
+	callReq.Params.Name = tool
+	callReq.Params.Arguments = parameters.BodyParams
+	result, err := s.callTool(callCtx, callReq)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("mcp tool %s timed out after %s: %w", tool, timeout, context.DeadlineExceeded)
+		} else {
+			err = fmt.Errorf("call mcp tool %s failed: %w", tool, err)
+		}
+		recordUpstreamResponse(ctx, 0, []byte(err.Error()))
+		return nil, err
+	}
+	data, err := mcpToolResult(result)
+	if err != nil {
+		return nil, err
+	}
+	if result.IsError {
+		recordUpstreamResponse(ctx, 0, data)
+		if len(data) == 0 {
+			return nil, fmt.Errorf("mcp tool %s failed", tool)
+		}
+		return nil, errors.New(string(data))
+	}
+	recordUpstreamResponse(ctx, http.StatusOK, data)
+	return data, nil
+}
+
+func (h MCPAdapter) Compatible(meta RequestMeta) bool {
+	// 凭证通过应用环境变量传给子进程
+	return meta.Protocol == "mcp" && meta.AuthType == "none"
+}
@@ -0,0 +1,144 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TestHelperMCPServer 被测试以子进程启动，作为 stdio MCP 服务器
+func TestHelperMCPServer(t *testing.T) {
+	if os.Getenv("MCP_ADAPTER_TEST_STDIO_SERVER") != "1" {
+		return
+	}
+	s := server.NewMCPServer("helper", "1.0.0")
+	s.AddTool(mcp.NewTool("echo", mcp.WithString("message", mcp.Required()), mcp.WithNumber("times")),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			times := req.GetInt("times", 1)
+			return mcp.NewToolResultText(strings.Repeat(req.GetString("message", ""), times)), nil
+		})
+	s.AddTool(mcp.NewTool("add", mcp.WithNumber("a"), mcp.WithNumber("b"), mcp.WithOutputSchema[struct {
+		Sum float64 `json:"sum"`
+	}]()),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			sum := req.GetFloat("a", 0) + req.GetFloat("b", 0)
+			return mcp.NewToolResultStructured(map[string]any{"sum": sum}, fmt.Sprint(sum)), nil
+		})
+	s.AddTool(mcp.NewTool("fail"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("boom"), nil
+	})
+	s.AddTool(mcp.NewTool("crash"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		fmt.Fprintln(os.Stderr, "fatal: out of cheese")
+		os.Exit(3)
+		return nil, nil
+	})
+	server.ServeStdio(s)
+	os.Exit(0)
+}
+
+// helperMCPEnv 允许以 helper 命令启动测试子进程，返回启动参数和应用环境变量
+func helperMCPEnv(t *testing.T) (string, map[string]string) {
+	t.Setenv(CommandAllowlistEnv, "helper="+os.Args[0])
+	t.Cleanup(CloseStdioServers)
+	return "-test.run=^TestHelperMCPServer$", map[string]string{"MCP_ADAPTER_TEST_STDIO_SERVER": "1"}
+}
+
+func TestMCPAdapterDoRequest(t *testing.T) {
+	args, env := helperMCPEnv(t)
+	h := MCPAdapter{}
+	meta := RequestMeta{URL: "stdio://helper/echo", Method: "POST", AuthType: "none", Protocol: "mcp", Env: env, Query: args}
+	if !h.Compatible(meta) || (HTTPSimpleAdapter{}).Compatible(meta) {
+		t.Fatal("expected only the mcp handle to accept mcp interfaces")
+	}
+	call := func(tool string, body map[string]any) (string, error) {
+		m := meta
+		m.URL = StdioToolURL("helper", tool)
+		data, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{BodyParams: body}, m)
+		return string(data), err
+	}
+
+	tools, err := ListStdioTools(context.Background(), "helper", []string{args}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 4 {
+		t.Errorf("expected 4 tools, got %d", len(tools))
+	}
+
+	if out, err := call("echo", map[string]any{"message": "ab", "times": float64(2)}); err != nil || out != "abab" {
+		t.Errorf("echo = %q, %v", out, err)
+	}
+	var sum map[string]any
+	out, err := call("add", map[string]any{"a": float64(1), "b": 2.5})
+	if err != nil || json.Unmarshal([]byte(out), &sum) != nil || sum["sum"] != 3.5 {
+		t.Errorf("add = %q, %v", out, err)
+	}
+	if _, err := call("fail", nil); err == nil || err.Error() != "boom" {
+		t.Errorf("expected tool error, got %v", err)
+	}
+	if len(stdioServers) != 1 {
+		t.Errorf("expected calls to share one server, got %d", len(stdioServers))
+	}
+
+	// 子进程退出时返回标准错误输出，下一次调用重新启动
+	if _, err := call("crash", nil); err == nil || !strings.Contains(err.Error(), "exited: fatal: out of cheese") {
+		t.Errorf("expected crash error, got %v", err)
+	}
+	if out, err := call("echo", map[string]any{"message": "back"}); err != nil || out != "back" {
+		t.Errorf("echo after restart = %q, %v", out, err)
+	}
+
+	if closed := closeIdleStdioServers(time.Now().Add(stdioIdleTimeout + time.Minute)); closed != 1 || len(stdioServers) != 0 {
+		t.Errorf("expected idle server to be closed, closed %d, remaining %d", closed, len(stdioServers))
+	}
+
+	denied := meta
+	denied.URL = "stdio://other/echo"
+	if _, err := h.DoRequest(context.Background(), mcp.CallToolRequest{}, Parameters{}, denied); err == nil || !strings.Contains(err.Error(), "command other is not allowed") {
+		t.Errorf("expected not allowed error, got %v", err)
+	}
+}
+
+func TestAddToolMCP(t *testing.T) {
+	args, env := helperMCPEnv(t)
+	sm := setupEnabledTestManager(t)
+	sm.handles = append(sm.handles, MCPAdapter{})
+
+	environment, _ := json.Marshal(env)
+	app := models.Application{Name: "Proxy", Path: "proxy-app", Protocol: "sse", Environment: string(environment)}
+	createWithEnabled(t, &app, true)
+	iface := models.Interface{AppID: app.ID, Name: "shout", Protocol: "mcp", URL: "stdio://helper/echo", Method: "POST", AuthType: "none", Query: args}
+	createWithEnabled(t, &iface, true)
+	database.GetDB().Create(&[]models.InterfaceParameter{
+		{AppID: app.ID, InterfaceID: iface.ID, Name: "message", Type: "string", Location: "body", Required: true, Group: "input"},
+		{AppID: app.ID, InterfaceID: iface.ID, Name: "times", Type: "number", Location: "body", Group: "fixed", DefaultValue: stringPtr("3")},
+	})
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatal(err)
+	}
+	defer sm.removeApplication(&app)
+
+	s, _ := sm.sseServers.Load("proxy-app")
+	tool := s.(*Server).server.GetTool("shout")
+	if tool == nil {
+		t.Fatal("tool shout not registered")
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"message": "hi"}
+	result, err := tool.Handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; result.IsError || text != "hihihi" {
+		t.Errorf("unexpected result %s", text)
+	}
+}
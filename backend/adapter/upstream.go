@@ -0,0 +1,475 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	upstreamRetryMin = time.Second // 连接远程服务器失败后首次重试的等待时间，之后指数增长
+	upstreamRetryMax = time.Minute // 重试等待时间上限
+)
+
+// upstreamPrefixPattern 工具名前缀只允许 MCP 工具名中常用的字符
+var upstreamPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{0,64}$`)
+
+// UpstreamConfig 应用挂载的远程 MCP 服务器，远程工具镜像为应用的工具，调用时转发给远程服务器
+// 远程服务器发送 tools/list_changed 通知时重新同步工具列表
+type UpstreamConfig struct {
+	URL        string            `json:"url"`                   // 远程服务器地址，sse 传输为 SSE 端点，streamable 传输为 MCP 端点
+	Transport  string            `json:"transport,omitempty"`   // sse 或 streamable，默认 streamable
+	Prefix     string            `json:"prefix,omitempty"`      // 工具名前缀，避免与本地接口重名
+	Allow      []string          `json:"allow,omitempty"`       // 只暴露匹配的远程工具，支持 * 和 ? 通配，为空表示全部
+	Deny       []string          `json:"deny,omitempty"`        // 不暴露匹配的远程工具，优先于 allow
+	AuthType   string            `json:"auth_type,omitempty"`   // none (默认), bearer, basic, apikey, oauth2
+	AuthConfig json.RawMessage   `json:"auth_config,omitempty"` // 鉴权配置，格式与接口的 auth_config 相同
+	Headers    map[string]string `json:"headers,omitempty"`     // 额外请求头，值可以使用 secret://name 引用密钥
+
+	auth any
+}
+
+// ParseUpstreamConfig 解析并校验远程 MCP 服务器配置，空字符串表示应用没有挂载远程服务器
+func ParseUpstreamConfig(raw string) (*UpstreamConfig, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var cfg UpstreamConfig
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid upstream: %v", err)
+	}
+	if u, err := url.Parse(cfg.URL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.New("upstream url must be an absolute http or https URL")
+	}
+	if cfg.Transport == "" {
+		cfg.Transport = "streamable"
+	}
+	if cfg.Transport != "sse" && cfg.Transport != "streamable" {
+		return nil, errors.New("upstream transport must be sse or streamable")
+	}
+	if !upstreamPrefixPattern.MatchString(cfg.Prefix) {
+		return nil, errors.New("upstream prefix must be at most 64 letters, digits, _, - or .")
+	}
+	for _, pattern := range append(append([]string{}, cfg.Allow...), cfg.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, fmt.Errorf("invalid upstream tool pattern %q", pattern)
+		}
+	}
+	if cfg.AuthType == "" {
+		cfg.AuthType = "none"
+	}
+	switch cfg.AuthType {
+	case "none", "bearer", "basic", "apikey", "oauth2":
+	default:
+		return nil, errors.New("upstream auth_type must be none, bearer, basic, apikey or oauth2")
+	}
+	authConfig := ""
+	if len(bytes.TrimSpace(cfg.AuthConfig)) > 0 && string(bytes.TrimSpace(cfg.AuthConfig)) != "null" {
+		authConfig = string(cfg.AuthConfig)
+	}
+	auth, err := ParseAuthConfig(cfg.AuthType, authConfig)
+	if err != nil {
+		return nil, fmt.Errorf("upstream: %w", err)
+	}
+	cfg.auth = auth
+	for name := range cfg.Headers {
+		if !headerName.MatchString(name) {
+			return nil, fmt.Errorf("invalid upstream header name: %q", name)
+		}
+		if forbiddenForwardHeaders[http.CanonicalHeaderKey(name)] {
+			return nil, fmt.Errorf("upstream header %s cannot be set", http.CanonicalHeaderKey(name))
+		}
+	}
+	return &cfg, nil
+}
+
+// UpstreamConfigSecretRefs 返回鉴权配置和请求头中引用的密钥名称
+func UpstreamConfigSecretRefs(cfg *UpstreamConfig) []string {
+	if cfg == nil {
+		return nil
+	}
+	names := AuthConfigSecretRefs(cfg.auth)
+	for _, value := range cfg.Headers {
+		if name, ok := ParseSecretRef(value); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// UpstreamConfigPlaintextCredential 返回鉴权配置中第一个没有使用密钥引用的凭据字段名
+func UpstreamConfigPlaintextCredential(cfg *UpstreamConfig) (string, bool) {
+	if cfg == nil {
+		return "", false
+	}
+	return AuthConfigPlaintextCredential(cfg.auth)
+}
+
+// exposes deny 优先，allow 为空时暴露全部远程工具
+func (c *UpstreamConfig) exposes(tool string) bool {
+	for _, pattern := range c.Deny {
+		if ok, _ := path.Match(pattern, tool); ok {
+			return false
+		}
+	}
+	if len(c.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range c.Allow {
+		if ok, _ := path.Match(pattern, tool); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// upstreamCallKey context 中保存 MCP 客户端的调用请求，用于透传请求头
+type upstreamCallKey struct{}
+
+// upstreamTransport 给发往远程服务器的每个 HTTP 请求注入凭证，SSE 长连接和消息请求都会经过这里
+type upstreamTransport struct {
+	base           http.RoundTripper
+	cfg            *UpstreamConfig
+	httpConfig     HTTPClientConfig
+	forwardHeaders map[string]string
+}
+
+func (t *upstreamTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	request = request.Clone(ctx)
+	for name, value := range t.cfg.Headers {
+		resolved, err := resolveCallSecret(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set(name, resolved)
+	}
+	if t.cfg.auth != nil {
+		if err := applyAuth(ctx, request, t.cfg.auth, t.httpConfig); err != nil {
+			return nil, err
+		}
+	}
+	if req, ok := ctx.Value(upstreamCallKey{}).(mcp.CallToolRequest); ok {
+		applyForwardHeaders(request, req, RequestMeta{ForwardHeaders: t.forwardHeaders})
+		recordUpstreamRequest(request, 0)
+	}
+	resp, err := t.base.RoundTrip(request)
+	if resp != nil {
+		invalidateOAuth2TokenOnUnauthorized(t.cfg.auth, resp.StatusCode)
+	}
+	return resp, err
+}
+
+// upstreamTool 已注册的远程工具，def 是远程工具定义，用于同步时跳过没有变化的工具
+type upstreamTool struct {
+	remote string
+	def    []byte
+}
+
+// upstreamServer 应用与远程 MCP 服务器之间的连接，断开后在后台按指数退避重连
+type upstreamServer struct {
+	app        models.Application
+	cfg        *UpstreamConfig
+	srv        *Server
+	httpClient *http.Client
+	timeout    time.Duration
+	wrap       func(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	resync chan struct{}
+
+	mu     sync.Mutex
+	client *client.Client
+	tools  map[string]upstreamTool // 本地工具名 -> 远程工具
+}
+
+// newUpstreamServer 按应用配置创建远程连接，调用 start 后才会连接
+// HTTP 客户端沿用应用的 TLS 和代理配置，不设置总超时以免断开 SSE 长连接，单次调用的超时是应用的 timeout
+func newUpstreamServer(app *models.Application, cfg *UpstreamConfig, srv *Server) (*upstreamServer, error) {
+	httpConfig, err := ParseHTTPConfig(app.HTTPConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http config of application %s: %w", app.Name, err)
+	}
+	forwardHeaders, err := ParseForwardHeaders(app.ForwardHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid forward headers of application %s: %w", app.Name, err)
+	}
+	rateLimit, err := ParseRateLimitConfig(app.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("application %s: %w", app.Name, err)
+	}
+	invocationLog, err := ParseInvocationLogConfig(app.InvocationLog)
+	if err != nil {
+		return nil, fmt.Errorf("application %s: %w", app.Name, err)
+	}
+	httpConfig.clientKey = upstreamHTTPClientKey(app.ID)
+	base, err := httpClientFor(httpConfig)
+	if err != nil {
+		return nil, err
+	}
+	limiter := applicationRateLimiter(rateLimit, app)
+	ctx, cancel := context.WithCancel(context.Background())
+	u := &upstreamServer{
+		app: *app,
+		cfg: cfg,
+		srv: srv,
+		httpClient: &http.Client{Transport: &upstreamTransport{
+			base:           base.Transport,
+			cfg:            cfg,
+			httpConfig:     httpConfig,
+			forwardHeaders: forwardHeaders,
+		}},
+		timeout: commandTimeout(httpConfig),
+		ctx:     ctx,
+		cancel:  cancel,
+		resync:  make(chan struct{}, 1),
+		tools:   make(map[string]upstreamTool),
+	}
+	// 远程工具没有对应的接口，调用日志中 interface_id 为 0
+	u.wrap = func(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return logInvocations(invocationLog, &u.app, &models.Interface{AppID: app.ID, Name: name}, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			release, err := acquireRateLimits(ctx, req.Header, limiter)
+			if err != nil {
+				var limitErr *RateLimitError
+				if errors.As(err, &limitErr) {
+					return mcp.NewToolResultError(limitErr.ToolResultText()), nil
+				}
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			defer release()
+			return handler(ctx, req)
+		})
+	}
+	return u, nil
+}
+
+// start 在后台连接远程服务器并同步工具，不阻塞应用注册
+func (u *upstreamServer) start() {
+	go u.run()
+}
+
+func (u *upstreamServer) run() {
+	backoff := upstreamRetryMin
+	for {
+		var retry <-chan time.Time
+		if err := u.sync(); err != nil {
+			log.Printf("Error syncing upstream %s of application %s, retry in %s: %v", u.cfg.URL, u.app.Name, backoff, err)
+			retry = time.After(backoff)
+			backoff = min(backoff*2, upstreamRetryMax)
+		} else {
+			backoff = upstreamRetryMin
+		}
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-u.resync:
+		case <-retry:
+		}
+	}
+}
+
+// trigger 请求后台重新同步，已有待处理的请求时合并
+func (u *upstreamServer) trigger() {
+	select {
+	case u.resync <- struct{}{}:
+	default:
+	}
+}
+
+// close 应用移除时断开连接，已注册的工具随应用一起移除
+func (u *upstreamServer) close() {
+	u.cancel()
+	u.mu.Lock()
+	c := u.client
+	u.client = nil
+	u.mu.Unlock()
+	if c != nil {
+		_ = c.Close()
+	}
+}
+
+func (u *upstreamServer) current() *client.Client {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.client
+}
+
+// disconnect 丢弃失效的连接，下一次同步时重新连接
+func (u *upstreamServer) disconnect(c *client.Client) {
+	u.mu.Lock()
+	if u.client == c {
+		u.client = nil
+	}
+	u.mu.Unlock()
+	_ = c.Close()
+	u.trigger()
+}
+
+// connect 返回当前连接，没有连接时新建并完成 initialize
+func (u *upstreamServer) connect() (*client.Client, error) {
+	if c := u.current(); c != nil {
+		return c, nil
+	}
+	var c *client.Client
+	var err error
+	if u.cfg.Transport == "sse" {
+		c, err = client.NewSSEMCPClient(u.cfg.URL, client.WithHTTPClient(u.httpClient))
+	} else {
+		c, err = client.NewStreamableHttpClient(u.cfg.URL, transport.WithHTTPBasicClient(u.httpClient), transport.WithContinuousListening())
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationToolsListChanged {
+			u.trigger()
+		}
+	})
+	c.OnConnectionLost(func(err error) {
+		log.Printf("Lost connection to upstream %s of application %s: %v", u.cfg.URL, u.app.Name, err)
+		go u.disconnect(c)
+	})
+	// SSE 长连接和持续监听使用应用级 context，应用移除时断开
+	if err := c.Start(u.ctx); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("connect upstream mcp server failed: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(u.ctx, u.timeout)
+	defer cancel()
+	initReq := mcp.InitializeRequest{}
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initReq.Params.ClientInfo = mcp.Implementation{Name: "mcp-adapter", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initReq); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("initialize upstream mcp server failed: %w", err)
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.ctx.Err() != nil {
+		_ = c.Close()
+		return nil, u.ctx.Err()
+	}
+	u.client = c
+	log.Printf("Connected to upstream %s of application %s", u.cfg.URL, u.app.Name)
+	return c, nil
+}
+
+// sync 列出远程工具并更新应用的工具
+func (u *upstreamServer) sync() error {
+	c, err := u.connect()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(u.ctx, u.timeout)
+	defer cancel()
+	result, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		if u.ctx.Err() == nil {
+			u.disconnect(c)
+		}
+		return fmt.Errorf("list upstream tools failed: %w", err)
+	}
+	u.apply(result.Tools)
+	return nil
+}
+
+// apply 按 allow/deny 过滤并加上前缀后注册远程工具，移除远程已删除的工具
+// 与本地接口重名的远程工具会被跳过，定义没有变化的工具不会重新注册
+func (u *upstreamServer) apply(tools []mcp.Tool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.ctx.Err() != nil {
+		return
+	}
+	next := make(map[string]upstreamTool, len(tools))
+	changed := make([]server.ServerTool, 0)
+	for _, tool := range tools {
+		if !u.cfg.exposes(tool.Name) {
+			continue
+		}
+		name := u.cfg.Prefix + tool.Name
+		if _, ok := next[name]; ok {
+			continue
+		}
+		if _, ours := u.tools[name]; !ours && u.srv.server.GetTool(name) != nil {
+			log.Printf("Upstream tool %s of application %s conflicts with an existing tool, skipped", name, u.app.Name)
+			continue
+		}
+		remote := tool.Name
+		tool.Name = name
+		def, err := json.Marshal(tool)
+		if err != nil {
+			log.Printf("Error encoding upstream tool %s of application %s: %v", name, u.app.Name, err)
+			continue
+		}
+		next[name] = upstreamTool{remote: remote, def: def}
+		if old, ok := u.tools[name]; ok && bytes.Equal(old.def, def) {
+			continue
+		}
+		changed = append(changed, server.ServerTool{Tool: tool, Handler: u.wrap(name, u.handler(remote))})
+	}
+	removed := make([]string, 0)
+	for name := range u.tools {
+		if _, ok := next[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) > 0 {
+		u.srv.server.DeleteTools(removed...)
+	}
+	if len(changed) > 0 {
+		u.srv.server.AddTools(changed...)
+	}
+	u.tools = next
+	log.Printf("Synced upstream %s of application %s: %d tools, %d updated, %d removed", u.cfg.URL, u.app.Name, len(next), len(changed), len(removed))
+}
+
+// handler 把调用原样转发给远程工具，远程返回的结果 (包括 isError) 原样返回给客户端
+func (u *upstreamServer) handler(remote string) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		c := u.current()
+		if c == nil {
+			u.trigger()
+			return mcp.NewToolResultError(fmt.Sprintf("upstream mcp server %s is not connected", u.cfg.URL)), nil
+		}
+		callCtx, cancel := context.WithTimeout(context.WithValue(ctx, upstreamCallKey{}, req), u.timeout)
+		defer cancel()
+		call := mcp.CallToolRequest{}
+		call.Params.Name = remote
+		call.Params.Arguments = req.Params.Arguments
+		result, err := c.CallTool(callCtx, call)
+		if err != nil {
+			// 远程服务器重启后会话失效，重新连接
+			if errors.Is(err, transport.ErrSessionTerminated) {
+				u.disconnect(c)
+			}
+			if ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+				return mcp.NewToolResultError(fmt.Sprintf("upstream tool %s timed out after %s", remote, u.timeout)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("call upstream tool %s failed: %v", remote, err)), nil
+		}
+		if data, err := json.Marshal(result); err == nil {
+			recordUpstreamResponse(ctx, 0, data)
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,201 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestParseUpstreamConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "empty", raw: ""},
+		{name: "defaults", raw: `{"url":"https://mcp.example.com/mcp"}`},
+		{name: "full", raw: `{"url":"http://localhost:8080/sse","transport":"sse","prefix":"gh_","allow":["search_*"],"deny":["*_delete"],"auth_type":"bearer","auth_config":{"token":"secret://gh"},"headers":{"X-Tenant":"acme"}}`},
+		{name: "unknown field", raw: `{"url":"https://a.example.com","tools":[]}`, wantErr: "invalid upstream"},
+		{name: "relative url", raw: `{"url":"/mcp"}`, wantErr: "upstream url must be an absolute http or https URL"},
+		{name: "transport", raw: `{"url":"https://a.example.com","transport":"stdio"}`, wantErr: "upstream transport must be sse or streamable"},
+		{name: "prefix", raw: `{"url":"https://a.example.com","prefix":"gh/"}`, wantErr: "upstream prefix"},
+		{name: "pattern", raw: `{"url":"https://a.example.com","allow":["[a"]}`, wantErr: `invalid upstream tool pattern "[a"`},
+		{name: "unsupported auth", raw: `{"url":"https://a.example.com","auth_type":"aws_sigv4"}`, wantErr: "upstream auth_type must be none"},
+		{name: "missing auth config", raw: `{"url":"https://a.example.com","auth_type":"bearer"}`, wantErr: "upstream: auth_config is required for auth type bearer"},
+		{name: "forbidden header", raw: `{"url":"https://a.example.com","headers":{"host":"x"}}`, wantErr: "upstream header Host cannot be set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseUpstreamConfig(tt.raw)
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	cfg, _ := ParseUpstreamConfig(`{"url":"https://a.example.com","auth_type":"basic","auth_config":{"username":"secret://user","password":"p"},"headers":{"X-Key":"secret://key"}}`)
+	if refs := UpstreamConfigSecretRefs(cfg); !slices.Equal(refs, []string{"user", "key"}) {
+		t.Errorf("unexpected secret refs %v", refs)
+	}
+	cfg, _ = ParseUpstreamConfig(`{"url":"https://a.example.com","allow":["search_*","get_issue"],"deny":["*_delete"]}`)
+	for tool, want := range map[string]bool{"search_code": true, "get_issue": true, "search_delete": false, "create_issue": false} {
+		if got := cfg.exposes(tool); got != want {
+			t.Errorf("exposes(%s) = %v, want %v", tool, got, want)
+		}
+	}
+}
+
+// newRemoteMCPServer 启动一个要求 Bearer 鉴权的远程 MCP 服务器
+func newRemoteMCPServer(t *testing.T, transport string) (*server.MCPServer, string) {
+	remote := server.NewMCPServer("remote", "1.0.0", server.WithToolCapabilities(true))
+	echo := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(req.GetString("message", "") + " from " + req.Params.Name), nil
+	}
+	remote.AddTool(mcp.NewTool("echo", mcp.WithDescription("Echo a message"), mcp.WithString("message", mcp.Required())), echo)
+	remote.AddTool(mcp.NewTool("repo_delete"), echo)
+	remote.AddTool(mcp.NewTool("fail"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("boom"), nil
+	})
+
+	var handler http.Handler
+	var httpServer *httptest.Server
+	if transport == "sse" {
+		sse := server.NewSSEServer(remote)
+		handler = sse
+		t.Cleanup(func() { _ = sse.Shutdown(context.Background()) })
+	} else {
+		handler = server.NewStreamableHTTPServer(remote)
+	}
+	httpServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer remote-token" || r.Header.Get("X-Tenant") != "acme" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if sse, ok := handler.(*server.SSEServer); ok && strings.HasPrefix(r.URL.Path, "/message") {
+			sse.MessageHandler().ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+	if transport == "sse" {
+		return remote, httpServer.URL + "/sse"
+	}
+	return remote, httpServer.URL + "/mcp"
+}
+
+// waitForTools 等待后台同步完成，返回应用当前的工具名
+func waitForTools(t *testing.T, sm *ServerManager, path string, ready func([]string) bool) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		names := toolNames(sm, path)
+		slices.Sort(names)
+		if ready(names) {
+			return names
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for tools, got %v", names)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestUpstreamApplication(t *testing.T) {
+	for _, transport := range []string{"streamable", "sse"} {
+		t.Run(transport, func(t *testing.T) {
+			remote, url := newRemoteMCPServer(t, transport)
+			sm := setupEnabledTestManager(t)
+
+			upstream, _ := json.Marshal(map[string]any{
+				"url":         url,
+				"transport":   transport,
+				"prefix":      "gh_",
+				"deny":        []string{"*_delete"},
+				"auth_type":   "bearer",
+				"auth_config": map[string]string{"token": "remote-token"},
+				"headers":     map[string]string{"X-Tenant": "acme"},
+			})
+			app := models.Application{Name: "Federated", Path: "federated", Protocol: "sse", Upstream: string(upstream)}
+			createWithEnabled(t, &app, true)
+			// 与远程工具重名的本地接口优先
+			local := models.Interface{AppID: app.ID, Name: "gh_fail", Protocol: "http", URL: "http://127.0.0.1:1/fail", Method: "GET", AuthType: "none"}
+			createWithEnabled(t, &local, true)
+			if err := sm.addApplication(&app); err != nil {
+				t.Fatal(err)
+			}
+
+			names := waitForTools(t, sm, "federated", func(names []string) bool { return slices.Contains(names, "gh_echo") })
+			if !slices.Equal(names, []string{"gh_echo", "gh_fail"}) {
+				t.Errorf("unexpected tools %v", names)
+			}
+			s, _ := sm.sseServers.Load("federated")
+			tool := s.(*Server).server.GetTool("gh_echo")
+			if tool.Tool.Description != "Echo a message" || !slices.Equal(tool.Tool.InputSchema.Required, []string{"message"}) {
+				t.Errorf("unexpected tool definition %+v", tool.Tool)
+			}
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "gh_echo"
+			req.Params.Arguments = map[string]any{"message": "hi"}
+			result, err := tool.Handler(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text := result.Content[0].(mcp.TextContent).Text; result.IsError || text != "hi from echo" {
+				t.Errorf("unexpected result %q", text)
+			}
+
+			// 远程工具变化后通过 tools/list_changed 重新同步
+			remote.AddTool(mcp.NewTool("search"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("found"), nil
+			})
+			remote.DeleteTools("echo")
+			waitForTools(t, sm, "federated", func(names []string) bool {
+				return slices.Equal(names, []string{"gh_fail", "gh_search"})
+			})
+
+			if err := sm.removeApplication(&app); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := sm.sseServers.Load("federated"); ok {
+				t.Error("expected application to be removed")
+			}
+		})
+	}
+}
+
+func TestUpstreamApplicationUnavailable(t *testing.T) {
+	sm := setupEnabledTestManager(t)
+	_, url := newRemoteMCPServer(t, "streamable")
+
+	// 凭证错误时不注册远程工具，后台持续重试
+	upstream, _ := json.Marshal(map[string]any{"url": url, "auth_type": "bearer", "auth_config": map[string]string{"token": "wrong"}})
+	app := models.Application{Name: "Unauthorized", Path: "unauthorized", Protocol: "streamable", Upstream: string(upstream)}
+	createWithEnabled(t, &app, true)
+	if err := sm.addApplication(&app); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if names := toolNames(sm, "unauthorized"); len(names) != 0 {
+		t.Errorf("expected no tools, got %v", names)
+	}
+
+	// 已注册的工具在连接断开后返回错误
+	u := &upstreamServer{cfg: &UpstreamConfig{URL: url}, resync: make(chan struct{}, 1)}
+	result, err := u.handler("echo")(context.Background(), mcp.CallToolRequest{})
+	if err != nil || !result.IsError || result.Content[0].(mcp.TextContent).Text != "upstream mcp server "+url+" is not connected" {
+		t.Errorf("unexpected result %+v, %v", result, err)
+	}
+}
@@ -56,6 +56,10 @@ func InitDatabase(dbPath string) {
 		&models.CustomTypeField{},
 		&models.InterfaceParameter{},
 		&models.EventLog{},
+		&models.Secret{},
+		&models.Datasource{},
+		&models.ResponseCacheEntry{},
+		&models.ToolInvocation{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"io"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportApplicationBundle 导出应用完整定义，format=yaml 时输出 YAML
+func ExportApplicationBundle(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid application ID")
+		return
+	}
+	bundle, err := service.ExportApplicationBundle(service.ExportApplicationBundleRequest{ID: id})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("format") == "yaml" {
+		c.YAML(http.StatusOK, bundle)
+		return
+	}
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportApplicationBundle 导入应用导出包，请求体为 JSON 或 YAML
+// conflict=fail|overwrite|rename 指定同 Path 应用已存在时的处理方式，preview=true 时只返回差异
+func ImportApplicationBundle(c *gin.Context) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	bundle, err := service.ParseApplicationBundle(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	preview, _ := strconv.ParseBool(c.DefaultQuery("preview", "false"))
+	resp, err := service.ImportApplicationBundle(service.ImportApplicationBundleRequest{
+		Bundle:   bundle,
+		Conflict: c.Query("conflict"),
+		Preview:  preview,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportApplicationBundle(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	app := models.Application{Name: "Bundle App", Path: "bundle-app", Protocol: "sse", Enabled: true}
+	db := database.GetDB()
+	db.Create(&app)
+	db.Create(&models.Interface{AppID: app.ID, Name: "Ping", Protocol: "http", URL: "https://api.example.com/ping", Method: "GET", AuthType: "none", Enabled: true})
+
+	router := setupTestRouter()
+	router.GET("/applications/:id/export", ExportApplicationBundle)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "json bundle",
+			path:           "/applications/" + strconv.FormatInt(app.ID, 10) + "/export",
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var bundle service.ApplicationBundle
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &bundle))
+				assert.Equal(t, "bundle-app", bundle.Application.Path)
+				assert.Len(t, bundle.Interfaces, 1)
+			},
+		},
+		{
+			name:           "yaml bundle",
+			path:           "/applications/" + strconv.FormatInt(app.ID, 10) + "/export?format=yaml",
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Contains(t, resp.Header().Get("Content-Type"), "yaml")
+				bundle, err := service.ParseApplicationBundle(resp.Body.Bytes())
+				assert.NoError(t, err)
+				assert.Equal(t, "Ping", bundle.Interfaces[0].Name)
+			},
+		},
+		{
+			name:           "invalid application ID",
+			path:           "/applications/abc/export",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "non-existent application",
+			path:           "/applications/9999/export",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
+
+func TestImportApplicationBundle(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	router := setupTestRouter()
+	router.POST("/applications/import", ImportApplicationBundle)
+
+	bundle := `
+version: 1
+application:
+  name: Imported
+  path: imported
+  protocol: sse
+  enabled: true
+custom_types: []
+interfaces:
+  - name: Ping
+    protocol: http
+    url: https://api.example.com/ping
+    method: GET
+    auth_type: none
+    enabled: true
+    parameters: []
+`
+
+	tests := []struct {
+		name           string
+		path           string
+		requestBody    string
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "import yaml bundle",
+			path:           "/applications/import",
+			requestBody:    bundle,
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var result service.ImportApplicationBundleResponse
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+				assert.Equal(t, "create", result.Action)
+				assert.Equal(t, "imported", result.Application.Path)
+			},
+		},
+		{
+			name:           "conflict fails by default",
+			path:           "/applications/import",
+			requestBody:    bundle,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "preview diff",
+			path:           "/applications/import?conflict=overwrite&preview=true",
+			requestBody:    bundle,
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var result service.ImportApplicationBundleResponse
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+				assert.True(t, result.Preview)
+				assert.Nil(t, result.Application)
+				assert.Equal(t, []string{"Ping"}, result.Diff.Interfaces.Unchanged)
+			},
+		},
+		{
+			name:           "rename",
+			path:           "/applications/import?conflict=rename",
+			requestBody:    bundle,
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var count int64
+				database.GetDB().Model(&models.Application{}).Count(&count)
+				assert.Equal(t, int64(2), count)
+			},
+		},
+		{
+			name:           "invalid conflict mode",
+			path:           "/applications/import?conflict=merge",
+			requestBody:    bundle,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid bundle",
+			path:           "/applications/import",
+			requestBody:    `{invalid`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/yaml")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PurgeCache 按应用或接口清除响应缓存，参数通过 app_id / interface_id 查询参数传入
+func PurgeCache(c *gin.Context) {
+	var req service.PurgeCacheRequest
+	for name, target := range map[string]*int64{"app_id": &req.AppID, "interface_id": &req.InterfaceID} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Invalid "+name)
+			return
+		}
+		*target = id
+	}
+	resp, err := service.PurgeCache(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeCache(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	router := setupTestRouter()
+	router.DELETE("/cache", PurgeCache)
+	database.GetDB().Create(&models.ResponseCacheEntry{Key: "1:a", AppID: 1, InterfaceID: 1, ExpiresAt: time.Now().Add(time.Minute)})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "purge by application", path: "/cache?app_id=1", expectedStatus: http.StatusOK, expectedBody: `{"purged":1}`},
+		{name: "purge by interface", path: "/cache?interface_id=1", expectedStatus: http.StatusOK, expectedBody: `{"purged":0}`},
+		{name: "missing scope", path: "/cache", expectedStatus: http.StatusBadRequest},
+		{name: "invalid app_id", path: "/cache?app_id=abc", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, tt.path, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+			}
+		})
+	}
+}
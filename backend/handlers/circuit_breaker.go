@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCircuitBreakers 查看熔断器当前状态，可以通过 state 查询参数按状态过滤
+func ListCircuitBreakers(c *gin.Context) {
+	resp, err := service.ListCircuitBreakers(service.ListCircuitBreakersRequest{State: c.Query("state")})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
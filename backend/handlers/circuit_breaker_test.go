@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCircuitBreakers(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/circuit-breakers", ListCircuitBreakers)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "list all", path: "/circuit-breakers", expectedStatus: http.StatusOK, expectedBody: `{"circuit_breakers":[]}`},
+		{name: "filter by state", path: "/circuit-breakers?state=half_open", expectedStatus: http.StatusOK, expectedBody: `{"circuit_breakers":[]}`},
+		{name: "invalid state", path: "/circuit-breakers?state=broken", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDatasource 创建数据源
+func CreateDatasource(c *gin.Context) {
+	var req service.CreateDatasourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	resp, err := service.CreateDatasource(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp.Datasource)
+}
+
+// GetDatasources 获取所有数据源
+func GetDatasources(c *gin.Context) {
+	resp, err := service.ListDatasources(service.ListDatasourcesRequest{})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to fetch datasources")
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetDatasource 获取单个数据源
+func GetDatasource(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid datasource ID")
+		return
+	}
+	resp, err := service.GetDatasource(service.GetDatasourceRequest{ID: id})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp.Datasource)
+}
+
+// UpdateDatasource 更新数据源（部分字段）
+func UpdateDatasource(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid datasource ID")
+		return
+	}
+	var body service.UpdateDatasourceRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	body.ID = id
+	resp, err := service.UpdateDatasource(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp.Datasource)
+}
+
+// DeleteDatasource 删除数据源，被 sql 接口引用时不能删除
+func DeleteDatasource(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid datasource ID")
+		return
+	}
+	_, err = service.DeleteDatasource(service.DeleteDatasourceRequest{ID: id})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
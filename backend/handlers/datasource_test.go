@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasourceCRUD(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	router := setupTestRouter()
+	router.POST("/datasources", CreateDatasource)
+	router.GET("/datasources", GetDatasources)
+	router.GET("/datasources/:id", GetDatasource)
+	router.PUT("/datasources/:id", UpdateDatasource)
+	router.DELETE("/datasources/:id", DeleteDatasource)
+
+	var created service.DatasourceDTO
+
+	tests := []struct {
+		name           string
+		method         string
+		path           func() string
+		requestBody    string
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "create datasource",
+			method:         http.MethodPost,
+			path:           func() string { return "/datasources" },
+			requestBody:    `{"name":"reporting","driver":"sqlite","dsn":"file:reporting.db","max_rows":20,"timeout":"3s"}`,
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+				assert.Equal(t, "reporting", created.Name)
+				assert.Equal(t, 20, created.MaxRows)
+			},
+		},
+		{
+			name:           "unsupported driver",
+			method:         http.MethodPost,
+			path:           func() string { return "/datasources" },
+			requestBody:    `{"name":"pg","driver":"postgres","dsn":"x"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid JSON format",
+			method:         http.MethodPost,
+			path:           func() string { return "/datasources" },
+			requestBody:    `{invalid`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "list datasources",
+			method:         http.MethodGet,
+			path:           func() string { return "/datasources" },
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var list service.DatasourcesResponse
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+				assert.Len(t, list.Datasources, 1)
+			},
+		},
+		{
+			name:           "get datasource",
+			method:         http.MethodGet,
+			path:           func() string { return "/datasources/" + strconv.FormatInt(created.ID, 10) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "update datasource",
+			method:         http.MethodPut,
+			path:           func() string { return "/datasources/" + strconv.FormatInt(created.ID, 10) },
+			requestBody:    `{"timeout":"500ms"}`,
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var updated service.DatasourceDTO
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &updated))
+				assert.Equal(t, "500ms", updated.Timeout)
+			},
+		},
+		{
+			name:           "invalid timeout",
+			method:         http.MethodPut,
+			path:           func() string { return "/datasources/" + strconv.FormatInt(created.ID, 10) },
+			requestBody:    `{"timeout":"-1s"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid datasource ID",
+			method:         http.MethodGet,
+			path:           func() string { return "/datasources/abc" },
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "delete datasource",
+			method:         http.MethodDelete,
+			path:           func() string { return "/datasources/" + strconv.FormatInt(created.ID, 10) },
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "get deleted datasource",
+			method:         http.MethodGet,
+			path:           func() string { return "/datasources/" + strconv.FormatInt(created.ID, 10) },
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path(), bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
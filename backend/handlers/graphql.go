@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportGraphQL 根据 GraphQL 内省结果导入接口和自定义类型
+func ImportGraphQL(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid application ID")
+		return
+	}
+	var req service.ImportGraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	req.AppID = id
+	resp, err := service.ImportGraphQL(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
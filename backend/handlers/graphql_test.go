@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportGraphQL(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	app := models.Application{Name: "GraphQL App", Path: "graphql-app", Protocol: "sse", Enabled: true}
+	database.GetDB().Create(&app)
+
+	router := setupTestRouter()
+	router.POST("/applications/:id/import/graphql", ImportGraphQL)
+
+	introspection := `{"data":{"__schema":{"queryType":{"name":"Query"},"mutationType":null,"types":[
+		{"kind":"OBJECT","name":"Query","fields":[{"name":"hello","args":[{"name":"name","type":{"kind":"SCALAR","name":"String"},"defaultValue":null}],"type":{"kind":"SCALAR","name":"String"}}]},
+		{"kind":"SCALAR","name":"String"}]}}}`
+	path := "/applications/" + strconv.FormatInt(app.ID, 10) + "/import/graphql"
+
+	tests := []struct {
+		name           string
+		path           string
+		requestBody    interface{}
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "dry run preview",
+			path:           path,
+			requestBody:    service.ImportGraphQLRequest{URL: "https://api.example.com/graphql", Introspection: introspection, DryRun: true},
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var result service.ImportResponse
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+				assert.True(t, result.DryRun)
+				assert.Len(t, result.Interfaces, 1)
+				assert.Equal(t, "hello", result.Interfaces[0].Name)
+				assert.Equal(t, "query https://api.example.com/graphql", result.Interfaces[0].Detail)
+			},
+		},
+		{
+			name:           "import",
+			path:           path,
+			requestBody:    service.ImportGraphQLRequest{URL: "https://api.example.com/graphql", Introspection: introspection},
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var iface models.Interface
+				assert.NoError(t, database.GetDB().Where("app_id = ? AND name = ?", app.ID, "hello").First(&iface).Error)
+				assert.Equal(t, "graphql", iface.Protocol)
+				assert.Equal(t, "query hello($name: String) {\n  hello(name: $name)\n}\n", iface.Query)
+			},
+		},
+		{
+			name:           "invalid application ID",
+			path:           "/applications/abc/import/graphql",
+			requestBody:    service.ImportGraphQLRequest{URL: "https://api.example.com/graphql", Introspection: introspection},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid JSON format",
+			path:           path,
+			requestBody:    `{invalid json}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid introspection",
+			path:           path,
+			requestBody:    service.ImportGraphQLRequest{URL: "https://api.example.com/graphql", Introspection: `{"data":{}}`},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if str, ok := tt.requestBody.(string); ok {
+				body = []byte(str)
+			} else {
+				body, _ = json.Marshal(tt.requestBody)
+			}
+			req, _ := http.NewRequest(http.MethodPost, tt.path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportGRPC 根据描述集或服务端反射导入 gRPC 接口和自定义类型
+func ImportGRPC(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid application ID")
+		return
+	}
+	var req service.ImportGRPCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	req.AppID = id
+	resp, err := service.ImportGRPC(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
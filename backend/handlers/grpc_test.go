@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestImportGRPC(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	app := models.Application{Name: "gRPC App", Path: "grpc-app", Protocol: "sse", Enabled: true}
+	database.GetDB().Create(&app)
+
+	router := setupTestRouter()
+	router.POST("/applications/:id/import/grpc", ImportGRPC)
+
+	descriptorSet, err := adapter.EncodeDescriptorSet(healthpb.File_grpc_health_v1_health_proto)
+	assert.NoError(t, err)
+	path := "/applications/" + strconv.FormatInt(app.ID, 10) + "/import/grpc"
+
+	tests := []struct {
+		name           string
+		path           string
+		requestBody    interface{}
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "dry run preview",
+			path:           path,
+			requestBody:    service.ImportGRPCRequest{URL: "grpc://health:50051", DescriptorSet: descriptorSet, DryRun: true},
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var result service.ImportResponse
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+				assert.True(t, result.DryRun)
+				actions := make(map[string]string)
+				for _, item := range result.Interfaces {
+					actions[item.Name] = item.Action
+				}
+				assert.Equal(t, "create", actions["Check"])
+				assert.Equal(t, "skip", actions["Watch"])
+			},
+		},
+		{
+			name:           "import",
+			path:           path,
+			requestBody:    service.ImportGRPCRequest{URL: "grpc://health:50051", DescriptorSet: descriptorSet},
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var iface models.Interface
+				assert.NoError(t, database.GetDB().Where("app_id = ? AND name = ?", app.ID, "Check").First(&iface).Error)
+				assert.Equal(t, "grpc", iface.Protocol)
+				assert.Equal(t, "grpc://health:50051/grpc.health.v1.Health/Check", iface.URL)
+				assert.Equal(t, descriptorSet, iface.DescriptorSet)
+			},
+		},
+		{
+			name:           "invalid application ID",
+			path:           "/applications/abc/import/grpc",
+			requestBody:    service.ImportGRPCRequest{URL: "grpc://health:50051", DescriptorSet: descriptorSet},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid JSON format",
+			path:           path,
+			requestBody:    `{invalid json}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid url",
+			path:           path,
+			requestBody:    service.ImportGRPCRequest{URL: "https://health", DescriptorSet: descriptorSet},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if str, ok := tt.requestBody.(string); ok {
+				body = []byte(str)
+			} else {
+				body, _ = json.Marshal(tt.requestBody)
+			}
+			req, _ := http.NewRequest(http.MethodPost, tt.path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
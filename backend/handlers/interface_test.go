@@ -46,7 +46,7 @@ func TestCreateInterface(t *testing.T) {
 				URL:         "https://api.example.com/test",
 				Method:      "GET",
 				AuthType:    "none",
-				Enabled:     true,
+				Enabled:     boolPtr(true),
 				Parameters: []service.CreateInterfaceParameterReq{
 					{
 						Name:        "param1",
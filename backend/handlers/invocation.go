@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListInvocations 分页查询工具调用日志，过滤条件通过查询参数传入
+func ListInvocations(c *gin.Context) {
+	var req service.ListInvocationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.String(http.StatusBadRequest, "Invalid query parameters")
+		return
+	}
+	resp, err := service.ListInvocations(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetInvocation 获取单条调用日志
+func GetInvocation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid invocation ID")
+		return
+	}
+	resp, err := service.GetInvocation(service.GetInvocationRequest{ID: id})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
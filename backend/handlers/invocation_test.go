@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListInvocations(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	router := setupTestRouter()
+	router.GET("/invocations", ListInvocations)
+	router.GET("/invocations/:id", GetInvocation)
+	invocation := models.ToolInvocation{AppID: 1, InterfaceID: 2, Tool: "search", Status: "success", CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	database.GetDB().Create(&invocation)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "list all", path: "/invocations", expectedStatus: http.StatusOK, expectedBody: `"total":1`},
+		{name: "filter by tool and time", path: "/invocations?tool=search&since=2024-01-01T00:00:00Z&until=2024-01-02T00:00:00Z", expectedStatus: http.StatusOK, expectedBody: `"total":1`},
+		{name: "filter by status", path: "/invocations?status=error&page=1&page_size=10", expectedStatus: http.StatusOK, expectedBody: `"total":0`},
+		{name: "invalid since", path: "/invocations?since=yesterday", expectedStatus: http.StatusBadRequest},
+		{name: "invalid page size", path: "/invocations?page_size=1000", expectedStatus: http.StatusBadRequest},
+		{name: "get by id", path: "/invocations/" + strconv.FormatInt(invocation.ID, 10), expectedStatus: http.StatusOK, expectedBody: `"tool":"search"`},
+		{name: "get missing", path: "/invocations/9999", expectedStatus: http.StatusBadRequest},
+		{name: "invalid id", path: "/invocations/abc", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, resp.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportMCP(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+	t.Setenv(adapter.CommandAllowlistEnv, "npx=/bin/sh")
+
+	app := models.Application{Name: "MCP App", Path: "mcp-app", Protocol: "sse", Enabled: true}
+	database.GetDB().Create(&app)
+
+	router := setupTestRouter()
+	router.POST("/applications/:id/import/mcp", ImportMCP)
+	path := "/applications/" + strconv.FormatInt(app.ID, 10) + "/import/mcp"
+
+	tests := []struct {
+		name           string
+		path           string
+		requestBody    interface{}
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "command not allowed",
+			path:           path,
+			requestBody:    service.ImportMCPRequest{Command: "bash", Args: []string{"-c", "id"}},
+			expectedStatus: http.StatusBadRequest,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Contains(t, resp.Body.String(), "command bash is not allowed")
+			},
+		},
+		{
+			name:           "missing command",
+			path:           path,
+			requestBody:    service.ImportMCPRequest{Args: []string{"server"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid application ID",
+			path:           "/applications/abc/import/mcp",
+			requestBody:    service.ImportMCPRequest{Command: "npx"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid JSON format",
+			path:           path,
+			requestBody:    `{invalid json}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if str, ok := tt.requestBody.(string); ok {
+				body = []byte(str)
+			} else {
+				body, _ = json.Marshal(tt.requestBody)
+			}
+			req, _ := http.NewRequest(http.MethodPost, tt.path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
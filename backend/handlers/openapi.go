@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportOpenAPI 从 OpenAPI 文档导入接口和自定义类型
+func ImportOpenAPI(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid application ID")
+		return
+	}
+	var req service.ImportOpenAPIRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	req.AppID = id
+	resp, err := service.ImportOpenAPI(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExportOpenAPI 将应用导出为 OpenAPI 3.1 文档，format=yaml 时输出 YAML
+func ExportOpenAPI(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid application ID")
+		return
+	}
+	doc, err := service.ExportOpenAPI(service.ExportOpenAPIRequest{ID: id})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("format") == "yaml" {
+		c.YAML(http.StatusOK, doc)
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
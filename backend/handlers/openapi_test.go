@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportOpenAPI(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	app := models.Application{Name: "OpenAPI App", Path: "openapi-app", Protocol: "sse", Enabled: true}
+	database.GetDB().Create(&app)
+
+	router := setupTestRouter()
+	router.POST("/applications/:id/import/openapi", ImportOpenAPI)
+
+	spec := `{"openapi":"3.1.0","info":{"title":"t","version":"1"},"servers":[{"url":"https://api.example.com"}],
+		"paths":{"/users/{id}":{"get":{"operationId":"getUser","parameters":[{"name":"id","in":"path","required":true,"schema":{"type":"integer"}}],"responses":{"200":{"description":"ok"}}}}}}`
+
+	tests := []struct {
+		name           string
+		path           string
+		requestBody    interface{}
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "dry run preview",
+			path:           "/applications/" + strconv.FormatInt(app.ID, 10) + "/import/openapi",
+			requestBody:    service.ImportOpenAPIRequest{Spec: spec, DryRun: true},
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var result service.ImportResponse
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+				assert.True(t, result.DryRun)
+				assert.Len(t, result.Interfaces, 1)
+				assert.Equal(t, "getUser", result.Interfaces[0].Name)
+				assert.Equal(t, "create", result.Interfaces[0].Action)
+			},
+		},
+		{
+			name:           "import",
+			path:           "/applications/" + strconv.FormatInt(app.ID, 10) + "/import/openapi",
+			requestBody:    service.ImportOpenAPIRequest{Spec: spec},
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var count int64
+				database.GetDB().Model(&models.Interface{}).Where("app_id = ? AND name = ?", app.ID, "getUser").Count(&count)
+				assert.Equal(t, int64(1), count)
+			},
+		},
+		{
+			name:           "invalid application ID",
+			path:           "/applications/abc/import/openapi",
+			requestBody:    service.ImportOpenAPIRequest{Spec: spec},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid JSON format",
+			path:           "/applications/" + strconv.FormatInt(app.ID, 10) + "/import/openapi",
+			requestBody:    `{invalid json}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported document",
+			path:           "/applications/" + strconv.FormatInt(app.ID, 10) + "/import/openapi",
+			requestBody:    service.ImportOpenAPIRequest{Spec: `{"swagger":"2.0"}`},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if str, ok := tt.requestBody.(string); ok {
+				body = []byte(str)
+			} else {
+				body, _ = json.Marshal(tt.requestBody)
+			}
+			req, _ := http.NewRequest(http.MethodPost, tt.path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
+
+func TestExportOpenAPI(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+
+	app := models.Application{Name: "Export App", Path: "export-app", Protocol: "sse", Enabled: true}
+	db := database.GetDB()
+	db.Create(&app)
+	db.Create(&models.Interface{AppID: app.ID, Name: "Ping", Protocol: "http", URL: "https://api.example.com/ping", Method: "GET", AuthType: "none", Enabled: true})
+
+	router := setupTestRouter()
+	router.GET("/applications/:id/openapi", ExportOpenAPI)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "json document",
+			path:           "/applications/" + strconv.FormatInt(app.ID, 10) + "/openapi",
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				var doc map[string]any
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &doc))
+				assert.Equal(t, "3.1.0", doc["openapi"])
+				assert.Contains(t, doc["paths"], "/ping")
+			},
+		},
+		{
+			name:           "yaml document",
+			path:           "/applications/" + strconv.FormatInt(app.ID, 10) + "/openapi?format=yaml",
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Contains(t, resp.Header().Get("Content-Type"), "yaml")
+				assert.Contains(t, resp.Body.String(), "openapi: 3.1.0")
+			},
+		},
+		{
+			name:           "invalid application ID",
+			path:           "/applications/abc/openapi",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "non-existent application",
+			path:           "/applications/9999/openapi",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
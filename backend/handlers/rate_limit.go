@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRateLimits 查看限流器当前状态，可以通过 app_id 查询参数按应用过滤
+func ListRateLimits(c *gin.Context) {
+	var req service.ListRateLimitsRequest
+	if value := c.Query("app_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Invalid app_id")
+			return
+		}
+		req.AppID = id
+	}
+	resp, err := service.ListRateLimits(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListRateLimits(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/rate-limits", ListRateLimits)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "list all", path: "/rate-limits", expectedStatus: http.StatusOK, expectedBody: `{"rate_limits":[]}`},
+		{name: "filter by application", path: "/rate-limits?app_id=1", expectedStatus: http.StatusOK, expectedBody: `{"rate_limits":[]}`},
+		{name: "invalid app_id", path: "/rate-limits?app_id=abc", expectedStatus: http.StatusBadRequest},
+		{name: "negative app_id", path: "/rate-limits?app_id=-1", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunScript 用示例输入试运行脚本钩子
+func RunScript(c *gin.Context) {
+	var req service.RunScriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	resp, err := service.RunScript(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
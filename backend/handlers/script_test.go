@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunScript(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/scripts/test", RunScript)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp service.RunScriptResponse)
+	}{
+		{
+			name:           "run pre_request",
+			requestBody:    `{"script":"def pre_request(params):\n    params[\"header\"][\"X-Env\"] = \"test\"","hook":"pre_request","input":{"query":{"q":"go"}}}`,
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp service.RunScriptResponse) {
+				assert.Empty(t, resp.Error)
+				output := resp.Output.(map[string]any)
+				assert.Equal(t, map[string]any{"X-Env": "test"}, output["header"])
+				assert.Equal(t, map[string]any{"q": "go"}, output["query"])
+			},
+		},
+		{
+			name:           "compile error is reported in response",
+			requestBody:    `{"script":"def post_response(","hook":"post_response","input":"x"}`,
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp service.RunScriptResponse) {
+				assert.Contains(t, resp.Error, "invalid script")
+			},
+		},
+		{
+			name:           "unknown hook",
+			requestBody:    `{"script":"def pre_request(p): pass","hook":"on_error"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid JSON format",
+			requestBody:    `{invalid`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/scripts/test", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.validateFunc != nil {
+				var body service.RunScriptResponse
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+				tt.validateFunc(t, body)
+			}
+		})
+	}
+}
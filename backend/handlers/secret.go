@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"mcp-adapter/backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSecret 创建密钥
+func CreateSecret(c *gin.Context) {
+	var req service.CreateSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	resp, err := service.CreateSecret(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp.Secret)
+}
+
+// GetSecrets 获取所有密钥，值均为掩码
+func GetSecrets(c *gin.Context) {
+	resp, err := service.ListSecrets(service.ListSecretsRequest{})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to fetch secrets")
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSecret 获取单个密钥，值为掩码
+func GetSecret(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid secret ID")
+		return
+	}
+	resp, err := service.GetSecret(service.GetSecretRequest{ID: id})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp.Secret)
+}
+
+// UpdateSecret 更新密钥（部分字段）
+func UpdateSecret(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid secret ID")
+		return
+	}
+	var body service.UpdateSecretRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	body.ID = id
+	resp, err := service.UpdateSecret(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp.Secret)
+}
+
+// DeleteSecret 删除密钥
+func DeleteSecret(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid secret ID")
+		return
+	}
+	_, err = service.DeleteSecret(service.DeleteSecretRequest{ID: id})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
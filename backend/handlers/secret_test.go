@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretCRUD(t *testing.T) {
+	setupTestDB()
+	defer cleanupTestDB()
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	router := setupTestRouter()
+	router.POST("/secrets", CreateSecret)
+	router.GET("/secrets", GetSecrets)
+	router.GET("/secrets/:id", GetSecret)
+	router.PUT("/secrets/:id", UpdateSecret)
+	router.DELETE("/secrets/:id", DeleteSecret)
+
+	const plain = "plaintext-value-9876"
+	var created service.SecretDTO
+
+	tests := []struct {
+		name           string
+		method         string
+		path           func() string
+		requestBody    string
+		expectedStatus int
+		validateFunc   func(t *testing.T, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "create secret",
+			method:         http.MethodPost,
+			path:           func() string { return "/secrets" },
+			requestBody:    `{"name":"api-key","value":"` + plain + `"}`,
+			expectedStatus: http.StatusOK,
+			validateFunc: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+				assert.Equal(t, "********9876", created.Value)
+			},
+		},
+		{
+			name:           "invalid JSON format",
+			method:         http.MethodPost,
+			path:           func() string { return "/secrets" },
+			requestBody:    `{invalid`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "list secrets",
+			method:         http.MethodGet,
+			path:           func() string { return "/secrets" },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "get secret",
+			method:         http.MethodGet,
+			path:           func() string { return "/secrets/" + strconv.FormatInt(created.ID, 10) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "update secret",
+			method:         http.MethodPut,
+			path:           func() string { return "/secrets/" + strconv.FormatInt(created.ID, 10) },
+			requestBody:    `{"description":"rotated","value":"` + plain + `-2"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid secret ID",
+			method:         http.MethodGet,
+			path:           func() string { return "/secrets/abc" },
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "delete secret",
+			method:         http.MethodDelete,
+			path:           func() string { return "/secrets/" + strconv.FormatInt(created.ID, 10) },
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "get deleted secret",
+			method:         http.MethodGet,
+			path:           func() string { return "/secrets/" + strconv.FormatInt(created.ID, 10) },
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path(), bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			// 任何响应都不能包含明文
+			assert.False(t, strings.Contains(resp.Body.String(), plain))
+			if tt.validateFunc != nil {
+				tt.validateFunc(t, resp)
+			}
+		})
+	}
+}
@@ -29,9 +29,18 @@ func cleanupTestDB() {
 	db.Exec("DELETE FROM custom_type_fields")
 	db.Exec("DELETE FROM custom_types")
 	db.Exec("DELETE FROM applications")
+	db.Exec("DELETE FROM secrets")
+	db.Exec("DELETE FROM datasources")
+	db.Exec("DELETE FROM response_cache_entries")
+	db.Exec("DELETE FROM tool_invocations")
 }
 
 // stringPtr 返回字符串指针
 func stringPtr(s string) *string {
 	return &s
 }
+
+// boolPtr 返回布尔值指针
+func boolPtr(b bool) *bool {
+	return &b
+}
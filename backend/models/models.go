@@ -8,34 +8,48 @@ import (
 
 // Application 应用实体
 type Application struct {
-	ID          int64          `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"not null;size:255" validate:"required"` // 应用名称
-	Description string         `json:"description" gorm:"type:text"`                      // 应用描述
-	Path        string         `json:"path" gorm:"size:255"`                              // 应用路径标识
-	Protocol    string         `json:"protocol" gorm:"size:255"`                          // 应用对外协议 sse, streamable
-	PostProcess string         `json:"post_process" gorm:"type:text"`                     // 后处理脚本
-	Environment string         `json:"environment" gorm:"type:text"`                      // 环境变量 (JSON String)
-	Enabled     bool           `json:"enabled" gorm:"default:true"`                       // 是否启用
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             int64          `json:"id" gorm:"primaryKey"`
+	Name           string         `json:"name" gorm:"not null;size:255" validate:"required"` // 应用名称
+	Description    string         `json:"description" gorm:"type:text"`                      // 应用描述
+	Path           string         `json:"path" gorm:"size:255"`                              // 应用路径标识
+	Protocol       string         `json:"protocol" gorm:"size:255"`                          // 应用对外协议 sse, streamable
+	PostProcess    string         `json:"post_process" gorm:"type:text"`                     // 应用级脚本钩子 (Starlark)，定义 pre_request / post_response，作用于应用下所有接口
+	Environment    string         `json:"environment" gorm:"type:text"`                      // 环境变量 (JSON 对象，接口 URL 和 fixed 参数中通过 ${VAR} 引用)
+	ForwardHeaders string         `json:"forward_headers" gorm:"type:text"`                  // 透传的 MCP 客户端请求头 (JSON 对象，客户端头名 -> 上游头名)
+	HTTPConfig     string         `json:"http_config" gorm:"type:text"`                      // HTTP 客户端默认配置 (JSON)，接口可按字段覆盖
+	RateLimit      string         `json:"rate_limit" gorm:"type:text"`                       // 应用下所有工具合计的限流和并发限制 (JSON)
+	InvocationLog  string         `json:"invocation_log" gorm:"type:text"`                   // 调用日志配置 (JSON)：是否记录、保留时间、脱敏规则
+	Upstream       string         `json:"upstream" gorm:"type:text"`                         // 挂载的远程 MCP 服务器 (JSON)：地址、传输方式、工具名前缀、allow/deny 列表和凭证
+	Enabled        bool           `json:"enabled" gorm:"default:true"`                       // 是否启用
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // Interface 接口实体
 type Interface struct {
-	ID          int64          `json:"id" gorm:"primaryKey"`
-	AppID       int64          `json:"app_id" gorm:"not null;index" validate:"required"`  // 应用ID 一个应用对应多个Interface
-	Name        string         `json:"name" gorm:"not null;size:255" validate:"required"` // 接口名称
-	Description string         `json:"description" gorm:"type:text"`                      // 接口描述
-	Protocol    string         `json:"protocol"`                                          // 接口协议: HTTP
-	URL         string         `json:"url"`                                               // 接口地址
-	Method      string         `json:"method" gorm:"size:50"`                             // HTTP方法: GET, POST, PUT, DELETE等
-	AuthType    string         `json:"auth_type"`                                         // 鉴权类型
-	Enabled     bool           `json:"enabled" gorm:"default:true"`                       // 是否启用
-	PostProcess string         `json:"post_process" gorm:"type:text"`                     // 后处理脚本
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            int64          `json:"id" gorm:"primaryKey"`
+	AppID         int64          `json:"app_id" gorm:"not null;index" validate:"required"`  // 应用ID 一个应用对应多个Interface
+	Name          string         `json:"name" gorm:"not null;size:255" validate:"required"` // 接口名称
+	Description   string         `json:"description" gorm:"type:text"`                      // 接口描述
+	Protocol      string         `json:"protocol"`                                          // 接口协议: http, graphql, grpc, sql, command, mcp
+	URL           string         `json:"url"`                                               // 接口地址
+	Method        string         `json:"method" gorm:"size:50"`                             // HTTP方法: GET, POST, PUT, DELETE等
+	AuthType      string         `json:"auth_type"`                                         // 鉴权类型: none, capi, bearer, basic, apikey, oauth2
+	AuthConfig    string         `json:"auth_config" gorm:"type:text"`                      // 鉴权配置 (JSON String)
+	HTTPConfig    string         `json:"http_config" gorm:"type:text"`                      // HTTP 客户端配置 (JSON String)：超时、重试、TLS、代理
+	BodyEncoding  string         `json:"body_encoding" gorm:"size:20"`                      // 请求体编码: json, form, multipart, raw，空表示 json
+	BodyTemplate  string         `json:"body_template" gorm:"type:text"`                    // 请求体模板 (Go text/template)，为空时按参数名生成扁平的请求体
+	Query         string         `json:"query" gorm:"type:text"`                            // GraphQL 查询文档；sql 接口的 SELECT 语句 (:name 占位符绑定 body 参数)；command 接口的参数模板和 mcp 接口的启动参数 (每行一个)
+	DescriptorSet string         `json:"descriptor_set" gorm:"type:text"`                   // gRPC FileDescriptorSet (base64)，为空时通过服务端反射解析方法
+	Enabled       bool           `json:"enabled" gorm:"default:true"`                       // 是否启用
+	PostProcess   string         `json:"post_process" gorm:"type:text"`                     // 后处理配置 (JSON)：截取字段、结构化输出、响应处理管道
+	Script        string         `json:"script" gorm:"type:text"`                           // 接口级脚本钩子 (Starlark)，定义 pre_request / post_response
+	CacheConfig   string         `json:"cache_config" gorm:"type:text"`                     // 响应缓存配置 (JSON String)，为空表示不缓存
+	RateLimit     string         `json:"rate_limit" gorm:"type:text"`                       // 工具的限流和并发限制 (JSON String)，为空表示不限制
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // CustomType 自定义类型定义（纯类型定义，不包含使用属性）
@@ -84,6 +98,66 @@ type InterfaceParameter struct {
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// Secret 密钥，值使用主密钥加密后存储，通过 secret://name 引用
+type Secret struct {
+	ID          int64          `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"not null;size:255" validate:"required"` // 密钥名称，全局唯一
+	Description string         `json:"description" gorm:"type:text"`                      // 密钥描述
+	Value       string         `json:"-" gorm:"type:text;not null"`                       // 加密后的值 (base64)
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// Datasource sql 接口使用的数据库连接，接口地址 sql://name 引用
+type Datasource struct {
+	ID          int64          `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"not null;size:255" validate:"required"` // 数据源名称，全局唯一
+	Description string         `json:"description" gorm:"type:text"`                      // 数据源描述
+	Driver      string         `json:"driver" gorm:"size:20"`                             // 数据库驱动: sqlite, mysql
+	DSN         string         `json:"dsn" gorm:"type:text"`                              // 连接串，可以是 secret://name
+	MaxRows     int            `json:"max_rows"`                                          // 单次查询最多返回的行数，0 表示默认 100
+	Timeout     string         `json:"timeout" gorm:"size:50"`                            // 语句超时，如 5s，为空表示默认 10s
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// ResponseCacheEntry 工具响应缓存 (database 后端)，多个实例共享，过期和超出限制的记录直接删除
+type ResponseCacheEntry struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Key         string    `json:"key" gorm:"not null;size:128;uniqueIndex"` // 接口 ID + 参数摘要
+	AppID       int64     `json:"app_id" gorm:"not null;index"`
+	InterfaceID int64     `json:"interface_id" gorm:"not null;index"`
+	Value       []byte    `json:"-" gorm:"type:longblob"` // 上游响应体
+	Size        int64     `json:"size"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ToolInvocation 工具调用日志，参数、请求头和查询参数按应用配置的规则脱敏，响应体截断后保存
+type ToolInvocation struct {
+	ID             int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	AppID          int64     `json:"app_id" gorm:"not null;index"`
+	InterfaceID    int64     `json:"interface_id" gorm:"not null;index"`
+	Tool           string    `json:"tool" gorm:"size:255;index"`
+	SessionID      string    `json:"session_id" gorm:"size:255;index"` // MCP 会话 ID
+	Arguments      string    `json:"arguments" gorm:"type:text"`       // 模型传入的参数 (JSON)
+	Method         string    `json:"method" gorm:"size:16"`            // 最终请求上游的方法
+	URL            string    `json:"url" gorm:"type:text"`             // 最终请求上游的 URL
+	RequestHeaders string    `json:"request_headers" gorm:"type:text"` // 上游请求头 (JSON)
+	Status         string    `json:"status" gorm:"size:16;index"`      // success 或 error
+	StatusCode     int       `json:"status_code"`                      // 上游响应状态码，未发出请求或连接失败时为 0
+	Attempts       int       `json:"attempts"`                         // 包含重试的请求次数
+	CacheHit       bool      `json:"cache_hit"`
+	LatencyMs      int64     `json:"latency_ms"`
+	ResponseSize   int64     `json:"response_size"`                  // 上游响应体大小 (字节)
+	ResponseBody   string    `json:"response_body" gorm:"type:text"` // 截断后的上游响应体
+	Truncated      bool      `json:"truncated"`
+	Error          string    `json:"error" gorm:"type:text"` // 返回给模型的错误
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+}
+
 // EventLog 事件日志表
 type EventLog struct {
 	ID              int64          `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -27,6 +27,13 @@ func SetupRoutes() *gin.Engine {
 		api.PUT("/applications/:id", handlers.UpdateApplication)
 		api.DELETE("/applications/:id", handlers.DeleteApplication)
 		api.GET("/applications-detail/:id", handlers.GetApplicationDetail)
+		api.POST("/applications/:id/import/openapi", handlers.ImportOpenAPI)
+		api.POST("/applications/:id/import/graphql", handlers.ImportGraphQL)
+		api.POST("/applications/:id/import/grpc", handlers.ImportGRPC)
+		api.POST("/applications/:id/import/mcp", handlers.ImportMCP)
+		api.GET("/applications/:id/openapi", handlers.ExportOpenAPI)
+		api.GET("/applications/:id/export", handlers.ExportApplicationBundle)
+		api.POST("/applications/import", handlers.ImportApplicationBundle)
 
 		// 接口相关路由
 		api.POST("/interfaces", handlers.CreateInterface)
@@ -41,6 +48,36 @@ func SetupRoutes() *gin.Engine {
 		api.GET("/custom-types/:id", handlers.GetCustomType)
 		api.PUT("/custom-types/:id", handlers.UpdateCustomType)
 		api.DELETE("/custom-types/:id", handlers.DeleteCustomType)
+
+		// 密钥相关路由，值只返回掩码
+		api.POST("/secrets", handlers.CreateSecret)
+		api.GET("/secrets", handlers.GetSecrets)
+		api.GET("/secrets/:id", handlers.GetSecret)
+		api.PUT("/secrets/:id", handlers.UpdateSecret)
+		api.DELETE("/secrets/:id", handlers.DeleteSecret)
+
+		// 数据源相关路由，sql 接口通过 sql://name 引用
+		api.POST("/datasources", handlers.CreateDatasource)
+		api.GET("/datasources", handlers.GetDatasources)
+		api.GET("/datasources/:id", handlers.GetDatasource)
+		api.PUT("/datasources/:id", handlers.UpdateDatasource)
+		api.DELETE("/datasources/:id", handlers.DeleteDatasource)
+
+		// 脚本调试路由，用示例输入执行 pre_request / post_response
+		api.POST("/scripts/test", handlers.RunScript)
+
+		// 响应缓存路由，按 app_id 或 interface_id 清除
+		api.DELETE("/cache", handlers.PurgeCache)
+
+		// 限流状态路由，查看当前实例的令牌和进行中的调用数
+		api.GET("/rate-limits", handlers.ListRateLimits)
+
+		// 熔断器状态路由，查看上游主机或工具的熔断状态
+		api.GET("/circuit-breakers", handlers.ListCircuitBreakers)
+
+		// 工具调用日志路由
+		api.GET("/invocations", handlers.ListInvocations)
+		api.GET("/invocations/:id", handlers.GetInvocation)
 	}
 
 	// 静态文件服务
@@ -3,6 +3,7 @@ package service
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"mcp-adapter/backend/adapter"
 	"mcp-adapter/backend/database"
@@ -10,18 +11,24 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
 )
 
 var validate = validator.New()
 
 type CreateApplicationRequest struct {
-	Name        string `json:"name" validate:"required,max=128"`                  // 应用名称 不允许重复
-	Description string `json:"description" validate:"max=16384"`                  // 应用描述
-	Path        string `json:"path" validate:"required,max=128"`                  // 应用路由标识
-	Protocol    string `json:"protocol" validate:"required,oneof=sse streamable"` // 应用暴露协议
-	PostProcess string `json:"post_process" validate:"max=1048576"`               // 应用后处理脚本
-	Environment string `json:"environment" validate:"max=1048576"`                // 应用环境变量
-	Enabled     *bool  `json:"enabled,omitempty"`                                 // 是否启用应用
+	Name           string `json:"name" validate:"required,max=128"`                  // 应用名称 不允许重复
+	Description    string `json:"description" validate:"max=16384"`                  // 应用描述
+	Path           string `json:"path" validate:"required,max=128"`                  // 应用路由标识
+	Protocol       string `json:"protocol" validate:"required,oneof=sse streamable"` // 应用暴露协议
+	PostProcess    string `json:"post_process" validate:"max=1048576"`               // 应用脚本钩子 (Starlark)，定义 pre_request / post_response
+	Environment    string `json:"environment" validate:"max=1048576"`                // 应用环境变量
+	ForwardHeaders string `json:"forward_headers" validate:"max=65536"`              // 透传到上游的 MCP 客户端请求头
+	HTTPConfig     string `json:"http_config" validate:"max=65536"`                  // HTTP 客户端配置，接口可覆盖
+	RateLimit      string `json:"rate_limit" validate:"max=65536"`                   // 应用下所有工具合计的限流配置
+	InvocationLog  string `json:"invocation_log" validate:"max=65536"`               // 调用日志配置：enabled, retention, max_body_bytes, redact
+	Upstream       string `json:"upstream" validate:"max=65536"`                     // 挂载的远程 MCP 服务器配置
+	Enabled        *bool  `json:"enabled,omitempty"`                                 // 是否启用应用
 }
 
 type GetApplicationRequest struct {
@@ -32,14 +39,19 @@ type GetApplicationRequest struct {
 type ListApplicationsRequest struct{}
 
 type UpdateApplicationRequest struct {
-	ID          int64   `json:"id" validate:"required,gt=0"`
-	Name        *string `json:"name" validate:"omitempty,max=128"`                  // 应用名称 不允许重复
-	Description *string `json:"description" validate:"omitempty,max=16384"`         // 应用描述
-	Path        *string `json:"path" validate:"omitempty,max=128"`                  // 应用路径标识
-	Protocol    *string `json:"protocol" validate:"omitempty,oneof=sse streamable"` // 应用暴露协议
-	PostProcess *string `json:"post_process" validate:"omitempty,max=1048576"`      // 应用后处理脚本
-	Environment *string `json:"environment" validate:"omitempty,max=1048576"`       // 应用环境变量
-	Enabled     *bool   `json:"enabled,omitempty"`                                  // 是否启用应用
+	ID             int64   `json:"id" validate:"required,gt=0"`
+	Name           *string `json:"name" validate:"omitempty,max=128"`                  // 应用名称 不允许重复
+	Description    *string `json:"description" validate:"omitempty,max=16384"`         // 应用描述
+	Path           *string `json:"path" validate:"omitempty,max=128"`                  // 应用路径标识
+	Protocol       *string `json:"protocol" validate:"omitempty,oneof=sse streamable"` // 应用暴露协议
+	PostProcess    *string `json:"post_process" validate:"omitempty,max=1048576"`      // 应用脚本钩子 (Starlark)
+	Environment    *string `json:"environment" validate:"omitempty,max=1048576"`       // 应用环境变量
+	ForwardHeaders *string `json:"forward_headers" validate:"omitempty,max=65536"`     // 透传到上游的 MCP 客户端请求头
+	HTTPConfig     *string `json:"http_config" validate:"omitempty,max=65536"`         // HTTP 客户端配置，接口可覆盖
+	RateLimit      *string `json:"rate_limit" validate:"omitempty,max=65536"`          // 应用下所有工具合计的限流配置，空字符串表示不限制
+	InvocationLog  *string `json:"invocation_log" validate:"omitempty,max=65536"`      // 调用日志配置，空字符串表示使用默认配置
+	Upstream       *string `json:"upstream" validate:"omitempty,max=65536"`            // 挂载的远程 MCP 服务器配置，空字符串表示不挂载
+	Enabled        *bool   `json:"enabled,omitempty"`                                  // 是否启用应用
 }
 
 type DeleteApplicationRequest struct {
@@ -47,16 +59,21 @@ type DeleteApplicationRequest struct {
 }
 
 type ApplicationDTO struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Path        string    `json:"path"`
-	Protocol    string    `json:"protocol"`
-	PostProcess string    `json:"post_process"`
-	Environment string    `json:"environment"`
-	Enabled     bool      `json:"enabled"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Path           string    `json:"path"`
+	Protocol       string    `json:"protocol"`
+	PostProcess    string    `json:"post_process"`
+	Environment    string    `json:"environment"`
+	ForwardHeaders string    `json:"forward_headers"`
+	HTTPConfig     string    `json:"http_config"`
+	RateLimit      string    `json:"rate_limit"`
+	InvocationLog  string    `json:"invocation_log"`
+	Upstream       string    `json:"upstream"`
+	Enabled        bool      `json:"enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type FixedInputDTO struct {
@@ -100,16 +117,21 @@ type EmptyResponse struct{}
 
 func toApplicationDTO(m models.Application) ApplicationDTO {
 	return ApplicationDTO{
-		ID:          m.ID,
-		Name:        m.Name,
-		Description: m.Description,
-		Path:        m.Path,
-		Protocol:    m.Protocol,
-		PostProcess: m.PostProcess,
-		Environment: m.Environment,
-		Enabled:     m.Enabled,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
+		ID:             m.ID,
+		Name:           m.Name,
+		Description:    m.Description,
+		Path:           m.Path,
+		Protocol:       m.Protocol,
+		PostProcess:    m.PostProcess,
+		Environment:    m.Environment,
+		ForwardHeaders: m.ForwardHeaders,
+		HTTPConfig:     m.HTTPConfig,
+		RateLimit:      m.RateLimit,
+		InvocationLog:  m.InvocationLog,
+		Upstream:       m.Upstream,
+		Enabled:        m.Enabled,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
 	}
 }
 
@@ -118,14 +140,42 @@ func CreateApplication(req CreateApplicationRequest) (ApplicationResponse, error
 	if err := validate.Struct(req); err != nil {
 		return ApplicationResponse{}, err
 	}
+	if _, err := adapter.ParseForwardHeaders(req.ForwardHeaders); err != nil {
+		return ApplicationResponse{}, err
+	}
+	// 应用脚本钩子对所有接口生效，保存前编译检查
+	if _, err := adapter.CompileScript(req.Name, req.PostProcess); err != nil {
+		return ApplicationResponse{}, err
+	}
+	if _, err := adapter.ParseRateLimitConfig(req.RateLimit); err != nil {
+		return ApplicationResponse{}, err
+	}
+	if _, err := adapter.ParseInvocationLogConfig(req.InvocationLog); err != nil {
+		return ApplicationResponse{}, err
+	}
 	db := database.GetDB()
+	// 环境变量必须是 JSON 对象
+	if err := checkEnvironment(db, req.Environment); err != nil {
+		return ApplicationResponse{}, err
+	}
+	if err := checkHTTPConfig(db, req.HTTPConfig); err != nil {
+		return ApplicationResponse{}, err
+	}
+	if err := checkUpstreamConfig(db, req.Upstream); err != nil {
+		return ApplicationResponse{}, err
+	}
 	app := models.Application{
-		Name:        req.Name,
-		Description: req.Description,
-		Path:        req.Path,
-		Protocol:    req.Protocol,
-		PostProcess: req.PostProcess,
-		Environment: req.Environment,
+		Name:           req.Name,
+		Description:    req.Description,
+		Path:           req.Path,
+		Protocol:       req.Protocol,
+		PostProcess:    req.PostProcess,
+		Environment:    req.Environment,
+		ForwardHeaders: req.ForwardHeaders,
+		HTTPConfig:     req.HTTPConfig,
+		RateLimit:      req.RateLimit,
+		InvocationLog:  req.InvocationLog,
+		Upstream:       req.Upstream,
 	}
 	if req.Enabled != nil {
 		app.Enabled = *req.Enabled
@@ -144,6 +194,12 @@ func CreateApplication(req CreateApplicationRequest) (ApplicationResponse, error
 	if err := db.Create(&app).Error; err != nil {
 		return ApplicationResponse{}, err
 	}
+	// gorm 对零值字段使用数据库默认值 (true)，显式禁用时需要单独写入
+	if req.Enabled != nil && !*req.Enabled {
+		if err := db.Model(&app).Update("enabled", false).Error; err != nil {
+			return ApplicationResponse{}, err
+		}
+	}
 	adapter.SendEvent(adapter.Event{
 		App:       &app,
 		Interface: nil,
@@ -282,11 +338,47 @@ func UpdateApplication(req UpdateApplicationRequest) (ApplicationResponse, error
 		existing.Protocol = *req.Protocol
 	}
 	if req.PostProcess != nil {
+		if _, err := adapter.CompileScript(existing.Name, *req.PostProcess); err != nil {
+			return ApplicationResponse{}, err
+		}
 		existing.PostProcess = *req.PostProcess
 	}
 	if req.Environment != nil {
+		if err := checkEnvironment(db, *req.Environment); err != nil {
+			return ApplicationResponse{}, err
+		}
 		existing.Environment = *req.Environment
 	}
+	if req.ForwardHeaders != nil {
+		if _, err := adapter.ParseForwardHeaders(*req.ForwardHeaders); err != nil {
+			return ApplicationResponse{}, err
+		}
+		existing.ForwardHeaders = *req.ForwardHeaders
+	}
+	if req.HTTPConfig != nil {
+		if err := checkHTTPConfig(db, *req.HTTPConfig); err != nil {
+			return ApplicationResponse{}, err
+		}
+		existing.HTTPConfig = *req.HTTPConfig
+	}
+	if req.RateLimit != nil {
+		if _, err := adapter.ParseRateLimitConfig(*req.RateLimit); err != nil {
+			return ApplicationResponse{}, err
+		}
+		existing.RateLimit = *req.RateLimit
+	}
+	if req.InvocationLog != nil {
+		if _, err := adapter.ParseInvocationLogConfig(*req.InvocationLog); err != nil {
+			return ApplicationResponse{}, err
+		}
+		existing.InvocationLog = *req.InvocationLog
+	}
+	if req.Upstream != nil {
+		if err := checkUpstreamConfig(db, *req.Upstream); err != nil {
+			return ApplicationResponse{}, err
+		}
+		existing.Upstream = *req.Upstream
+	}
 	if req.Enabled != nil {
 		existing.Enabled = *req.Enabled
 	}
@@ -338,3 +430,24 @@ func DeleteApplication(req DeleteApplicationRequest) (EmptyResponse, error) {
 	})
 	return EmptyResponse{}, nil
 }
+
+// checkEnvironment 校验应用环境变量，以及变量值中引用的密钥是否存在
+func checkEnvironment(tx *gorm.DB, raw string) error {
+	env, err := adapter.ParseEnvironment(raw)
+	if err != nil {
+		return err
+	}
+	return checkSecretRefs(tx, adapter.EnvironmentSecretRefs(env)...)
+}
+
+// checkUpstreamConfig 校验远程 MCP 服务器配置，以及鉴权配置和请求头中引用的密钥是否存在
+func checkUpstreamConfig(tx *gorm.DB, raw string) error {
+	cfg, err := adapter.ParseUpstreamConfig(raw)
+	if err != nil {
+		return err
+	}
+	if field, ok := adapter.UpstreamConfigPlaintextCredential(cfg); ok {
+		return fmt.Errorf("upstream auth_config %s must be a secret:// reference", field)
+	}
+	return checkSecretRefs(tx, adapter.UpstreamConfigSecretRefs(cfg)...)
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"mcp-adapter/backend/adapter"
 	"mcp-adapter/backend/database"
 	"mcp-adapter/backend/models"
 	"testing"
@@ -25,6 +26,10 @@ func cleanupTestDB() {
 	db.Exec("DELETE FROM custom_type_fields")
 	db.Exec("DELETE FROM custom_types")
 	db.Exec("DELETE FROM applications")
+	db.Exec("DELETE FROM secrets")
+	db.Exec("DELETE FROM datasources")
+	db.Exec("DELETE FROM response_cache_entries")
+	db.Exec("DELETE FROM tool_invocations")
 }
 
 // boolPtr 返回布尔指针
@@ -104,6 +109,28 @@ func TestCreateApplication(t *testing.T) {
 			wantErr: true,
 			errMsg:  "duplicate application path",
 		},
+		{
+			name: "环境变量不是JSON对象",
+			req: CreateApplicationRequest{
+				Name:        "EnvApp",
+				Path:        "env-app",
+				Protocol:    "sse",
+				Environment: `["BASE_URL"]`,
+			},
+			wantErr: true,
+			errMsg:  "environment must be a JSON object",
+		},
+		{
+			name: "透传头不允许转发Host",
+			req: CreateApplicationRequest{
+				Name:           "ForwardApp",
+				Path:           "forward-app",
+				Protocol:       "sse",
+				ForwardHeaders: `{"Host":""}`,
+			},
+			wantErr: true,
+			errMsg:  "header Host cannot be forwarded",
+		},
 		{
 			name: "使用streamable协议",
 			req: CreateApplicationRequest{
@@ -134,6 +161,28 @@ func TestCreateApplication(t *testing.T) {
 	}
 }
 
+func TestCreateApplicationDisabled(t *testing.T) {
+	setupTestDB(t)
+
+	resp, err := CreateApplication(CreateApplicationRequest{
+		Name:     "DisabledApp",
+		Path:     "disabled-app",
+		Protocol: "sse",
+		Enabled:  boolPtr(false),
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Application.Enabled)
+
+	var stored models.Application
+	require.NoError(t, database.GetDB().First(&stored, resp.Application.ID).Error)
+	assert.False(t, stored.Enabled)
+
+	// 未指定时默认启用
+	resp, err = CreateApplication(CreateApplicationRequest{Name: "DefaultApp", Path: "default-app", Protocol: "sse"})
+	require.NoError(t, err)
+	assert.True(t, resp.Application.Enabled)
+}
+
 func TestGetApplication(t *testing.T) {
 	setupTestDB(t)
 
@@ -211,7 +260,7 @@ func TestGetApplicationWithDetail(t *testing.T) {
 		URL:         "https://api.example.com/test",
 		Method:      "GET",
 		AuthType:    "none",
-		Enabled:     true,
+		Enabled:     boolPtr(true),
 		Parameters: []CreateInterfaceParameterReq{
 			{
 				Name:        "param1",
@@ -325,6 +374,40 @@ func TestUpdateApplication(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "成功更新环境变量",
+			req: UpdateApplicationRequest{
+				ID:          createResp.Application.ID,
+				Environment: stringPtr(`{"BASE_URL":"https://prod.example.com"}`),
+			},
+			wantErr: false,
+		},
+		{
+			name: "环境变量值不是标量",
+			req: UpdateApplicationRequest{
+				ID:          createResp.Application.ID,
+				Environment: stringPtr(`{"BASE_URL":{"host":"x"}}`),
+			},
+			wantErr: true,
+			errMsg:  "must be a string, number or boolean",
+		},
+		{
+			name: "成功更新透传头",
+			req: UpdateApplicationRequest{
+				ID:             createResp.Application.ID,
+				ForwardHeaders: stringPtr(`{"Authorization":"","X-User-Token":"X-Backend-Token"}`),
+			},
+			wantErr: false,
+		},
+		{
+			name: "透传头不是JSON对象",
+			req: UpdateApplicationRequest{
+				ID:             createResp.Application.ID,
+				ForwardHeaders: stringPtr(`["Authorization"]`),
+			},
+			wantErr: true,
+			errMsg:  "forward_headers must be a JSON object",
+		},
 		{
 			name: "更新不存在的应用",
 			req: UpdateApplicationRequest{
@@ -367,6 +450,87 @@ func TestUpdateApplication(t *testing.T) {
 	}
 }
 
+func TestApplicationScript(t *testing.T) {
+	setupTestDB(t)
+
+	_, err := CreateApplication(CreateApplicationRequest{Name: "BadScript", Path: "bad-script", Protocol: "sse", PostProcess: "def pre_request(:"})
+	assert.ErrorContains(t, err, "invalid script")
+	_, err = CreateApplication(CreateApplicationRequest{Name: "NoHook", Path: "no-hook", Protocol: "sse", PostProcess: "x = 1"})
+	assert.ErrorContains(t, err, "define pre_request or post_response")
+
+	resp, err := CreateApplication(CreateApplicationRequest{Name: "Scripted", Path: "scripted", Protocol: "sse",
+		PostProcess: "def post_response(body):\n    return body"})
+	require.NoError(t, err)
+	_, err = UpdateApplication(UpdateApplicationRequest{ID: resp.Application.ID, PostProcess: stringPtr("def post_response():\n    pass")})
+	assert.ErrorContains(t, err, "post_response must be a function with one parameter")
+	updated, err := UpdateApplication(UpdateApplicationRequest{ID: resp.Application.ID, PostProcess: stringPtr("")})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Application.PostProcess)
+}
+
+func TestApplicationUpstream(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	_, err := CreateApplication(CreateApplicationRequest{Name: "Remote", Path: "remote", Protocol: "sse", Upstream: `{"url":"mcp.example.com"}`})
+	assert.EqualError(t, err, "upstream url must be an absolute http or https URL")
+	_, err = CreateApplication(CreateApplicationRequest{Name: "Remote", Path: "remote", Protocol: "sse", Upstream: `{"url":"https://mcp.example.com/mcp","auth_type":"bearer","auth_config":{"token":"plain"}}`})
+	assert.EqualError(t, err, "upstream auth_config token must be a secret:// reference")
+	upstream := `{"url":"https://mcp.example.com/mcp","prefix":"gh_","auth_type":"bearer","auth_config":{"token":"secret://gh-token"}}`
+	_, err = CreateApplication(CreateApplicationRequest{Name: "Remote", Path: "remote", Protocol: "sse", Upstream: upstream})
+	assert.EqualError(t, err, "secret gh-token not found")
+
+	secret, err := CreateSecret(CreateSecretRequest{Name: "gh-token", Value: "token-value-1234"})
+	require.NoError(t, err)
+	resp, err := CreateApplication(CreateApplicationRequest{Name: "Remote", Path: "remote", Protocol: "sse", Upstream: upstream})
+	require.NoError(t, err)
+	assert.Equal(t, upstream, resp.Application.Upstream)
+
+	// 远程 MCP 服务器配置引用的密钥不能删除或重命名
+	secretID := secret.Secret.ID
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secretID})
+	assert.EqualError(t, err, "secret is referenced by interfaces")
+	_, err = UpdateSecret(UpdateSecretRequest{ID: secretID, Name: stringPtr("renamed")})
+	assert.EqualError(t, err, "secret is referenced by interfaces and cannot be renamed")
+
+	_, err = UpdateApplication(UpdateApplicationRequest{ID: resp.Application.ID, Upstream: stringPtr(`{"url":"https://mcp.example.com/sse","transport":"websocket"}`)})
+	assert.EqualError(t, err, "upstream transport must be sse or streamable")
+	updated, err := UpdateApplication(UpdateApplicationRequest{ID: resp.Application.ID, Upstream: stringPtr("")})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Application.Upstream)
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secretID})
+	assert.NoError(t, err)
+}
+
+func TestApplicationEnvironmentSecrets(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	environment := `{"BASE_URL":"https://api.example.com","API_TOKEN":"secret://cli-token"}`
+	_, err := CreateApplication(CreateApplicationRequest{Name: "Cli", Path: "cli", Protocol: "sse", Environment: environment})
+	assert.EqualError(t, err, "secret cli-token not found")
+	app, err := CreateApplication(CreateApplicationRequest{Name: "Cli", Path: "cli", Protocol: "sse"})
+	require.NoError(t, err)
+	_, err = UpdateApplication(UpdateApplicationRequest{ID: app.Application.ID, Environment: stringPtr(environment)})
+	assert.EqualError(t, err, "secret cli-token not found")
+
+	secret, err := CreateSecret(CreateSecretRequest{Name: "cli-token", Value: "token-value-1234"})
+	require.NoError(t, err)
+	_, err = UpdateApplication(UpdateApplicationRequest{ID: app.Application.ID, Environment: stringPtr(environment)})
+	require.NoError(t, err)
+
+	// 环境变量引用的密钥不能删除或重命名
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secret.Secret.ID})
+	assert.EqualError(t, err, "secret is referenced by interfaces")
+	_, err = UpdateSecret(UpdateSecretRequest{ID: secret.Secret.ID, Name: stringPtr("renamed")})
+	assert.EqualError(t, err, "secret is referenced by interfaces and cannot be renamed")
+
+	_, err = UpdateApplication(UpdateApplicationRequest{ID: app.Application.ID, Environment: stringPtr(`{"BASE_URL":"https://api.example.com"}`)})
+	require.NoError(t, err)
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secret.Secret.ID})
+	assert.NoError(t, err)
+}
+
 func TestUpdateApplicationDuplicateName(t *testing.T) {
 	setupTestDB(t)
 
@@ -512,14 +676,15 @@ func TestDeleteApplicationWithInterfaces(t *testing.T) {
 
 func TestToApplicationDTO(t *testing.T) {
 	now := models.Application{
-		ID:          1,
-		Name:        "TestApp",
-		Description: "Test description",
-		Path:        "test-path",
-		Protocol:    "sse",
-		PostProcess: "script",
-		Environment: "env",
-		Enabled:     true,
+		ID:             1,
+		Name:           "TestApp",
+		Description:    "Test description",
+		Path:           "test-path",
+		Protocol:       "sse",
+		PostProcess:    "script",
+		Environment:    "env",
+		ForwardHeaders: `{"Authorization":""}`,
+		Enabled:        true,
 	}
 
 	dto := toApplicationDTO(now)
@@ -530,5 +695,6 @@ func TestToApplicationDTO(t *testing.T) {
 	assert.Equal(t, now.Protocol, dto.Protocol)
 	assert.Equal(t, now.PostProcess, dto.PostProcess)
 	assert.Equal(t, now.Environment, dto.Environment)
+	assert.Equal(t, now.ForwardHeaders, dto.ForwardHeaders)
 	assert.Equal(t, now.Enabled, dto.Enabled)
 }
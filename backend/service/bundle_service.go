@@ -0,0 +1,707 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// bundleVersion 导出包格式版本
+const bundleVersion = 1
+
+// ApplicationBundle 应用导出包，类型引用使用类型名称而不是数据库 ID，可以在不同环境之间迁移
+type ApplicationBundle struct {
+	Version     int                `json:"version" yaml:"version"`
+	Application BundleApplication  `json:"application" yaml:"application"`
+	CustomTypes []BundleCustomType `json:"custom_types" yaml:"custom_types"`
+	Interfaces  []BundleInterface  `json:"interfaces" yaml:"interfaces"`
+}
+
+type BundleApplication struct {
+	Name           string `json:"name" yaml:"name"`
+	Description    string `json:"description" yaml:"description"`
+	Path           string `json:"path" yaml:"path"`
+	Protocol       string `json:"protocol" yaml:"protocol"`
+	PostProcess    string `json:"post_process" yaml:"post_process"`
+	Environment    string `json:"environment" yaml:"environment"`
+	ForwardHeaders string `json:"forward_headers,omitempty" yaml:"forward_headers,omitempty"`
+	HTTPConfig     string `json:"http_config,omitempty" yaml:"http_config,omitempty"`
+	RateLimit      string `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	InvocationLog  string `json:"invocation_log,omitempty" yaml:"invocation_log,omitempty"`
+	Upstream       string `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+}
+
+type BundleCustomType struct {
+	Name        string        `json:"name" yaml:"name"`
+	Description string        `json:"description" yaml:"description"`
+	Fields      []BundleField `json:"fields" yaml:"fields"`
+}
+
+type BundleField struct {
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Ref         string `json:"ref,omitempty" yaml:"ref,omitempty"` // 引用的类型名称
+	IsArray     bool   `json:"is_array" yaml:"is_array"`
+	Required    bool   `json:"required" yaml:"required"`
+	Description string `json:"description" yaml:"description"`
+}
+
+type BundleInterface struct {
+	Name          string            `json:"name" yaml:"name"`
+	Description   string            `json:"description" yaml:"description"`
+	Protocol      string            `json:"protocol" yaml:"protocol"`
+	URL           string            `json:"url" yaml:"url"`
+	Method        string            `json:"method" yaml:"method"`
+	AuthType      string            `json:"auth_type" yaml:"auth_type"`
+	AuthConfig    string            `json:"auth_config,omitempty" yaml:"auth_config,omitempty"`
+	HTTPConfig    string            `json:"http_config,omitempty" yaml:"http_config,omitempty"`
+	BodyEncoding  string            `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+	BodyTemplate  string            `json:"body_template,omitempty" yaml:"body_template,omitempty"`
+	Query         string            `json:"query,omitempty" yaml:"query,omitempty"`
+	DescriptorSet string            `json:"descriptor_set,omitempty" yaml:"descriptor_set,omitempty"`
+	Enabled       bool              `json:"enabled" yaml:"enabled"`
+	PostProcess   string            `json:"post_process" yaml:"post_process"`
+	Script        string            `json:"script,omitempty" yaml:"script,omitempty"`
+	CacheConfig   string            `json:"cache_config,omitempty" yaml:"cache_config,omitempty"`
+	RateLimit     string            `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	Parameters    []BundleParameter `json:"parameters" yaml:"parameters"`
+}
+
+type BundleParameter struct {
+	Name         string  `json:"name" yaml:"name"`
+	Type         string  `json:"type" yaml:"type"`
+	Ref          string  `json:"ref,omitempty" yaml:"ref,omitempty"` // 引用的类型名称
+	Location     string  `json:"location" yaml:"location"`
+	IsArray      bool    `json:"is_array" yaml:"is_array"`
+	Required     bool    `json:"required" yaml:"required"`
+	Description  string  `json:"description" yaml:"description"`
+	DefaultValue *string `json:"default_value" yaml:"default_value"`
+	Group        string  `json:"group" yaml:"group"`
+}
+
+type ExportApplicationBundleRequest struct {
+	ID int64 `json:"id" validate:"required,gt=0"`
+}
+
+type ImportApplicationBundleRequest struct {
+	Bundle   ApplicationBundle `json:"bundle"`
+	Conflict string            `json:"conflict" validate:"omitempty,oneof=fail overwrite rename"` // 同 Path 应用已存在时的处理方式，默认 fail
+	Preview  bool              `json:"preview"`                                                   // 只返回差异，不写入数据库
+}
+
+type FieldChangeDTO struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+type BundleDiffSetDTO struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Changed   []string `json:"changed"`
+	Unchanged []string `json:"unchanged"`
+}
+
+type BundleDiffDTO struct {
+	Application []FieldChangeDTO `json:"application"`
+	CustomTypes BundleDiffSetDTO `json:"custom_types"`
+	Interfaces  BundleDiffSetDTO `json:"interfaces"`
+}
+
+type ImportApplicationBundleResponse struct {
+	Preview     bool            `json:"preview"`
+	Action      string          `json:"action"` // create, overwrite, rename
+	Application *ApplicationDTO `json:"application,omitempty"`
+	Diff        *BundleDiffDTO  `json:"diff,omitempty"` // 与同 Path 的现有应用对比，不存在时为空
+}
+
+// ParseApplicationBundle 解析 JSON 或 YAML 格式的导出包
+func ParseApplicationBundle(data []byte) (ApplicationBundle, error) {
+	var bundle ApplicationBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		if yamlErr := yaml.Unmarshal(data, &bundle); yamlErr != nil {
+			return ApplicationBundle{}, fmt.Errorf("invalid bundle: %v", yamlErr)
+		}
+	}
+	return bundle, nil
+}
+
+// buildApplicationBundle 从数据库读取应用的完整定义
+func buildApplicationBundle(db *gorm.DB, app models.Application) (ApplicationBundle, error) {
+	var customTypes []models.CustomType
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&customTypes).Error; err != nil {
+		return ApplicationBundle{}, err
+	}
+	var fields []models.CustomTypeField
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&fields).Error; err != nil {
+		return ApplicationBundle{}, err
+	}
+	var interfaces []models.Interface
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&interfaces).Error; err != nil {
+		return ApplicationBundle{}, err
+	}
+	var params []models.InterfaceParameter
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&params).Error; err != nil {
+		return ApplicationBundle{}, err
+	}
+
+	typeNames := make(map[int64]string, len(customTypes))
+	for _, ct := range customTypes {
+		typeNames[ct.ID] = ct.Name
+	}
+	refName := func(ref *int64) string {
+		if ref == nil {
+			return ""
+		}
+		return typeNames[*ref]
+	}
+	fieldsByType := make(map[int64][]BundleField)
+	for _, f := range fields {
+		fieldsByType[f.CustomTypeID] = append(fieldsByType[f.CustomTypeID], BundleField{
+			Name:        f.Name,
+			Type:        f.Type,
+			Ref:         refName(f.Ref),
+			IsArray:     f.IsArray,
+			Required:    f.Required,
+			Description: f.Description,
+		})
+	}
+	paramsByIface := make(map[int64][]BundleParameter)
+	for _, p := range params {
+		paramsByIface[p.InterfaceID] = append(paramsByIface[p.InterfaceID], BundleParameter{
+			Name:         p.Name,
+			Type:         p.Type,
+			Ref:          refName(p.Ref),
+			Location:     p.Location,
+			IsArray:      p.IsArray,
+			Required:     p.Required,
+			Description:  p.Description,
+			DefaultValue: p.DefaultValue,
+			Group:        p.Group,
+		})
+	}
+
+	bundle := ApplicationBundle{
+		Version: bundleVersion,
+		Application: BundleApplication{
+			Name:           app.Name,
+			Description:    app.Description,
+			Path:           app.Path,
+			Protocol:       app.Protocol,
+			PostProcess:    app.PostProcess,
+			Environment:    app.Environment,
+			ForwardHeaders: app.ForwardHeaders,
+			HTTPConfig:     app.HTTPConfig,
+			RateLimit:      app.RateLimit,
+			InvocationLog:  app.InvocationLog,
+			Upstream:       app.Upstream,
+			Enabled:        app.Enabled,
+		},
+		CustomTypes: make([]BundleCustomType, 0, len(customTypes)),
+		Interfaces:  make([]BundleInterface, 0, len(interfaces)),
+	}
+	for _, ct := range customTypes {
+		f := fieldsByType[ct.ID]
+		if f == nil {
+			f = []BundleField{}
+		}
+		bundle.CustomTypes = append(bundle.CustomTypes, BundleCustomType{
+			Name:        ct.Name,
+			Description: ct.Description,
+			Fields:      f,
+		})
+	}
+	for _, iface := range interfaces {
+		p := paramsByIface[iface.ID]
+		if p == nil {
+			p = []BundleParameter{}
+		}
+		bundle.Interfaces = append(bundle.Interfaces, BundleInterface{
+			Name:          iface.Name,
+			Description:   iface.Description,
+			Protocol:      iface.Protocol,
+			URL:           iface.URL,
+			Method:        iface.Method,
+			AuthType:      iface.AuthType,
+			AuthConfig:    iface.AuthConfig,
+			HTTPConfig:    iface.HTTPConfig,
+			BodyEncoding:  iface.BodyEncoding,
+			BodyTemplate:  iface.BodyTemplate,
+			Query:         iface.Query,
+			DescriptorSet: iface.DescriptorSet,
+			Enabled:       iface.Enabled,
+			PostProcess:   iface.PostProcess,
+			Script:        iface.Script,
+			CacheConfig:   iface.CacheConfig,
+			RateLimit:     iface.RateLimit,
+			Parameters:    p,
+		})
+	}
+	return bundle, nil
+}
+
+// ExportApplicationBundle 导出应用及其接口、参数、自定义类型
+func ExportApplicationBundle(req ExportApplicationBundleRequest) (ApplicationBundle, error) {
+	if err := validate.Struct(req); err != nil {
+		return ApplicationBundle{}, err
+	}
+	db := database.GetDB()
+	var app models.Application
+	if err := db.First(&app, req.ID).Error; err != nil {
+		return ApplicationBundle{}, errors.New("no such application")
+	}
+	return buildApplicationBundle(db, app)
+}
+
+// validateApplicationBundle 导入前检查导出包的结构是否合法
+func validateApplicationBundle(bundle ApplicationBundle) error {
+	if bundle.Version != bundleVersion {
+		return fmt.Errorf("unsupported bundle version: %d", bundle.Version)
+	}
+	app := bundle.Application
+	if err := validate.Struct(CreateApplicationRequest{
+		Name:           app.Name,
+		Description:    app.Description,
+		Path:           app.Path,
+		Protocol:       app.Protocol,
+		PostProcess:    app.PostProcess,
+		Environment:    app.Environment,
+		ForwardHeaders: app.ForwardHeaders,
+		HTTPConfig:     app.HTTPConfig,
+		RateLimit:      app.RateLimit,
+		InvocationLog:  app.InvocationLog,
+		Upstream:       app.Upstream,
+	}); err != nil {
+		return err
+	}
+	if _, err := adapter.ParseEnvironment(app.Environment); err != nil {
+		return err
+	}
+	if _, err := adapter.ParseForwardHeaders(app.ForwardHeaders); err != nil {
+		return err
+	}
+	if _, err := adapter.CompileScript(app.Name, app.PostProcess); err != nil {
+		return err
+	}
+	if _, err := adapter.ParseHTTPConfig(app.HTTPConfig); err != nil {
+		return err
+	}
+	if _, err := adapter.ParseRateLimitConfig(app.RateLimit); err != nil {
+		return err
+	}
+	if _, err := adapter.ParseInvocationLogConfig(app.InvocationLog); err != nil {
+		return err
+	}
+	if _, err := adapter.ParseUpstreamConfig(app.Upstream); err != nil {
+		return err
+	}
+	typeNames := make(map[string]bool, len(bundle.CustomTypes))
+	for _, ct := range bundle.CustomTypes {
+		if typeNames[ct.Name] {
+			return fmt.Errorf("duplicate custom type name in bundle: %s", ct.Name)
+		}
+		typeNames[ct.Name] = true
+	}
+	for _, ct := range bundle.CustomTypes {
+		if err := validate.Struct(CreateCustomTypeRequest{AppID: 1, Name: ct.Name, Description: ct.Description}); err != nil {
+			return fmt.Errorf("custom type %s: %w", ct.Name, err)
+		}
+		for _, f := range ct.Fields {
+			if err := validate.Struct(CreateCustomTypeFieldReq{Name: f.Name, Type: f.Type, Description: f.Description}); err != nil {
+				return fmt.Errorf("custom type %s: %w", ct.Name, err)
+			}
+			if f.Type == "custom" && !typeNames[f.Ref] {
+				return fmt.Errorf("custom type %s: field %s references unknown type %q", ct.Name, f.Name, f.Ref)
+			}
+		}
+	}
+	ifaceNames := make(map[string]bool, len(bundle.Interfaces))
+	for _, iface := range bundle.Interfaces {
+		if ifaceNames[iface.Name] {
+			return fmt.Errorf("duplicate interface name in bundle: %s", iface.Name)
+		}
+		ifaceNames[iface.Name] = true
+		if err := validate.Struct(CreateInterfaceRequest{
+			AppID:         1,
+			Name:          iface.Name,
+			Description:   iface.Description,
+			Protocol:      iface.Protocol,
+			URL:           iface.URL,
+			Method:        iface.Method,
+			AuthType:      iface.AuthType,
+			AuthConfig:    iface.AuthConfig,
+			HTTPConfig:    iface.HTTPConfig,
+			BodyEncoding:  iface.BodyEncoding,
+			BodyTemplate:  iface.BodyTemplate,
+			Query:         iface.Query,
+			DescriptorSet: iface.DescriptorSet,
+			PostProcess:   iface.PostProcess,
+			Script:        iface.Script,
+			CacheConfig:   iface.CacheConfig,
+			RateLimit:     iface.RateLimit,
+		}); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+		for _, p := range iface.Parameters {
+			if err := validate.Struct(CreateInterfaceParameterReq{
+				Name:        p.Name,
+				Type:        p.Type,
+				Location:    p.Location,
+				Description: p.Description,
+				Group:       p.Group,
+			}); err != nil {
+				return fmt.Errorf("interface %s: %w", iface.Name, err)
+			}
+			if p.Type == "custom" && !typeNames[p.Ref] {
+				return fmt.Errorf("interface %s: parameter %s references unknown type %q", iface.Name, p.Name, p.Ref)
+			}
+		}
+	}
+	return nil
+}
+
+// diffBundles 对比现有应用与导出包之间的差异
+func diffBundles(existing, incoming ApplicationBundle) BundleDiffDTO {
+	diff := BundleDiffDTO{Application: make([]FieldChangeDTO, 0)}
+	oldApp := reflect.ValueOf(existing.Application)
+	newApp := reflect.ValueOf(incoming.Application)
+	appType := oldApp.Type()
+	for i := 0; i < appType.NumField(); i++ {
+		o, n := oldApp.Field(i).Interface(), newApp.Field(i).Interface()
+		if o != n {
+			diff.Application = append(diff.Application, FieldChangeDTO{Field: appType.Field(i).Tag.Get("json"), Old: o, New: n})
+		}
+	}
+
+	oldTypes := make(map[string]any, len(existing.CustomTypes))
+	for _, ct := range existing.CustomTypes {
+		oldTypes[ct.Name] = ct
+	}
+	newTypes := make(map[string]any, len(incoming.CustomTypes))
+	for _, ct := range incoming.CustomTypes {
+		newTypes[ct.Name] = ct
+	}
+	diff.CustomTypes = diffNamedItems(oldTypes, newTypes)
+
+	oldIfaces := make(map[string]any, len(existing.Interfaces))
+	for _, iface := range existing.Interfaces {
+		oldIfaces[iface.Name] = iface
+	}
+	newIfaces := make(map[string]any, len(incoming.Interfaces))
+	for _, iface := range incoming.Interfaces {
+		newIfaces[iface.Name] = iface
+	}
+	diff.Interfaces = diffNamedItems(oldIfaces, newIfaces)
+	return diff
+}
+
+func diffNamedItems(old, incoming map[string]any) BundleDiffSetDTO {
+	set := BundleDiffSetDTO{Added: []string{}, Removed: []string{}, Changed: []string{}, Unchanged: []string{}}
+	for name, item := range incoming {
+		prev, ok := old[name]
+		switch {
+		case !ok:
+			set.Added = append(set.Added, name)
+		case bundleItemEqual(prev, item):
+			set.Unchanged = append(set.Unchanged, name)
+		default:
+			set.Changed = append(set.Changed, name)
+		}
+	}
+	for name := range old {
+		if _, ok := incoming[name]; !ok {
+			set.Removed = append(set.Removed, name)
+		}
+	}
+	sort.Strings(set.Added)
+	sort.Strings(set.Removed)
+	sort.Strings(set.Changed)
+	sort.Strings(set.Unchanged)
+	return set
+}
+
+// bundleItemEqual 通过 JSON 序列化比较，避免 nil 切片和空切片被认为不同
+func bundleItemEqual(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+// uniqueApplicationIdentity 为 rename 模式生成不冲突的名称和路径
+func uniqueApplicationIdentity(db *gorm.DB, name, path string) (string, string) {
+	for i := 2; ; i++ {
+		newName := fmt.Sprintf("%s-%d", name, i)
+		newPath := fmt.Sprintf("%s-%d", path, i)
+		var count int64
+		db.Model(&models.Application{}).Where("name = ? OR path = ?", newName, newPath).Count(&count)
+		if count == 0 {
+			return newName, newPath
+		}
+	}
+}
+
+// createBundleContent 在事务中创建导出包中的类型、接口和参数
+func createBundleContent(tx *gorm.DB, appID int64, bundle ApplicationBundle) error {
+	// 先创建所有类型，再创建字段，这样字段可以引用任意顺序定义的类型
+	typeIDs := make(map[string]int64, len(bundle.CustomTypes))
+	for _, ct := range bundle.CustomTypes {
+		customType := models.CustomType{AppID: appID, Name: ct.Name, Description: ct.Description}
+		if err := tx.Create(&customType).Error; err != nil {
+			return err
+		}
+		typeIDs[ct.Name] = customType.ID
+	}
+	var firstTypeID int64
+	var firstFields []CreateCustomTypeFieldReq
+	for i, ct := range bundle.CustomTypes {
+		for _, f := range ct.Fields {
+			field := models.CustomTypeField{
+				AppID:        appID,
+				CustomTypeID: typeIDs[ct.Name],
+				Name:         f.Name,
+				Type:         f.Type,
+				Ref:          lookupTypeRef(typeIDs, f.Ref),
+				IsArray:      f.IsArray,
+				Required:     f.Required,
+				Description:  f.Description,
+			}
+			if err := tx.Create(&field).Error; err != nil {
+				return err
+			}
+			if i == 0 {
+				firstFields = append(firstFields, CreateCustomTypeFieldReq{Name: f.Name, Type: f.Type, Ref: field.Ref})
+			}
+		}
+		if i == 0 {
+			firstTypeID = typeIDs[ct.Name]
+		}
+	}
+	// 所有字段写入后检查一次整个应用的类型引用图
+	if len(bundle.CustomTypes) > 0 {
+		if err := checkCustomTypeCycle(tx, firstTypeID, appID, firstFields); err != nil {
+			return err
+		}
+	}
+
+	for _, iface := range bundle.Interfaces {
+		params := make([]CreateInterfaceParameterReq, 0, len(iface.Parameters))
+		for _, p := range iface.Parameters {
+			params = append(params, CreateInterfaceParameterReq{
+				Name:         p.Name,
+				Type:         p.Type,
+				Ref:          lookupTypeRef(typeIDs, p.Ref),
+				Location:     p.Location,
+				IsArray:      p.IsArray,
+				Required:     p.Required,
+				Description:  p.Description,
+				DefaultValue: p.DefaultValue,
+				Group:        p.Group,
+			})
+		}
+		if err := checkParameters(&params, tx, appID); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+		if err := checkAuthConfig(tx, iface.AuthType, iface.AuthConfig); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+		if err := checkHTTPConfig(tx, iface.HTTPConfig); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+		if _, err := adapter.ParsePostProcess(iface.PostProcess); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+		if _, err := adapter.CompileScript(iface.Name, iface.Script); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+		if _, err := adapter.ParseCacheConfig(iface.CacheConfig); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+		if _, err := adapter.ParseRateLimitConfig(iface.RateLimit); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+		model := models.Interface{
+			AppID:         appID,
+			Name:          iface.Name,
+			Description:   iface.Description,
+			Protocol:      iface.Protocol,
+			URL:           iface.URL,
+			Method:        iface.Method,
+			AuthType:      iface.AuthType,
+			AuthConfig:    iface.AuthConfig,
+			HTTPConfig:    iface.HTTPConfig,
+			BodyEncoding:  iface.BodyEncoding,
+			BodyTemplate:  iface.BodyTemplate,
+			Query:         iface.Query,
+			DescriptorSet: iface.DescriptorSet,
+			Enabled:       iface.Enabled,
+			PostProcess:   iface.PostProcess,
+			Script:        iface.Script,
+			CacheConfig:   iface.CacheConfig,
+			RateLimit:     iface.RateLimit,
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		// gorm 会对零值字段使用数据库默认值，这里显式写入禁用状态
+		if !iface.Enabled {
+			if err := tx.Model(&model).Update("enabled", false).Error; err != nil {
+				return err
+			}
+		}
+		created := make([]models.InterfaceParameter, 0, len(params))
+		for _, p := range params {
+			param := models.InterfaceParameter{
+				AppID:        appID,
+				InterfaceID:  model.ID,
+				Name:         p.Name,
+				Type:         p.Type,
+				Ref:          p.Ref,
+				Location:     p.Location,
+				IsArray:      p.IsArray,
+				Required:     p.Required,
+				Description:  p.Description,
+				DefaultValue: p.DefaultValue,
+				Group:        p.Group,
+			}
+			if err := tx.Create(&param).Error; err != nil {
+				return err
+			}
+			created = append(created, param)
+		}
+		if err := checkRequestBody(tx, model, created); err != nil {
+			return fmt.Errorf("interface %s: %w", iface.Name, err)
+		}
+	}
+	return nil
+}
+
+// ImportApplicationBundle 在一个事务中导入应用，所有 ID 重新分配
+func ImportApplicationBundle(req ImportApplicationBundleRequest) (ImportApplicationBundleResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return ImportApplicationBundleResponse{}, err
+	}
+	if req.Conflict == "" {
+		req.Conflict = "fail"
+	}
+	bundle := req.Bundle
+	if err := validateApplicationBundle(bundle); err != nil {
+		return ImportApplicationBundleResponse{}, err
+	}
+	db := database.GetDB()
+
+	resp := ImportApplicationBundleResponse{Preview: req.Preview, Action: "create"}
+	var existing *models.Application
+	var found models.Application
+	if err := db.Where("path = ?", bundle.Application.Path).First(&found).Error; err == nil {
+		existing = &found
+		current, err := buildApplicationBundle(db, found)
+		if err != nil {
+			return ImportApplicationBundleResponse{}, err
+		}
+		diff := diffBundles(current, bundle)
+		resp.Diff = &diff
+		resp.Action = req.Conflict
+	}
+	if req.Preview {
+		return resp, nil
+	}
+
+	// 名称在所有应用中唯一，覆盖时排除目标应用自身
+	var count int64
+	query := db.Model(&models.Application{}).Where("name = ?", bundle.Application.Name)
+	if existing != nil && req.Conflict == "overwrite" {
+		query = query.Where("id <> ?", existing.ID)
+	}
+	query.Count(&count)
+	if existing == nil && count > 0 && req.Conflict != "rename" {
+		return ImportApplicationBundleResponse{}, errors.New("duplicate application name")
+	}
+	if existing != nil && req.Conflict == "fail" {
+		return ImportApplicationBundleResponse{}, errors.New("application with the same path already exists")
+	}
+	if existing != nil && req.Conflict == "overwrite" && count > 0 {
+		return ImportApplicationBundleResponse{}, errors.New("duplicate application name")
+	}
+
+	app := models.Application{
+		Name:           bundle.Application.Name,
+		Description:    bundle.Application.Description,
+		Path:           bundle.Application.Path,
+		Protocol:       bundle.Application.Protocol,
+		PostProcess:    bundle.Application.PostProcess,
+		Environment:    bundle.Application.Environment,
+		ForwardHeaders: bundle.Application.ForwardHeaders,
+		HTTPConfig:     bundle.Application.HTTPConfig,
+		RateLimit:      bundle.Application.RateLimit,
+		InvocationLog:  bundle.Application.InvocationLog,
+		Upstream:       bundle.Application.Upstream,
+		Enabled:        bundle.Application.Enabled,
+	}
+	if req.Conflict == "rename" && (existing != nil || count > 0) {
+		resp.Action = "rename"
+		app.Name, app.Path = uniqueApplicationIdentity(db, app.Name, app.Path)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkEnvironment(tx, app.Environment); err != nil {
+			return err
+		}
+		if err := checkHTTPConfig(tx, app.HTTPConfig); err != nil {
+			return err
+		}
+		if err := checkUpstreamConfig(tx, app.Upstream); err != nil {
+			return err
+		}
+		if existing != nil && req.Conflict == "overwrite" {
+			app.ID = existing.ID
+			app.CreatedAt = existing.CreatedAt
+			// 清理目标应用的旧定义
+			for _, model := range []any{&models.InterfaceParameter{}, &models.Interface{}, &models.CustomTypeField{}, &models.CustomType{}} {
+				if err := tx.Where("app_id = ?", existing.ID).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Save(&app).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Create(&app).Error; err != nil {
+				return err
+			}
+			if !app.Enabled {
+				if err := tx.Model(&app).Update("enabled", false).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return createBundleContent(tx, app.ID, bundle)
+	})
+	if err != nil {
+		return ImportApplicationBundleResponse{}, err
+	}
+
+	if existing != nil && req.Conflict == "overwrite" {
+		adapter.SendEvent(adapter.Event{
+			App:       &models.Application{Name: existing.Name, Path: existing.Path},
+			Interface: nil,
+			Code:      adapter.RemoveApplicationEvent,
+		})
+	}
+	adapter.SendEvent(adapter.Event{
+		App:       &app,
+		Interface: nil,
+		Code:      adapter.AddApplicationEvent,
+	})
+	dto := toApplicationDTO(app)
+	resp.Application = &dto
+	return resp, nil
+}
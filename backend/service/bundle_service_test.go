@@ -0,0 +1,221 @@
+package service
+
+import (
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createBundleTestApp 创建一个带有嵌套类型和接口的应用
+func createBundleTestApp(t *testing.T) ApplicationDTO {
+	app, err := CreateApplication(CreateApplicationRequest{Name: "Bundle App", Path: "bundle-app", Protocol: "sse", ForwardHeaders: `{"Authorization":""}`, HTTPConfig: `{"timeout":"10s"}`})
+	require.NoError(t, err)
+	address, err := CreateCustomType(CreateCustomTypeRequest{
+		AppID: app.Application.ID,
+		Name:  "Address",
+		Fields: []CreateCustomTypeFieldReq{
+			{Name: "city", Type: "string", Required: true},
+		},
+	})
+	require.NoError(t, err)
+	user, err := CreateCustomType(CreateCustomTypeRequest{
+		AppID: app.Application.ID,
+		Name:  "User",
+		Fields: []CreateCustomTypeFieldReq{
+			{Name: "name", Type: "string"},
+			{Name: "addresses", Type: "custom", Ref: &address.CustomType.ID, IsArray: true},
+		},
+	})
+	require.NoError(t, err)
+	_, err = CreateInterface(CreateInterfaceRequest{
+		AppID:      app.Application.ID,
+		Name:       "CreateUser",
+		Protocol:   "http",
+		URL:        "https://api.example.com/users",
+		Method:     "POST",
+		AuthType:   "none",
+		HTTPConfig: `{"retry":{"max_retries":2}}`,
+		Enabled:    boolPtr(true),
+		Parameters: []CreateInterfaceParameterReq{
+			{Name: "user", Type: "custom", Ref: &user.CustomType.ID, Location: "body", Group: "input", Required: true},
+			{Name: "X-Env", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("dev")},
+		},
+	})
+	require.NoError(t, err)
+	return app.Application
+}
+
+func TestExportApplicationBundle(t *testing.T) {
+	setupTestDB(t)
+	app := createBundleTestApp(t)
+
+	bundle, err := ExportApplicationBundle(ExportApplicationBundleRequest{ID: app.ID})
+	require.NoError(t, err)
+	assert.Equal(t, bundleVersion, bundle.Version)
+	assert.Equal(t, "bundle-app", bundle.Application.Path)
+	assert.Equal(t, `{"Authorization":""}`, bundle.Application.ForwardHeaders)
+	assert.Equal(t, `{"timeout":"10s"}`, bundle.Application.HTTPConfig)
+	require.Len(t, bundle.CustomTypes, 2)
+	assert.Equal(t, "User", bundle.CustomTypes[1].Name)
+	assert.Equal(t, "Address", bundle.CustomTypes[1].Fields[1].Ref)
+	require.Len(t, bundle.Interfaces, 1)
+	assert.Equal(t, `{"retry":{"max_retries":2}}`, bundle.Interfaces[0].HTTPConfig)
+	assert.Equal(t, "User", bundle.Interfaces[0].Parameters[0].Ref)
+	assert.Equal(t, "dev", *bundle.Interfaces[0].Parameters[1].DefaultValue)
+
+	_, err = ExportApplicationBundle(ExportApplicationBundleRequest{ID: 9999})
+	assert.Error(t, err)
+}
+
+func TestParseApplicationBundle(t *testing.T) {
+	yamlBundle := `
+version: 1
+application:
+  name: Y
+  path: y
+  protocol: sse
+custom_types:
+  - name: T
+    fields:
+      - name: f
+        type: string
+interfaces: []
+`
+	bundle, err := ParseApplicationBundle([]byte(yamlBundle))
+	require.NoError(t, err)
+	assert.Equal(t, "y", bundle.Application.Path)
+	assert.Equal(t, "f", bundle.CustomTypes[0].Fields[0].Name)
+
+	_, err = ParseApplicationBundle([]byte("{not: [valid"))
+	assert.Error(t, err)
+}
+
+func TestImportApplicationBundle(t *testing.T) {
+	setupTestDB(t)
+	app := createBundleTestApp(t)
+	bundle, err := ExportApplicationBundle(ExportApplicationBundleRequest{ID: app.ID})
+	require.NoError(t, err)
+	db := database.GetDB()
+
+	t.Run("fail on existing path", func(t *testing.T) {
+		_, err := ImportApplicationBundle(ImportApplicationBundleRequest{Bundle: bundle})
+		assert.Error(t, err)
+	})
+
+	t.Run("preview unchanged", func(t *testing.T) {
+		resp, err := ImportApplicationBundle(ImportApplicationBundleRequest{Bundle: bundle, Preview: true})
+		require.NoError(t, err)
+		assert.True(t, resp.Preview)
+		require.NotNil(t, resp.Diff)
+		assert.Empty(t, resp.Diff.Application)
+		assert.Equal(t, []string{"Address", "User"}, resp.Diff.CustomTypes.Unchanged)
+		assert.Equal(t, []string{"CreateUser"}, resp.Diff.Interfaces.Unchanged)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		resp, err := ImportApplicationBundle(ImportApplicationBundleRequest{Bundle: bundle, Conflict: "rename"})
+		require.NoError(t, err)
+		assert.Equal(t, "rename", resp.Action)
+		require.NotNil(t, resp.Application)
+		assert.Equal(t, "bundle-app-2", resp.Application.Path)
+		assert.Equal(t, "Bundle App-2", resp.Application.Name)
+
+		// 新应用中的引用指向新创建的类型
+		var user models.CustomType
+		require.NoError(t, db.Where("app_id = ? AND name = ?", resp.Application.ID, "User").First(&user).Error)
+		var param models.InterfaceParameter
+		require.NoError(t, db.Where("app_id = ? AND name = ?", resp.Application.ID, "user").First(&param).Error)
+		require.NotNil(t, param.Ref)
+		assert.Equal(t, user.ID, *param.Ref)
+
+		copied, err := ExportApplicationBundle(ExportApplicationBundleRequest{ID: resp.Application.ID})
+		require.NoError(t, err)
+		assert.Equal(t, bundle.CustomTypes, copied.CustomTypes)
+		assert.Equal(t, bundle.Interfaces, copied.Interfaces)
+	})
+
+	t.Run("preview and overwrite with changes", func(t *testing.T) {
+		changed := bundle
+		changed.Application.Description = "promoted"
+		changed.Interfaces = []BundleInterface{
+			{Name: "Ping", Protocol: "http", URL: "https://api.example.com/ping", Method: "GET", AuthType: "none", Enabled: false, Parameters: []BundleParameter{}},
+		}
+		changed.CustomTypes = changed.CustomTypes[:1]
+
+		resp, err := ImportApplicationBundle(ImportApplicationBundleRequest{Bundle: changed, Conflict: "overwrite", Preview: true})
+		require.NoError(t, err)
+		assert.Equal(t, "overwrite", resp.Action)
+		require.Len(t, resp.Diff.Application, 1)
+		assert.Equal(t, "description", resp.Diff.Application[0].Field)
+		assert.Equal(t, []string{"User"}, resp.Diff.CustomTypes.Removed)
+		assert.Equal(t, []string{"Ping"}, resp.Diff.Interfaces.Added)
+		assert.Equal(t, []string{"CreateUser"}, resp.Diff.Interfaces.Removed)
+
+		resp, err = ImportApplicationBundle(ImportApplicationBundleRequest{Bundle: changed, Conflict: "overwrite"})
+		require.NoError(t, err)
+		assert.Equal(t, app.ID, resp.Application.ID)
+		assert.Equal(t, "promoted", resp.Application.Description)
+
+		var interfaces []models.Interface
+		db.Where("app_id = ?", app.ID).Find(&interfaces)
+		require.Len(t, interfaces, 1)
+		assert.Equal(t, "Ping", interfaces[0].Name)
+		assert.False(t, interfaces[0].Enabled)
+		var typeCount int64
+		db.Model(&models.CustomType{}).Where("app_id = ?", app.ID).Count(&typeCount)
+		assert.Equal(t, int64(1), typeCount)
+	})
+}
+
+func TestImportApplicationBundleInvalid(t *testing.T) {
+	setupTestDB(t)
+
+	base := ApplicationBundle{
+		Version:     bundleVersion,
+		Application: BundleApplication{Name: "New", Path: "new", Protocol: "sse", Enabled: true},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(b *ApplicationBundle)
+	}{
+		{"unsupported version", func(b *ApplicationBundle) { b.Version = 99 }},
+		{"invalid protocol", func(b *ApplicationBundle) { b.Application.Protocol = "grpc" }},
+		{"unknown type reference", func(b *ApplicationBundle) {
+			b.CustomTypes = []BundleCustomType{{Name: "A", Fields: []BundleField{{Name: "b", Type: "custom", Ref: "B"}}}}
+		}},
+		{"circular reference", func(b *ApplicationBundle) {
+			b.CustomTypes = []BundleCustomType{
+				{Name: "A", Fields: []BundleField{{Name: "b", Type: "custom", Ref: "B"}}},
+				{Name: "B", Fields: []BundleField{{Name: "a", Type: "custom", Ref: "A"}}},
+			}
+		}},
+		{"duplicate interface", func(b *ApplicationBundle) {
+			iface := BundleInterface{Name: "X", Protocol: "http", URL: "https://x", Method: "GET", AuthType: "none"}
+			b.Interfaces = []BundleInterface{iface, iface}
+		}},
+		{"invalid parameter location", func(b *ApplicationBundle) {
+			b.Interfaces = []BundleInterface{{Name: "X", Protocol: "http", URL: "https://x", Method: "GET", AuthType: "none",
+				Parameters: []BundleParameter{{Name: "p", Type: "string", Location: "cookie", Group: "input"}}}}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := base
+			tt.modify(&b)
+			_, err := ImportApplicationBundle(ImportApplicationBundleRequest{Bundle: b})
+			assert.Error(t, err)
+		})
+	}
+
+	// 失败的导入不会留下任何数据
+	var count int64
+	database.GetDB().Model(&models.Application{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	_, err := ImportApplicationBundle(ImportApplicationBundleRequest{Bundle: base, Conflict: "merge"})
+	assert.Error(t, err)
+}
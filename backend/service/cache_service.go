@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+	"mcp-adapter/backend/adapter"
+)
+
+type PurgeCacheRequest struct {
+	AppID       int64 `json:"app_id" validate:"gte=0"`       // 清除应用下所有接口的缓存
+	InterfaceID int64 `json:"interface_id" validate:"gte=0"` // 清除单个接口的缓存
+}
+
+type PurgeCacheResponse struct {
+	Purged int64 `json:"purged"` // 清除的缓存条目数
+}
+
+// PurgeCache 清除应用或接口的响应缓存，包括本实例的内存缓存和数据库缓存
+func PurgeCache(req PurgeCacheRequest) (PurgeCacheResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return PurgeCacheResponse{}, err
+	}
+	if req.AppID == 0 && req.InterfaceID == 0 {
+		return PurgeCacheResponse{}, errors.New("app_id or interface_id is required")
+	}
+	purged, err := adapter.PurgeResponseCache(req.AppID, req.InterfaceID)
+	if err != nil {
+		return PurgeCacheResponse{}, err
+	}
+	return PurgeCacheResponse{Purged: purged}, nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeCache(t *testing.T) {
+	setupTestDB(t)
+
+	_, err := PurgeCache(PurgeCacheRequest{})
+	assert.EqualError(t, err, "app_id or interface_id is required")
+	_, err = PurgeCache(PurgeCacheRequest{AppID: -1})
+	assert.Error(t, err)
+
+	db := database.GetDB()
+	expires := time.Now().Add(time.Minute)
+	for i, entry := range []struct{ appID, interfaceID int64 }{{1, 10}, {1, 11}, {2, 20}} {
+		db.Create(&models.ResponseCacheEntry{Key: string(rune('a' + i)), AppID: entry.appID, InterfaceID: entry.interfaceID, ExpiresAt: expires})
+	}
+
+	resp, err := PurgeCache(PurgeCacheRequest{InterfaceID: 11})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Purged)
+	resp, err = PurgeCache(PurgeCacheRequest{AppID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Purged)
+
+	var remaining int64
+	db.Model(&models.ResponseCacheEntry{}).Count(&remaining)
+	assert.Equal(t, int64(1), remaining)
+}
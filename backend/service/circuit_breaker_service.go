@@ -0,0 +1,27 @@
+package service
+
+import (
+	"mcp-adapter/backend/adapter"
+)
+
+type ListCircuitBreakersRequest struct {
+	State string `json:"state" validate:"omitempty,oneof=closed open half_open"` // 只返回指定状态的熔断器
+}
+
+type ListCircuitBreakersResponse struct {
+	CircuitBreakers []adapter.CircuitBreakerStatus `json:"circuit_breakers"`
+}
+
+// ListCircuitBreakers 返回当前实例中熔断器的状态，熔断状态保存在内存中，多实例部署时各实例独立计算
+func ListCircuitBreakers(req ListCircuitBreakersRequest) (ListCircuitBreakersResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return ListCircuitBreakersResponse{}, err
+	}
+	statuses := make([]adapter.CircuitBreakerStatus, 0)
+	for _, status := range adapter.CircuitBreakerStatuses() {
+		if req.State == "" || status.State == req.State {
+			statuses = append(statuses, status)
+		}
+	}
+	return ListCircuitBreakersResponse{CircuitBreakers: statuses}, nil
+}
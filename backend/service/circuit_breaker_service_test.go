@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCircuitBreakers(t *testing.T) {
+	_, err := ListCircuitBreakers(ListCircuitBreakersRequest{State: "broken"})
+	assert.Error(t, err)
+
+	resp, err := ListCircuitBreakers(ListCircuitBreakersRequest{State: "open"})
+	require.NoError(t, err)
+	assert.NotNil(t, resp.CircuitBreakers)
+	assert.Empty(t, resp.CircuitBreakers)
+}
+
+func TestInterfaceCircuitBreakerConfig(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "BreakerApp", Path: "breaker-app", Protocol: "sse",
+		HTTPConfig: `{"circuit_breaker": {"scope": "upstream"}}`})
+	assert.ErrorContains(t, err, "circuit_breaker.scope must be host or interface")
+
+	app, err = CreateApplication(CreateApplicationRequest{Name: "BreakerApp", Path: "breaker-app", Protocol: "sse",
+		HTTPConfig: `{"circuit_breaker": {"failure_threshold": 3, "cooldown": "1m"}}`})
+	require.NoError(t, err)
+	_, err = CreateInterface(CreateInterfaceRequest{
+		AppID:      app.Application.ID,
+		Name:       "Fragile",
+		Protocol:   "http",
+		URL:        "https://api.example.com/fragile",
+		Method:     "GET",
+		AuthType:   "none",
+		HTTPConfig: `{"circuit_breaker": {"cooldown": "later"}}`,
+		Enabled:    boolPtr(true),
+	})
+	assert.ErrorContains(t, err, "circuit_breaker.cooldown must be a positive duration")
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"regexp"
+	"time"
+)
+
+// datasourceNamePattern 数据源名称作为 sql:// 地址的主机部分，只允许字母、数字、点、下划线和中划线
+var datasourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+type CreateDatasourceRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`              // 数据源名称 全局唯一，sql 接口通过 sql://name 引用
+	Description string `json:"description" validate:"max=16384"`              // 数据源描述
+	Driver      string `json:"driver" validate:"required,oneof=sqlite mysql"` // 数据库驱动
+	DSN         string `json:"dsn" validate:"required,max=4096"`              // 连接串，建议使用 secret://name 引用密钥
+	MaxRows     int    `json:"max_rows" validate:"min=0,max=10000"`           // 单次查询最多返回的行数，0 表示默认 100
+	Timeout     string `json:"timeout" validate:"max=50"`                     // 语句超时，如 5s，为空表示默认 10s
+}
+
+type GetDatasourceRequest struct {
+	ID int64 `json:"id" validate:"required,gt=0"`
+}
+
+type ListDatasourcesRequest struct{}
+
+type UpdateDatasourceRequest struct {
+	ID          int64   `json:"id" validate:"required,gt=0"`
+	Name        *string `json:"name" validate:"omitempty,max=255"`
+	Description *string `json:"description" validate:"omitempty,max=16384"`
+	Driver      *string `json:"driver" validate:"omitempty,oneof=sqlite mysql"`
+	DSN         *string `json:"dsn" validate:"omitempty,max=4096"`
+	MaxRows     *int    `json:"max_rows" validate:"omitempty,min=0,max=10000"`
+	Timeout     *string `json:"timeout" validate:"omitempty,max=50"`
+}
+
+type DeleteDatasourceRequest struct {
+	ID int64 `json:"id" validate:"required,gt=0"`
+}
+
+type DatasourceDTO struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Driver      string    `json:"driver"`
+	DSN         string    `json:"dsn"`
+	MaxRows     int       `json:"max_rows"`
+	Timeout     string    `json:"timeout"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type DatasourceResponse struct {
+	Datasource DatasourceDTO `json:"datasource"`
+}
+
+type DatasourcesResponse struct {
+	Datasources []DatasourceDTO `json:"datasources"`
+}
+
+func toDatasourceDTO(m models.Datasource) DatasourceDTO {
+	return DatasourceDTO{
+		ID:          m.ID,
+		Name:        m.Name,
+		Description: m.Description,
+		Driver:      m.Driver,
+		DSN:         m.DSN,
+		MaxRows:     m.MaxRows,
+		Timeout:     m.Timeout,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// checkDatasource 校验数据源配置以及连接串引用的密钥是否存在
+func checkDatasource(ds models.Datasource) error {
+	if err := adapter.ValidateDatasource(ds); err != nil {
+		return err
+	}
+	if name, ok := adapter.ParseSecretRef(ds.DSN); ok {
+		return checkSecretRefs(database.GetDB(), name)
+	}
+	return nil
+}
+
+// datasourceReferenced 检查是否有 sql 接口引用了该数据源，使用环境变量的地址无法检查
+func datasourceReferenced(name string) bool {
+	var count int64
+	database.GetDB().Model(&models.Interface{}).
+		Where("protocol = ? AND url = ?", "sql", "sql://"+name).Count(&count)
+	return count > 0
+}
+
+func CreateDatasource(req CreateDatasourceRequest) (DatasourceResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return DatasourceResponse{}, err
+	}
+	if !datasourceNamePattern.MatchString(req.Name) {
+		return DatasourceResponse{}, errors.New("invalid datasource name")
+	}
+	db := database.GetDB()
+	var count int64
+	db.Model(&models.Datasource{}).Where("name = ?", req.Name).Count(&count)
+	if count > 0 {
+		return DatasourceResponse{}, errors.New("duplicate datasource name")
+	}
+	ds := models.Datasource{
+		Name:        req.Name,
+		Description: req.Description,
+		Driver:      req.Driver,
+		DSN:         req.DSN,
+		MaxRows:     req.MaxRows,
+		Timeout:     req.Timeout,
+	}
+	if err := checkDatasource(ds); err != nil {
+		return DatasourceResponse{}, err
+	}
+	if err := db.Create(&ds).Error; err != nil {
+		return DatasourceResponse{}, err
+	}
+	return DatasourceResponse{Datasource: toDatasourceDTO(ds)}, nil
+}
+
+func GetDatasource(req GetDatasourceRequest) (DatasourceResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return DatasourceResponse{}, err
+	}
+	var ds models.Datasource
+	if err := database.GetDB().First(&ds, req.ID).Error; err != nil {
+		return DatasourceResponse{}, errors.New("datasource not found")
+	}
+	return DatasourceResponse{Datasource: toDatasourceDTO(ds)}, nil
+}
+
+func ListDatasources(req ListDatasourcesRequest) (DatasourcesResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return DatasourcesResponse{}, err
+	}
+	var datasources []models.Datasource
+	if err := database.GetDB().Order("id").Find(&datasources).Error; err != nil {
+		return DatasourcesResponse{}, err
+	}
+	dtos := make([]DatasourceDTO, 0, len(datasources))
+	for _, ds := range datasources {
+		dtos = append(dtos, toDatasourceDTO(ds))
+	}
+	return DatasourcesResponse{Datasources: dtos}, nil
+}
+
+// UpdateDatasource 更新数据源，调用时按名称读取，连接串变化后连接池会重建，不需要重新注册工具
+func UpdateDatasource(req UpdateDatasourceRequest) (DatasourceResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return DatasourceResponse{}, err
+	}
+	db := database.GetDB()
+	var existing models.Datasource
+	if err := db.First(&existing, req.ID).Error; err != nil {
+		return DatasourceResponse{}, errors.New("datasource not found")
+	}
+	if req.Name != nil && *req.Name != existing.Name {
+		if !datasourceNamePattern.MatchString(*req.Name) {
+			return DatasourceResponse{}, errors.New("invalid datasource name")
+		}
+		var count int64
+		db.Model(&models.Datasource{}).Where("name = ? AND id <> ?", *req.Name, existing.ID).Count(&count)
+		if count > 0 {
+			return DatasourceResponse{}, errors.New("duplicate datasource name")
+		}
+		// 改名会使已有引用失效
+		if datasourceReferenced(existing.Name) {
+			return DatasourceResponse{}, errors.New("datasource is referenced by interfaces and cannot be renamed")
+		}
+		existing.Name = *req.Name
+	}
+	if req.Description != nil {
+		existing.Description = *req.Description
+	}
+	if req.Driver != nil {
+		existing.Driver = *req.Driver
+	}
+	if req.DSN != nil {
+		existing.DSN = *req.DSN
+	}
+	if req.MaxRows != nil {
+		existing.MaxRows = *req.MaxRows
+	}
+	if req.Timeout != nil {
+		existing.Timeout = *req.Timeout
+	}
+	if err := checkDatasource(existing); err != nil {
+		return DatasourceResponse{}, err
+	}
+	if err := db.Save(&existing).Error; err != nil {
+		return DatasourceResponse{}, err
+	}
+	return DatasourceResponse{Datasource: toDatasourceDTO(existing)}, nil
+}
+
+func DeleteDatasource(req DeleteDatasourceRequest) (EmptyResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return EmptyResponse{}, err
+	}
+	db := database.GetDB()
+	var ds models.Datasource
+	if err := db.First(&ds, req.ID).Error; err != nil {
+		return EmptyResponse{}, errors.New("datasource not found")
+	}
+	if datasourceReferenced(ds.Name) {
+		return EmptyResponse{}, errors.New("datasource is referenced by interfaces")
+	}
+	if err := db.Delete(&ds).Error; err != nil {
+		return EmptyResponse{}, err
+	}
+	return EmptyResponse{}, nil
+}
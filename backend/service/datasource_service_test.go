@@ -0,0 +1,90 @@
+package service
+
+import (
+	"mcp-adapter/backend/adapter"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndUpdateDatasource(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	resp, err := CreateDatasource(CreateDatasourceRequest{Name: "reporting", Driver: "sqlite", DSN: "file:reporting.db", MaxRows: 50, Timeout: "5s"})
+	require.NoError(t, err)
+	assert.Equal(t, "reporting", resp.Datasource.Name)
+	assert.Equal(t, 50, resp.Datasource.MaxRows)
+
+	_, err = CreateDatasource(CreateDatasourceRequest{Name: "reporting", Driver: "sqlite", DSN: "x"})
+	assert.EqualError(t, err, "duplicate datasource name")
+	_, err = CreateDatasource(CreateDatasourceRequest{Name: "bad/name", Driver: "sqlite", DSN: "x"})
+	assert.EqualError(t, err, "invalid datasource name")
+	_, err = CreateDatasource(CreateDatasourceRequest{Name: "pg", Driver: "postgres", DSN: "x"})
+	assert.Error(t, err)
+	_, err = CreateDatasource(CreateDatasourceRequest{Name: "slow", Driver: "sqlite", DSN: "x", Timeout: "soon"})
+	assert.ErrorContains(t, err, "datasource timeout must be a positive duration")
+	_, err = CreateDatasource(CreateDatasourceRequest{Name: "warehouse", Driver: "mysql", DSN: "secret://mysql-dsn"})
+	assert.EqualError(t, err, "secret mysql-dsn not found")
+
+	_, err = CreateSecret(CreateSecretRequest{Name: "mysql-dsn", Value: "reader:pw@tcp(db:3306)/shop"})
+	require.NoError(t, err)
+	mysql, err := CreateDatasource(CreateDatasourceRequest{Name: "warehouse", Driver: "mysql", DSN: "secret://mysql-dsn"})
+	require.NoError(t, err)
+	assert.Equal(t, "secret://mysql-dsn", mysql.Datasource.DSN)
+
+	updated, err := UpdateDatasource(UpdateDatasourceRequest{ID: resp.Datasource.ID, Description: stringPtr("reports"), Timeout: stringPtr("")})
+	require.NoError(t, err)
+	assert.Equal(t, "reports", updated.Datasource.Description)
+	assert.Equal(t, "", updated.Datasource.Timeout)
+	_, err = UpdateDatasource(UpdateDatasourceRequest{ID: resp.Datasource.ID, Name: stringPtr("warehouse")})
+	assert.EqualError(t, err, "duplicate datasource name")
+	_, err = UpdateDatasource(UpdateDatasourceRequest{ID: 9999, Name: stringPtr("x")})
+	assert.EqualError(t, err, "datasource not found")
+
+	list, err := ListDatasources(ListDatasourcesRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Datasources, 2)
+	got, err := GetDatasource(GetDatasourceRequest{ID: mysql.Datasource.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "mysql", got.Datasource.Driver)
+
+	// 连接串引用的密钥不能删除
+	secrets, err := ListSecrets(ListSecretsRequest{})
+	require.NoError(t, err)
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secrets.Secrets[0].ID})
+	assert.EqualError(t, err, "secret is referenced by interfaces")
+}
+
+func TestDatasourceReferences(t *testing.T) {
+	setupTestDB(t)
+
+	ds, err := CreateDatasource(CreateDatasourceRequest{Name: "reporting", Driver: "sqlite", DSN: "file:reporting.db"})
+	require.NoError(t, err)
+	app, err := CreateApplication(CreateApplicationRequest{Name: "SQLApp", Path: "sql-app", Protocol: "sse"})
+	require.NoError(t, err)
+	iface, err := CreateInterface(CreateInterfaceRequest{
+		AppID:    app.Application.ID,
+		Name:     "ListUsers",
+		Protocol: "sql",
+		URL:      "sql://reporting",
+		Method:   "POST",
+		AuthType: "none",
+		Query:    "SELECT id, name FROM users",
+		Enabled:  boolPtr(true),
+	})
+	require.NoError(t, err)
+
+	_, err = UpdateDatasource(UpdateDatasourceRequest{ID: ds.Datasource.ID, Name: stringPtr("renamed")})
+	assert.EqualError(t, err, "datasource is referenced by interfaces and cannot be renamed")
+	_, err = DeleteDatasource(DeleteDatasourceRequest{ID: ds.Datasource.ID})
+	assert.EqualError(t, err, "datasource is referenced by interfaces")
+
+	_, err = DeleteInterface(DeleteInterfaceRequest{ID: iface.Interface.ID})
+	require.NoError(t, err)
+	_, err = DeleteDatasource(DeleteDatasourceRequest{ID: ds.Datasource.ID})
+	require.NoError(t, err)
+	_, err = GetDatasource(GetDatasourceRequest{ID: ds.Datasource.ID})
+	assert.EqualError(t, err, "datasource not found")
+}
@@ -0,0 +1,511 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"strings"
+)
+
+// defaultGraphQLSelectionDepth 生成选择集时默认展开的对象层数
+const defaultGraphQLSelectionDepth = 3
+
+type ImportGraphQLRequest struct {
+	AppID         int64  `json:"app_id" validate:"required,gt=0"`                                                // 导入到的应用 ID
+	URL           string `json:"url" validate:"required,max=1024"`                                               // GraphQL 端点，导入的接口都使用该地址
+	Introspection string `json:"introspection" validate:"max=10485760"`                                          // 内省查询结果 (JSON)，为空时向 url 发送内省查询
+	AuthType      string `json:"auth_type" validate:"omitempty,oneof=none bearer basic apikey oauth2 aws_sigv4"` // 导入接口的鉴权类型，默认 none，内省查询同样使用
+	AuthConfig    string `json:"auth_config" validate:"max=65536"`                                               // 鉴权配置 (JSON)，结构由 auth_type 决定
+	MaxDepth      int    `json:"max_depth" validate:"omitempty,min=1,max=10"`                                    // 选择集展开的对象层数，默认 3
+	DryRun        bool   `json:"dry_run"`                                                                        // 只预览，不写入数据库
+}
+
+// introspectionTypeRef 内省结果中的类型引用，NON_NULL 和 LIST 通过 ofType 包装
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+type introspectionInputValue struct {
+	Name         string               `json:"name"`
+	Description  string               `json:"description"`
+	Type         introspectionTypeRef `json:"type"`
+	DefaultValue *string              `json:"defaultValue"`
+}
+
+type introspectionField struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Args        []introspectionInputValue `json:"args"`
+	Type        introspectionTypeRef      `json:"type"`
+}
+
+type introspectionType struct {
+	Kind        string                    `json:"kind"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Fields      []introspectionField      `json:"fields"`
+	InputFields []introspectionInputValue `json:"inputFields"`
+	EnumValues  []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+}
+
+type introspectionSchema struct {
+	QueryType *struct {
+		Name string `json:"name"`
+	} `json:"queryType"`
+	MutationType *struct {
+		Name string `json:"name"`
+	} `json:"mutationType"`
+	Types []introspectionType `json:"types"`
+}
+
+// parseIntrospection 解析内省结果，兼容完整响应 {"data": {"__schema": ...}} 和只有 data 部分的写法
+func parseIntrospection(raw []byte) (*introspectionSchema, error) {
+	var doc struct {
+		Data *struct {
+			Schema *introspectionSchema `json:"__schema"`
+		} `json:"data"`
+		Schema *introspectionSchema `json:"__schema"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %v", err)
+	}
+	schema := doc.Schema
+	if schema == nil && doc.Data != nil {
+		schema = doc.Data.Schema
+	}
+	if schema == nil {
+		return nil, errors.New("invalid introspection result: __schema not found")
+	}
+	if schema.QueryType == nil && schema.MutationType == nil {
+		return nil, errors.New("invalid introspection result: schema has no query or mutation type")
+	}
+	return schema, nil
+}
+
+// graphQLImporter 把内省结果转换为导入计划：Query 和 Mutation 的每个字段对应一个接口
+type graphQLImporter struct {
+	url      string
+	maxDepth int
+	schema   map[string]*introspectionType
+	types    map[string]*importedType
+	order    []string // 类型的发现顺序，打破循环时优先保留靠近根字段的引用
+	warnings []string
+}
+
+func newGraphQLImporter(schema *introspectionSchema, url string, maxDepth int) *graphQLImporter {
+	im := &graphQLImporter{
+		url:      url,
+		maxDepth: maxDepth,
+		schema:   make(map[string]*introspectionType, len(schema.Types)),
+		types:    make(map[string]*importedType),
+	}
+	for i := range schema.Types {
+		im.schema[schema.Types[i].Name] = &schema.Types[i]
+	}
+	return im
+}
+
+func (im *graphQLImporter) warn(format string, args ...any) {
+	im.warnings = append(im.warnings, fmt.Sprintf(format, args...))
+}
+
+// unwrapTypeRef 去掉 NON_NULL 和 LIST 包装，返回命名类型；嵌套列表无法表示，ok 为 false
+func unwrapTypeRef(ref introspectionTypeRef) (named introspectionTypeRef, nonNull, isList, ok bool) {
+	t := &ref
+	if t.Kind == "NON_NULL" {
+		nonNull, t = true, t.OfType
+	}
+	if t != nil && t.Kind == "LIST" {
+		isList, t = true, t.OfType
+		if t != nil && t.Kind == "NON_NULL" {
+			t = t.OfType
+		}
+	}
+	if t == nil || t.Kind == "LIST" || t.Kind == "NON_NULL" || t.Name == "" {
+		return introspectionTypeRef{}, false, false, false
+	}
+	return *t, nonNull, isList, true
+}
+
+// graphQLTypeString 将类型引用还原为 GraphQL 类型字面量，例如 [String!]!
+func graphQLTypeString(ref *introspectionTypeRef) string {
+	if ref == nil {
+		return ""
+	}
+	switch ref.Kind {
+	case "NON_NULL":
+		return graphQLTypeString(ref.OfType) + "!"
+	case "LIST":
+		return "[" + graphQLTypeString(ref.OfType) + "]"
+	}
+	return ref.Name
+}
+
+// graphQLDefault 将内省结果中的默认值字面量转换为参数默认值，列表、对象和 null 不支持
+func graphQLDefault(literal *string) *string {
+	if literal == nil {
+		return nil
+	}
+	s := strings.TrimSpace(*literal)
+	switch {
+	case s == "" || s == "null" || strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{"):
+		return nil
+	case strings.HasPrefix(s, `"`):
+		var unquoted string
+		if err := json.Unmarshal([]byte(s), &unquoted); err != nil {
+			return nil
+		}
+		return &unquoted
+	}
+	return &s
+}
+
+// describeEnum 在描述后附上枚举的取值
+func (im *graphQLImporter) describeEnum(description string, named introspectionTypeRef) string {
+	t := im.schema[named.Name]
+	if named.Kind != "ENUM" || t == nil || len(t.EnumValues) == 0 {
+		return description
+	}
+	values := make([]string, 0, len(t.EnumValues))
+	for _, v := range t.EnumValues {
+		values = append(values, v.Name)
+	}
+	return strings.TrimSpace(description + "\n\nOne of: " + strings.Join(values, ", "))
+}
+
+// fieldType 将命名类型转换为 (type, refName)，ok 为 false 表示无法表示 (union)
+func (im *graphQLImporter) fieldType(named introspectionTypeRef) (string, string, bool) {
+	switch named.Kind {
+	case "SCALAR":
+		switch named.Name {
+		case "Int", "Float":
+			return "number", "", true
+		case "Boolean":
+			return "boolean", "", true
+		}
+		// String、ID 和自定义标量都按字符串处理
+		return "string", "", true
+	case "ENUM":
+		return "string", "", true
+	case "INPUT_OBJECT":
+		im.defineInput(named.Name)
+		return "custom", named.Name, true
+	case "OBJECT", "INTERFACE":
+		im.defineOutput(named.Name)
+		return "custom", named.Name, true
+	}
+	return "", "", false
+}
+
+// defineInput 由输入类型的 inputFields 定义自定义类型
+func (im *graphQLImporter) defineInput(name string) {
+	if im.types[name] != nil {
+		return
+	}
+	src := im.schema[name]
+	t := &importedType{Name: name}
+	im.types[name] = t
+	im.order = append(im.order, name)
+	if src == nil {
+		im.warn("type %s not found in schema", name)
+		return
+	}
+	t.Description = src.Description
+	for _, f := range src.InputFields {
+		named, nonNull, isList, ok := unwrapTypeRef(f.Type)
+		typ, ref := "", ""
+		if ok {
+			typ, ref, ok = im.fieldType(named)
+		}
+		if !ok {
+			im.warn("%s.%s skipped: unsupported type %s", name, f.Name, graphQLTypeString(&f.Type))
+			continue
+		}
+		t.Fields = append(t.Fields, importedField{
+			Name:        f.Name,
+			Type:        typ,
+			RefName:     ref,
+			IsArray:     isList,
+			Required:    nonNull && f.DefaultValue == nil,
+			Description: im.describeEnum(f.Description, named),
+		})
+	}
+}
+
+// defineOutput 由对象或接口类型的 fields 定义自定义类型；对象字段可能因深度限制不被选择，因此不标记为必填
+func (im *graphQLImporter) defineOutput(name string) {
+	if im.types[name] != nil {
+		return
+	}
+	src := im.schema[name]
+	t := &importedType{Name: name}
+	im.types[name] = t
+	im.order = append(im.order, name)
+	if src == nil {
+		im.warn("type %s not found in schema", name)
+		return
+	}
+	t.Description = src.Description
+	for _, f := range src.Fields {
+		if requiredArg := requiredArgument(f.Args); requiredArg != "" {
+			im.warn("%s.%s skipped: argument %s is required", name, f.Name, requiredArg)
+			continue
+		}
+		named, nonNull, isList, ok := unwrapTypeRef(f.Type)
+		typ, ref := "", ""
+		if ok {
+			typ, ref, ok = im.fieldType(named)
+		}
+		if !ok {
+			im.warn("%s.%s skipped: unsupported type %s", name, f.Name, graphQLTypeString(&f.Type))
+			continue
+		}
+		t.Fields = append(t.Fields, importedField{
+			Name:        f.Name,
+			Type:        typ,
+			RefName:     ref,
+			IsArray:     isList,
+			Required:    nonNull && typ != "custom",
+			Description: im.describeEnum(f.Description, named),
+		})
+	}
+}
+
+// requiredArgument 返回第一个没有默认值的非空参数名
+func requiredArgument(args []introspectionInputValue) string {
+	for _, arg := range args {
+		if arg.Type.Kind == "NON_NULL" && arg.DefaultValue == nil {
+			return arg.Name
+		}
+	}
+	return ""
+}
+
+// selection 按导入后的类型生成选择集，超过最大深度的对象字段不再展开
+func (im *graphQLImporter) selection(typeName string, depth int, indent string) string {
+	t := im.types[typeName]
+	if t == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range t.Fields {
+		if f.Type != "custom" {
+			b.WriteString(indent + f.Name + "\n")
+			continue
+		}
+		if depth >= im.maxDepth {
+			continue
+		}
+		if sub := im.selection(f.RefName, depth+1, indent+"  "); sub != "" {
+			b.WriteString(indent + f.Name + " {\n" + sub + indent + "}\n")
+		}
+	}
+	return b.String()
+}
+
+// graphQLOperation 根字段对应的参数和返回类型，在打破循环引用之后生成查询文档
+type graphQLOperation struct {
+	iface     *importedInterface
+	field     introspectionField
+	args      []introspectionInputValue
+	returnRef string // 返回的对象类型名称，标量时为空
+	union     bool
+}
+
+// buildOperation 将根字段转换为接口定义，查询文档在 document 中生成
+func (im *graphQLImporter) buildOperation(operation string, field introspectionField) graphQLOperation {
+	iface := &importedInterface{
+		Name:        field.Name,
+		Description: field.Description,
+		URL:         im.url,
+		Method:      "POST",
+		Protocol:    "graphql",
+		Operation:   operation,
+	}
+	op := graphQLOperation{iface: iface, field: field}
+	for _, arg := range field.Args {
+		named, nonNull, isList, ok := unwrapTypeRef(arg.Type)
+		typ, ref := "", ""
+		if ok {
+			typ, ref, ok = im.fieldType(named)
+		}
+		required := nonNull && arg.DefaultValue == nil
+		if !ok {
+			if required {
+				iface.Skip = fmt.Sprintf("argument %s has unsupported type %s", arg.Name, graphQLTypeString(&arg.Type))
+				return op
+			}
+			im.warn("%s: argument %s skipped: unsupported type %s", field.Name, arg.Name, graphQLTypeString(&arg.Type))
+			continue
+		}
+		param := importedField{
+			Name:        arg.Name,
+			Type:        typ,
+			RefName:     ref,
+			IsArray:     isList,
+			Required:    required,
+			Description: im.describeEnum(arg.Description, named),
+			Location:    "body",
+			Group:       "input",
+		}
+		if !isList && typ != "custom" {
+			param.DefaultValue = graphQLDefault(arg.DefaultValue)
+		}
+		iface.Params = append(iface.Params, param)
+		op.args = append(op.args, arg)
+	}
+
+	// 返回值: data 中以根字段名为键，作为唯一的出参
+	named, nonNull, isList, ok := unwrapTypeRef(field.Type)
+	if ok && named.Kind == "UNION" {
+		op.union = true
+		im.warn("%s: union result only selects __typename", field.Name)
+		return op
+	}
+	typ, ref := "", ""
+	if ok {
+		typ, ref, ok = im.fieldType(named)
+	}
+	if !ok {
+		iface.Skip = fmt.Sprintf("unsupported result type %s", graphQLTypeString(&field.Type))
+		return op
+	}
+	op.returnRef = ref
+	iface.Params = append(iface.Params, importedField{
+		Name:        field.Name,
+		Type:        typ,
+		RefName:     ref,
+		IsArray:     isList,
+		Required:    nonNull,
+		Description: im.describeEnum("", named),
+		Location:    "body",
+		Group:       "output",
+	})
+	return op
+}
+
+// document 生成操作的查询文档，参数全部作为变量传入
+func (im *graphQLImporter) document(operation string, op graphQLOperation) string {
+	var defs, args []string
+	for _, arg := range op.args {
+		def := "$" + arg.Name + ": " + graphQLTypeString(&arg.Type)
+		if arg.DefaultValue != nil {
+			def += " = " + *arg.DefaultValue
+		}
+		defs = append(defs, def)
+		args = append(args, arg.Name+": $"+arg.Name)
+	}
+	var b strings.Builder
+	b.WriteString(operation + " " + op.field.Name)
+	if len(defs) > 0 {
+		b.WriteString("(" + strings.Join(defs, ", ") + ")")
+	}
+	b.WriteString(" {\n  " + op.field.Name)
+	if len(args) > 0 {
+		b.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+	switch {
+	case op.union:
+		b.WriteString(" {\n    __typename\n  }")
+	case op.returnRef != "":
+		sub := im.selection(op.returnRef, 1, "    ")
+		if sub == "" {
+			sub = "    __typename\n"
+		}
+		b.WriteString(" {\n" + sub + "  }")
+	}
+	b.WriteString("\n}\n")
+	return b.String()
+}
+
+// plan 生成完整的导入计划
+func (im *graphQLImporter) plan(schema *introspectionSchema) ([]*importedType, []importedInterface) {
+	type root struct {
+		operation string
+		typeName  string
+	}
+	var roots []root
+	if schema.QueryType != nil {
+		roots = append(roots, root{"query", schema.QueryType.Name})
+	}
+	if schema.MutationType != nil {
+		roots = append(roots, root{"mutation", schema.MutationType.Name})
+	}
+	var ops []graphQLOperation
+	used := make(map[string]bool)
+	for _, r := range roots {
+		t := im.schema[r.typeName]
+		if t == nil {
+			im.warn("root type %s not found in schema", r.typeName)
+			continue
+		}
+		for _, field := range t.Fields {
+			op := im.buildOperation(r.operation, field)
+			if used[op.iface.Name] && op.iface.Skip == "" {
+				op.iface.Skip = "duplicate operation name in schema"
+			}
+			used[op.iface.Name] = true
+			ops = append(ops, op)
+		}
+	}
+	// 选择集按打破循环之后的类型生成，与出参的结构保持一致
+	types := breakTypeCycles(im.types, im.order, im.warn)
+	interfaces := make([]importedInterface, 0, len(ops))
+	for _, op := range ops {
+		if op.iface.Skip == "" {
+			op.iface.Query = im.document(op.iface.Operation, op)
+		}
+		interfaces = append(interfaces, *op.iface)
+	}
+	return types, interfaces
+}
+
+// ImportGraphQL 根据内省结果导入接口和自定义类型，Query 和 Mutation 的每个字段生成一个 graphql 接口
+func ImportGraphQL(req ImportGraphQLRequest) (ImportResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return ImportResponse{}, err
+	}
+	if req.AuthType == "" {
+		req.AuthType = "none"
+	}
+	if req.MaxDepth == 0 {
+		req.MaxDepth = defaultGraphQLSelectionDepth
+	}
+	db := database.GetDB()
+	var app models.Application
+	if err := db.First(&app, req.AppID).Error; err != nil {
+		return ImportResponse{}, errors.New("application not found")
+	}
+	if err := checkAuthConfig(db, req.AuthType, req.AuthConfig); err != nil {
+		return ImportResponse{}, err
+	}
+	raw := []byte(req.Introspection)
+	if strings.TrimSpace(req.Introspection) == "" {
+		env, err := adapter.ParseEnvironment(app.Environment)
+		if err != nil {
+			return ImportResponse{}, err
+		}
+		httpConfig, err := adapter.ParseHTTPConfig(app.HTTPConfig)
+		if err != nil {
+			return ImportResponse{}, err
+		}
+		if raw, err = adapter.IntrospectGraphQL(context.Background(), req.URL, req.AuthType, req.AuthConfig, env, httpConfig); err != nil {
+			return ImportResponse{}, fmt.Errorf("introspection failed: %w", err)
+		}
+	}
+	schema, err := parseIntrospection(raw)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	importer := newGraphQLImporter(schema, req.URL, req.MaxDepth)
+	types, interfaces := importer.plan(schema)
+	return applyImportPlan(db, app, types, interfaces, importer.warnings, req.DryRun, req.AuthType, req.AuthConfig)
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"encoding/json"
+	"mcp-adapter/backend/adapter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 内省结果中的类型引用
+func gqlNamed(kind, name string) map[string]any {
+	return map[string]any{"kind": kind, "name": name, "ofType": nil}
+}
+
+func gqlNonNull(t map[string]any) map[string]any {
+	return map[string]any{"kind": "NON_NULL", "name": nil, "ofType": t}
+}
+
+func gqlList(t map[string]any) map[string]any {
+	return map[string]any{"kind": "LIST", "name": nil, "ofType": t}
+}
+
+func gqlField(name string, typ map[string]any, args ...map[string]any) map[string]any {
+	if args == nil {
+		args = []map[string]any{}
+	}
+	return map[string]any{"name": name, "description": "", "args": args, "type": typ}
+}
+
+func gqlInput(name string, typ map[string]any, defaultValue any) map[string]any {
+	return map[string]any{"name": name, "description": "", "type": typ, "defaultValue": defaultValue}
+}
+
+// testIntrospection 返回以下 schema 的内省结果:
+//
+//	type Query { user(id: ID!): User  users(first: Int = 10, order: Order = ASC, filter: UserFilter): [User!]!  search(q: String!): SearchResult  version: String! }
+//	type Mutation { createUser(input: CreateUserInput!): User! }
+//	type User { id: ID!  name: String  role: Role!  friends: [User!]!  posts(limit: Int): [Post!]  avatar(size: Int!): String }
+//	type Post { id: ID!  title: String!  author: User! }
+//	input UserFilter { name: String  roles: [Role!] }
+//	input CreateUserInput { name: String!  role: Role = USER }
+//	enum Role { ADMIN USER }  enum Order { ASC DESC }  union SearchResult = User | Post
+func testIntrospection(t *testing.T) string {
+	scalar := func(name string) map[string]any { return gqlNamed("SCALAR", name) }
+	object := func(name string) map[string]any { return gqlNamed("OBJECT", name) }
+	enum := func(name string) map[string]any { return gqlNamed("ENUM", name) }
+	input := func(name string) map[string]any { return gqlNamed("INPUT_OBJECT", name) }
+	enumValues := func(names ...string) []map[string]any {
+		values := make([]map[string]any, 0, len(names))
+		for _, n := range names {
+			values = append(values, map[string]any{"name": n})
+		}
+		return values
+	}
+	schema := map[string]any{
+		"queryType":    map[string]any{"name": "Query"},
+		"mutationType": map[string]any{"name": "Mutation"},
+		"types": []map[string]any{
+			{"kind": "OBJECT", "name": "Query", "fields": []map[string]any{
+				gqlField("user", object("User"), gqlInput("id", gqlNonNull(scalar("ID")), nil)),
+				gqlField("users", gqlNonNull(gqlList(gqlNonNull(object("User")))),
+					gqlInput("first", scalar("Int"), "10"),
+					gqlInput("order", enum("Order"), "ASC"),
+					gqlInput("filter", input("UserFilter"), nil)),
+				gqlField("search", gqlNamed("UNION", "SearchResult"), gqlInput("q", gqlNonNull(scalar("String")), nil)),
+				gqlField("version", gqlNonNull(scalar("String"))),
+			}},
+			{"kind": "OBJECT", "name": "Mutation", "fields": []map[string]any{
+				gqlField("createUser", gqlNonNull(object("User")), gqlInput("input", gqlNonNull(input("CreateUserInput")), nil)),
+			}},
+			{"kind": "OBJECT", "name": "User", "description": "A user", "fields": []map[string]any{
+				gqlField("id", gqlNonNull(scalar("ID"))),
+				gqlField("name", scalar("String")),
+				gqlField("role", gqlNonNull(enum("Role"))),
+				gqlField("friends", gqlNonNull(gqlList(gqlNonNull(object("User"))))),
+				gqlField("posts", gqlList(gqlNonNull(object("Post"))), gqlInput("limit", scalar("Int"), nil)),
+				gqlField("avatar", scalar("String"), gqlInput("size", gqlNonNull(scalar("Int")), nil)),
+			}},
+			{"kind": "OBJECT", "name": "Post", "fields": []map[string]any{
+				gqlField("id", gqlNonNull(scalar("ID"))),
+				gqlField("title", gqlNonNull(scalar("String"))),
+				gqlField("author", gqlNonNull(object("User"))),
+			}},
+			{"kind": "INPUT_OBJECT", "name": "UserFilter", "inputFields": []map[string]any{
+				gqlInput("name", scalar("String"), nil),
+				gqlInput("roles", gqlList(gqlNonNull(enum("Role"))), nil),
+			}},
+			{"kind": "INPUT_OBJECT", "name": "CreateUserInput", "inputFields": []map[string]any{
+				gqlInput("name", gqlNonNull(scalar("String")), nil),
+				gqlInput("role", enum("Role"), "USER"),
+			}},
+			{"kind": "ENUM", "name": "Role", "enumValues": enumValues("ADMIN", "USER")},
+			{"kind": "ENUM", "name": "Order", "enumValues": enumValues("ASC", "DESC")},
+			{"kind": "UNION", "name": "SearchResult"},
+			{"kind": "SCALAR", "name": "ID"},
+			{"kind": "SCALAR", "name": "String"},
+			{"kind": "SCALAR", "name": "Int"},
+		},
+	}
+	data, err := json.Marshal(map[string]any{"data": map[string]any{"__schema": schema}})
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestParseIntrospection(t *testing.T) {
+	schema, err := parseIntrospection([]byte(`{"__schema": {"queryType": {"name": "Query"}, "types": []}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Query", schema.QueryType.Name)
+
+	_, err = parseIntrospection([]byte(`{"data": {}}`))
+	assert.EqualError(t, err, "invalid introspection result: __schema not found")
+	_, err = parseIntrospection([]byte(`{"__schema": {"types": []}}`))
+	assert.EqualError(t, err, "invalid introspection result: schema has no query or mutation type")
+	_, err = parseIntrospection([]byte(`not json`))
+	assert.ErrorContains(t, err, "invalid introspection result")
+}
+
+func TestImportGraphQL_Create(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "GraphQLImport", Path: "graphql-import", Protocol: "sse"})
+	require.NoError(t, err)
+	appID := app.Application.ID
+
+	resp, err := ImportGraphQL(ImportGraphQLRequest{AppID: appID, URL: "https://api.example.com/graphql", Introspection: testIntrospection(t)})
+	require.NoError(t, err)
+	assert.Contains(t, strings.Join(resp.Warnings, "\n"), "User.avatar skipped: argument size is required")
+	assert.Contains(t, strings.Join(resp.Warnings, "\n"), "circular reference")
+
+	types, err := ListCustomTypes(ListCustomTypesRequest{AppID: appID})
+	require.NoError(t, err)
+	typeIDs := make(map[string]int64)
+	for _, ct := range types.CustomTypes {
+		typeIDs[ct.Name] = ct.ID
+	}
+	assert.ElementsMatch(t, []string{"User", "Post", "UserFilter", "CreateUserInput"}, keys(typeIDs))
+
+	ifaces, err := ListInterfaces(ListInterfacesRequest{AppID: appID})
+	require.NoError(t, err)
+	byName := make(map[string]InterfaceDTO)
+	for _, iface := range ifaces.Interfaces {
+		byName[iface.Name] = iface
+		assert.Equal(t, "graphql", iface.Protocol)
+		assert.Equal(t, "POST", iface.Method)
+		assert.Equal(t, "https://api.example.com/graphql", iface.URL)
+	}
+	require.Len(t, byName, 5)
+
+	user := byName["user"]
+	assert.True(t, strings.HasPrefix(user.Query, "query user($id: ID!) {\n  user(id: $id) {\n    id\n    name\n    role\n"), user.Query)
+	assert.NotContains(t, user.Query, "avatar")
+	params := make(map[string]InterfaceParameterDTO)
+	for _, p := range user.Parameters {
+		params[p.Group+":"+p.Name] = p
+	}
+	assert.True(t, params["input:id"].Required)
+	assert.Equal(t, "body", params["input:id"].Location)
+	assert.Equal(t, typeIDs["User"], *params["output:user"].Ref)
+
+	users := byName["users"]
+	assert.Contains(t, users.Query, "query users($first: Int = 10, $order: Order = ASC, $filter: UserFilter)")
+	params = make(map[string]InterfaceParameterDTO)
+	for _, p := range users.Parameters {
+		params[p.Group+":"+p.Name] = p
+	}
+	require.NotNil(t, params["input:first"].DefaultValue)
+	assert.Equal(t, "10", *params["input:first"].DefaultValue)
+	assert.Equal(t, "number", params["input:first"].Type)
+	assert.Contains(t, params["input:order"].Description, "One of: ASC, DESC")
+	assert.Equal(t, typeIDs["UserFilter"], *params["input:filter"].Ref)
+	assert.True(t, params["output:users"].IsArray)
+
+	assert.Contains(t, byName["search"].Query, "search(q: $q) {\n    __typename\n  }")
+	assert.Equal(t, "query version {\n  version\n}\n", byName["version"].Query)
+	assert.Contains(t, byName["createUser"].Query, "mutation createUser($input: CreateUserInput!)")
+
+	// 再次导入时，已存在的接口被跳过，类型被复用
+	resp, err = ImportGraphQL(ImportGraphQLRequest{AppID: appID, URL: "https://api.example.com/graphql", Introspection: testIntrospection(t)})
+	require.NoError(t, err)
+	for _, item := range resp.Interfaces {
+		assert.Equal(t, "skip", item.Action)
+	}
+}
+
+func keys(m map[string]int64) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
+
+func TestImportGraphQL_Introspect(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	introspection := testIntrospection(t)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Header.Get("Authorization") != "Bearer t0ken" || !strings.Contains(body.Query, "__schema") {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors": [{"message": "unauthorized"}]}`))
+			return
+		}
+		w.Write([]byte(introspection))
+	}))
+	defer upstream.Close()
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "GraphQLIntrospect", Path: "graphql-introspect", Protocol: "sse"})
+	require.NoError(t, err)
+	_, err = CreateSecret(CreateSecretRequest{Name: "graphql-token", Value: "t0ken"})
+	require.NoError(t, err)
+
+	_, err = ImportGraphQL(ImportGraphQLRequest{AppID: app.Application.ID, URL: upstream.URL, AuthType: "bearer", AuthConfig: `{"token": "t0ken"}`, DryRun: true})
+	assert.EqualError(t, err, "auth_config token must be a secret:// reference")
+	resp, err := ImportGraphQL(ImportGraphQLRequest{AppID: app.Application.ID, URL: upstream.URL, AuthType: "bearer", AuthConfig: `{"token": "secret://graphql-token"}`, DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, resp.DryRun)
+	require.Len(t, resp.Interfaces, 5)
+	assert.Equal(t, "query "+upstream.URL, resp.Interfaces[0].Detail)
+
+	_, err = ImportGraphQL(ImportGraphQLRequest{AppID: app.Application.ID, URL: upstream.URL, DryRun: true})
+	assert.EqualError(t, err, "introspection failed: graphql request failed: unauthorized")
+}
+
+func TestImportGraphQL_Errors(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "GraphQLErrors", Path: "graphql-errors", Protocol: "sse"})
+	require.NoError(t, err)
+
+	_, err = ImportGraphQL(ImportGraphQLRequest{AppID: 99999, URL: "https://api.example.com/graphql", Introspection: testIntrospection(t)})
+	assert.EqualError(t, err, "application not found")
+	_, err = ImportGraphQL(ImportGraphQLRequest{AppID: app.Application.ID, URL: "https://api.example.com/graphql", AuthType: "bearer", Introspection: testIntrospection(t)})
+	assert.EqualError(t, err, "auth_config is required for auth type bearer")
+	_, err = ImportGraphQL(ImportGraphQLRequest{AppID: app.Application.ID, URL: "https://api.example.com/graphql", Introspection: `{}`})
+	assert.EqualError(t, err, "invalid introspection result: __schema not found")
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+type ImportGRPCRequest struct {
+	AppID         int64    `json:"app_id" validate:"required,gt=0"`                                      // 导入到的应用 ID
+	URL           string   `json:"url" validate:"required,max=1024"`                                     // 服务地址 grpc://host:port 或 grpcs://host:port
+	DescriptorSet string   `json:"descriptor_set" validate:"max=10485760"`                               // FileDescriptorSet (base64)，为空时通过服务端反射获取
+	Services      []string `json:"services" validate:"max=100,dive,max=255"`                             // 只导入这些服务 (完整名称，如 demo.v1.UserService)，为空时导入全部
+	AuthType      string   `json:"auth_type" validate:"omitempty,oneof=none bearer basic apikey oauth2"` // 导入接口的鉴权类型，默认 none，反射查询同样使用
+	AuthConfig    string   `json:"auth_config" validate:"max=65536"`                                     // 鉴权配置 (JSON)，结构由 auth_type 决定
+	DryRun        bool     `json:"dry_run"`                                                              // 只预览，不写入数据库
+}
+
+// protoScalar 按 JSON 映射表示为标量的标准类型
+type protoScalar struct {
+	typ  string
+	note string
+}
+
+var protoWellKnownTypes = map[protoreflect.FullName]protoScalar{
+	"google.protobuf.Timestamp":   {"string", "RFC 3339 timestamp, e.g. 2024-01-01T00:00:00Z."},
+	"google.protobuf.Duration":    {"string", "Duration in seconds with an s suffix, e.g. 1.5s."},
+	"google.protobuf.FieldMask":   {"string", "Comma-separated field paths."},
+	"google.protobuf.StringValue": {"string", ""},
+	"google.protobuf.BytesValue":  {"string", "Base64 encoded."},
+	"google.protobuf.BoolValue":   {"boolean", ""},
+	"google.protobuf.Int32Value":  {"number", ""},
+	"google.protobuf.UInt32Value": {"number", ""},
+	"google.protobuf.FloatValue":  {"number", ""},
+	"google.protobuf.DoubleValue": {"number", ""},
+	"google.protobuf.Int64Value":  {"string", "64-bit integer encoded as a string."},
+	"google.protobuf.UInt64Value": {"string", "64-bit integer encoded as a string."},
+}
+
+// grpcImporter 把服务描述转换为导入计划：每个一元方法对应一个接口，消息对应自定义类型
+type grpcImporter struct {
+	url      string
+	embed    bool // 接口是否保存描述集，通过反射导入时为 false
+	types    map[string]*importedType
+	names    map[protoreflect.FullName]string // 消息 -> 自定义类型名称
+	order    []string                         // 类型的发现顺序，打破循环时优先保留靠近方法的引用
+	warnings []string
+	warned   map[string]bool // 消息展开为参数和定义为类型时会重复检查同一个字段
+}
+
+func newGRPCImporter(url string, embed bool) *grpcImporter {
+	return &grpcImporter{
+		url:    strings.TrimRight(url, "/"),
+		embed:  embed,
+		types:  make(map[string]*importedType),
+		names:  make(map[protoreflect.FullName]string),
+		warned: make(map[string]bool),
+	}
+}
+
+func (im *grpcImporter) warn(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if !im.warned[msg] {
+		im.warned[msg] = true
+		im.warnings = append(im.warnings, msg)
+	}
+}
+
+// protoComments 返回描述的前导注释，描述集需要包含源码信息 (--include_source_info)
+func protoComments(d protoreflect.Descriptor) string {
+	return strings.TrimSpace(d.ParentFile().SourceLocations().ByDescriptor(d).LeadingComments)
+}
+
+func describeProto(comment, note string) string {
+	if note == "" {
+		return comment
+	}
+	return strings.TrimSpace(comment + "\n\n" + note)
+}
+
+// fieldType 将字段转换为 (type, refName, note)，ok 为 false 表示无法表示 (map、Struct、Any 等)
+func (im *grpcImporter) fieldType(fd protoreflect.FieldDescriptor) (string, string, string, bool) {
+	if fd.IsMap() {
+		return "", "", "", false
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return "boolean", "", "", true
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "number", "", "", true
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson 把 64 位整数编码为字符串
+		return "string", "", "64-bit integer encoded as a string.", true
+	case protoreflect.StringKind:
+		return "string", "", "", true
+	case protoreflect.BytesKind:
+		return "string", "", "Base64 encoded.", true
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return "string", "", "One of: " + strings.Join(names, ", "), true
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		if scalar, ok := protoWellKnownTypes[md.FullName()]; ok {
+			return scalar.typ, "", scalar.note, true
+		}
+		if strings.HasPrefix(string(md.FullName()), "google.protobuf.") {
+			// Struct、Value、Any 等可以是任意 JSON，无法用自定义类型表示
+			return "", "", "", false
+		}
+		return "custom", im.defineMessage(md), "", true
+	}
+	return "", "", "", false
+}
+
+// messageFields 转换消息的字段，proto2 的 required 字段标记为必填
+func (im *grpcImporter) messageFields(owner string, md protoreflect.MessageDescriptor) []importedField {
+	fields := md.Fields()
+	result := make([]importedField, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		typ, ref, note, ok := im.fieldType(fd)
+		if !ok {
+			if fd.IsMap() {
+				im.warn("%s.%s skipped: map fields are not supported", owner, fd.Name())
+			} else {
+				im.warn("%s.%s skipped: unsupported type %s", owner, fd.Name(), fd.Message().FullName())
+			}
+			continue
+		}
+		result = append(result, importedField{
+			Name:        string(fd.Name()),
+			Type:        typ,
+			RefName:     ref,
+			IsArray:     fd.IsList(),
+			Required:    fd.Cardinality() == protoreflect.Required,
+			Description: describeProto(protoComments(fd), note),
+		})
+	}
+	return result
+}
+
+// defineMessage 由消息定义自定义类型，不同包中的同名消息使用完整名称
+func (im *grpcImporter) defineMessage(md protoreflect.MessageDescriptor) string {
+	if name, ok := im.names[md.FullName()]; ok {
+		return name
+	}
+	name := string(md.Name())
+	if im.types[name] != nil {
+		name = strings.ReplaceAll(string(md.FullName()), ".", "_")
+	}
+	im.names[md.FullName()] = name
+	t := &importedType{Name: name, Description: protoComments(md)}
+	im.types[name] = t
+	im.order = append(im.order, name)
+	t.Fields = im.messageFields(name, md)
+	return name
+}
+
+// buildMethod 请求消息的字段作为 body 入参，响应消息的字段作为出参
+func (im *grpcImporter) buildMethod(sd protoreflect.ServiceDescriptor, md protoreflect.MethodDescriptor) importedInterface {
+	iface := importedInterface{
+		Name:        string(md.Name()),
+		Description: protoComments(md),
+		URL:         im.url + "/" + string(sd.FullName()) + "/" + string(md.Name()),
+		Method:      "POST",
+		Protocol:    "grpc",
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		iface.Skip = "streaming methods are not supported"
+		return iface
+	}
+	for _, f := range im.messageFields(string(md.Input().Name()), md.Input()) {
+		f.Location, f.Group = "body", "input"
+		iface.Params = append(iface.Params, f)
+	}
+	for _, f := range im.messageFields(string(md.Output().Name()), md.Output()) {
+		f.Location, f.Group, f.Required = "body", "output", false
+		iface.Params = append(iface.Params, f)
+	}
+	return iface
+}
+
+// plan 生成完整的导入计划，不同服务中的同名方法使用 服务名_方法名
+func (im *grpcImporter) plan(services []protoreflect.ServiceDescriptor) ([]*importedType, []importedInterface) {
+	interfaces := make([]importedInterface, 0)
+	used := make(map[string]bool)
+	for _, sd := range services {
+		descriptorSet := ""
+		if im.embed {
+			encoded, err := adapter.EncodeDescriptorSet(sd.ParentFile())
+			if err != nil {
+				im.warn("%s: failed to encode descriptor set: %v", sd.FullName(), err)
+			}
+			descriptorSet = encoded
+		}
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			iface := im.buildMethod(sd, methods.Get(i))
+			if used[iface.Name] {
+				iface.Name = string(sd.Name()) + "_" + iface.Name
+			}
+			if used[iface.Name] && iface.Skip == "" {
+				iface.Skip = "duplicate method name"
+			}
+			used[iface.Name] = true
+			iface.DescriptorSet = descriptorSet
+			interfaces = append(interfaces, iface)
+		}
+	}
+	return breakTypeCycles(im.types, im.order, im.warn), interfaces
+}
+
+// selectGRPCServices 按名称筛选服务，结果按完整名称排序
+func selectGRPCServices(files *protoregistry.Files, names []string) ([]protoreflect.ServiceDescriptor, error) {
+	all := adapter.GRPCServices(files)
+	sort.Slice(all, func(i, j int) bool { return all[i].FullName() < all[j].FullName() })
+	if len(names) == 0 {
+		if len(all) == 0 {
+			return nil, errors.New("no grpc services found")
+		}
+		return all, nil
+	}
+	byName := make(map[string]protoreflect.ServiceDescriptor, len(all))
+	for _, sd := range all {
+		byName[string(sd.FullName())] = sd
+	}
+	selected := make([]protoreflect.ServiceDescriptor, 0, len(names))
+	for _, name := range names {
+		sd, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("grpc service %s not found", name)
+		}
+		selected = append(selected, sd)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].FullName() < selected[j].FullName() })
+	return selected, nil
+}
+
+// ImportGRPC 根据描述集或服务端反射导入接口和自定义类型，每个一元方法生成一个 grpc 接口
+func ImportGRPC(req ImportGRPCRequest) (ImportResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return ImportResponse{}, err
+	}
+	if req.AuthType == "" {
+		req.AuthType = "none"
+	}
+	db := database.GetDB()
+	var app models.Application
+	if err := db.First(&app, req.AppID).Error; err != nil {
+		return ImportResponse{}, errors.New("application not found")
+	}
+	if err := checkAuthConfig(db, req.AuthType, req.AuthConfig); err != nil {
+		return ImportResponse{}, err
+	}
+	if err := checkGRPCAuth(req.AuthType, req.AuthConfig); err != nil {
+		return ImportResponse{}, err
+	}
+	if err := adapter.ValidateGRPCEndpoint(req.URL); err != nil {
+		return ImportResponse{}, err
+	}
+	var files *protoregistry.Files
+	var err error
+	if strings.TrimSpace(req.DescriptorSet) != "" {
+		if files, err = adapter.ParseDescriptorSet(req.DescriptorSet); err != nil {
+			return ImportResponse{}, err
+		}
+	} else {
+		env, err := adapter.ParseEnvironment(app.Environment)
+		if err != nil {
+			return ImportResponse{}, err
+		}
+		httpConfig, err := adapter.ParseHTTPConfig(app.HTTPConfig)
+		if err != nil {
+			return ImportResponse{}, err
+		}
+		if files, err = adapter.ReflectGRPCServices(context.Background(), req.URL, req.Services, req.AuthType, req.AuthConfig, env, httpConfig); err != nil {
+			return ImportResponse{}, fmt.Errorf("grpc reflection failed: %w", err)
+		}
+	}
+	services, err := selectGRPCServices(files, req.Services)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	importer := newGRPCImporter(req.URL, strings.TrimSpace(req.DescriptorSet) != "")
+	types, interfaces := importer.plan(services)
+	return applyImportPlan(db, app, types, interfaces, importer.warnings, req.DryRun, req.AuthType, req.AuthConfig)
+}
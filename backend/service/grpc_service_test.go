@@ -0,0 +1,262 @@
+package service
+
+import (
+	"mcp-adapter/backend/adapter"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// testGRPCProto demo/v1/user.proto 的文件描述:
+//
+//	service UserService { rpc GetUser(GetUserRequest) returns (User); rpc ListUsers(ListUsersRequest) returns (ListUsersResponse); rpc WatchUsers(ListUsersRequest) returns (stream User); }
+//	service AdminService { rpc GetUser(GetUserRequest) returns (User); }
+//	message User { int64 id; string display_name; Role role; User manager; map<string, string> labels; google.protobuf.Struct metadata; google.protobuf.Timestamp created_at; }
+const testGRPCProto = `
+name: "demo/v1/user.proto"
+package: "demo.v1"
+dependency: "google/protobuf/timestamp.proto"
+dependency: "google/protobuf/struct.proto"
+syntax: "proto3"
+message_type {
+  name: "GetUserRequest"
+  field { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "id" }
+}
+message_type {
+  name: "User"
+  field { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "id" }
+  field { name: "display_name" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "displayName" }
+  field { name: "role" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".demo.v1.Role" json_name: "role" }
+  field { name: "manager" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".demo.v1.User" json_name: "manager" }
+  field { name: "labels" number: 5 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".demo.v1.User.LabelsEntry" json_name: "labels" }
+  field { name: "metadata" number: 6 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Struct" json_name: "metadata" }
+  field { name: "created_at" number: 7 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" json_name: "createdAt" }
+  nested_type {
+    name: "LabelsEntry"
+    field { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "key" }
+    field { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "value" }
+    options { map_entry: true }
+  }
+}
+message_type {
+  name: "ListUsersRequest"
+  field { name: "page_size" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "pageSize" }
+  field { name: "roles" number: 2 label: LABEL_REPEATED type: TYPE_ENUM type_name: ".demo.v1.Role" json_name: "roles" }
+}
+message_type {
+  name: "ListUsersResponse"
+  field { name: "users" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".demo.v1.User" json_name: "users" }
+  field { name: "next_page_token" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "nextPageToken" }
+}
+enum_type {
+  name: "Role"
+  value { name: "ROLE_UNSPECIFIED" number: 0 }
+  value { name: "ROLE_ADMIN" number: 1 }
+}
+service {
+  name: "UserService"
+  method { name: "GetUser" input_type: ".demo.v1.GetUserRequest" output_type: ".demo.v1.User" }
+  method { name: "ListUsers" input_type: ".demo.v1.ListUsersRequest" output_type: ".demo.v1.ListUsersResponse" }
+  method { name: "WatchUsers" input_type: ".demo.v1.ListUsersRequest" output_type: ".demo.v1.User" server_streaming: true }
+}
+service {
+  name: "AdminService"
+  method { name: "GetUser" input_type: ".demo.v1.GetUserRequest" output_type: ".demo.v1.User" }
+}
+source_code_info {
+  location { path: [6, 0, 2, 0] span: [10, 2, 40] leading_comments: " Returns a user by id.\n" }
+}`
+
+func testGRPCFiles(t *testing.T) *protoregistry.Files {
+	var fdp descriptorpb.FileDescriptorProto
+	require.NoError(t, prototext.Unmarshal([]byte(testGRPCProto), &fdp))
+	fd, err := protodesc.NewFile(&fdp, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	files := new(protoregistry.Files)
+	require.NoError(t, files.RegisterFile(fd))
+	return files
+}
+
+func testGRPCDescriptorSet(t *testing.T) string {
+	fd, err := testGRPCFiles(t).FindFileByPath("demo/v1/user.proto")
+	require.NoError(t, err)
+	encoded, err := adapter.EncodeDescriptorSet(fd)
+	require.NoError(t, err)
+	return encoded
+}
+
+// testServiceInfo 只用于反射服务列出服务名
+type testServiceInfo map[string]grpc.ServiceInfo
+
+func (s testServiceInfo) GetServiceInfo() map[string]grpc.ServiceInfo { return s }
+
+// startTestReflectionServer 启动只提供反射服务的 gRPC 服务端，要求 authorization: Bearer t0ken
+func startTestReflectionServer(t *testing.T) string {
+	auth := func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		if v := md.Get("authorization"); len(v) == 0 || v[0] != "Bearer t0ken" {
+			return status.Error(codes.Unauthenticated, "missing token")
+		}
+		return handler(srv, ss)
+	}
+	s := grpc.NewServer(grpc.StreamInterceptor(auth))
+	reflectionpb.RegisterServerReflectionServer(s, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           testServiceInfo{"demo.v1.UserService": {}, "demo.v1.AdminService": {}},
+		DescriptorResolver: testGRPCFiles(t),
+	}))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func TestImportGRPC_DescriptorSet(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "GRPCImport", Path: "grpc-import", Protocol: "sse"})
+	require.NoError(t, err)
+	appID := app.Application.ID
+
+	resp, err := ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://users:50051", DescriptorSet: testGRPCDescriptorSet(t)})
+	require.NoError(t, err)
+	warnings := strings.Join(resp.Warnings, "\n")
+	assert.Contains(t, warnings, "User.labels skipped: map fields are not supported")
+	assert.Contains(t, warnings, "User.metadata skipped: unsupported type google.protobuf.Struct")
+	assert.Contains(t, warnings, "circular reference")
+	assert.Equal(t, 1, strings.Count(warnings, "User.labels skipped"))
+
+	actions := make(map[string]string)
+	for _, item := range resp.Interfaces {
+		actions[item.Name] = item.Action
+	}
+	assert.Equal(t, "skip", actions["WatchUsers"])
+
+	types, err := ListCustomTypes(ListCustomTypesRequest{AppID: appID})
+	require.NoError(t, err)
+	typeIDs := make(map[string]int64)
+	for _, ct := range types.CustomTypes {
+		typeIDs[ct.Name] = ct.ID
+	}
+	assert.ElementsMatch(t, []string{"User"}, keys(typeIDs))
+
+	ifaces, err := ListInterfaces(ListInterfacesRequest{AppID: appID})
+	require.NoError(t, err)
+	byName := make(map[string]InterfaceDTO)
+	for _, iface := range ifaces.Interfaces {
+		byName[iface.Name] = iface
+		assert.Equal(t, "grpc", iface.Protocol)
+		assert.Equal(t, "POST", iface.Method)
+		assert.NotEmpty(t, iface.DescriptorSet)
+	}
+	// 服务按名称排序，AdminService 先导入，UserService 的同名方法加上服务名前缀
+	assert.ElementsMatch(t, []string{"GetUser", "UserService_GetUser", "ListUsers"}, []string{
+		byName["GetUser"].Name, byName["UserService_GetUser"].Name, byName["ListUsers"].Name,
+	})
+	require.Len(t, byName, 3)
+	assert.Equal(t, "grpc://users:50051/demo.v1.AdminService/GetUser", byName["GetUser"].URL)
+
+	getUser := byName["UserService_GetUser"]
+	assert.Equal(t, "grpc://users:50051/demo.v1.UserService/GetUser", getUser.URL)
+	assert.Equal(t, "Returns a user by id.", getUser.Description)
+	params := make(map[string]InterfaceParameterDTO)
+	for _, p := range getUser.Parameters {
+		params[p.Group+":"+p.Name] = p
+	}
+	assert.Equal(t, "string", params["input:id"].Type)
+	assert.Contains(t, params["input:id"].Description, "64-bit integer")
+	assert.Contains(t, params["output:role"].Description, "One of: ROLE_UNSPECIFIED, ROLE_ADMIN")
+	assert.Equal(t, typeIDs["User"], *params["output:manager"].Ref)
+	assert.Contains(t, params["output:created_at"].Description, "RFC 3339")
+	assert.NotContains(t, params, "output:labels")
+
+	list := byName["ListUsers"]
+	params = make(map[string]InterfaceParameterDTO)
+	for _, p := range list.Parameters {
+		params[p.Group+":"+p.Name] = p
+	}
+	assert.Equal(t, "number", params["input:page_size"].Type)
+	assert.True(t, params["input:roles"].IsArray)
+	assert.True(t, params["output:users"].IsArray)
+	assert.Equal(t, typeIDs["User"], *params["output:users"].Ref)
+
+	// 再次导入时，已存在的接口被跳过
+	resp, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://users:50051", DescriptorSet: testGRPCDescriptorSet(t)})
+	require.NoError(t, err)
+	for _, item := range resp.Interfaces {
+		assert.Equal(t, "skip", item.Action)
+	}
+}
+
+func TestImportGRPC_Reflection(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	addr := startTestReflectionServer(t)
+	app, err := CreateApplication(CreateApplicationRequest{Name: "GRPCReflect", Path: "grpc-reflect", Protocol: "sse"})
+	require.NoError(t, err)
+	appID := app.Application.ID
+	_, err = CreateSecret(CreateSecretRequest{Name: "grpc-token", Value: "t0ken"})
+	require.NoError(t, err)
+
+	resp, err := ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://" + addr, AuthType: "bearer", AuthConfig: `{"token": "secret://grpc-token"}`, DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, resp.DryRun)
+	require.Len(t, resp.Interfaces, 4)
+
+	resp, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://" + addr, Services: []string{"demo.v1.UserService"}, AuthType: "bearer", AuthConfig: `{"token": "secret://grpc-token"}`})
+	require.NoError(t, err)
+	require.Len(t, resp.Interfaces, 3)
+	ifaces, err := ListInterfaces(ListInterfacesRequest{AppID: appID})
+	require.NoError(t, err)
+	require.Len(t, ifaces.Interfaces, 2)
+	for _, iface := range ifaces.Interfaces {
+		assert.Empty(t, iface.DescriptorSet)
+		assert.Equal(t, "bearer", iface.AuthType)
+	}
+
+	_, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://" + addr, DryRun: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "grpc reflection failed")
+	assert.Contains(t, err.Error(), "missing token")
+}
+
+func TestImportGRPC_Errors(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "GRPCErrors", Path: "grpc-errors", Protocol: "sse"})
+	require.NoError(t, err)
+	appID := app.Application.ID
+	_, err = CreateSecret(CreateSecretRequest{Name: "grpc-key", Value: "k"})
+	require.NoError(t, err)
+	set := testGRPCDescriptorSet(t)
+
+	_, err = ImportGRPC(ImportGRPCRequest{AppID: 99999, URL: "grpc://users:50051", DescriptorSet: set})
+	assert.EqualError(t, err, "application not found")
+	_, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "https://users", DescriptorSet: set})
+	assert.ErrorContains(t, err, "must start with grpc:// or grpcs://")
+	_, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://users:50051/demo.v1.UserService/GetUser", DescriptorSet: set})
+	assert.ErrorContains(t, err, "must not contain a method")
+	_, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://users:50051", DescriptorSet: set, Services: []string{"demo.v1.OrderService"}})
+	assert.EqualError(t, err, "grpc service demo.v1.OrderService not found")
+	_, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://users:50051", DescriptorSet: "not base64!"})
+	assert.ErrorContains(t, err, "invalid descriptor_set")
+	_, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://users:50051", DescriptorSet: set, AuthType: "apikey", AuthConfig: `{"name": "key", "in": "query", "value": "secret://grpc-key"}`})
+	assert.ErrorContains(t, err, "query")
+	_, err = ImportGRPC(ImportGRPCRequest{AppID: appID, URL: "grpc://users:50051", DescriptorSet: set, AuthType: "capi"})
+	assert.Error(t, err)
+}
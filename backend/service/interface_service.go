@@ -6,22 +6,32 @@ import (
 	"mcp-adapter/backend/adapter"
 	"mcp-adapter/backend/database"
 	"mcp-adapter/backend/models"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type CreateInterfaceRequest struct {
-	AppID       int64                         `json:"app_id" validate:"required,gt=0"`                                         // 所属应用 ID
-	Name        string                        `json:"name" validate:"required,max=255"`                                        // 接口名称
-	Description string                        `json:"description" validate:"max=16384"`                                        // 接口描述
-	Protocol    string                        `json:"protocol" validate:"required,oneof=http"`                                 // 协议类型
-	URL         string                        `json:"url" validate:"required,max=1024"`                                        // 接口 URL
-	Method      string                        `json:"method" validate:"required,oneof=GET POST PUT DELETE PATCH HEAD OPTIONS"` // HTTP 方法
-	AuthType    string                        `json:"auth_type" validate:"required,oneof=none capi"`                           // 鉴权类型
-	Enabled     bool                          `json:"enabled"`                                                                 // 是否启用
-	PostProcess string                        `json:"post_process" validate:"max=1048576"`                                     // 后置处理脚本
-	Parameters  []CreateInterfaceParameterReq `json:"parameters"`                                                              // 接口参数列表
+	AppID         int64                         `json:"app_id" validate:"required,gt=0"`                                                    // 所属应用 ID
+	Name          string                        `json:"name" validate:"required,max=255"`                                                   // 接口名称
+	Description   string                        `json:"description" validate:"max=16384"`                                                   // 接口描述
+	Protocol      string                        `json:"protocol" validate:"required,oneof=http graphql grpc sql command mcp"`               // 协议类型
+	URL           string                        `json:"url" validate:"required,max=1024"`                                                   // 接口 URL
+	Method        string                        `json:"method" validate:"required,oneof=GET POST PUT DELETE PATCH HEAD OPTIONS"`            // HTTP 方法
+	AuthType      string                        `json:"auth_type" validate:"required,oneof=none capi bearer basic apikey oauth2 aws_sigv4"` // 鉴权类型
+	AuthConfig    string                        `json:"auth_config" validate:"max=65536"`                                                   // 鉴权配置 (JSON)，结构由 auth_type 决定
+	HTTPConfig    string                        `json:"http_config" validate:"max=65536"`                                                   // HTTP 客户端配置 (JSON)，覆盖应用级配置
+	BodyEncoding  string                        `json:"body_encoding" validate:"omitempty,oneof=json form multipart raw"`                   // 请求体编码，默认 json
+	BodyTemplate  string                        `json:"body_template" validate:"max=1048576"`                                               // 请求体模板 (Go text/template)
+	Query         string                        `json:"query" validate:"max=1048576"`                                                       // GraphQL 查询文档、SQL 语句、command 参数模板或 mcp 启动参数，每行一个参数
+	DescriptorSet string                        `json:"descriptor_set" validate:"max=10485760"`                                             // gRPC 描述集 (base64)，仅 grpc 协议使用，为空时通过服务端反射解析
+	Enabled       *bool                         `json:"enabled,omitempty"`                                                                  // 是否启用，默认启用
+	PostProcess   string                        `json:"post_process" validate:"max=1048576"`                                                // 后置处理脚本
+	Script        string                        `json:"script" validate:"max=1048576"`                                                      // 脚本钩子 (Starlark)，定义 pre_request / post_response
+	CacheConfig   string                        `json:"cache_config" validate:"max=65536"`                                                  // 响应缓存配置 (JSON)：ttl, max_entries, max_bytes, backend
+	RateLimit     string                        `json:"rate_limit" validate:"max=65536"`                                                    // 限流配置 (JSON)：requests, per, burst, max_in_flight, key
+	Parameters    []CreateInterfaceParameterReq `json:"parameters"`                                                                         // 接口参数列表
 }
 
 type CreateInterfaceParameterReq struct {
@@ -44,16 +54,25 @@ type ListInterfacesRequest struct {
 	AppID int64 `json:"app_id" validate:"required,gt=0"`
 }
 type UpdateInterfaceRequest struct {
-	ID          int64                          `json:"id" validate:"required,gt=0"`                                                        // 要更新的接口 ID
-	Name        *string                        `json:"name,omitempty" validate:"omitempty,max=255"`                                        // 接口名称
-	Description *string                        `json:"description,omitempty" validate:"omitempty,max=16384"`                               // 接口描述
-	Protocol    *string                        `json:"protocol,omitempty" validate:"omitempty,oneof=http"`                                 // 协议类型
-	URL         *string                        `json:"url,omitempty" validate:"omitempty,max=1024"`                                        // 接口 URL
-	Method      *string                        `json:"method,omitempty" validate:"omitempty,oneof=GET POST PUT DELETE PATCH HEAD OPTIONS"` // HTTP 方法
-	AuthType    *string                        `json:"auth_type,omitempty" validate:"omitempty,oneof=none capi"`                           // 鉴权类型
-	Enabled     *bool                          `json:"enabled,omitempty"`                                                                  // 是否启用
-	PostProcess *string                        `json:"post_process,omitempty" validate:"omitempty,max=1048576"`                            // 后置处理脚本
-	Parameters  *[]CreateInterfaceParameterReq `json:"parameters,omitempty"`                                                               // 如果提供，则完全替换参数列表
+	ID            int64                          `json:"id" validate:"required,gt=0"`                                                                   // 要更新的接口 ID
+	Name          *string                        `json:"name,omitempty" validate:"omitempty,max=255"`                                                   // 接口名称
+	Description   *string                        `json:"description,omitempty" validate:"omitempty,max=16384"`                                          // 接口描述
+	Protocol      *string                        `json:"protocol,omitempty" validate:"omitempty,oneof=http graphql grpc sql command mcp"`               // 协议类型
+	URL           *string                        `json:"url,omitempty" validate:"omitempty,max=1024"`                                                   // 接口 URL
+	Method        *string                        `json:"method,omitempty" validate:"omitempty,oneof=GET POST PUT DELETE PATCH HEAD OPTIONS"`            // HTTP 方法
+	AuthType      *string                        `json:"auth_type,omitempty" validate:"omitempty,oneof=none capi bearer basic apikey oauth2 aws_sigv4"` // 鉴权类型
+	AuthConfig    *string                        `json:"auth_config,omitempty" validate:"omitempty,max=65536"`                                          // 鉴权配置 (JSON)
+	HTTPConfig    *string                        `json:"http_config,omitempty" validate:"omitempty,max=65536"`                                          // HTTP 客户端配置 (JSON)
+	BodyEncoding  *string                        `json:"body_encoding,omitempty" validate:"omitempty,oneof=json form multipart raw"`                    // 请求体编码
+	BodyTemplate  *string                        `json:"body_template,omitempty" validate:"omitempty,max=1048576"`                                      // 请求体模板，空字符串表示不使用模板
+	Query         *string                        `json:"query,omitempty" validate:"omitempty,max=1048576"`                                              // GraphQL 查询文档、SQL 语句、command 参数模板或 mcp 启动参数
+	DescriptorSet *string                        `json:"descriptor_set,omitempty" validate:"omitempty,max=10485760"`                                    // gRPC 描述集 (base64)，空字符串表示使用服务端反射
+	Enabled       *bool                          `json:"enabled,omitempty"`                                                                             // 是否启用
+	PostProcess   *string                        `json:"post_process,omitempty" validate:"omitempty,max=1048576"`                                       // 后置处理脚本
+	Script        *string                        `json:"script,omitempty" validate:"omitempty,max=1048576"`                                             // 脚本钩子，空字符串表示不使用脚本
+	CacheConfig   *string                        `json:"cache_config,omitempty" validate:"omitempty,max=65536"`                                         // 响应缓存配置，空字符串表示不缓存
+	RateLimit     *string                        `json:"rate_limit,omitempty" validate:"omitempty,max=65536"`                                           // 限流配置，空字符串表示不限制
+	Parameters    *[]CreateInterfaceParameterReq `json:"parameters,omitempty"`                                                                          // 如果提供，则完全替换参数列表
 }
 
 type DeleteInterfaceRequest struct {
@@ -77,19 +96,28 @@ type InterfaceParameterDTO struct {
 }
 
 type InterfaceDTO struct {
-	ID          int64                   `json:"id"`
-	AppID       int64                   `json:"app_id"`
-	Name        string                  `json:"name"`
-	Description string                  `json:"description"`
-	Protocol    string                  `json:"protocol"`
-	URL         string                  `json:"url"`
-	Method      string                  `json:"method"`
-	AuthType    string                  `json:"auth_type"`
-	Enabled     bool                    `json:"enabled"`
-	PostProcess string                  `json:"post_process"`
-	Parameters  []InterfaceParameterDTO `json:"parameters"`
-	CreatedAt   time.Time               `json:"created_at"`
-	UpdatedAt   time.Time               `json:"updated_at"`
+	ID            int64                   `json:"id"`
+	AppID         int64                   `json:"app_id"`
+	Name          string                  `json:"name"`
+	Description   string                  `json:"description"`
+	Protocol      string                  `json:"protocol"`
+	URL           string                  `json:"url"`
+	Method        string                  `json:"method"`
+	AuthType      string                  `json:"auth_type"`
+	AuthConfig    string                  `json:"auth_config"`
+	HTTPConfig    string                  `json:"http_config"`
+	BodyEncoding  string                  `json:"body_encoding"`
+	BodyTemplate  string                  `json:"body_template"`
+	Query         string                  `json:"query"`
+	DescriptorSet string                  `json:"descriptor_set"`
+	Enabled       bool                    `json:"enabled"`
+	PostProcess   string                  `json:"post_process"`
+	Script        string                  `json:"script"`
+	CacheConfig   string                  `json:"cache_config"`
+	RateLimit     string                  `json:"rate_limit"`
+	Parameters    []InterfaceParameterDTO `json:"parameters"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
 }
 
 type InterfaceResponse struct {
@@ -124,19 +152,28 @@ func toInterfaceDTO(m models.Interface, params []models.InterfaceParameter) Inte
 		paramDTOs = append(paramDTOs, toInterfaceParameterDTO(p))
 	}
 	return InterfaceDTO{
-		ID:          m.ID,
-		AppID:       m.AppID,
-		Name:        m.Name,
-		Description: m.Description,
-		Protocol:    m.Protocol,
-		URL:         m.URL,
-		Method:      m.Method,
-		AuthType:    m.AuthType,
-		Enabled:     m.Enabled,
-		PostProcess: m.PostProcess,
-		Parameters:  paramDTOs,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
+		ID:            m.ID,
+		AppID:         m.AppID,
+		Name:          m.Name,
+		Description:   m.Description,
+		Protocol:      m.Protocol,
+		URL:           m.URL,
+		Method:        m.Method,
+		AuthType:      m.AuthType,
+		AuthConfig:    m.AuthConfig,
+		HTTPConfig:    m.HTTPConfig,
+		BodyEncoding:  m.BodyEncoding,
+		BodyTemplate:  m.BodyTemplate,
+		Query:         m.Query,
+		DescriptorSet: m.DescriptorSet,
+		Enabled:       m.Enabled,
+		PostProcess:   m.PostProcess,
+		Script:        m.Script,
+		CacheConfig:   m.CacheConfig,
+		RateLimit:     m.RateLimit,
+		Parameters:    paramDTOs,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
 	}
 }
 
@@ -163,18 +200,45 @@ func CreateInterface(req CreateInterfaceRequest) (InterfaceResponse, error) {
 	if err != nil {
 		return InterfaceResponse{}, err
 	}
+	if err := checkAuthConfig(db, req.AuthType, req.AuthConfig); err != nil {
+		return InterfaceResponse{}, err
+	}
+	if err := checkHTTPConfig(db, req.HTTPConfig); err != nil {
+		return InterfaceResponse{}, err
+	}
+	if _, err := adapter.ParsePostProcess(req.PostProcess); err != nil {
+		return InterfaceResponse{}, err
+	}
+	if _, err := adapter.CompileScript(req.Name, req.Script); err != nil {
+		return InterfaceResponse{}, err
+	}
+	if _, err := adapter.ParseCacheConfig(req.CacheConfig); err != nil {
+		return InterfaceResponse{}, err
+	}
+	if _, err := adapter.ParseRateLimitConfig(req.RateLimit); err != nil {
+		return InterfaceResponse{}, err
+	}
 
 	// 创建接口
 	iface := models.Interface{
-		AppID:       req.AppID,
-		Name:        req.Name,
-		Description: req.Description,
-		Protocol:    req.Protocol,
-		URL:         req.URL,
-		Method:      req.Method,
-		AuthType:    req.AuthType,
-		PostProcess: req.PostProcess,
-		Enabled:     req.Enabled,
+		AppID:         req.AppID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Protocol:      req.Protocol,
+		URL:           req.URL,
+		Method:        req.Method,
+		AuthType:      req.AuthType,
+		AuthConfig:    req.AuthConfig,
+		HTTPConfig:    req.HTTPConfig,
+		BodyEncoding:  req.BodyEncoding,
+		BodyTemplate:  req.BodyTemplate,
+		Query:         req.Query,
+		DescriptorSet: req.DescriptorSet,
+		PostProcess:   req.PostProcess,
+		Script:        req.Script,
+		CacheConfig:   req.CacheConfig,
+		RateLimit:     req.RateLimit,
+		Enabled:       req.Enabled == nil || *req.Enabled,
 	}
 
 	// 使用事务
@@ -183,6 +247,13 @@ func CreateInterface(req CreateInterfaceRequest) (InterfaceResponse, error) {
 		tx.Rollback()
 		return InterfaceResponse{}, err
 	}
+	// gorm 对零值字段使用数据库默认值 (true)，显式禁用时需要单独写入
+	if req.Enabled != nil && !*req.Enabled {
+		if err := tx.Model(&iface).Update("enabled", false).Error; err != nil {
+			tx.Rollback()
+			return InterfaceResponse{}, err
+		}
+	}
 
 	// 创建参数
 	params := make([]models.InterfaceParameter, 0, len(req.Parameters))
@@ -207,6 +278,10 @@ func CreateInterface(req CreateInterfaceRequest) (InterfaceResponse, error) {
 		}
 		params = append(params, param)
 	}
+	if err := checkRequestBody(tx, iface, params); err != nil {
+		tx.Rollback()
+		return InterfaceResponse{}, err
+	}
 	tx.Commit()
 	// 发送创建事件
 	adapter.SendEvent(adapter.Event{
@@ -312,15 +387,70 @@ func UpdateInterface(req UpdateInterfaceRequest) (InterfaceResponse, error) {
 		existing.Method = *req.Method
 	}
 	if req.AuthType != nil {
+		// 切换到不需要配置的鉴权类型时清空旧配置
+		if req.AuthConfig == nil && (*req.AuthType == "none" || *req.AuthType == "capi") {
+			existing.AuthConfig = ""
+		}
 		existing.AuthType = *req.AuthType
 	}
+	if req.AuthConfig != nil {
+		existing.AuthConfig = *req.AuthConfig
+	}
+	if req.HTTPConfig != nil {
+		existing.HTTPConfig = *req.HTTPConfig
+	}
+	if req.BodyEncoding != nil {
+		existing.BodyEncoding = *req.BodyEncoding
+	}
+	if req.BodyTemplate != nil {
+		existing.BodyTemplate = *req.BodyTemplate
+	}
+	if req.Query != nil {
+		existing.Query = *req.Query
+	}
+	if req.DescriptorSet != nil {
+		existing.DescriptorSet = *req.DescriptorSet
+	}
 	if req.PostProcess != nil {
 		existing.PostProcess = *req.PostProcess
 	}
+	if req.Script != nil {
+		existing.Script = *req.Script
+	}
+	if req.CacheConfig != nil {
+		existing.CacheConfig = *req.CacheConfig
+	}
+	if req.RateLimit != nil {
+		existing.RateLimit = *req.RateLimit
+	}
 	if req.Enabled != nil {
 		existing.Enabled = *req.Enabled
 	}
 
+	if err := checkAuthConfig(tx, existing.AuthType, existing.AuthConfig); err != nil {
+		tx.Rollback()
+		return InterfaceResponse{}, err
+	}
+	if err := checkHTTPConfig(tx, existing.HTTPConfig); err != nil {
+		tx.Rollback()
+		return InterfaceResponse{}, err
+	}
+	if _, err := adapter.ParsePostProcess(existing.PostProcess); err != nil {
+		tx.Rollback()
+		return InterfaceResponse{}, err
+	}
+	if _, err := adapter.CompileScript(existing.Name, existing.Script); err != nil {
+		tx.Rollback()
+		return InterfaceResponse{}, err
+	}
+	if _, err := adapter.ParseCacheConfig(existing.CacheConfig); err != nil {
+		tx.Rollback()
+		return InterfaceResponse{}, err
+	}
+	if _, err := adapter.ParseRateLimitConfig(existing.RateLimit); err != nil {
+		tx.Rollback()
+		return InterfaceResponse{}, err
+	}
 	if err := tx.Save(&existing).Error; err != nil {
 		tx.Rollback()
 		return InterfaceResponse{}, err
@@ -361,10 +491,14 @@ func UpdateInterface(req UpdateInterfaceRequest) (InterfaceResponse, error) {
 		// 如果没有提供参数列表，保持原有参数
 		tx.Where("interface_id = ?", existing.ID).Find(&params)
 	}
+	if err := checkRequestBody(tx, existing, params); err != nil {
+		tx.Rollback()
+		return InterfaceResponse{}, err
+	}
 	tx.Commit()
-	// 发送更新事件 删除根据名字删除就好了
+	// 发送更新事件，移除时使用旧名字，ID 用于释放该工具的缓存、限流器和熔断器
 	adapter.SendEvent(adapter.Event{
-		Interface: &models.Interface{Name: oldName},
+		Interface: &models.Interface{ID: existing.ID, AppID: existing.AppID, Name: oldName},
 		App:       &app,
 		Code:      adapter.RemoveToolEvent,
 	})
@@ -376,6 +510,204 @@ func UpdateInterface(req UpdateInterfaceRequest) (InterfaceResponse, error) {
 	return InterfaceResponse{Interface: toInterfaceDTO(existing, params)}, nil
 }
 
+// checkAuthConfig 校验鉴权配置的结构，凭据必须使用密钥引用且引用的密钥存在
+func checkAuthConfig(tx *gorm.DB, authType, authConfig string) error {
+	cfg, err := adapter.ParseAuthConfig(authType, authConfig)
+	if err != nil {
+		return err
+	}
+	if field, ok := adapter.AuthConfigPlaintextCredential(cfg); ok {
+		return fmt.Errorf("auth_config %s must be a secret:// reference", field)
+	}
+	return checkSecretRefs(tx, adapter.AuthConfigSecretRefs(cfg)...)
+}
+
+// checkHTTPConfig 校验 HTTP 客户端配置，以及证书中引用的密钥是否存在
+func checkHTTPConfig(tx *gorm.DB, raw string) error {
+	cfg, err := adapter.ParseHTTPConfig(raw)
+	if err != nil {
+		return err
+	}
+	return checkSecretRefs(tx, adapter.HTTPConfigSecretRefs(cfg)...)
+}
+
+// checkRequestBody 校验请求体模板和编码：模板用示例参数渲染一次；
+// 没有模板时 raw 编码原样发送唯一的 body 参数，该参数不能是数组；
+// graphql 接口的请求体由查询文档和 body 参数组成，grpc 接口的请求体是请求消息，
+// sql、command 和 mcp 接口不发送 HTTP 请求，单独校验
+func checkRequestBody(tx *gorm.DB, iface models.Interface, params []models.InterfaceParameter) error {
+	if iface.Protocol != "grpc" && strings.TrimSpace(iface.DescriptorSet) != "" {
+		return errors.New("descriptor_set is only supported for grpc interfaces")
+	}
+	switch iface.Protocol {
+	case "graphql":
+		return checkGraphQLRequest(iface, params)
+	case "grpc":
+		return checkGRPCRequest(iface, params)
+	case "sql":
+		return checkSQLRequest(tx, iface, params)
+	case "command", "mcp":
+		return checkLocalProcessRequest(iface, params)
+	}
+	if strings.TrimSpace(iface.Query) != "" {
+		return errors.New("query is only supported for graphql, sql, command and mcp interfaces")
+	}
+	var body []models.InterfaceParameter
+	for _, p := range params {
+		if p.Location == "body" && p.Group != "output" {
+			body = append(body, p)
+		}
+	}
+	if strings.TrimSpace(iface.BodyTemplate) != "" {
+		if iface.Method == "GET" || iface.Method == "HEAD" {
+			return errors.New("body_template is not supported for GET and HEAD interfaces")
+		}
+		return adapter.ValidateBodyTemplate(iface.BodyTemplate, iface.BodyEncoding, sampleBodyParams(body))
+	}
+	if iface.BodyEncoding != adapter.BodyEncodingRaw {
+		return nil
+	}
+	if len(body) != 1 {
+		return errors.New("raw body encoding requires exactly one body parameter")
+	}
+	if body[0].IsArray || body[0].Type != "string" {
+		return errors.New("raw body parameter must be a non-array string")
+	}
+	return nil
+}
+
+// checkGraphQLRequest graphql 接口固定使用 POST 发送 JSON 请求体，不支持请求体模板和 capi 鉴权
+func checkGraphQLRequest(iface models.Interface, params []models.InterfaceParameter) error {
+	if iface.Method != "POST" {
+		return errors.New("graphql interfaces must use POST")
+	}
+	if strings.TrimSpace(iface.BodyTemplate) != "" {
+		return errors.New("body_template is not supported for graphql interfaces")
+	}
+	if iface.BodyEncoding != "" && iface.BodyEncoding != adapter.BodyEncodingJSON {
+		return errors.New("graphql interfaces only support json body encoding")
+	}
+	if iface.AuthType == "capi" {
+		return errors.New("capi auth is not supported for graphql interfaces")
+	}
+	return adapter.ValidateGraphQLQuery(iface.Query, params)
+}
+
+// checkGRPCRequest grpc 接口的 body 参数组成请求消息，header 参数作为 metadata 发送；
+// 不支持请求体模板、capi 和 aws_sigv4 鉴权以及放在 query 中的 apikey
+func checkGRPCRequest(iface models.Interface, params []models.InterfaceParameter) error {
+	if iface.Method != "POST" {
+		return errors.New("grpc interfaces must use POST")
+	}
+	if strings.TrimSpace(iface.BodyTemplate) != "" {
+		return errors.New("body_template is not supported for grpc interfaces")
+	}
+	if iface.BodyEncoding != "" && iface.BodyEncoding != adapter.BodyEncodingJSON {
+		return errors.New("grpc interfaces only support json body encoding")
+	}
+	if strings.TrimSpace(iface.Query) != "" {
+		return errors.New("query is only supported for graphql, sql, command and mcp interfaces")
+	}
+	if err := checkGRPCAuth(iface.AuthType, iface.AuthConfig); err != nil {
+		return err
+	}
+	return adapter.ValidateGRPCInterface(iface.URL, iface.DescriptorSet, params)
+}
+
+// checkSQLRequest sql 接口在数据源上执行只读查询，不使用请求体编码、模板和鉴权
+func checkSQLRequest(tx *gorm.DB, iface models.Interface, params []models.InterfaceParameter) error {
+	if iface.Method != "POST" {
+		return errors.New("sql interfaces must use POST")
+	}
+	if strings.TrimSpace(iface.BodyTemplate) != "" {
+		return errors.New("body_template is not supported for sql interfaces")
+	}
+	if iface.BodyEncoding != "" && iface.BodyEncoding != adapter.BodyEncodingJSON {
+		return errors.New("sql interfaces only support json body encoding")
+	}
+	if iface.AuthType != "none" {
+		return errors.New("sql interfaces do not support auth, configure credentials in the datasource")
+	}
+	if err := adapter.ValidateSQLInterface(iface.URL, iface.Query, params); err != nil {
+		return err
+	}
+	// 使用环境变量的地址在注册工具时才能确定数据源
+	if name, ok := adapter.SQLDatasourceName(iface.URL); ok {
+		var count int64
+		tx.Model(&models.Datasource{}).Where("name = ?", name).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("datasource %s not found", name)
+		}
+	}
+	return nil
+}
+
+// checkLocalProcessRequest command 接口执行本地命令，mcp 接口调用 stdio MCP 服务器的工具，
+// 都只接收 body 参数，凭证通过应用环境变量传给子进程
+func checkLocalProcessRequest(iface models.Interface, params []models.InterfaceParameter) error {
+	if iface.Method != "POST" {
+		return fmt.Errorf("%s interfaces must use POST", iface.Protocol)
+	}
+	if strings.TrimSpace(iface.BodyTemplate) != "" {
+		return fmt.Errorf("body_template is not supported for %s interfaces", iface.Protocol)
+	}
+	if iface.BodyEncoding != "" && iface.BodyEncoding != adapter.BodyEncodingJSON {
+		return fmt.Errorf("%s interfaces only support json body encoding", iface.Protocol)
+	}
+	if iface.AuthType != "none" {
+		return fmt.Errorf("%s interfaces do not support auth, pass credentials through the application environment", iface.Protocol)
+	}
+	if iface.Protocol == "mcp" {
+		return adapter.ValidateMCPInterface(iface.URL, params)
+	}
+	if err := adapter.ValidateCommandInterface(iface.URL, iface.Query, params); err != nil {
+		return err
+	}
+	var body []models.InterfaceParameter
+	for _, p := range params {
+		if p.Group != "output" {
+			body = append(body, p)
+		}
+	}
+	return adapter.ValidateCommandArgs(iface.Query, sampleBodyParams(body))
+}
+
+// checkGRPCAuth 鉴权信息作为 metadata 发送，无法签名请求或放在查询参数中
+func checkGRPCAuth(authType, authConfig string) error {
+	switch authType {
+	case "capi", "aws_sigv4":
+		return fmt.Errorf("%s auth is not supported for grpc interfaces", authType)
+	case "apikey":
+		if cfg, err := adapter.ParseAuthConfig(authType, authConfig); err == nil && cfg.(adapter.APIKeyAuthConfig).In == "query" {
+			return errors.New("apikey auth in query is not supported for grpc interfaces")
+		}
+	}
+	return nil
+}
+
+// sampleBodyParams 按参数定义生成示例值，用于保存时试渲染请求体模板
+func sampleBodyParams(params []models.InterfaceParameter) map[string]any {
+	sample := make(map[string]any, len(params))
+	for _, p := range params {
+		var v any
+		switch p.Type {
+		case "number":
+			v = float64(0)
+		case "boolean":
+			v = false
+		case "custom":
+			v = map[string]any{}
+		default:
+			v = ""
+		}
+		if p.IsArray {
+			v = []any{}
+		}
+		sample[p.Name] = v
+	}
+	return sample
+}
+
 func checkParameters(parameters *[]CreateInterfaceParameterReq, tx *gorm.DB, appId int64) error {
 	// 验证参数的 Ref 引用和 fixed 参数规则
 	for _, paramReq := range *parameters {
@@ -436,6 +768,17 @@ func checkParameters(parameters *[]CreateInterfaceParameterReq, tx *gorm.DB, app
 				}
 			}
 		}
+		// 密钥引用只能用于 fixed 的 string 参数，且密钥必须存在
+		if paramReq.DefaultValue != nil {
+			if name, ok := adapter.ParseSecretRef(*paramReq.DefaultValue); ok {
+				if paramReq.Group != "fixed" || paramReq.Type != "string" {
+					return errors.New("secret references are only allowed in fixed string parameters")
+				}
+				if err := checkSecretRefs(tx, name); err != nil {
+					return err
+				}
+			}
+		}
 		// fixed 参数必须有默认值且不能是数组
 		if paramReq.Group == "fixed" {
 			if paramReq.DefaultValue == nil || *paramReq.DefaultValue == "" {
@@ -469,6 +812,11 @@ func DeleteInterface(req DeleteInterfaceRequest) (EmptyResponse, error) {
 		tx.Rollback()
 		return EmptyResponse{}, err
 	}
+	// 删除数据库中的响应缓存
+	if err := tx.Where("interface_id = ?", iface.ID).Delete(&models.ResponseCacheEntry{}).Error; err != nil {
+		tx.Rollback()
+		return EmptyResponse{}, err
+	}
 	// 删除接口
 	if err := tx.Delete(&iface).Error; err != nil {
 		tx.Rollback()
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"mcp-adapter/backend/adapter"
 	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -40,7 +43,7 @@ func TestCreateInterface(t *testing.T) {
 				URL:         "https://api.example.com/test",
 				Method:      "GET",
 				AuthType:    "none",
-				Enabled:     true,
+				Enabled:     boolPtr(true),
 				Parameters: []CreateInterfaceParameterReq{
 					{
 						Name:        "param1",
@@ -221,6 +224,608 @@ func TestCreateInterface(t *testing.T) {
 	}
 }
 
+func TestCreateInterfaceDisabled(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "DisabledIfaceApp", Path: "disabled-iface-app", Protocol: "sse"})
+	require.NoError(t, err)
+
+	resp, err := CreateInterface(CreateInterfaceRequest{
+		AppID:    app.Application.ID,
+		Name:     "DisabledInterface",
+		Protocol: "http",
+		URL:      "https://api.example.com/disabled",
+		Method:   "GET",
+		AuthType: "none",
+		Enabled:  boolPtr(false),
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Interface.Enabled)
+
+	var stored models.Interface
+	require.NoError(t, database.GetDB().First(&stored, resp.Interface.ID).Error)
+	assert.False(t, stored.Enabled)
+
+	// 省略 enabled 时与之前一样默认启用
+	resp, err = CreateInterface(CreateInterfaceRequest{
+		AppID:    app.Application.ID,
+		Name:     "DefaultInterface",
+		Protocol: "http",
+		URL:      "https://api.example.com/default",
+		Method:   "GET",
+		AuthType: "none",
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Interface.Enabled)
+	var defaulted models.Interface
+	require.NoError(t, database.GetDB().First(&defaulted, resp.Interface.ID).Error)
+	assert.True(t, defaulted.Enabled)
+}
+
+func TestInterfaceAuthConfig(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "AuthApp", Path: "auth-app", Protocol: "sse"})
+	require.NoError(t, err)
+	newReq := func(name, authType, authConfig string) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:      app.Application.ID,
+			Name:       name,
+			Protocol:   "http",
+			URL:        "https://api.example.com/auth",
+			Method:     "GET",
+			AuthType:   authType,
+			AuthConfig: authConfig,
+			Enabled:    boolPtr(true),
+		}
+	}
+
+	_, err = CreateInterface(newReq("MissingConfig", "bearer", ""))
+	assert.EqualError(t, err, "auth_config is required for auth type bearer")
+	_, err = CreateInterface(newReq("InvalidConfig", "apikey", `{"name":"key","in":"cookie","value":"v"}`))
+	assert.EqualError(t, err, "apikey auth 'in' must be header or query")
+	_, err = CreateInterface(newReq("UnexpectedConfig", "none", `{"token":"abc"}`))
+	assert.EqualError(t, err, "auth_config is not supported for auth type none")
+	_, err = CreateInterface(newReq("SigV4Interface", "aws_sigv4", `{"region":"us-east-1","service":"s3"}`))
+	assert.NoError(t, err)
+	_, err = CreateInterface(newReq("SigV4MissingSecret", "aws_sigv4", `{"region":"us-east-1","service":"s3","access_key_id":"AKID","secret_access_key":"secret://aws-secret"}`))
+	assert.EqualError(t, err, "secret aws-secret not found")
+	_, err = CreateInterface(newReq("MissingSecret", "bearer", `{"token":"secret://upstream-token"}`))
+	assert.EqualError(t, err, "secret upstream-token not found")
+	// 凭据字段只能保存密钥引用
+	_, err = CreateInterface(newReq("PlainToken", "bearer", `{"token":"abc"}`))
+	assert.EqualError(t, err, "auth_config token must be a secret:// reference")
+	_, err = CreateInterface(newReq("PlainPassword", "basic", `{"username":"u","password":"p"}`))
+	assert.EqualError(t, err, "auth_config password must be a secret:// reference")
+	_, err = CreateInterface(newReq("PlainSigV4", "aws_sigv4", `{"region":"us-east-1","service":"s3","access_key_id":"AKID","secret_access_key":"plain"}`))
+	assert.EqualError(t, err, "auth_config secret_access_key must be a secret:// reference")
+
+	secret, err := CreateSecret(CreateSecretRequest{Name: "upstream-token", Value: "token-value-1234"})
+	require.NoError(t, err)
+	resp, err := CreateInterface(newReq("BearerInterface", "bearer", `{"token":"secret://upstream-token"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "bearer", resp.Interface.AuthType)
+	assert.Equal(t, `{"token":"secret://upstream-token"}`, resp.Interface.AuthConfig)
+
+	// 被鉴权配置引用的密钥不能删除
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secret.Secret.ID})
+	assert.EqualError(t, err, "secret is referenced by interfaces")
+
+	// 只修改鉴权类型时沿用原有配置，需要满足新类型的结构
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, AuthType: stringPtr("basic")})
+	assert.Error(t, err)
+
+	updated, err := UpdateInterface(UpdateInterfaceRequest{
+		ID:         resp.Interface.ID,
+		AuthType:   stringPtr("oauth2"),
+		AuthConfig: stringPtr(`{"token_url":"https://auth.example.com/token","client_id":"id","client_secret":"secret://upstream-token","scopes":["read"]}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "oauth2", updated.Interface.AuthType)
+
+	// 切换为 none 时清空鉴权配置
+	updated, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, AuthType: stringPtr("none")})
+	require.NoError(t, err)
+	assert.Equal(t, "none", updated.Interface.AuthType)
+	assert.Empty(t, updated.Interface.AuthConfig)
+
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secret.Secret.ID})
+	assert.NoError(t, err)
+}
+
+func TestHTTPConfig(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	_, err := CreateApplication(CreateApplicationRequest{Name: "BadHTTPApp", Path: "bad-http-app", Protocol: "sse", HTTPConfig: `{"timeout":"soon"}`})
+	assert.EqualError(t, err, "http_config timeout must be a positive duration such as 500ms or 10s")
+	app, err := CreateApplication(CreateApplicationRequest{Name: "HTTPApp", Path: "http-app", Protocol: "sse", HTTPConfig: `{"timeout":"30s","retry":{"max_retries":2}}`})
+	require.NoError(t, err)
+	assert.Equal(t, `{"timeout":"30s","retry":{"max_retries":2}}`, app.Application.HTTPConfig)
+
+	newReq := func(name, httpConfig string) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:      app.Application.ID,
+			Name:       name,
+			Protocol:   "http",
+			URL:        "https://api.example.com/http",
+			Method:     "GET",
+			AuthType:   "none",
+			HTTPConfig: httpConfig,
+			Enabled:    boolPtr(true),
+		}
+	}
+	_, err = CreateInterface(newReq("UnknownField", `{"retries":3}`))
+	assert.ErrorContains(t, err, "invalid http_config")
+	_, err = CreateInterface(newReq("BadProxy", `{"proxy":"ftp://proxy:21"}`))
+	assert.EqualError(t, err, "http_config proxy must be an http, https or socks5 URL")
+	_, err = CreateInterface(newReq("MissingSecret", `{"client_cert":"secret://client-cert","client_key":"secret://client-key"}`))
+	assert.EqualError(t, err, "secret client-cert not found")
+
+	_, err = CreateSecret(CreateSecretRequest{Name: "client-cert", Value: "cert-pem-value"})
+	require.NoError(t, err)
+	key, err := CreateSecret(CreateSecretRequest{Name: "client-key", Value: "key-pem-value"})
+	require.NoError(t, err)
+	resp, err := CreateInterface(newReq("MTLSInterface", `{"client_cert":"secret://client-cert","client_key":"secret://client-key"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"client_cert":"secret://client-cert","client_key":"secret://client-key"}`, resp.Interface.HTTPConfig)
+
+	// 被 HTTP 客户端配置引用的密钥不能删除
+	_, err = DeleteSecret(DeleteSecretRequest{ID: key.Secret.ID})
+	assert.EqualError(t, err, "secret is referenced by interfaces")
+
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, HTTPConfig: stringPtr(`{"connect_timeout":"0s"}`)})
+	assert.Error(t, err)
+	updated, err := UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, HTTPConfig: stringPtr("")})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Interface.HTTPConfig)
+	_, err = DeleteSecret(DeleteSecretRequest{ID: key.Secret.ID})
+	assert.NoError(t, err)
+
+	_, err = UpdateApplication(UpdateApplicationRequest{ID: app.Application.ID, HTTPConfig: stringPtr(`{"retry":{"max_retries":20}}`)})
+	assert.EqualError(t, err, "http_config retry.max_retries must be between 0 and 10")
+	updatedApp, err := UpdateApplication(UpdateApplicationRequest{ID: app.Application.ID, HTTPConfig: stringPtr(`{"insecure_skip_verify":true}`)})
+	require.NoError(t, err)
+	assert.Equal(t, `{"insecure_skip_verify":true}`, updatedApp.Application.HTTPConfig)
+}
+
+func TestInterfaceBodyEncoding(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "BodyApp", Path: "body-app", Protocol: "sse"})
+	require.NoError(t, err)
+	newReq := func(name, encoding string, params ...CreateInterfaceParameterReq) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:        app.Application.ID,
+			Name:         name,
+			Protocol:     "http",
+			URL:          "https://api.example.com/body/" + name,
+			Method:       "POST",
+			AuthType:     "none",
+			BodyEncoding: encoding,
+			Enabled:      boolPtr(true),
+			Parameters:   params,
+		}
+	}
+	content := CreateInterfaceParameterReq{Name: "content", Type: "string", Location: "body", Group: "input", Required: true}
+	extra := CreateInterfaceParameterReq{Name: "extra", Type: "string", Location: "body", Group: "input"}
+
+	_, err = CreateInterface(newReq("BadEncoding", "xml", content))
+	assert.Error(t, err)
+	_, err = CreateInterface(newReq("RawTwoBody", "raw", content, extra))
+	assert.EqualError(t, err, "raw body encoding requires exactly one body parameter")
+	_, err = CreateInterface(newReq("RawNumber", "raw", CreateInterfaceParameterReq{Name: "n", Type: "number", Location: "body", Group: "input"}))
+	assert.EqualError(t, err, "raw body parameter must be a non-array string")
+
+	raw, err := CreateInterface(newReq("RawBody", "raw", content))
+	require.NoError(t, err)
+	assert.Equal(t, "raw", raw.Interface.BodyEncoding)
+
+	form, err := CreateInterface(newReq("FormBody", "form", content, extra))
+	require.NoError(t, err)
+	// 切换为 raw 时使用已有参数校验
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: form.Interface.ID, BodyEncoding: stringPtr("raw")})
+	assert.EqualError(t, err, "raw body encoding requires exactly one body parameter")
+	updated, err := UpdateInterface(UpdateInterfaceRequest{ID: form.Interface.ID, BodyEncoding: stringPtr("multipart")})
+	require.NoError(t, err)
+	assert.Equal(t, "multipart", updated.Interface.BodyEncoding)
+
+	// 导出的 OpenAPI 使用对应的媒体类型，重新导入后编码不变
+	doc, err := ExportOpenAPI(ExportOpenAPIRequest{ID: app.Application.ID})
+	require.NoError(t, err)
+	paths := doc["paths"].(map[string]any)
+	bodyContent := func(path string) map[string]any {
+		post := paths[path].(map[string]any)["post"].(map[string]any)
+		return post["requestBody"].(map[string]any)["content"].(map[string]any)
+	}
+	assert.Contains(t, bodyContent("/body/FormBody"), "multipart/form-data")
+	assert.Equal(t, map[string]any{"schema": map[string]any{"type": "string"}}, bodyContent("/body/RawBody")["text/plain"])
+
+	target, err := CreateApplication(CreateApplicationRequest{Name: "BodyReimport", Path: "body-reimport", Protocol: "sse"})
+	require.NoError(t, err)
+	_, err = ImportOpenAPI(ImportOpenAPIRequest{AppID: target.Application.ID, Spec: `
+openapi: 3.0.3
+info: {title: Body, version: 1.0.0}
+servers: [{url: "https://api.example.com"}]
+paths:
+  /form:
+    post:
+      operationId: submitForm
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+  /xml:
+    post:
+      operationId: sendXML
+      requestBody:
+        required: true
+        content:
+          application/xml:
+            schema: {type: string, description: XML document}
+`})
+	require.NoError(t, err)
+	ifaces, err := ListInterfaces(ListInterfacesRequest{AppID: target.Application.ID})
+	require.NoError(t, err)
+	byName := make(map[string]InterfaceDTO)
+	for _, iface := range ifaces.Interfaces {
+		byName[iface.Name] = iface
+	}
+	assert.Equal(t, "form", byName["submitForm"].BodyEncoding)
+	xml := byName["sendXML"]
+	assert.Equal(t, "raw", xml.BodyEncoding)
+	require.Len(t, xml.Parameters, 1)
+	assert.Equal(t, "body", xml.Parameters[0].Name)
+	assert.True(t, xml.Parameters[0].Required)
+}
+
+func TestInterfaceBodyTemplate(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "TemplateApp", Path: "template-app", Protocol: "sse"})
+	require.NoError(t, err)
+	newReq := func(name, method, encoding, template string) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:        app.Application.ID,
+			Name:         name,
+			Protocol:     "http",
+			URL:          "https://api.example.com/" + name,
+			Method:       method,
+			AuthType:     "none",
+			BodyEncoding: encoding,
+			BodyTemplate: template,
+			Enabled:      boolPtr(true),
+			Parameters: []CreateInterfaceParameterReq{
+				{Name: "name", Type: "string", Location: "body", Group: "input", Required: true},
+				{Name: "tags", Type: "string", Location: "body", Group: "input", IsArray: true},
+			},
+		}
+	}
+
+	_, err = CreateInterface(newReq("Unclosed", "POST", "", `{"data": {{json .name}`))
+	assert.ErrorContains(t, err, "invalid body_template")
+	// 字符串参数没有使用 json 函数时，渲染结果不是合法 JSON
+	_, err = CreateInterface(newReq("Unquoted", "POST", "", `{"data": {"name": {{.name}}}}`))
+	assert.ErrorContains(t, err, "body_template must render a JSON object")
+	_, err = CreateInterface(newReq("GetTemplate", "GET", "", `{"data": {}}`))
+	assert.EqualError(t, err, "body_template is not supported for GET and HEAD interfaces")
+
+	resp, err := CreateInterface(newReq("Wrapped", "POST", "", `{"data": {"attributes": {"name": {{json .name}}, "tags": {{json .tags}}}}}`))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Interface.BodyTemplate, "attributes")
+	// 使用模板时 raw 编码可以有多个 body 参数
+	_, err = CreateInterface(newReq("RawTemplate", "POST", "raw", `<user>{{.name}}</user>`))
+	assert.NoError(t, err)
+
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, Method: stringPtr("GET")})
+	assert.Error(t, err)
+	updated, err := UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, BodyTemplate: stringPtr("")})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Interface.BodyTemplate)
+}
+
+func TestInterfaceGraphQL(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "GraphQLApp", Path: "graphql-app", Protocol: "sse"})
+	require.NoError(t, err)
+	newReq := func(name, method, authType, query string) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:    app.Application.ID,
+			Name:     name,
+			Protocol: "graphql",
+			URL:      "https://api.example.com/graphql",
+			Method:   method,
+			AuthType: authType,
+			Query:    query,
+			Enabled:  boolPtr(true),
+			Parameters: []CreateInterfaceParameterReq{
+				{Name: "id", Type: "string", Location: "body", Group: "input", Required: true},
+			},
+		}
+	}
+	query := `query user($id: ID!) { user(id: $id) { name } }`
+
+	_, err = CreateInterface(newReq("GetMethod", "GET", "none", query))
+	assert.EqualError(t, err, "graphql interfaces must use POST")
+	_, err = CreateInterface(newReq("Capi", "POST", "capi", query))
+	assert.EqualError(t, err, "capi auth is not supported for graphql interfaces")
+	_, err = CreateInterface(newReq("NoQuery", "POST", "none", ""))
+	assert.EqualError(t, err, "graphql interfaces require a query")
+	_, err = CreateInterface(newReq("Undeclared", "POST", "none", `{ me { name } }`))
+	assert.EqualError(t, err, "parameter id is not declared as a variable in the graphql query")
+	withTemplate := newReq("Template", "POST", "none", query)
+	withTemplate.BodyTemplate = `{"id": {{json .id}}}`
+	_, err = CreateInterface(withTemplate)
+	assert.EqualError(t, err, "body_template is not supported for graphql interfaces")
+
+	_, err = CreateInterface(newReq("User", "POST", "bearer", query))
+	assert.ErrorContains(t, err, "auth_config is required")
+	resp, err := CreateInterface(newReq("User", "POST", "none", query))
+	require.NoError(t, err)
+	assert.Equal(t, query, resp.Interface.Query)
+
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, Query: stringPtr("query { user { name } ")})
+	assert.ErrorContains(t, err, "invalid graphql query")
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, Protocol: stringPtr("http")})
+	assert.EqualError(t, err, "query is only supported for graphql, sql, command and mcp interfaces")
+}
+
+func TestInterfaceGRPC(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "GRPCApp", Path: "grpc-app", Protocol: "sse"})
+	require.NoError(t, err)
+	set := testGRPCDescriptorSet(t)
+	newReq := func(name, url, authType, descriptorSet string) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:         app.Application.ID,
+			Name:          name,
+			Protocol:      "grpc",
+			URL:           url,
+			Method:        "POST",
+			AuthType:      authType,
+			DescriptorSet: descriptorSet,
+			Enabled:       boolPtr(true),
+			Parameters: []CreateInterfaceParameterReq{
+				{Name: "id", Type: "string", Location: "body", Group: "input", Required: true},
+			},
+		}
+	}
+	url := "grpc://users:50051/demo.v1.UserService/GetUser"
+
+	get := newReq("GetMethod", url, "none", set)
+	get.Method = "GET"
+	_, err = CreateInterface(get)
+	assert.EqualError(t, err, "grpc interfaces must use POST")
+	_, err = CreateInterface(newReq("Capi", url, "capi", set))
+	assert.EqualError(t, err, "capi auth is not supported for grpc interfaces")
+	_, err = CreateInterface(newReq("NoMethod", "grpc://users:50051", "none", set))
+	assert.ErrorContains(t, err, "must end with /package.Service/Method")
+	_, err = CreateInterface(newReq("Streaming", "grpc://users:50051/demo.v1.UserService/WatchUsers", "none", set))
+	assert.ErrorContains(t, err, "only unary methods are supported")
+	withTemplate := newReq("Template", url, "none", set)
+	withTemplate.BodyTemplate = `{"id": {{json .id}}}`
+	_, err = CreateInterface(withTemplate)
+	assert.EqualError(t, err, "body_template is not supported for grpc interfaces")
+
+	resp, err := CreateInterface(newReq("GetUser", url, "none", set))
+	require.NoError(t, err)
+	assert.Equal(t, set, resp.Interface.DescriptorSet)
+	// 未配置描述集时通过服务端反射解析，保存时不检查方法
+	_, err = CreateInterface(newReq("Reflected", url, "none", ""))
+	require.NoError(t, err)
+
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, DescriptorSet: stringPtr("not base64!")})
+	assert.ErrorContains(t, err, "invalid descriptor_set")
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, Protocol: stringPtr("http")})
+	assert.EqualError(t, err, "descriptor_set is only supported for grpc interfaces")
+}
+
+func TestInterfacePostProcessPipeline(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "PipelineApp", Path: "pipeline-app", Protocol: "sse"})
+	require.NoError(t, err)
+	newReq := func(name, postProcess string) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:       app.Application.ID,
+			Name:        name,
+			Protocol:    "http",
+			URL:         "https://api.example.com/" + name,
+			Method:      "GET",
+			AuthType:    "none",
+			PostProcess: postProcess,
+			Enabled:     boolPtr(true),
+		}
+	}
+
+	_, err = CreateInterface(newReq("BadJSONPath", `{"pipeline": [{"jsonpath": "data.items"}]}`))
+	assert.ErrorContains(t, err, "must start with $")
+	_, err = CreateInterface(newReq("BadJMESPath", `{"pipeline": [{"jmespath": "data.items[?"}]}`))
+	assert.ErrorContains(t, err, "invalid jmespath")
+	_, err = CreateInterface(newReq("BadFormat", `{"format": "csv"}`))
+	assert.ErrorContains(t, err, "post_process format must be json, markdown or yaml")
+
+	resp, err := CreateInterface(newReq("Projected", `{"pipeline": [{"jsonpath": "$.data.items"}, {"drop": ["*.internal"]}, {"limit": 10}], "format": "markdown"}`))
+	require.NoError(t, err)
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, PostProcess: stringPtr(`{"pipeline": [{"limit": 1, "flatten": 1}]}`)})
+	assert.ErrorContains(t, err, "exactly one of")
+}
+
+func TestInterfaceSQL(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "SQLApp", Path: "sql-app", Protocol: "sse"})
+	require.NoError(t, err)
+	_, err = CreateDatasource(CreateDatasourceRequest{Name: "reporting", Driver: "sqlite", DSN: "file:reporting.db"})
+	require.NoError(t, err)
+	newReq := func(name, url, query string) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:    app.Application.ID,
+			Name:     name,
+			Protocol: "sql",
+			URL:      url,
+			Method:   "POST",
+			AuthType: "none",
+			Query:    query,
+			Enabled:  boolPtr(true),
+			Parameters: []CreateInterfaceParameterReq{
+				{Name: "team", Type: "string", Location: "body", Group: "input", Required: true},
+			},
+		}
+	}
+	query := "SELECT id, name FROM users WHERE team = :team"
+
+	get := newReq("GetMethod", "sql://reporting", query)
+	get.Method = "GET"
+	_, err = CreateInterface(get)
+	assert.EqualError(t, err, "sql interfaces must use POST")
+	withAuth := newReq("Auth", "sql://reporting", query)
+	withAuth.AuthType = "basic"
+	withAuth.AuthConfig = `{"username":"x"}`
+	_, err = CreateInterface(withAuth)
+	assert.EqualError(t, err, "sql interfaces do not support auth, configure credentials in the datasource")
+	_, err = CreateInterface(newReq("Missing", "sql://unknown", query))
+	assert.EqualError(t, err, "datasource unknown not found")
+	_, err = CreateInterface(newReq("Write", "sql://reporting", "DELETE FROM users WHERE team = :team"))
+	assert.EqualError(t, err, "sql query must be read-only, DELETE is not allowed")
+	_, err = CreateInterface(newReq("Unbound", "sql://reporting", "SELECT id FROM users WHERE team = :team AND role = :role"))
+	assert.EqualError(t, err, "placeholder :role has no matching body parameter")
+	_, err = CreateInterface(newReq("NoQuery", "sql://reporting", ""))
+	assert.EqualError(t, err, "sql interfaces require a query")
+
+	resp, err := CreateInterface(newReq("ListUsers", "sql://reporting", query))
+	require.NoError(t, err)
+	assert.Equal(t, query, resp.Interface.Query)
+	// 使用环境变量的地址在注册工具时才能确定数据源
+	_, err = CreateInterface(newReq("FromEnv", "sql://${DATASOURCE}", query))
+	require.NoError(t, err)
+
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, Query: stringPtr("SELECT 1; DROP TABLE users")})
+	assert.EqualError(t, err, "sql query must contain a single statement")
+}
+
+func TestInterfaceCommandAndMCP(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.CommandAllowlistEnv, "git=/bin/sh,proxy=/bin/sh")
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "LocalApp", Path: "local-app", Protocol: "sse"})
+	require.NoError(t, err)
+	newReq := func(name, protocol, url, query string) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:    app.Application.ID,
+			Name:     name,
+			Protocol: protocol,
+			URL:      url,
+			Method:   "POST",
+			AuthType: "none",
+			Query:    query,
+			Enabled:  boolPtr(true),
+			Parameters: []CreateInterfaceParameterReq{
+				{Name: "ref", Type: "string", Location: "body", Group: "input", Required: true},
+			},
+		}
+	}
+	args := "log\n--oneline\n--\n{{.ref}}"
+
+	resp, err := CreateInterface(newReq("GitLog", "command", "command://git", args))
+	require.NoError(t, err)
+	assert.Equal(t, args, resp.Interface.Query)
+	_, err = CreateInterface(newReq("Rm", "command", "command://rm", args))
+	assert.EqualError(t, err, "command rm is not allowed, add it to MCP_ADAPTER_COMMANDS")
+	_, err = CreateInterface(newReq("BadURL", "command", "https://example.com", args))
+	assert.EqualError(t, err, "command url must be command://name")
+	_, err = CreateInterface(newReq("BadTemplate", "command", "command://git", "{{.ref"))
+	assert.ErrorContains(t, err, "invalid command argument on line 1")
+	withAuth := newReq("Auth", "command", "command://git", args)
+	withAuth.AuthType = "basic"
+	withAuth.AuthConfig = `{"username":"x"}`
+	_, err = CreateInterface(withAuth)
+	assert.EqualError(t, err, "command interfaces do not support auth, pass credentials through the application environment")
+	withHeader := newReq("Header", "command", "command://git", args)
+	withHeader.Parameters = append(withHeader.Parameters, CreateInterfaceParameterReq{Name: "X-Token", Type: "string", Location: "header", Group: "input"})
+	_, err = CreateInterface(withHeader)
+	assert.EqualError(t, err, "command interfaces do not support header parameter X-Token")
+
+	_, err = CreateInterface(newReq("Proxied", "mcp", "stdio://proxy/search", "-y\n@example/server"))
+	require.NoError(t, err)
+	_, err = CreateInterface(newReq("NoTool", "mcp", "stdio://proxy", ""))
+	assert.EqualError(t, err, "mcp url must be stdio://command/tool")
+	get := newReq("GetMethod", "mcp", "stdio://proxy/search", "")
+	get.Method = "GET"
+	_, err = CreateInterface(get)
+	assert.EqualError(t, err, "mcp interfaces must use POST")
+
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, Protocol: stringPtr("http"), URL: stringPtr("https://example.com")})
+	assert.EqualError(t, err, "query is only supported for graphql, sql, command and mcp interfaces")
+}
+
+func TestInterfaceScript(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "ScriptApp", Path: "script-app", Protocol: "sse"})
+	require.NoError(t, err)
+	req := CreateInterfaceRequest{
+		AppID:    app.Application.ID,
+		Name:     "Scripted",
+		Protocol: "http",
+		URL:      "https://api.example.com/scripted",
+		Method:   "GET",
+		AuthType: "none",
+		Script:   "def pre_request(params):\n    params[\"query\"][\"page\"] = 1",
+		Enabled:  boolPtr(true),
+	}
+	resp, err := CreateInterface(req)
+	require.NoError(t, err)
+	assert.Equal(t, req.Script, resp.Interface.Script)
+
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, Script: stringPtr("def post_response(body)")})
+	assert.ErrorContains(t, err, "invalid script")
+	got, err := GetInterface(GetInterfaceRequest{ID: resp.Interface.ID})
+	require.NoError(t, err)
+	assert.Equal(t, req.Script, got.Interface.Script)
+}
+
+func TestInterfaceCacheConfig(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "CacheApp", Path: "cache-app", Protocol: "sse"})
+	require.NoError(t, err)
+	req := CreateInterfaceRequest{
+		AppID:       app.Application.ID,
+		Name:        "Cached",
+		Protocol:    "http",
+		URL:         "https://api.example.com/cached",
+		Method:      "GET",
+		AuthType:    "none",
+		CacheConfig: `{"ttl": "forever"}`,
+		Enabled:     boolPtr(true),
+	}
+	_, err = CreateInterface(req)
+	assert.ErrorContains(t, err, "cache_config ttl must be a positive duration")
+
+	req.CacheConfig = `{"ttl": "5m", "backend": "database"}`
+	resp, err := CreateInterface(req)
+	require.NoError(t, err)
+	assert.Equal(t, req.CacheConfig, resp.Interface.CacheConfig)
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, CacheConfig: stringPtr(`{"ttl": "5m", "backend": "redis"}`)})
+	assert.ErrorContains(t, err, "backend must be memory or database")
+
+	// 删除接口时清除数据库中的缓存
+	db := database.GetDB()
+	db.Create(&models.ResponseCacheEntry{Key: "cached", AppID: app.Application.ID, InterfaceID: resp.Interface.ID, Value: []byte("{}"), ExpiresAt: time.Now().Add(time.Minute)})
+	_, err = DeleteInterface(DeleteInterfaceRequest{ID: resp.Interface.ID})
+	require.NoError(t, err)
+	var count int64
+	db.Model(&models.ResponseCacheEntry{}).Where("interface_id = ?", resp.Interface.ID).Count(&count)
+	assert.Zero(t, count)
+}
+
 func TestCreateInterfaceWithInvalidParameters(t *testing.T) {
 	setupTestDB(t)
 
@@ -496,7 +1101,7 @@ func TestUpdateInterface(t *testing.T) {
 		URL:         "https://api.example.com/test",
 		Method:      "GET",
 		AuthType:    "none",
-		Enabled:     true,
+		Enabled:     boolPtr(true),
 	})
 	require.NoError(t, err)
 
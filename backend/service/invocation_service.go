@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"time"
+)
+
+const defaultInvocationPageSize = 20
+
+type ListInvocationsRequest struct {
+	AppID       int64      `form:"app_id" validate:"gte=0"`
+	InterfaceID int64      `form:"interface_id" validate:"gte=0"`
+	Tool        string     `form:"tool" validate:"max=255"`                         // 工具名称
+	SessionID   string     `form:"session_id" validate:"max=255"`                   // MCP 会话 ID
+	Status      string     `form:"status" validate:"omitempty,oneof=success error"` // 调用结果
+	Since       *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`   // 开始时间 (包含)，RFC3339
+	Until       *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`   // 结束时间 (不包含)，RFC3339
+	Page        int        `form:"page" validate:"gte=0"`                           // 页码，从 1 开始，默认 1
+	PageSize    int        `form:"page_size" validate:"gte=0,lte=100"`              // 每页条数，默认 20
+}
+
+type ListInvocationsResponse struct {
+	Total       int64                   `json:"total"`
+	Page        int                     `json:"page"`
+	PageSize    int                     `json:"page_size"`
+	Invocations []models.ToolInvocation `json:"invocations"`
+}
+
+type GetInvocationRequest struct {
+	ID int64 `json:"id" validate:"required,gt=0"`
+}
+
+// ListInvocations 按条件分页查询工具调用日志，按时间倒序
+func ListInvocations(req ListInvocationsRequest) (ListInvocationsResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return ListInvocationsResponse{}, err
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultInvocationPageSize
+	}
+	query := database.GetDB().Model(&models.ToolInvocation{})
+	if req.AppID != 0 {
+		query = query.Where("app_id = ?", req.AppID)
+	}
+	if req.InterfaceID != 0 {
+		query = query.Where("interface_id = ?", req.InterfaceID)
+	}
+	if req.Tool != "" {
+		query = query.Where("tool = ?", req.Tool)
+	}
+	if req.SessionID != "" {
+		query = query.Where("session_id = ?", req.SessionID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.Since != nil {
+		query = query.Where("created_at >= ?", *req.Since)
+	}
+	if req.Until != nil {
+		query = query.Where("created_at < ?", *req.Until)
+	}
+	resp := ListInvocationsResponse{Page: req.Page, PageSize: req.PageSize, Invocations: make([]models.ToolInvocation, 0)}
+	if err := query.Count(&resp.Total).Error; err != nil {
+		return ListInvocationsResponse{}, err
+	}
+	err := query.Order("created_at DESC, id DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&resp.Invocations).Error
+	if err != nil {
+		return ListInvocationsResponse{}, err
+	}
+	return resp, nil
+}
+
+// GetInvocation 获取单条调用日志
+func GetInvocation(req GetInvocationRequest) (models.ToolInvocation, error) {
+	if err := validate.Struct(req); err != nil {
+		return models.ToolInvocation{}, err
+	}
+	var invocation models.ToolInvocation
+	if err := database.GetDB().First(&invocation, req.ID).Error; err != nil {
+		return models.ToolInvocation{}, errors.New("invocation not found")
+	}
+	return invocation, nil
+}
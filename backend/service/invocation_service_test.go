@@ -0,0 +1,74 @@
+package service
+
+import (
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListInvocations(t *testing.T) {
+	setupTestDB(t)
+
+	db := database.GetDB()
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 25; i++ {
+		status := "success"
+		if i%5 == 0 {
+			status = "error"
+		}
+		db.Create(&models.ToolInvocation{AppID: 1, InterfaceID: int64(1 + i%2), Tool: "search", SessionID: "s1", Status: status, CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	db.Create(&models.ToolInvocation{AppID: 2, InterfaceID: 3, Tool: "other", SessionID: "s2", Status: "success", CreatedAt: base})
+
+	resp, err := ListInvocations(ListInvocationsRequest{AppID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), resp.Total)
+	assert.Equal(t, 1, resp.Page)
+	assert.Len(t, resp.Invocations, 20)
+	assert.True(t, resp.Invocations[0].CreatedAt.After(resp.Invocations[1].CreatedAt), "newest invocations first")
+
+	resp, err = ListInvocations(ListInvocationsRequest{AppID: 1, Page: 2, PageSize: 20})
+	require.NoError(t, err)
+	assert.Len(t, resp.Invocations, 5)
+
+	resp, err = ListInvocations(ListInvocationsRequest{Status: "error"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), resp.Total)
+
+	since, until := base.Add(10*time.Minute), base.Add(20*time.Minute)
+	resp, err = ListInvocations(ListInvocationsRequest{InterfaceID: 1, Since: &since, Until: &until})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), resp.Total)
+
+	resp, err = ListInvocations(ListInvocationsRequest{Tool: "other", SessionID: "s2"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Total)
+
+	_, err = ListInvocations(ListInvocationsRequest{PageSize: 101})
+	assert.Error(t, err)
+	_, err = ListInvocations(ListInvocationsRequest{Status: "pending"})
+	assert.Error(t, err)
+
+	invocation, err := GetInvocation(GetInvocationRequest{ID: resp.Invocations[0].ID})
+	require.NoError(t, err)
+	assert.Equal(t, "other", invocation.Tool)
+	_, err = GetInvocation(GetInvocationRequest{ID: 9999})
+	assert.EqualError(t, err, "invocation not found")
+}
+
+func TestApplicationInvocationLog(t *testing.T) {
+	setupTestDB(t)
+
+	_, err := CreateApplication(CreateApplicationRequest{Name: "Logged", Path: "logged", Protocol: "sse", InvocationLog: `{"retention": "-1h"}`})
+	assert.ErrorContains(t, err, "invocation_log retention must be a positive duration")
+
+	resp, err := CreateApplication(CreateApplicationRequest{Name: "Logged", Path: "logged", Protocol: "sse", InvocationLog: `{"retention": "72h", "redact": ["^x-internal-"]}`})
+	require.NoError(t, err)
+	assert.Equal(t, `{"retention": "72h", "redact": ["^x-internal-"]}`, resp.Application.InvocationLog)
+	_, err = UpdateApplication(UpdateApplicationRequest{ID: resp.Application.ID, InvocationLog: stringPtr(`{"redact": ["[a-"]}`)})
+	assert.ErrorContains(t, err, "redact pattern")
+}
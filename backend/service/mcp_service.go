@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// mcpListToolsTimeout 导入时启动服务器并列出工具的超时时间
+const mcpListToolsTimeout = 90 * time.Second
+
+type ImportMCPRequest struct {
+	AppID   int64    `json:"app_id" validate:"required,gt=0"`        // 导入到的应用 ID
+	Command string   `json:"command" validate:"required,max=255"`    // MCP_ADAPTER_COMMANDS 中允许的命令名称
+	Args    []string `json:"args" validate:"max=100,dive,max=1024"`  // 启动参数，例如 ["-y", "@modelcontextprotocol/server-filesystem", "/data"]
+	Tools   []string `json:"tools" validate:"max=1000,dive,max=255"` // 只导入这些工具，为空时导入全部
+	DryRun  bool     `json:"dry_run"`                                // 只预览，不写入数据库
+}
+
+// mcpImporter 把 stdio MCP 服务器的工具转换为导入计划，工具的 JSON Schema 复用 OpenAPI 的转换规则
+type mcpImporter struct {
+	schemas *openAPIImporter
+	command string
+	args    string
+}
+
+func newMCPImporter(command string, args []string) *mcpImporter {
+	return &mcpImporter{
+		schemas: &openAPIImporter{
+			schemas:  make(map[string]any),
+			types:    make(map[string]*importedType),
+			resolved: make(map[string]bool),
+			inline:   make(map[string]int),
+		},
+		command: command,
+		args:    strings.Join(args, "\n"),
+	}
+}
+
+// schemaFields 转换工具的输入或输出 schema，$ref 在 schema 自身内解析 (如 #/$defs/Item)
+func (im *mcpImporter) schemaFields(owner string, schema any) []importedField {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		im.schemas.warn("%s: %v", owner, err)
+		return nil
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil || doc == nil {
+		return nil
+	}
+	im.schemas.doc = doc
+	return im.schemas.objectFields(owner, doc)
+}
+
+// buildTool 输入 schema 的属性作为 body 入参，声明了输出 schema 时其属性作为出参
+func (im *mcpImporter) buildTool(tool mcp.Tool) importedInterface {
+	iface := importedInterface{
+		Name:        operationName(map[string]any{"operationId": tool.Name}, "", ""),
+		Description: tool.Description,
+		URL:         adapter.StdioToolURL(im.command, tool.Name),
+		Method:      "POST",
+		Protocol:    "mcp",
+		Query:       im.args,
+	}
+	if iface.Name == "" {
+		iface.Skip = "invalid tool name"
+		return iface
+	}
+	owner := capitalize(iface.Name)
+	for _, f := range im.schemaFields(owner+"Input", tool.InputSchema) {
+		f.Location, f.Group = "body", "input"
+		iface.Params = append(iface.Params, f)
+	}
+	if tool.OutputSchema.Type != "" {
+		for _, f := range im.schemaFields(owner+"Output", tool.OutputSchema) {
+			f.Location, f.Group, f.Required = "body", "output", false
+			iface.Params = append(iface.Params, f)
+		}
+	}
+	return iface
+}
+
+// plan 生成导入计划，工具按名称排序
+func (im *mcpImporter) plan(tools []mcp.Tool) ([]*importedType, []importedInterface) {
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	interfaces := make([]importedInterface, 0, len(tools))
+	used := make(map[string]bool)
+	for _, tool := range tools {
+		iface := im.buildTool(tool)
+		if used[iface.Name] && iface.Skip == "" {
+			iface.Skip = "duplicate tool name"
+		}
+		used[iface.Name] = true
+		interfaces = append(interfaces, iface)
+	}
+	return im.schemas.breakCycles(), interfaces
+}
+
+// selectMCPTools 按名称筛选工具
+func selectMCPTools(tools []mcp.Tool, names []string) ([]mcp.Tool, error) {
+	if len(names) == 0 {
+		if len(tools) == 0 {
+			return nil, errors.New("no mcp tools found")
+		}
+		return tools, nil
+	}
+	byName := make(map[string]mcp.Tool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+	}
+	selected := make([]mcp.Tool, 0, len(names))
+	for _, name := range names {
+		tool, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("mcp tool %s not found", name)
+		}
+		selected = append(selected, tool)
+	}
+	return selected, nil
+}
+
+// ImportMCP 启动 stdio MCP 服务器，把它的工具导入为 mcp 接口，调用时转发给同一个子进程
+func ImportMCP(req ImportMCPRequest) (ImportResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return ImportResponse{}, err
+	}
+	for _, arg := range req.Args {
+		if strings.ContainsAny(arg, "\r\n") || strings.TrimSpace(arg) != arg || arg == "" {
+			return ImportResponse{}, errors.New("mcp server arguments must be non-empty single lines without surrounding spaces")
+		}
+	}
+	db := database.GetDB()
+	var app models.Application
+	if err := db.First(&app, req.AppID).Error; err != nil {
+		return ImportResponse{}, errors.New("application not found")
+	}
+	env, err := adapter.ParseEnvironment(app.Environment)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mcpListToolsTimeout)
+	defer cancel()
+	tools, err := adapter.ListStdioTools(ctx, req.Command, req.Args, env)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	tools, err = selectMCPTools(tools, req.Tools)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	importer := newMCPImporter(req.Command, req.Args)
+	types, interfaces := importer.plan(tools)
+	return applyImportPlan(db, app, types, interfaces, importer.schemas.warnings, req.DryRun, "none", "")
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"os"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperMCPServer 被导入测试以子进程启动，作为 stdio MCP 服务器
+func TestHelperMCPServer(t *testing.T) {
+	if os.Getenv("MCP_ADAPTER_TEST_STDIO_SERVER") != "1" {
+		return
+	}
+	s := server.NewMCPServer("helper", "1.0.0")
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	s.AddTool(mcp.NewTool("search_issues",
+		mcp.WithDescription("Search issues"),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Search text")),
+		mcp.WithArray("labels", mcp.WithStringItems()),
+		mcp.WithObject("page", mcp.Properties(map[string]any{
+			"size":   map[string]any{"type": "integer"},
+			"cursor": map[string]any{"type": "string"},
+		})),
+		mcp.WithOutputSchema[struct {
+			Total int `json:"total"`
+		}](),
+	), handler)
+	s.AddTool(mcp.NewTool("fs.read", mcp.WithString("path", mcp.Required())), handler)
+	server.ServeStdio(s)
+	os.Exit(0)
+}
+
+func TestImportMCP(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.CommandAllowlistEnv, "helper="+os.Args[0])
+	t.Cleanup(adapter.CloseStdioServers)
+
+	environment, _ := json.Marshal(map[string]string{"MCP_ADAPTER_TEST_STDIO_SERVER": "1"})
+	app, err := CreateApplication(CreateApplicationRequest{Name: "ProxyApp", Path: "proxy-app", Protocol: "sse", Environment: string(environment)})
+	require.NoError(t, err)
+	args := []string{"-test.run=^TestHelperMCPServer$"}
+
+	preview, err := ImportMCP(ImportMCPRequest{AppID: app.Application.ID, Command: "helper", Args: args, DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, preview.Interfaces, 2)
+	assert.Equal(t, "fs_read", preview.Interfaces[0].Name)
+	assert.Equal(t, "search_issues", preview.Interfaces[1].Name)
+
+	resp, err := ImportMCP(ImportMCPRequest{AppID: app.Application.ID, Command: "helper", Args: args, Tools: []string{"search_issues"}})
+	require.NoError(t, err)
+	require.Len(t, resp.Interfaces, 1)
+	assert.Equal(t, []ImportItemDTO{{Name: "Search_issuesInputPage", Action: "create", Detail: "2 fields"}}, resp.CustomTypes)
+
+	var iface models.Interface
+	require.NoError(t, database.GetDB().Where("app_id = ? AND name = ?", app.Application.ID, "search_issues").First(&iface).Error)
+	assert.Equal(t, "mcp", iface.Protocol)
+	assert.Equal(t, "stdio://helper/search_issues", iface.URL)
+	assert.Equal(t, args[0], iface.Query)
+	assert.Equal(t, "Search issues", iface.Description)
+	var params []models.InterfaceParameter
+	database.GetDB().Where("interface_id = ?", iface.ID).Order("id").Find(&params)
+	byName := make(map[string]models.InterfaceParameter)
+	for _, p := range params {
+		byName[p.Group+":"+p.Name] = p
+	}
+	assert.True(t, byName["input:query"].Required)
+	assert.True(t, byName["input:labels"].IsArray)
+	assert.Equal(t, "custom", byName["input:page"].Type)
+	assert.Equal(t, "number", byName["output:total"].Type)
+
+	_, err = ImportMCP(ImportMCPRequest{AppID: app.Application.ID, Command: "helper", Args: args, Tools: []string{"missing"}})
+	assert.EqualError(t, err, "mcp tool missing not found")
+	_, err = ImportMCP(ImportMCPRequest{AppID: app.Application.ID, Command: "rm", Args: args})
+	assert.EqualError(t, err, "command rm is not allowed, add it to MCP_ADAPTER_COMMANDS")
+	_, err = ImportMCP(ImportMCPRequest{AppID: app.Application.ID, Command: "helper", Args: []string{"a\nb"}})
+	assert.ErrorContains(t, err, "single lines")
+	_, err = ImportMCP(ImportMCPRequest{AppID: 9999, Command: "helper"})
+	assert.EqualError(t, err, "application not found")
+}
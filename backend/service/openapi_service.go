@@ -0,0 +1,1337 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+type ImportOpenAPIRequest struct {
+	AppID      int64  `json:"app_id" validate:"required,gt=0"`                                                     // 导入到的应用 ID
+	Spec       string `json:"spec" validate:"required,max=10485760"`                                               // OpenAPI 3.0/3.1 文档 (JSON 或 YAML)
+	BaseURL    string `json:"base_url" validate:"max=1024"`                                                        // 如果提供，则覆盖文档中的 servers
+	AuthType   string `json:"auth_type" validate:"omitempty,oneof=none capi bearer basic apikey oauth2 aws_sigv4"` // 导入接口的鉴权类型，默认 none
+	AuthConfig string `json:"auth_config" validate:"max=65536"`                                                    // 鉴权配置 (JSON)，结构由 auth_type 决定
+	DryRun     bool   `json:"dry_run"`                                                                             // 只预览，不写入数据库
+}
+
+type ImportItemDTO struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`           // create: 将会创建, skip: 跳过
+	Reason string `json:"reason,omitempty"` // 跳过原因
+	Detail string `json:"detail,omitempty"` // 附加信息，如 "GET https://api.example.com/users"
+}
+
+type ImportResponse struct {
+	DryRun      bool            `json:"dry_run"`
+	CustomTypes []ImportItemDTO `json:"custom_types"`
+	Interfaces  []ImportItemDTO `json:"interfaces"`
+	Warnings    []string        `json:"warnings"`
+}
+
+// openAPIMethods 支持导入的 HTTP 方法，按导入顺序排列
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+var toolNameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+var underscoreRun = regexp.MustCompile(`_{2,}`)
+
+// importedField 导入计划中的字段/参数定义，custom 类型通过名称引用
+type importedField struct {
+	Name         string
+	Type         string
+	RefName      string
+	IsArray      bool
+	Required     bool
+	Description  string
+	Location     string
+	DefaultValue *string
+	Group        string
+}
+
+type importedType struct {
+	Name        string
+	Description string
+	Fields      []importedField
+	Existing    bool // 应用内已存在同名类型，直接复用
+}
+
+type importedInterface struct {
+	Name          string
+	Description   string
+	URL           string
+	Method        string
+	Encoding      string // 请求体编码，由 requestBody 的媒体类型决定
+	Protocol      string // 接口协议，默认 http
+	Operation     string // graphql 操作类型: query, mutation
+	Query         string // graphql 查询文档
+	DescriptorSet string // grpc 描述集 (base64)，为空时通过服务端反射解析
+	Params        []importedField
+	Skip          string // 非空表示跳过原因
+}
+
+// openAPIImporter 把 OpenAPI 文档转换为导入计划
+type openAPIImporter struct {
+	doc      map[string]any
+	baseURL  string
+	schemas  map[string]any
+	types    map[string]*importedType
+	resolved map[string]bool // 正在或已经解析过的 components/schemas
+	inline   map[string]int  // 内联类型名称计数，用于去重
+	warnings []string
+}
+
+// parseSpecDocument 解析 JSON 或 YAML 格式的文档
+func parseSpecDocument(spec string) (map[string]any, error) {
+	var doc any
+	if err := json.Unmarshal([]byte(spec), &doc); err != nil {
+		if yamlErr := yaml.Unmarshal([]byte(spec), &doc); yamlErr != nil {
+			return nil, fmt.Errorf("invalid document: %v", yamlErr)
+		}
+	}
+	m, ok := normalizeYAMLValue(doc).(map[string]any)
+	if !ok {
+		return nil, errors.New("invalid document: root must be an object")
+	}
+	return m, nil
+}
+
+// normalizeYAMLValue 将 YAML 解析出的 map[any]any 统一转换为 map[string]any
+func normalizeYAMLValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = normalizeYAMLValue(item)
+		}
+		return val
+	case map[any]any:
+		m := make(map[string]any, len(val))
+		for k, item := range val {
+			m[fmt.Sprint(k)] = normalizeYAMLValue(item)
+		}
+		return m
+	case []any:
+		for i, item := range val {
+			val[i] = normalizeYAMLValue(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func newOpenAPIImporter(doc map[string]any, baseURL string) (*openAPIImporter, error) {
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, errors.New("only OpenAPI 3.0 and 3.1 documents are supported")
+	}
+	schemas := make(map[string]any)
+	if components, ok := doc["components"].(map[string]any); ok {
+		if s, ok := components["schemas"].(map[string]any); ok {
+			schemas = s
+		}
+	}
+	return &openAPIImporter{
+		doc:      doc,
+		baseURL:  baseURL,
+		schemas:  schemas,
+		types:    make(map[string]*importedType),
+		resolved: make(map[string]bool),
+		inline:   make(map[string]int),
+	}, nil
+}
+
+func (im *openAPIImporter) warn(format string, args ...any) {
+	im.warnings = append(im.warnings, fmt.Sprintf(format, args...))
+}
+
+// resolveRef 解析文档内部引用，例如 #/components/parameters/Limit
+func (im *openAPIImporter) resolveRef(ref string) (map[string]any, bool) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	var cur any = im.doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur = m[part]
+	}
+	m, ok := cur.(map[string]any)
+	return m, ok
+}
+
+// deref 展开非 schema 对象（参数、请求体、响应）的 $ref
+func (im *openAPIImporter) deref(obj map[string]any) map[string]any {
+	for i := 0; i < 32 && obj != nil; i++ {
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj
+		}
+		target, ok := im.resolveRef(ref)
+		if !ok {
+			im.warn("unresolvable reference %s", ref)
+			return nil
+		}
+		obj = target
+	}
+	return obj
+}
+
+// schemaType 返回 schema 的类型，兼容 3.1 中的 ["string", "null"] 写法
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["allOf"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return ""
+}
+
+// mergeObjectSchema 合并 allOf 的各个部分，返回 properties 和 required
+func (im *openAPIImporter) mergeObjectSchema(schema map[string]any, depth int) (map[string]any, map[string]bool) {
+	properties := make(map[string]any)
+	required := make(map[string]bool)
+	if depth > 32 || schema == nil {
+		return properties, required
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		target, ok := im.resolveRef(ref)
+		if !ok {
+			im.warn("unresolvable reference %s", ref)
+			return properties, required
+		}
+		return im.mergeObjectSchema(target, depth+1)
+	}
+	if allOf, ok := schema["allOf"].([]any); ok {
+		for _, part := range allOf {
+			if m, ok := part.(map[string]any); ok {
+				p, r := im.mergeObjectSchema(m, depth+1)
+				for k, v := range p {
+					properties[k] = v
+				}
+				for k := range r {
+					required[k] = true
+				}
+			}
+		}
+	}
+	if p, ok := schema["properties"].(map[string]any); ok {
+		for k, v := range p {
+			properties[k] = v
+		}
+	}
+	if r, ok := schema["required"].([]any); ok {
+		for _, item := range r {
+			if s, ok := item.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+	return properties, required
+}
+
+// firstVariant 对 oneOf/anyOf 取第一个非 null 的分支
+func (im *openAPIImporter) firstVariant(schema map[string]any, hint string) map[string]any {
+	for _, key := range []string{"oneOf", "anyOf"} {
+		variants, ok := schema[key].([]any)
+		if !ok {
+			continue
+		}
+		for _, v := range variants {
+			m, ok := v.(map[string]any)
+			if !ok || schemaType(m) == "null" {
+				continue
+			}
+			if len(variants) > 1 {
+				im.warn("%s: %s has multiple variants, only the first one is imported", hint, key)
+			}
+			return m
+		}
+	}
+	return nil
+}
+
+// fieldType 将 schema 转换为 (type, refName, isArray)，ok 为 false 表示无法表示
+func (im *openAPIImporter) fieldType(schema map[string]any, hint string, depth int) (string, string, bool, bool) {
+	if schema == nil || depth > 32 {
+		return "", "", false, false
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		name, isComponent := strings.CutPrefix(ref, "#/components/schemas/")
+		target, found := im.resolveRef(ref)
+		if !found {
+			im.warn("%s: unresolvable reference %s", hint, ref)
+			return "", "", false, false
+		}
+		if isComponent && !strings.Contains(name, "/") && schemaType(target) == "object" {
+			if err := im.defineComponent(name); err != nil {
+				im.warn("%s: %v", hint, err)
+				return "", "", false, false
+			}
+			return "custom", name, false, true
+		}
+		return im.fieldType(target, hint, depth+1)
+	}
+	if variant := im.firstVariant(schema, hint); variant != nil {
+		return im.fieldType(variant, hint, depth+1)
+	}
+	switch schemaType(schema) {
+	case "string":
+		return "string", "", false, true
+	case "integer", "number":
+		return "number", "", false, true
+	case "boolean":
+		return "boolean", "", false, true
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		typ, ref, isArray, ok := im.fieldType(items, hint, depth+1)
+		if !ok {
+			return "", "", false, false
+		}
+		if isArray {
+			im.warn("%s: nested arrays are not supported", hint)
+			return "", "", false, false
+		}
+		return typ, ref, true, true
+	case "object":
+		properties, _ := im.mergeObjectSchema(schema, 0)
+		if len(properties) == 0 {
+			im.warn("%s: free-form objects are not supported", hint)
+			return "", "", false, false
+		}
+		name := im.defineInline(hint, schema)
+		return "custom", name, false, true
+	default:
+		im.warn("%s: schema without a supported type", hint)
+		return "", "", false, false
+	}
+}
+
+// objectFields 将对象 schema 的属性转换为字段列表
+func (im *openAPIImporter) objectFields(owner string, schema map[string]any) []importedField {
+	properties, required := im.mergeObjectSchema(schema, 0)
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]importedField, 0, len(names))
+	for _, name := range names {
+		prop, _ := properties[name].(map[string]any)
+		typ, ref, isArray, ok := im.fieldType(prop, owner+capitalize(name), 0)
+		if !ok {
+			im.warn("%s.%s skipped: unsupported schema", owner, name)
+			continue
+		}
+		description, _ := prop["description"].(string)
+		fields = append(fields, importedField{
+			Name:        name,
+			Type:        typ,
+			RefName:     ref,
+			IsArray:     isArray,
+			Required:    required[name],
+			Description: description,
+		})
+	}
+	return fields
+}
+
+// defineComponent 定义 components/schemas 中的对象类型
+func (im *openAPIImporter) defineComponent(name string) error {
+	if im.resolved[name] {
+		return nil
+	}
+	schema, ok := im.schemas[name].(map[string]any)
+	if !ok {
+		return fmt.Errorf("schema %s not found", name)
+	}
+	im.resolved[name] = true
+	description, _ := schema["description"].(string)
+	t := &importedType{Name: name, Description: description}
+	im.types[name] = t
+	t.Fields = im.objectFields(name, schema)
+	return nil
+}
+
+// defineInline 为内联对象 schema 生成一个具名类型
+func (im *openAPIImporter) defineInline(hint string, schema map[string]any) string {
+	name := hint
+	if n := im.inline[hint]; n > 0 || im.schemas[hint] != nil {
+		name = fmt.Sprintf("%s%d", hint, n+1)
+	}
+	im.inline[hint]++
+	description, _ := schema["description"].(string)
+	t := &importedType{Name: name, Description: description}
+	im.types[name] = t
+	t.Fields = im.objectFields(name, schema)
+	return name
+}
+
+// breakCycles 移除会形成循环引用的字段，并返回按依赖排序的类型列表（被引用的类型在前）
+func (im *openAPIImporter) breakCycles() []*importedType {
+	names := make([]string, 0, len(im.types))
+	for name := range im.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return breakTypeCycles(im.types, names, im.warn)
+}
+
+// breakTypeCycles 按 names 的顺序深度优先遍历，移除指回正在访问的类型的字段，返回按依赖排序的类型列表
+func breakTypeCycles(types map[string]*importedType, names []string, warn func(format string, args ...any)) []*importedType {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	ordered := make([]*importedType, 0, len(names))
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		t := types[name]
+		kept := t.Fields[:0]
+		for _, f := range t.Fields {
+			if f.Type == "custom" {
+				if _, ok := types[f.RefName]; ok {
+					switch state[f.RefName] {
+					case visiting:
+						warn("%s.%s skipped: circular reference to %s", name, f.Name, f.RefName)
+						continue
+					case unvisited:
+						visit(f.RefName)
+					}
+				}
+			}
+			kept = append(kept, f)
+		}
+		t.Fields = kept
+		state[name] = done
+		ordered = append(ordered, t)
+	}
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return ordered
+}
+
+// serverURL 计算接口的基础地址，优先级: 请求参数 > operation > path > 文档
+func (im *openAPIImporter) serverURL(levels ...map[string]any) string {
+	if im.baseURL != "" {
+		return strings.TrimRight(im.baseURL, "/")
+	}
+	for _, level := range levels {
+		servers, ok := level["servers"].([]any)
+		if !ok || len(servers) == 0 {
+			continue
+		}
+		server, ok := servers[0].(map[string]any)
+		if !ok {
+			continue
+		}
+		u, _ := server["url"].(string)
+		if vars, ok := server["variables"].(map[string]any); ok {
+			for name, v := range vars {
+				if vm, ok := v.(map[string]any); ok {
+					u = strings.ReplaceAll(u, "{"+name+"}", fmt.Sprint(vm["default"]))
+				}
+			}
+		}
+		return strings.TrimRight(u, "/")
+	}
+	return ""
+}
+
+// formatDefault 将 schema 中的 default 转换为参数默认值
+func formatDefault(v any) *string {
+	if v == nil {
+		return nil
+	}
+	var s string
+	switch val := v.(type) {
+	case string:
+		s = val
+	case bool, int, int64, float64:
+		b, _ := json.Marshal(val)
+		s = string(b)
+	default:
+		return nil
+	}
+	return &s
+}
+
+func capitalize(s string) string {
+	s = toolNameSanitizer.ReplaceAllString(s, "_")
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// operationName 生成工具名称，优先使用 operationId
+func operationName(op map[string]any, method, path string) string {
+	name, _ := op["operationId"].(string)
+	if name == "" {
+		name = method + "_" + path
+	}
+	name = toolNameSanitizer.ReplaceAllString(name, "_")
+	name = strings.Trim(underscoreRun.ReplaceAllString(name, "_"), "_")
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+// buildInterface 将单个 operation 转换为接口定义
+func (im *openAPIImporter) buildInterface(path, method string, pathItem, op map[string]any) importedInterface {
+	name := operationName(op, method, path)
+	summary, _ := op["summary"].(string)
+	description, _ := op["description"].(string)
+	if summary != "" && description != "" {
+		description = summary + "\n\n" + description
+	} else if description == "" {
+		description = summary
+	}
+	iface := importedInterface{
+		Name:        name,
+		Description: description,
+		URL:         im.serverURL(op, pathItem, im.doc) + path,
+		Method:      strings.ToUpper(method),
+	}
+	typePrefix := capitalize(name)
+	seen := make(map[string]bool)
+
+	// path 级别的参数先收集，operation 级别同名同位置的参数覆盖之
+	type paramKey struct{ name, in string }
+	merged := make(map[paramKey]map[string]any)
+	var keys []paramKey
+	for _, level := range []map[string]any{pathItem, op} {
+		list, _ := level["parameters"].([]any)
+		for _, item := range list {
+			m, _ := item.(map[string]any)
+			p := im.deref(m)
+			if p == nil {
+				continue
+			}
+			pn, _ := p["name"].(string)
+			in, _ := p["in"].(string)
+			key := paramKey{pn, in}
+			if _, ok := merged[key]; !ok {
+				keys = append(keys, key)
+			}
+			merged[key] = p
+		}
+	}
+	for _, key := range keys {
+		p := merged[key]
+		if key.in == "cookie" {
+			im.warn("%s: cookie parameter %s skipped", name, key.name)
+			continue
+		}
+		schema, _ := p["schema"].(map[string]any)
+		typ, ref, isArray, ok := im.fieldType(schema, typePrefix+capitalize(key.name), 0)
+		if !ok || typ == "custom" {
+			im.warn("%s: parameter %s skipped: unsupported schema", name, key.name)
+			continue
+		}
+		if seen[key.name] {
+			im.warn("%s: duplicate parameter %s skipped", name, key.name)
+			continue
+		}
+		seen[key.name] = true
+		required, _ := p["required"].(bool)
+		desc, _ := p["description"].(string)
+		field := importedField{
+			Name:        key.name,
+			Type:        typ,
+			RefName:     ref,
+			IsArray:     isArray,
+			Required:    required || key.in == "path",
+			Description: desc,
+			Location:    key.in,
+			Group:       "input",
+		}
+		if !isArray && schema != nil {
+			field.DefaultValue = formatDefault(schema["default"])
+		}
+		iface.Params = append(iface.Params, field)
+	}
+
+	// 请求体: 对象的每个属性作为一个 body 参数，字符串请求体作为 raw 编码的单个参数
+	if body := im.deref(asMap(op["requestBody"])); body != nil {
+		if mediaType, schema := pickContent(body); schema != nil {
+			iface.Encoding = bodyEncodingForMediaType(mediaType)
+			if typ := schemaType(im.derefSchema(schema)); typ == "string" && iface.Encoding == adapter.BodyEncodingRaw {
+				desc, _ := im.derefSchema(schema)["description"].(string)
+				required, _ := body["required"].(bool)
+				iface.Params = append(iface.Params, importedField{
+					Name:        "body",
+					Type:        "string",
+					Required:    required,
+					Description: desc,
+					Location:    "body",
+					Group:       "input",
+				})
+			} else if typ == "object" && iface.Encoding != adapter.BodyEncodingRaw {
+				for _, f := range im.objectFields(typePrefix+"Body", schema) {
+					if seen[f.Name] {
+						im.warn("%s: body field %s conflicts with another parameter, skipped", name, f.Name)
+						continue
+					}
+					seen[f.Name] = true
+					f.Location = "body"
+					f.Group = "input"
+					iface.Params = append(iface.Params, f)
+				}
+			} else {
+				iface.Encoding = ""
+				im.warn("%s: non-object request body skipped", name)
+			}
+		}
+	}
+
+	// 响应: 取第一个 2xx 响应的对象属性作为出参
+	if responses, ok := op["responses"].(map[string]any); ok {
+		codes := make([]string, 0, len(responses))
+		for code := range responses {
+			if strings.HasPrefix(code, "2") {
+				codes = append(codes, code)
+			}
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			resp := im.deref(asMap(responses[code]))
+			schema := pickContentSchema(resp)
+			if schema == nil {
+				continue
+			}
+			if schemaType(im.derefSchema(schema)) != "object" {
+				im.warn("%s: non-object response skipped", name)
+				break
+			}
+			for _, f := range im.objectFields(typePrefix+"Response", schema) {
+				f.Location = "body"
+				f.Group = "output"
+				iface.Params = append(iface.Params, f)
+			}
+			break
+		}
+	}
+	return iface
+}
+
+// derefSchema 展开 schema 的 $ref，用于判断类型
+func (im *openAPIImporter) derefSchema(schema map[string]any) map[string]any {
+	for i := 0; i < 32; i++ {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return schema
+		}
+		target, ok := im.resolveRef(ref)
+		if !ok {
+			return schema
+		}
+		schema = target
+	}
+	return schema
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+// pickContentSchema 优先选择 JSON 内容类型的 schema
+func pickContentSchema(obj map[string]any) map[string]any {
+	_, schema := pickContent(obj)
+	return schema
+}
+
+// pickContent 优先选择 JSON 媒体类型，返回媒体类型和 schema
+func pickContent(obj map[string]any) (string, map[string]any) {
+	content, ok := obj["content"].(map[string]any)
+	if !ok {
+		return "", nil
+	}
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		ji := strings.Contains(types[i], "json")
+		jj := strings.Contains(types[j], "json")
+		if ji != jj {
+			return ji
+		}
+		return types[i] < types[j]
+	})
+	for _, ct := range types {
+		if media, ok := content[ct].(map[string]any); ok {
+			if schema, ok := media["schema"].(map[string]any); ok {
+				return ct, schema
+			}
+		}
+	}
+	return "", nil
+}
+
+// bodyEncodingForMediaType 请求体媒体类型对应的接口编码，JSON 使用默认编码
+func bodyEncodingForMediaType(mediaType string) string {
+	switch {
+	case strings.Contains(mediaType, "json"):
+		return ""
+	case mediaType == "application/x-www-form-urlencoded":
+		return adapter.BodyEncodingForm
+	case mediaType == "multipart/form-data":
+		return adapter.BodyEncodingMultipart
+	}
+	return adapter.BodyEncodingRaw
+}
+
+// openAPIBodyMediaType 接口编码对应的请求体媒体类型
+func openAPIBodyMediaType(encoding string) string {
+	switch encoding {
+	case adapter.BodyEncodingForm:
+		return "application/x-www-form-urlencoded"
+	case adapter.BodyEncodingMultipart:
+		return "multipart/form-data"
+	case adapter.BodyEncodingRaw:
+		return "text/plain"
+	}
+	return "application/json"
+}
+
+// plan 生成完整的导入计划
+func (im *openAPIImporter) plan() ([]*importedType, []importedInterface) {
+	// 先定义所有组件，保证未被接口引用的类型也会被导入
+	names := make([]string, 0, len(im.schemas))
+	for name := range im.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if schema, ok := im.schemas[name].(map[string]any); ok && schemaType(schema) == "object" {
+			if err := im.defineComponent(name); err != nil {
+				im.warn("%v", err)
+			}
+		}
+	}
+
+	var interfaces []importedInterface
+	paths, _ := im.doc["paths"].(map[string]any)
+	pathNames := make([]string, 0, len(paths))
+	for p := range paths {
+		pathNames = append(pathNames, p)
+	}
+	sort.Strings(pathNames)
+	used := make(map[string]bool)
+	for _, p := range pathNames {
+		pathItem := im.deref(asMap(paths[p]))
+		if pathItem == nil {
+			continue
+		}
+		for _, method := range openAPIMethods {
+			op, ok := pathItem[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			iface := im.buildInterface(p, method, pathItem, op)
+			if used[iface.Name] {
+				iface.Skip = "duplicate operation name in document"
+			}
+			used[iface.Name] = true
+			interfaces = append(interfaces, iface)
+		}
+	}
+	return im.breakCycles(), interfaces
+}
+
+// ImportOpenAPI 从 OpenAPI 文档导入接口、参数和自定义类型
+func ImportOpenAPI(req ImportOpenAPIRequest) (ImportResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return ImportResponse{}, err
+	}
+	if req.AuthType == "" {
+		req.AuthType = "none"
+	}
+	db := database.GetDB()
+	var app models.Application
+	if err := db.First(&app, req.AppID).Error; err != nil {
+		return ImportResponse{}, errors.New("application not found")
+	}
+	if err := checkAuthConfig(db, req.AuthType, req.AuthConfig); err != nil {
+		return ImportResponse{}, err
+	}
+	doc, err := parseSpecDocument(req.Spec)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	importer, err := newOpenAPIImporter(doc, req.BaseURL)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	types, interfaces := importer.plan()
+	return applyImportPlan(db, app, types, interfaces, importer.warnings, req.DryRun, req.AuthType, req.AuthConfig)
+}
+
+// applyImportPlan 预览或写入导入计划：已存在的同名类型直接复用，已存在的同名接口跳过
+func applyImportPlan(db *gorm.DB, app models.Application, types []*importedType, interfaces []importedInterface, warnings []string, dryRun bool, authType, authConfig string) (ImportResponse, error) {
+	resp := ImportResponse{
+		DryRun:      dryRun,
+		CustomTypes: make([]ImportItemDTO, 0, len(types)),
+		Interfaces:  make([]ImportItemDTO, 0, len(interfaces)),
+	}
+
+	var existingTypes []models.CustomType
+	db.Where("app_id = ?", app.ID).Find(&existingTypes)
+	typeIDs := make(map[string]int64)
+	for _, t := range existingTypes {
+		typeIDs[t.Name] = t.ID
+	}
+	for _, t := range types {
+		item := ImportItemDTO{Name: t.Name, Action: "create", Detail: fmt.Sprintf("%d fields", len(t.Fields))}
+		if _, ok := typeIDs[t.Name]; ok {
+			t.Existing = true
+			item.Action = "skip"
+			item.Reason = "custom type already exists, reusing it"
+		}
+		resp.CustomTypes = append(resp.CustomTypes, item)
+	}
+	for i := range interfaces {
+		iface := &interfaces[i]
+		if iface.Skip == "" {
+			var count int64
+			db.Model(&models.Interface{}).Where("app_id = ? AND name = ?", app.ID, iface.Name).Count(&count)
+			if count > 0 {
+				iface.Skip = "interface already exists"
+			}
+		}
+		item := ImportItemDTO{Name: iface.Name, Action: "create", Detail: iface.Method + " " + iface.URL}
+		if iface.Operation != "" {
+			item.Detail = iface.Operation + " " + iface.URL
+		}
+		if iface.Skip != "" {
+			item.Action = "skip"
+			item.Reason = iface.Skip
+		}
+		resp.Interfaces = append(resp.Interfaces, item)
+	}
+	resp.Warnings = warnings
+	if resp.Warnings == nil {
+		resp.Warnings = []string{}
+	}
+	if dryRun {
+		return resp, nil
+	}
+
+	created := make([]models.Interface, 0, len(interfaces))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 按依赖顺序创建类型，保证引用的类型已经存在
+		for _, t := range types {
+			if t.Existing {
+				continue
+			}
+			fields := make([]CreateCustomTypeFieldReq, 0, len(t.Fields))
+			for _, f := range t.Fields {
+				fields = append(fields, CreateCustomTypeFieldReq{
+					Name:        f.Name,
+					Type:        f.Type,
+					Ref:         lookupTypeRef(typeIDs, f.RefName),
+					IsArray:     f.IsArray,
+					Required:    f.Required,
+					Description: f.Description,
+				})
+			}
+			if err := checkCustomTypeCycle(tx, 0, app.ID, fields); err != nil {
+				return fmt.Errorf("custom type %s: %w", t.Name, err)
+			}
+			customType := models.CustomType{AppID: app.ID, Name: t.Name, Description: t.Description}
+			if err := tx.Create(&customType).Error; err != nil {
+				return err
+			}
+			typeIDs[t.Name] = customType.ID
+			for _, f := range fields {
+				field := models.CustomTypeField{
+					AppID:        app.ID,
+					CustomTypeID: customType.ID,
+					Name:         f.Name,
+					Type:         f.Type,
+					Ref:          f.Ref,
+					IsArray:      f.IsArray,
+					Required:     f.Required,
+					Description:  f.Description,
+				}
+				if err := tx.Create(&field).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for _, iface := range interfaces {
+			if iface.Skip != "" {
+				continue
+			}
+			params := make([]CreateInterfaceParameterReq, 0, len(iface.Params))
+			for _, p := range iface.Params {
+				params = append(params, CreateInterfaceParameterReq{
+					Name:         p.Name,
+					Type:         p.Type,
+					Ref:          lookupTypeRef(typeIDs, p.RefName),
+					Location:     p.Location,
+					IsArray:      p.IsArray,
+					Required:     p.Required,
+					Description:  p.Description,
+					DefaultValue: p.DefaultValue,
+					Group:        p.Group,
+				})
+			}
+			if err := checkParameters(&params, tx, app.ID); err != nil {
+				return fmt.Errorf("interface %s: %w", iface.Name, err)
+			}
+			protocol := iface.Protocol
+			if protocol == "" {
+				protocol = "http"
+			}
+			model := models.Interface{
+				AppID:         app.ID,
+				Name:          iface.Name,
+				Description:   iface.Description,
+				Protocol:      protocol,
+				URL:           iface.URL,
+				Method:        iface.Method,
+				AuthType:      authType,
+				AuthConfig:    authConfig,
+				BodyEncoding:  iface.Encoding,
+				Query:         iface.Query,
+				DescriptorSet: iface.DescriptorSet,
+				Enabled:       true,
+			}
+			if err := tx.Create(&model).Error; err != nil {
+				return err
+			}
+			createdParams := make([]models.InterfaceParameter, 0, len(params))
+			for _, p := range params {
+				param := models.InterfaceParameter{
+					AppID:        app.ID,
+					InterfaceID:  model.ID,
+					Name:         p.Name,
+					Type:         p.Type,
+					Ref:          p.Ref,
+					Location:     p.Location,
+					IsArray:      p.IsArray,
+					Required:     p.Required,
+					Description:  p.Description,
+					DefaultValue: p.DefaultValue,
+					Group:        p.Group,
+				}
+				if err := tx.Create(&param).Error; err != nil {
+					return err
+				}
+				createdParams = append(createdParams, param)
+			}
+			if err := checkRequestBody(tx, model, createdParams); err != nil {
+				return fmt.Errorf("interface %s: %w", iface.Name, err)
+			}
+			created = append(created, model)
+		}
+		return nil
+	})
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	for i := range created {
+		adapter.SendEvent(adapter.Event{
+			Interface: &created[i],
+			App:       &app,
+			Code:      adapter.AddToolEvent,
+		})
+	}
+	return resp, nil
+}
+
+func lookupTypeRef(ids map[string]int64, name string) *int64 {
+	if name == "" {
+		return nil
+	}
+	id, ok := ids[name]
+	if !ok {
+		return nil
+	}
+	return &id
+}
+
+type ExportOpenAPIRequest struct {
+	ID int64 `json:"id" validate:"required,gt=0"`
+}
+
+// openAPIFieldSchema 构建字段/参数的 schema，自定义类型使用 $ref 引用 components/schemas
+func openAPIFieldSchema(typ string, ref *int64, isArray bool, description string, typeNames map[int64]string) map[string]any {
+	var schema map[string]any
+	if typ == "custom" {
+		name := ""
+		if ref != nil {
+			name = typeNames[*ref]
+		}
+		schema = map[string]any{"$ref": "#/components/schemas/" + name}
+	} else {
+		schema = map[string]any{"type": typ}
+	}
+	if isArray {
+		schema = map[string]any{"type": "array", "items": schema}
+	}
+	if description != "" {
+		schema["description"] = description
+	}
+	return schema
+}
+
+// openAPIParamSchema 构建接口参数的 schema，fixed 参数展开环境变量后以常量形式呈现，密钥引用不导出
+func openAPIParamSchema(p models.InterfaceParameter, typeNames map[int64]string, env map[string]string) map[string]any {
+	schema := openAPIFieldSchema(p.Type, p.Ref, p.IsArray, "", typeNames)
+	if p.DefaultValue == nil || *p.DefaultValue == "" || p.IsArray || p.Type == "custom" {
+		return schema
+	}
+	defaultValue := *p.DefaultValue
+	if p.Group == "fixed" {
+		expanded, err := adapter.ExpandEnvironment(defaultValue, env)
+		if err != nil {
+			return schema
+		}
+		defaultValue = expanded
+	}
+	if _, ok := adapter.ParseSecretRef(defaultValue); ok {
+		return schema
+	}
+	value, err := adapter.ConvertDefaultValue(defaultValue, p.Type)
+	if err != nil {
+		return schema
+	}
+	if p.Group == "fixed" {
+		schema["const"] = value
+	}
+	schema["default"] = value
+	return schema
+}
+
+// splitInterfaceURL 将接口地址拆分为 server 和 OpenAPI 风格的 path
+func splitInterfaceURL(raw string) (string, string) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", raw
+	}
+	path := u.EscapedPath()
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	if path == "" {
+		path = "/"
+	}
+	// 将 :name 风格的路径参数转换为 {name}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") && len(seg) > 1 {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return u.Scheme + "://" + u.Host, strings.Join(segments, "/")
+}
+
+// openAPISecurityScheme 根据接口鉴权配置生成 securityScheme，none 和 capi 没有对应的标准定义
+func openAPISecurityScheme(iface models.Interface) (string, map[string]any, []any) {
+	cfg, err := adapter.ParseAuthConfig(iface.AuthType, iface.AuthConfig)
+	if err != nil {
+		return "", nil, nil
+	}
+	switch c := cfg.(type) {
+	case adapter.BearerAuthConfig:
+		return "bearerAuth", map[string]any{"type": "http", "scheme": "bearer"}, []any{}
+	case adapter.BasicAuthConfig:
+		return "basicAuth", map[string]any{"type": "http", "scheme": "basic"}, []any{}
+	case adapter.APIKeyAuthConfig:
+		return "apiKeyAuth", map[string]any{"type": "apiKey", "name": c.Name, "in": c.In}, []any{}
+	case adapter.OAuth2AuthConfig:
+		scopes := make(map[string]any)
+		requested := make([]any, 0, len(c.Scopes))
+		for _, s := range c.Scopes {
+			scopes[s] = ""
+			requested = append(requested, s)
+		}
+		return "oauth2Auth", map[string]any{
+			"type": "oauth2",
+			"flows": map[string]any{
+				"clientCredentials": map[string]any{"tokenUrl": c.TokenURL, "scopes": scopes},
+			},
+		}, requested
+	}
+	return "", nil, nil
+}
+
+// ExportOpenAPI 将应用导出为 OpenAPI 3.1 文档
+func ExportOpenAPI(req ExportOpenAPIRequest) (map[string]any, error) {
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	db := database.GetDB()
+	var app models.Application
+	if err := db.First(&app, req.ID).Error; err != nil {
+		return nil, errors.New("no such application")
+	}
+	var customTypes []models.CustomType
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&customTypes).Error; err != nil {
+		return nil, err
+	}
+	var fields []models.CustomTypeField
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	var interfaces []models.Interface
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&interfaces).Error; err != nil {
+		return nil, err
+	}
+	var params []models.InterfaceParameter
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&params).Error; err != nil {
+		return nil, err
+	}
+
+	typeNames := make(map[int64]string, len(customTypes))
+	for _, ct := range customTypes {
+		typeNames[ct.ID] = ct.Name
+	}
+	fieldsByType := make(map[int64][]models.CustomTypeField)
+	for _, f := range fields {
+		fieldsByType[f.CustomTypeID] = append(fieldsByType[f.CustomTypeID], f)
+	}
+	paramsByIface := make(map[int64][]models.InterfaceParameter)
+	for _, p := range params {
+		paramsByIface[p.InterfaceID] = append(paramsByIface[p.InterfaceID], p)
+	}
+
+	securitySchemes := make(map[string]any)
+	schemas := make(map[string]any, len(customTypes))
+	for _, ct := range customTypes {
+		properties := make(map[string]any)
+		required := make([]string, 0)
+		for _, f := range fieldsByType[ct.ID] {
+			properties[f.Name] = openAPIFieldSchema(f.Type, f.Ref, f.IsArray, f.Description, typeNames)
+			if f.Required {
+				required = append(required, f.Name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		if ct.Description != "" {
+			schema["description"] = ct.Description
+		}
+		schemas[ct.Name] = schema
+	}
+
+	// 只导出 HTTP 接口，graphql、grpc、sql、command、mcp 接口没有对应的 OpenAPI operation；
+	// 接口地址中的 ${VAR} 使用应用环境变量展开，展开后仍然不是绝对 URL 的接口无法导出
+	env, err := adapter.ParseEnvironment(app.Environment)
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]models.Interface, 0, len(interfaces))
+	for _, iface := range interfaces {
+		if iface.Protocol != "http" {
+			log.Printf("Warning: %s interface %s skipped in OpenAPI export", iface.Protocol, iface.Name)
+			continue
+		}
+		expanded, err := adapter.ExpandEnvironment(iface.URL, env)
+		if err != nil {
+			log.Printf("Warning: interface %s skipped in OpenAPI export: %v", iface.Name, err)
+			continue
+		}
+		if server, _ := splitInterfaceURL(expanded); server == "" {
+			log.Printf("Warning: interface %s skipped in OpenAPI export: %s is not an absolute URL", iface.Name, expanded)
+			continue
+		}
+		iface.URL = expanded
+		exported = append(exported, iface)
+	}
+	interfaces = exported
+
+	// 出现次数最多的 server 作为文档级 server，其余的写到 operation 上
+	serverCount := make(map[string]int)
+	for _, iface := range interfaces {
+		if server, _ := splitInterfaceURL(iface.URL); server != "" {
+			serverCount[server]++
+		}
+	}
+	defaultServer := ""
+	for server, n := range serverCount {
+		if n > serverCount[defaultServer] || (n == serverCount[defaultServer] && server < defaultServer) {
+			defaultServer = server
+		}
+	}
+
+	paths := make(map[string]any)
+	for _, iface := range interfaces {
+		server, path := splitInterfaceURL(iface.URL)
+		method := strings.ToLower(iface.Method)
+		pathItem, _ := paths[path].(map[string]any)
+		if pathItem == nil {
+			pathItem = make(map[string]any)
+			paths[path] = pathItem
+		}
+		if _, exists := pathItem[method]; exists {
+			log.Printf("Warning: interface %s conflicts with another operation on %s %s, skipped in OpenAPI export", iface.Name, iface.Method, path)
+			continue
+		}
+
+		operation := map[string]any{
+			"operationId":        iface.Name,
+			"x-mcp-auth-type":    iface.AuthType,
+			"x-mcp-tool-enabled": iface.Enabled,
+		}
+		if iface.Description != "" {
+			operation["description"] = iface.Description
+		}
+		if server != "" && server != defaultServer {
+			operation["servers"] = []any{map[string]any{"url": server}}
+		}
+		if base, scheme, scopes := openAPISecurityScheme(iface); scheme != nil {
+			name := base
+			for i := 2; securitySchemes[name] != nil && !reflect.DeepEqual(securitySchemes[name], scheme); i++ {
+				name = fmt.Sprintf("%s%d", base, i)
+			}
+			securitySchemes[name] = scheme
+			operation["security"] = []any{map[string]any{name: scopes}}
+		}
+
+		parameters := make([]any, 0)
+		bodyProperties := make(map[string]any)
+		bodyRequired := make([]string, 0)
+		outputProperties := make(map[string]any)
+		outputRequired := make([]string, 0)
+		bodyAsQuery := iface.Method == "GET" || iface.Method == "HEAD"
+		for _, p := range paramsByIface[iface.ID] {
+			schema := openAPIParamSchema(p, typeNames, env)
+			description := p.Description
+			if p.Group == "fixed" {
+				description = strings.TrimSpace(description + "\n\nFixed value, always sent by the adapter.")
+			}
+			if p.Group == "output" {
+				if description != "" {
+					schema["description"] = description
+				}
+				outputProperties[p.Name] = schema
+				if p.Required {
+					outputRequired = append(outputRequired, p.Name)
+				}
+				continue
+			}
+			location := strings.ToLower(p.Location)
+			if location == "body" && bodyAsQuery {
+				location = "query"
+			}
+			if location == "body" {
+				if description != "" {
+					schema["description"] = description
+				}
+				bodyProperties[p.Name] = schema
+				if p.Required || p.Group == "fixed" {
+					bodyRequired = append(bodyRequired, p.Name)
+				}
+				continue
+			}
+			param := map[string]any{
+				"name":     p.Name,
+				"in":       location,
+				"required": p.Required || location == "path" || p.Group == "fixed",
+				"schema":   schema,
+			}
+			if description != "" {
+				param["description"] = description
+			}
+			if p.Group == "fixed" {
+				param["x-mcp-fixed"] = true
+			}
+			parameters = append(parameters, param)
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if len(bodyProperties) > 0 {
+			bodySchema := map[string]any{"type": "object", "properties": bodyProperties}
+			if len(bodyRequired) > 0 {
+				bodySchema["required"] = bodyRequired
+			}
+			// raw 编码直接发送唯一 body 参数的值
+			if iface.BodyEncoding == adapter.BodyEncodingRaw && len(bodyProperties) == 1 {
+				for _, schema := range bodyProperties {
+					bodySchema = schema.(map[string]any)
+				}
+			}
+			operation["requestBody"] = map[string]any{
+				"required": len(bodyRequired) > 0,
+				"content": map[string]any{
+					openAPIBodyMediaType(iface.BodyEncoding): map[string]any{"schema": bodySchema},
+				},
+			}
+		}
+		response := map[string]any{"description": "Successful response"}
+		if len(outputProperties) > 0 {
+			outputSchema := map[string]any{"type": "object", "properties": outputProperties}
+			if len(outputRequired) > 0 {
+				outputSchema["required"] = outputRequired
+			}
+			response["content"] = map[string]any{
+				"application/json": map[string]any{"schema": outputSchema},
+			}
+		}
+		operation["responses"] = map[string]any{"200": response}
+		pathItem[method] = operation
+	}
+
+	info := map[string]any{"title": app.Name, "version": "1.0.0"}
+	if app.Description != "" {
+		info["description"] = app.Description
+	}
+	components := map[string]any{"schemas": schemas}
+	if len(securitySchemes) > 0 {
+		components["securitySchemes"] = securitySchemes
+	}
+	doc := map[string]any{
+		"openapi":    "3.1.0",
+		"info":       info,
+		"paths":      paths,
+		"components": components,
+	}
+	if defaultServer != "" {
+		doc["servers"] = []any{map[string]any{"url": defaultServer}}
+	}
+	return doc, nil
+}
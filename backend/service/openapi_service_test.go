@@ -0,0 +1,464 @@
+package service
+
+import (
+	"encoding/json"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petStoreSpec = `
+openapi: 3.0.3
+info:
+  title: Pet Store
+  version: 1.0.0
+servers:
+  - url: https://{env}.example.com/v1
+    variables:
+      env:
+        default: api
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 20
+        - name: X-Trace-Id
+          in: header
+          schema:
+            type: string
+        - name: session
+          in: cookie
+          schema:
+            type: string
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  items:
+                    type: array
+                    items:
+                      $ref: '#/components/schemas/Pet'
+                  total:
+                    type: integer
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: string
+    delete:
+      responses:
+        "204":
+          description: deleted
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        tags:
+          type: array
+          items:
+            type: string
+        owner:
+          $ref: '#/components/schemas/Owner'
+    Owner:
+      type: object
+      properties:
+        name:
+          type: string
+        pets:
+          type: array
+          items:
+            $ref: '#/components/schemas/Pet'
+`
+
+func TestParseSpecDocument(t *testing.T) {
+	doc, err := parseSpecDocument(`{"openapi":"3.1.0","paths":{}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", doc["openapi"])
+
+	doc, err = parseSpecDocument("openapi: 3.0.0\npaths:\n  /a:\n    get:\n      responses:\n        200:\n          description: ok\n")
+	require.NoError(t, err)
+	paths := doc["paths"].(map[string]any)
+	responses := paths["/a"].(map[string]any)["get"].(map[string]any)["responses"].(map[string]any)
+	assert.Contains(t, responses, "200")
+
+	_, err = parseSpecDocument("- a\n- b\n")
+	assert.Error(t, err)
+}
+
+func TestImportOpenAPI_DryRun(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "OpenAPIDryRun", Path: "openapi-dry-run", Protocol: "sse"})
+	require.NoError(t, err)
+
+	resp, err := ImportOpenAPI(ImportOpenAPIRequest{AppID: app.Application.ID, Spec: petStoreSpec, DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, resp.DryRun)
+
+	names := make(map[string]string)
+	for _, item := range resp.Interfaces {
+		names[item.Name] = item.Detail
+	}
+	assert.Equal(t, "GET https://api.example.com/v1/pets", names["listPets"])
+	assert.Equal(t, "POST https://api.example.com/v1/pets", names["createPet"])
+	assert.Equal(t, "DELETE https://api.example.com/v1/pets/{petId}", names["delete_pets_petId"])
+	assert.Len(t, resp.CustomTypes, 2)
+	assert.Contains(t, resp.Warnings, "listPets: cookie parameter session skipped")
+	assert.Contains(t, resp.Warnings, "Pet.owner skipped: circular reference to Owner")
+
+	// 预览不写数据库
+	var count int64
+	database.GetDB().Model(&models.Interface{}).Where("app_id = ?", app.Application.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.GetDB().Model(&models.CustomType{}).Where("app_id = ?", app.Application.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestImportOpenAPI_Create(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "OpenAPIImport", Path: "openapi-import", Protocol: "sse"})
+	require.NoError(t, err)
+	appID := app.Application.ID
+
+	resp, err := ImportOpenAPI(ImportOpenAPIRequest{AppID: appID, Spec: petStoreSpec})
+	require.NoError(t, err)
+	assert.False(t, resp.DryRun)
+
+	types, err := ListCustomTypes(ListCustomTypesRequest{AppID: appID})
+	require.NoError(t, err)
+	typeIDs := make(map[string]int64)
+	for _, ct := range types.CustomTypes {
+		typeIDs[ct.Name] = ct.ID
+	}
+	require.Contains(t, typeIDs, "Pet")
+	require.Contains(t, typeIDs, "Owner")
+
+	ifaces, err := ListInterfaces(ListInterfacesRequest{AppID: appID})
+	require.NoError(t, err)
+	require.Len(t, ifaces.Interfaces, 3)
+	byName := make(map[string]InterfaceDTO)
+	for _, iface := range ifaces.Interfaces {
+		byName[iface.Name] = iface
+	}
+
+	list := byName["listPets"]
+	assert.Equal(t, "GET", list.Method)
+	params := make(map[string]InterfaceParameterDTO)
+	for _, p := range list.Parameters {
+		params[p.Name] = p
+	}
+	assert.Equal(t, "query", params["limit"].Location)
+	assert.Equal(t, "number", params["limit"].Type)
+	require.NotNil(t, params["limit"].DefaultValue)
+	assert.Equal(t, "20", *params["limit"].DefaultValue)
+	assert.Equal(t, "header", params["X-Trace-Id"].Location)
+	assert.NotContains(t, params, "session")
+	assert.Equal(t, "output", params["items"].Group)
+	assert.True(t, params["items"].IsArray)
+	assert.Equal(t, typeIDs["Pet"], *params["items"].Ref)
+
+	create := byName["createPet"]
+	inputs := make(map[string]InterfaceParameterDTO)
+	for _, p := range create.Parameters {
+		if p.Group == "input" {
+			inputs[p.Name] = p
+		}
+	}
+	assert.Equal(t, "body", inputs["name"].Location)
+	assert.True(t, inputs["name"].Required)
+	assert.Equal(t, typeIDs["Owner"], *inputs["owner"].Ref)
+	assert.True(t, inputs["tags"].IsArray)
+
+	del := byName["delete_pets_petId"]
+	require.Len(t, del.Parameters, 1)
+	assert.Equal(t, "path", del.Parameters[0].Location)
+	assert.True(t, del.Parameters[0].Required)
+
+	// 再次导入时，已存在的接口被跳过，类型被复用
+	resp, err = ImportOpenAPI(ImportOpenAPIRequest{AppID: appID, Spec: petStoreSpec})
+	require.NoError(t, err)
+	for _, item := range resp.Interfaces {
+		assert.Equal(t, "skip", item.Action)
+	}
+	for _, item := range resp.CustomTypes {
+		assert.Equal(t, "skip", item.Action)
+	}
+	types, err = ListCustomTypes(ListCustomTypesRequest{AppID: appID})
+	require.NoError(t, err)
+	assert.Len(t, types.CustomTypes, len(typeIDs))
+}
+
+func TestImportOpenAPI_Errors(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "OpenAPIErrors", Path: "openapi-errors", Protocol: "sse"})
+	require.NoError(t, err)
+
+	_, err = ImportOpenAPI(ImportOpenAPIRequest{AppID: 99999, Spec: petStoreSpec})
+	assert.EqualError(t, err, "application not found")
+
+	_, err = ImportOpenAPI(ImportOpenAPIRequest{AppID: app.Application.ID, Spec: `{"swagger":"2.0"}`})
+	assert.EqualError(t, err, "only OpenAPI 3.0 and 3.1 documents are supported")
+
+	_, err = ImportOpenAPI(ImportOpenAPIRequest{AppID: app.Application.ID, Spec: "::"})
+	assert.Error(t, err)
+
+	_, err = ImportOpenAPI(ImportOpenAPIRequest{AppID: app.Application.ID, Spec: petStoreSpec, AuthType: "bearer", AuthConfig: `{"token":"abc"}`})
+	assert.EqualError(t, err, "auth_config token must be a secret:// reference")
+	_, err = ImportOpenAPI(ImportOpenAPIRequest{AppID: app.Application.ID, Spec: petStoreSpec, AuthType: "bearer", AuthConfig: `{"token":"secret://missing"}`})
+	assert.EqualError(t, err, "secret missing not found")
+}
+
+func TestExportOpenAPI(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "OpenAPIExport", Description: "Export test", Path: "openapi-export", Protocol: "sse"})
+	require.NoError(t, err)
+	appID := app.Application.ID
+
+	addr, err := CreateCustomType(CreateCustomTypeRequest{
+		AppID: appID,
+		Name:  "Address",
+		Fields: []CreateCustomTypeFieldReq{
+			{Name: "city", Type: "string", Required: true},
+		},
+	})
+	require.NoError(t, err)
+	addrID := addr.CustomType.ID
+	_, err = CreateCustomType(CreateCustomTypeRequest{
+		AppID: appID,
+		Name:  "User",
+		Fields: []CreateCustomTypeFieldReq{
+			{Name: "name", Type: "string", Required: true},
+			{Name: "addresses", Type: "custom", Ref: &addrID, IsArray: true},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = CreateInterface(CreateInterfaceRequest{
+		AppID:    appID,
+		Name:     "UpdateUser",
+		Protocol: "http",
+		URL:      "https://api.example.com/users/:id",
+		Method:   "PUT",
+		AuthType: "none",
+		Enabled:  boolPtr(true),
+		Parameters: []CreateInterfaceParameterReq{
+			{Name: "id", Type: "string", Location: "path", Required: true, Group: "input"},
+			{Name: "address", Type: "custom", Ref: &addrID, Location: "body", Required: true, Group: "input"},
+			{Name: "X-Api-Version", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("2")},
+			{Name: "ok", Type: "boolean", Location: "body", Group: "output"},
+		},
+	})
+	require.NoError(t, err)
+	_, err = CreateInterface(CreateInterfaceRequest{
+		AppID:    appID,
+		Name:     "Search",
+		Protocol: "http",
+		URL:      "https://search.example.com/search",
+		Method:   "GET",
+		AuthType: "none",
+		Enabled:  boolPtr(true),
+		Parameters: []CreateInterfaceParameterReq{
+			{Name: "q", Type: "string", Location: "body", Required: true, Group: "input"},
+		},
+	})
+	require.NoError(t, err)
+
+	doc, err := ExportOpenAPI(ExportOpenAPIRequest{ID: appID})
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	assert.Equal(t, "OpenAPIExport", doc["info"].(map[string]any)["title"])
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	user := schemas["User"].(map[string]any)
+	addresses := user["properties"].(map[string]any)["addresses"].(map[string]any)
+	assert.Equal(t, "array", addresses["type"])
+	assert.Equal(t, map[string]any{"$ref": "#/components/schemas/Address"}, addresses["items"])
+
+	paths := doc["paths"].(map[string]any)
+	put := paths["/users/{id}"].(map[string]any)["put"].(map[string]any)
+	assert.Equal(t, "UpdateUser", put["operationId"])
+	params := put["parameters"].([]any)
+	require.Len(t, params, 2)
+	fixed := params[1].(map[string]any)
+	assert.Equal(t, "X-Api-Version", fixed["name"])
+	assert.Equal(t, "2", fixed["schema"].(map[string]any)["const"])
+	body := put["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	assert.Equal(t, "#/components/schemas/Address", body["properties"].(map[string]any)["address"].(map[string]any)["$ref"])
+
+	// GET 请求的 body 参数会被放到 query 中，不同 host 写到 operation 的 servers 上
+	search := paths["/search"].(map[string]any)["get"].(map[string]any)
+	assert.Equal(t, "query", search["parameters"].([]any)[0].(map[string]any)["in"])
+	assert.NotNil(t, search["servers"])
+
+	// 导出的文档可以被重新导入
+	target, err := CreateApplication(CreateApplicationRequest{Name: "OpenAPIReimport", Path: "openapi-reimport", Protocol: "sse"})
+	require.NoError(t, err)
+	spec, err := json.Marshal(doc)
+	require.NoError(t, err)
+	resp, err := ImportOpenAPI(ImportOpenAPIRequest{AppID: target.Application.ID, Spec: string(spec)})
+	require.NoError(t, err)
+	assert.Len(t, resp.CustomTypes, 2)
+	require.Len(t, resp.Interfaces, 2)
+	for _, item := range resp.Interfaces {
+		assert.Equal(t, "create", item.Action)
+	}
+
+	_, err = ExportOpenAPI(ExportOpenAPIRequest{ID: 99999})
+	assert.EqualError(t, err, "no such application")
+}
+
+func TestExportOpenAPIEnvironmentURL(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	_, err := CreateSecret(CreateSecretRequest{Name: "api-token", Value: "token-value-1234"})
+	require.NoError(t, err)
+	app, err := CreateApplication(CreateApplicationRequest{Name: "OpenAPIEnv", Path: "openapi-env", Protocol: "sse",
+		Environment: `{"BASE_URL":"https://api.example.com/v1","API_VERSION":"2","TOKEN":"secret://api-token"}`})
+	require.NoError(t, err)
+	for name, url := range map[string]string{"ListUsers": "${BASE_URL}/users", "Undefined": "${OTHER_URL}/orders"} {
+		_, err := CreateInterface(CreateInterfaceRequest{
+			AppID:    app.Application.ID,
+			Name:     name,
+			Protocol: "http",
+			URL:      url,
+			Method:   "GET",
+			AuthType: "none",
+			Parameters: []CreateInterfaceParameterReq{
+				{Name: "X-Api-Version", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("${API_VERSION}")},
+				{Name: "X-Api-Key", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("${TOKEN}")},
+				{Name: "X-Tenant-Key", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("secret://api-token")},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	doc, err := ExportOpenAPI(ExportOpenAPIRequest{ID: app.Application.ID})
+	require.NoError(t, err)
+	// 环境变量展开后拆分出 server 和 path，未定义变量的接口不导出
+	assert.Equal(t, []any{map[string]any{"url": "https://api.example.com"}}, doc["servers"])
+	paths := doc["paths"].(map[string]any)
+	assert.Len(t, paths, 1)
+	get := paths["/v1/users"].(map[string]any)["get"].(map[string]any)
+	assert.Equal(t, "ListUsers", get["operationId"])
+
+	// fixed 参数的默认值同样展开，密钥引用不导出
+	schemas := make(map[string]map[string]any)
+	for _, p := range get["parameters"].([]any) {
+		param := p.(map[string]any)
+		schemas[param["name"].(string)] = param["schema"].(map[string]any)
+	}
+	assert.Equal(t, "2", schemas["X-Api-Version"]["const"])
+	assert.Equal(t, map[string]any{"type": "string"}, schemas["X-Api-Key"])
+	assert.Equal(t, map[string]any{"type": "string"}, schemas["X-Tenant-Key"])
+}
+
+func TestExportOpenAPISkipsNonHTTPInterfaces(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "OpenAPIMixed", Path: "openapi-mixed", Protocol: "sse"})
+	require.NoError(t, err)
+	_, err = CreateDatasource(CreateDatasourceRequest{Name: "reporting", Driver: "sqlite", DSN: "file:reporting.db"})
+	require.NoError(t, err)
+	_, err = CreateInterface(CreateInterfaceRequest{
+		AppID: app.Application.ID, Name: "ListUsers", Protocol: "http", URL: "https://api.example.com/users", Method: "GET", AuthType: "none",
+	})
+	require.NoError(t, err)
+	_, err = CreateInterface(CreateInterfaceRequest{
+		AppID: app.Application.ID, Name: "QueryUsers", Protocol: "sql", URL: "sql://reporting", Method: "POST", AuthType: "none",
+		Query: "SELECT id, name FROM users",
+	})
+	require.NoError(t, err)
+
+	doc, err := ExportOpenAPI(ExportOpenAPIRequest{ID: app.Application.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"url": "https://api.example.com"}}, doc["servers"])
+	paths := doc["paths"].(map[string]any)
+	assert.Len(t, paths, 1)
+	assert.Contains(t, paths, "/users")
+}
+
+func TestExportOpenAPISecuritySchemes(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "OpenAPISecurity", Path: "openapi-security", Protocol: "sse"})
+	require.NoError(t, err)
+	for _, name := range []string{"abc", "def", "k", "s"} {
+		_, err := CreateSecret(CreateSecretRequest{Name: name, Value: name + "-value"})
+		require.NoError(t, err)
+	}
+	newIface := func(name, authType, authConfig string) {
+		_, err := CreateInterface(CreateInterfaceRequest{
+			AppID:      app.Application.ID,
+			Name:       name,
+			Protocol:   "http",
+			URL:        "https://api.example.com/" + name,
+			Method:     "GET",
+			AuthType:   authType,
+			AuthConfig: authConfig,
+			Enabled:    boolPtr(true),
+		})
+		require.NoError(t, err)
+	}
+	newIface("bearer", "bearer", `{"token":"secret://abc"}`)
+	newIface("bearer2", "bearer", `{"token":"secret://def"}`)
+	newIface("header", "apikey", `{"name":"X-Api-Key","in":"header","value":"secret://k"}`)
+	newIface("query", "apikey", `{"name":"key","in":"query","value":"secret://k"}`)
+	newIface("oauth", "oauth2", `{"token_url":"https://auth.example.com/token","client_id":"id","client_secret":"secret://s","scopes":["read"]}`)
+	newIface("open", "none", "")
+
+	doc, err := ExportOpenAPI(ExportOpenAPIRequest{ID: app.Application.ID})
+	require.NoError(t, err)
+	schemes := doc["components"].(map[string]any)["securitySchemes"].(map[string]any)
+	assert.Len(t, schemes, 4)
+	assert.Equal(t, map[string]any{"type": "http", "scheme": "bearer"}, schemes["bearerAuth"])
+	assert.Equal(t, map[string]any{"type": "apiKey", "name": "key", "in": "query"}, schemes["apiKeyAuth2"])
+
+	paths := doc["paths"].(map[string]any)
+	security := func(path string) any {
+		return paths[path].(map[string]any)["get"].(map[string]any)["security"]
+	}
+	// 相同定义共用一个 scheme
+	assert.Equal(t, []any{map[string]any{"bearerAuth": []any{}}}, security("/bearer"))
+	assert.Equal(t, []any{map[string]any{"bearerAuth": []any{}}}, security("/bearer2"))
+	assert.Equal(t, []any{map[string]any{"oauth2Auth": []any{"read"}}}, security("/oauth"))
+	assert.Nil(t, security("/open"))
+}
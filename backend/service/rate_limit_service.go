@@ -0,0 +1,27 @@
+package service
+
+import (
+	"mcp-adapter/backend/adapter"
+)
+
+type ListRateLimitsRequest struct {
+	AppID int64 `json:"app_id" validate:"gte=0"` // 只返回该应用及其接口的限流器，为 0 时返回全部
+}
+
+type ListRateLimitsResponse struct {
+	RateLimits []adapter.RateLimitStatus `json:"rate_limits"`
+}
+
+// ListRateLimits 返回当前实例中限流器的状态，限流状态保存在内存中，多实例部署时各实例独立计算
+func ListRateLimits(req ListRateLimitsRequest) (ListRateLimitsResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return ListRateLimitsResponse{}, err
+	}
+	statuses := make([]adapter.RateLimitStatus, 0)
+	for _, status := range adapter.RateLimitStatuses() {
+		if req.AppID == 0 || status.AppID == req.AppID {
+			statuses = append(statuses, status)
+		}
+	}
+	return ListRateLimitsResponse{RateLimits: statuses}, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRateLimits(t *testing.T) {
+	_, err := ListRateLimits(ListRateLimitsRequest{AppID: -1})
+	assert.Error(t, err)
+
+	// 没有注册工具时返回空列表而不是 null
+	resp, err := ListRateLimits(ListRateLimitsRequest{AppID: 1})
+	require.NoError(t, err)
+	assert.NotNil(t, resp.RateLimits)
+	assert.Empty(t, resp.RateLimits)
+}
+
+func TestApplicationRateLimit(t *testing.T) {
+	setupTestDB(t)
+
+	_, err := CreateApplication(CreateApplicationRequest{Name: "Limited", Path: "limited", Protocol: "sse", RateLimit: `{"per": "1s"}`})
+	assert.ErrorContains(t, err, "rate_limit requires requests or max_in_flight")
+
+	resp, err := CreateApplication(CreateApplicationRequest{Name: "Limited", Path: "limited", Protocol: "sse", RateLimit: `{"requests": 100, "per": "1m"}`})
+	require.NoError(t, err)
+	assert.Equal(t, `{"requests": 100, "per": "1m"}`, resp.Application.RateLimit)
+	_, err = UpdateApplication(UpdateApplicationRequest{ID: resp.Application.ID, RateLimit: stringPtr(`{"requests": 1, "key": "ip"}`)})
+	assert.ErrorContains(t, err, "rate_limit key must be")
+	updated, err := UpdateApplication(UpdateApplicationRequest{ID: resp.Application.ID, RateLimit: stringPtr("")})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Application.RateLimit)
+}
+
+func TestInterfaceRateLimit(t *testing.T) {
+	setupTestDB(t)
+
+	app, err := CreateApplication(CreateApplicationRequest{Name: "RateApp", Path: "rate-app", Protocol: "sse"})
+	require.NoError(t, err)
+	req := CreateInterfaceRequest{
+		AppID:     app.Application.ID,
+		Name:      "Limited",
+		Protocol:  "http",
+		URL:       "https://api.example.com/limited",
+		Method:    "GET",
+		AuthType:  "none",
+		RateLimit: `{"requests": 10, "per": "often"}`,
+		Enabled:   boolPtr(true),
+	}
+	_, err = CreateInterface(req)
+	assert.ErrorContains(t, err, "rate_limit per must be a positive duration")
+
+	req.RateLimit = `{"max_in_flight": 2, "key": "session"}`
+	resp, err := CreateInterface(req)
+	require.NoError(t, err)
+	assert.Equal(t, req.RateLimit, resp.Interface.RateLimit)
+	_, err = UpdateInterface(UpdateInterfaceRequest{ID: resp.Interface.ID, RateLimit: stringPtr(`{"max_in_flight": -1}`)})
+	assert.ErrorContains(t, err, "must not be negative")
+}
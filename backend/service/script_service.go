@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"mcp-adapter/backend/adapter"
+)
+
+type RunScriptRequest struct {
+	Script string `json:"script" validate:"required,max=1048576"`                   // Starlark 脚本
+	Hook   string `json:"hook" validate:"required,oneof=pre_request post_response"` // 要执行的钩子
+	Input  any    `json:"input"`                                                    // 示例输入：pre_request 为按位置分组的参数，post_response 为响应体
+}
+
+// RunScriptResponse 脚本执行结果，脚本本身的错误放在 Error 中，便于同时查看 print 输出
+type RunScriptResponse struct {
+	Output any      `json:"output"`
+	Logs   []string `json:"logs"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// RunScript 用示例输入执行脚本钩子，不会请求上游接口
+func RunScript(ctx context.Context, req RunScriptRequest) (RunScriptResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return RunScriptResponse{}, err
+	}
+	output, logs, err := adapter.RunScriptHook(ctx, req.Script, req.Hook, req.Input)
+	resp := RunScriptResponse{Output: output, Logs: logs}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp, nil
+}
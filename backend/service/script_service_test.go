@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScript(t *testing.T) {
+	_, err := RunScript(context.Background(), RunScriptRequest{Script: "def pre_request(p): pass", Hook: "on_error"})
+	assert.Error(t, err)
+
+	resp, err := RunScript(context.Background(), RunScriptRequest{
+		Script: "def post_response(body):\n    print(len(body[\"items\"]))\n    return body[\"items\"][0]",
+		Hook:   "post_response",
+		Input:  map[string]any{"items": []any{map[string]any{"id": 1}}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, map[string]any{"id": float64(1)}, resp.Output)
+	assert.Equal(t, []string{"1"}, resp.Logs)
+
+	// 脚本运行错误和 print 输出一起返回
+	resp, err = RunScript(context.Background(), RunScriptRequest{
+		Script: "def post_response(body):\n    print(\"before\")\n    fail(\"boom\")",
+		Hook:   "post_response",
+		Input:  "text",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "boom")
+	assert.Equal(t, []string{"before"}, resp.Logs)
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// secretNamePattern 密钥名称只允许字母、数字、点、下划线和中划线
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+type CreateSecretRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`    // 密钥名称 全局唯一，通过 secret://name 引用
+	Description string `json:"description" validate:"max=16384"`    // 密钥描述
+	Value       string `json:"value" validate:"required,max=65536"` // 密钥明文，只写不读
+}
+
+type GetSecretRequest struct {
+	ID int64 `json:"id" validate:"required,gt=0"`
+}
+
+type ListSecretsRequest struct{}
+
+type UpdateSecretRequest struct {
+	ID          int64   `json:"id" validate:"required,gt=0"`
+	Name        *string `json:"name" validate:"omitempty,max=255"`
+	Description *string `json:"description" validate:"omitempty,max=16384"`
+	Value       *string `json:"value" validate:"omitempty,max=65536"`
+}
+
+type DeleteSecretRequest struct {
+	ID int64 `json:"id" validate:"required,gt=0"`
+}
+
+// SecretDTO 对外只返回掩码后的值
+type SecretDTO struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Value       string    `json:"value"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type SecretResponse struct {
+	Secret SecretDTO `json:"secret"`
+}
+
+type SecretsResponse struct {
+	Secrets []SecretDTO `json:"secrets"`
+}
+
+// maskSecret 长度足够时保留末尾 4 位便于辨认，否则全部掩码
+func maskSecret(value string) string {
+	runes := []rune(value)
+	if len(runes) < 12 {
+		return "********"
+	}
+	return "********" + string(runes[len(runes)-4:])
+}
+
+func toSecretDTO(m models.Secret) SecretDTO {
+	masked := "********"
+	if plain, err := adapter.DecryptSecret(m.Value); err == nil {
+		masked = maskSecret(plain)
+	}
+	return SecretDTO{
+		ID:          m.ID,
+		Name:        m.Name,
+		Description: m.Description,
+		Value:       masked,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// checkSecretRefs 校验引用的密钥都存在，保存接口、应用和数据源前调用
+func checkSecretRefs(tx *gorm.DB, names ...string) error {
+	for _, name := range names {
+		var count int64
+		if err := tx.Model(&models.Secret{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("secret %s not found", name)
+		}
+	}
+	return nil
+}
+
+// secretReferenced 检查是否有接口参数、接口鉴权配置、HTTP 客户端配置、数据源连接串、应用环境变量或远程 MCP 服务器配置引用了该密钥
+func secretReferenced(name string) bool {
+	db := database.GetDB()
+	var count int64
+	db.Model(&models.InterfaceParameter{}).
+		Where("default_value = ?", adapter.SecretScheme+name).Count(&count)
+	if count > 0 {
+		return true
+	}
+	db.Model(&models.Datasource{}).Where("dsn = ?", adapter.SecretScheme+name).Count(&count)
+	if count > 0 {
+		return true
+	}
+	var interfaces []models.Interface
+	db.Select("auth_type", "auth_config").Where("auth_config LIKE ?", "%"+adapter.SecretScheme+"%").Find(&interfaces)
+	for _, iface := range interfaces {
+		cfg, err := adapter.ParseAuthConfig(iface.AuthType, iface.AuthConfig)
+		if err != nil {
+			continue
+		}
+		for _, ref := range adapter.AuthConfigSecretRefs(cfg) {
+			if ref == name {
+				return true
+			}
+		}
+	}
+	var configs []string
+	db.Model(&models.Interface{}).Where("http_config LIKE ?", "%"+adapter.SecretScheme+"%").Pluck("http_config", &configs)
+	var appConfigs []string
+	db.Model(&models.Application{}).Where("http_config LIKE ?", "%"+adapter.SecretScheme+"%").Pluck("http_config", &appConfigs)
+	for _, raw := range append(configs, appConfigs...) {
+		cfg, err := adapter.ParseHTTPConfig(raw)
+		if err != nil {
+			continue
+		}
+		for _, ref := range adapter.HTTPConfigSecretRefs(cfg) {
+			if ref == name {
+				return true
+			}
+		}
+	}
+	var environments []string
+	db.Model(&models.Application{}).Where("environment LIKE ?", "%"+adapter.SecretScheme+"%").Pluck("environment", &environments)
+	for _, raw := range environments {
+		env, err := adapter.ParseEnvironment(raw)
+		if err != nil {
+			continue
+		}
+		for _, ref := range adapter.EnvironmentSecretRefs(env) {
+			if ref == name {
+				return true
+			}
+		}
+	}
+	var upstreams []string
+	db.Model(&models.Application{}).Where("upstream LIKE ?", "%"+adapter.SecretScheme+"%").Pluck("upstream", &upstreams)
+	for _, raw := range upstreams {
+		cfg, err := adapter.ParseUpstreamConfig(raw)
+		if err != nil {
+			continue
+		}
+		for _, ref := range adapter.UpstreamConfigSecretRefs(cfg) {
+			if ref == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func CreateSecret(req CreateSecretRequest) (SecretResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return SecretResponse{}, err
+	}
+	if !secretNamePattern.MatchString(req.Name) {
+		return SecretResponse{}, errors.New("invalid secret name")
+	}
+	db := database.GetDB()
+	var count int64
+	db.Model(&models.Secret{}).Where("name = ?", req.Name).Count(&count)
+	if count > 0 {
+		return SecretResponse{}, errors.New("duplicate secret name")
+	}
+	encrypted, err := adapter.EncryptSecret(req.Value)
+	if err != nil {
+		return SecretResponse{}, err
+	}
+	secret := models.Secret{
+		Name:        req.Name,
+		Description: req.Description,
+		Value:       encrypted,
+	}
+	if err := db.Create(&secret).Error; err != nil {
+		return SecretResponse{}, err
+	}
+	return SecretResponse{Secret: toSecretDTO(secret)}, nil
+}
+
+func GetSecret(req GetSecretRequest) (SecretResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return SecretResponse{}, err
+	}
+	var secret models.Secret
+	if err := database.GetDB().First(&secret, req.ID).Error; err != nil {
+		return SecretResponse{}, errors.New("secret not found")
+	}
+	return SecretResponse{Secret: toSecretDTO(secret)}, nil
+}
+
+func ListSecrets(req ListSecretsRequest) (SecretsResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return SecretsResponse{}, err
+	}
+	var secrets []models.Secret
+	if err := database.GetDB().Order("id").Find(&secrets).Error; err != nil {
+		return SecretsResponse{}, err
+	}
+	dtos := make([]SecretDTO, 0, len(secrets))
+	for _, s := range secrets {
+		dtos = append(dtos, toSecretDTO(s))
+	}
+	return SecretsResponse{Secrets: dtos}, nil
+}
+
+// UpdateSecret 更新密钥，值在调用时解析，不需要重新注册工具
+func UpdateSecret(req UpdateSecretRequest) (SecretResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return SecretResponse{}, err
+	}
+	db := database.GetDB()
+	var existing models.Secret
+	if err := db.First(&existing, req.ID).Error; err != nil {
+		return SecretResponse{}, errors.New("secret not found")
+	}
+	if req.Name != nil && *req.Name != existing.Name {
+		if !secretNamePattern.MatchString(*req.Name) {
+			return SecretResponse{}, errors.New("invalid secret name")
+		}
+		var count int64
+		db.Model(&models.Secret{}).Where("name = ? AND id <> ?", *req.Name, existing.ID).Count(&count)
+		if count > 0 {
+			return SecretResponse{}, errors.New("duplicate secret name")
+		}
+		// 改名会使已有引用失效
+		if secretReferenced(existing.Name) {
+			return SecretResponse{}, errors.New("secret is referenced by interfaces and cannot be renamed")
+		}
+		existing.Name = *req.Name
+	}
+	if req.Description != nil {
+		existing.Description = *req.Description
+	}
+	if req.Value != nil {
+		if *req.Value == "" {
+			return SecretResponse{}, errors.New("secret value cannot be empty")
+		}
+		encrypted, err := adapter.EncryptSecret(*req.Value)
+		if err != nil {
+			return SecretResponse{}, err
+		}
+		existing.Value = encrypted
+	}
+	if err := db.Save(&existing).Error; err != nil {
+		return SecretResponse{}, err
+	}
+	return SecretResponse{Secret: toSecretDTO(existing)}, nil
+}
+
+func DeleteSecret(req DeleteSecretRequest) (EmptyResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return EmptyResponse{}, err
+	}
+	db := database.GetDB()
+	var secret models.Secret
+	if err := db.First(&secret, req.ID).Error; err != nil {
+		return EmptyResponse{}, errors.New("secret not found")
+	}
+	if secretReferenced(secret.Name) {
+		return EmptyResponse{}, errors.New("secret is referenced by interfaces")
+	}
+	if err := db.Delete(&secret).Error; err != nil {
+		return EmptyResponse{}, err
+	}
+	return EmptyResponse{}, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"encoding/json"
+	"mcp-adapter/backend/adapter"
+	"mcp-adapter/backend/database"
+	"mcp-adapter/backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSecret(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	resp, err := CreateSecret(CreateSecretRequest{Name: "tc-secret-key", Description: "capi", Value: "AKIDabcdefgh1234"})
+	require.NoError(t, err)
+	assert.Equal(t, "tc-secret-key", resp.Secret.Name)
+	assert.Equal(t, "********1234", resp.Secret.Value)
+
+	// 数据库中只保存密文
+	var stored models.Secret
+	require.NoError(t, database.GetDB().First(&stored, resp.Secret.ID).Error)
+	assert.NotContains(t, stored.Value, "AKIDabcdefgh1234")
+
+	short, err := CreateSecret(CreateSecretRequest{Name: "short", Value: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, "********", short.Secret.Value)
+
+	_, err = CreateSecret(CreateSecretRequest{Name: "tc-secret-key", Value: "x"})
+	assert.EqualError(t, err, "duplicate secret name")
+	_, err = CreateSecret(CreateSecretRequest{Name: "bad name", Value: "x"})
+	assert.EqualError(t, err, "invalid secret name")
+	_, err = CreateSecret(CreateSecretRequest{Name: "empty"})
+	assert.Error(t, err)
+
+	t.Setenv(adapter.SecretKeyEnv, "")
+	_, err = CreateSecret(CreateSecretRequest{Name: "no-key", Value: "x"})
+	assert.Error(t, err)
+}
+
+func TestListAndUpdateSecrets(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	created, err := CreateSecret(CreateSecretRequest{Name: "token", Value: "first-value-0001"})
+	require.NoError(t, err)
+
+	updated, err := UpdateSecret(UpdateSecretRequest{ID: created.Secret.ID, Value: stringPtr("second-value-0002")})
+	require.NoError(t, err)
+	assert.Equal(t, "********0002", updated.Secret.Value)
+	plain, err := adapter.LookupSecret("token")
+	require.NoError(t, err)
+	assert.Equal(t, "second-value-0002", plain)
+
+	_, err = UpdateSecret(UpdateSecretRequest{ID: created.Secret.ID, Value: stringPtr("")})
+	assert.Error(t, err)
+
+	list, err := ListSecrets(ListSecretsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Secrets, 1)
+	data, _ := json.Marshal(list)
+	assert.NotContains(t, string(data), "second-value")
+
+	got, err := GetSecret(GetSecretRequest{ID: created.Secret.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "********0002", got.Secret.Value)
+	_, err = GetSecret(GetSecretRequest{ID: 9999})
+	assert.Error(t, err)
+}
+
+func TestSecretReferences(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	secret, err := CreateSecret(CreateSecretRequest{Name: "tc-key", Value: "super-secret-value"})
+	require.NoError(t, err)
+	app, err := CreateApplication(CreateApplicationRequest{Name: "SecretApp", Path: "secret-app", Protocol: "sse"})
+	require.NoError(t, err)
+
+	newReq := func(param CreateInterfaceParameterReq) CreateInterfaceRequest {
+		return CreateInterfaceRequest{
+			AppID:      app.Application.ID,
+			Name:       "Call" + param.Name + param.Group,
+			Protocol:   "http",
+			URL:        "https://api.example.com",
+			Method:     "POST",
+			AuthType:   "capi",
+			Enabled:    boolPtr(true),
+			Parameters: []CreateInterfaceParameterReq{param},
+		}
+	}
+
+	_, err = CreateInterface(newReq(CreateInterfaceParameterReq{
+		Name: "SecretKey", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("secret://missing"),
+	}))
+	assert.EqualError(t, err, "secret missing not found")
+
+	_, err = CreateInterface(newReq(CreateInterfaceParameterReq{
+		Name: "SecretKey", Type: "string", Location: "header", Group: "input", DefaultValue: stringPtr("secret://tc-key"),
+	}))
+	assert.EqualError(t, err, "secret references are only allowed in fixed string parameters")
+
+	iface, err := CreateInterface(newReq(CreateInterfaceParameterReq{
+		Name: "SecretKey", Type: "string", Location: "header", Group: "fixed", DefaultValue: stringPtr("secret://tc-key"),
+	}))
+	require.NoError(t, err)
+
+	// 详情和接口列表只包含引用，不包含明文
+	detail, err := GetApplication(GetApplicationRequest{ID: app.Application.ID, ShowDetail: true})
+	require.NoError(t, err)
+	interfaces, err := ListInterfaces(ListInterfacesRequest{AppID: app.Application.ID})
+	require.NoError(t, err)
+	for _, v := range []any{detail, interfaces} {
+		data, _ := json.Marshal(v)
+		assert.Contains(t, string(data), "secret://tc-key")
+		assert.NotContains(t, string(data), "super-secret-value")
+	}
+
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secret.Secret.ID})
+	assert.EqualError(t, err, "secret is referenced by interfaces")
+	_, err = UpdateSecret(UpdateSecretRequest{ID: secret.Secret.ID, Name: stringPtr("renamed")})
+	assert.Error(t, err)
+
+	_, err = DeleteInterface(DeleteInterfaceRequest{ID: iface.Interface.ID})
+	require.NoError(t, err)
+	_, err = DeleteSecret(DeleteSecretRequest{ID: secret.Secret.ID})
+	assert.NoError(t, err)
+}
+
+func TestCheckSecretRefs(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv(adapter.SecretKeyEnv, "test-master-key")
+
+	_, err := CreateSecret(CreateSecretRequest{Name: "tc-key", Value: "super-secret-value"})
+	require.NoError(t, err)
+	db := database.GetDB()
+	assert.NoError(t, checkSecretRefs(db))
+	assert.NoError(t, checkSecretRefs(db, "tc-key"))
+	assert.EqualError(t, checkSecretRefs(db, "tc-key", "missing"), "secret missing not found")
+
+	// 查询失败时返回查询错误，而不是报告密钥不存在
+	tx := db.Begin()
+	tx.Rollback()
+	err = checkSecretRefs(tx, "tc-key")
+	require.Error(t, err)
+	assert.NotEqual(t, "secret tc-key not found", err.Error())
+}
@@ -989,9 +989,18 @@ async function showInterfaceForm(interfaceId = null) {
             document.getElementById('interface-description').value = iface.description || '';
             document.getElementById('interface-method').value = iface.method;
             document.getElementById('interface-protocol').value = iface.protocol;
+            document.getElementById('interface-body-encoding').value = iface.body_encoding || 'json';
             document.getElementById('interface-url').value = iface.url;
             document.getElementById('interface-auth').value = iface.auth_type;
+            document.getElementById('interface-auth-config').value = iface.auth_config || '';
+            document.getElementById('interface-http-config').value = iface.http_config || '';
+            document.getElementById('interface-body-template').value = iface.body_template || '';
+            document.getElementById('interface-query').value = iface.query || '';
+            document.getElementById('interface-descriptor-set').value = iface.descriptor_set || '';
             document.getElementById('interface-post-process').value = iface.post_process || '';
+            document.getElementById('interface-script').value = iface.script || '';
+            document.getElementById('interface-cache-config').value = iface.cache_config || '';
+            document.getElementById('interface-rate-limit').value = iface.rate_limit || '';
 
             // 加载参数，根据 group 分配到不同Tab
             if (iface.parameters && iface.parameters.length > 0) {
@@ -1008,9 +1017,18 @@ async function showInterfaceForm(interfaceId = null) {
         document.getElementById('interface-description').value = '';
         document.getElementById('interface-method').value = 'GET';
         document.getElementById('interface-protocol').value = 'http';
+        document.getElementById('interface-body-encoding').value = 'json';
         document.getElementById('interface-url').value = '';
         document.getElementById('interface-auth').value = 'none';
+        document.getElementById('interface-auth-config').value = '';
+        document.getElementById('interface-http-config').value = '';
+        document.getElementById('interface-body-template').value = '';
+        document.getElementById('interface-query').value = '';
+        document.getElementById('interface-descriptor-set').value = '';
         document.getElementById('interface-post-process').value = '';
+        document.getElementById('interface-script').value = '';
+        document.getElementById('interface-cache-config').value = '';
+        document.getElementById('interface-rate-limit').value = '';
     }
 }
 
@@ -1037,9 +1055,18 @@ document.getElementById('interface-form-submit').addEventListener('click', async
     const description = document.getElementById('interface-description').value;
     const method = document.getElementById('interface-method').value;
     const protocol = document.getElementById('interface-protocol').value;
+    const body_encoding = document.getElementById('interface-body-encoding').value;
     const url = document.getElementById('interface-url').value;
     const auth_type = document.getElementById('interface-auth').value;
+    const auth_config = document.getElementById('interface-auth-config').value;
+    const http_config = document.getElementById('interface-http-config').value;
+    const body_template = document.getElementById('interface-body-template').value;
+    const query = document.getElementById('interface-query').value;
+    const descriptor_set = document.getElementById('interface-descriptor-set').value;
     const post_process = document.getElementById('interface-post-process').value;
+    const script = document.getElementById('interface-script').value;
+    const cache_config = document.getElementById('interface-cache-config').value;
+    const rate_limit = document.getElementById('interface-rate-limit').value;
 
     if (!name || !url) {
         showToast('请填写必填字段', 'error');
@@ -1085,8 +1112,17 @@ document.getElementById('interface-form-submit').addEventListener('click', async
                     method,
                     protocol,
                     url,
+                    body_encoding,
                     auth_type,
+                    auth_config,
+                    http_config,
+                    body_template,
+                    query,
+                    descriptor_set,
                     post_process,
+                    script,
+                    cache_config,
+                    rate_limit,
                     parameters
                 })
             });
@@ -1102,9 +1138,18 @@ document.getElementById('interface-form-submit').addEventListener('click', async
                     method,
                     protocol,
                     url,
+                    body_encoding,
                     auth_type,
+                    auth_config,
+                    http_config,
+                    body_template,
+                    query,
+                    descriptor_set,
                     enabled: true,
                     post_process,
+                    script,
+                    cache_config,
+                    rate_limit,
                     parameters
                 })
             });